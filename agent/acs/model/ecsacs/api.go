@@ -90,18 +90,30 @@ type Container struct {
 
 	Cpu *int64 `locationName:"cpu" type:"integer"`
 
+	DependsOn []*ContainerDependency `locationName:"dependsOn" type:"list"`
+
 	DockerConfig *DockerConfig `locationName:"dockerConfig" type:"structure"`
 
 	EntryPoint []*string `locationName:"entryPoint" type:"list"`
 
 	Environment map[string]*string `locationName:"environment" type:"map"`
 
+	EnvironmentFiles []*string `locationName:"environmentFiles" type:"list"`
+
 	Essential *bool `locationName:"essential" type:"boolean"`
 
+	ExpectedImageDigest *string `locationName:"expectedImageDigest" type:"string"`
+
+	HealthCheck *HealthCheck `locationName:"healthCheck" type:"structure"`
+
 	Image *string `locationName:"image" type:"string"`
 
+	ImagePullBehavior *string `locationName:"imagePullBehavior" type:"string"`
+
 	Links []*string `locationName:"links" type:"list"`
 
+	LinuxParameters *LinuxParameters `locationName:"linuxParameters" type:"structure"`
+
 	Memory *int64 `locationName:"memory" type:"integer"`
 
 	MountPoints []*MountPoint `locationName:"mountPoints" type:"list"`
@@ -114,6 +126,14 @@ type Container struct {
 
 	RegistryAuthentication *RegistryAuthenticationData `locationName:"registryAuthentication" type:"structure"`
 
+	RestartPolicy *RestartPolicy `locationName:"restartPolicy" type:"structure"`
+
+	Secrets []*Secret `locationName:"secrets" type:"list"`
+
+	StopSignal *string `locationName:"stopSignal" type:"string"`
+
+	StopTimeout *int64 `locationName:"stopTimeout" type:"integer"`
+
 	VolumesFrom []*VolumeFrom `locationName:"volumesFrom" type:"list"`
 }
 
@@ -127,6 +147,44 @@ func (s Container) GoString() string {
 	return s.String()
 }
 
+type ContainerDependency struct {
+	_ struct{} `type:"structure"`
+
+	Condition *string `locationName:"condition" type:"string"`
+
+	ContainerName *string `locationName:"containerName" type:"string"`
+}
+
+// String returns the string representation
+func (s ContainerDependency) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s ContainerDependency) GoString() string {
+	return s.String()
+}
+
+type Device struct {
+	_ struct{} `type:"structure"`
+
+	ContainerPath *string `locationName:"containerPath" type:"string"`
+
+	HostPath *string `locationName:"hostPath" type:"string"`
+
+	Permissions *string `locationName:"permissions" type:"string"`
+}
+
+// String returns the string representation
+func (s Device) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s Device) GoString() string {
+	return s.String()
+}
+
 type DockerConfig struct {
 	_ struct{} `type:"structure"`
 
@@ -147,6 +205,30 @@ func (s DockerConfig) GoString() string {
 	return s.String()
 }
 
+type DockerVolumeConfiguration struct {
+	_ struct{} `type:"structure"`
+
+	Autoprovision *bool `locationName:"autoprovision" type:"boolean"`
+
+	Driver *string `locationName:"driver" type:"string"`
+
+	DriverOpts map[string]*string `locationName:"driverOpts" type:"map"`
+
+	Labels map[string]*string `locationName:"labels" type:"map"`
+
+	Scope *string `locationName:"scope" type:"string" enum:"Scope"`
+}
+
+// String returns the string representation
+func (s DockerVolumeConfiguration) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s DockerVolumeConfiguration) GoString() string {
+	return s.String()
+}
+
 type ECRAuthData struct {
 	_ struct{} `type:"structure"`
 
@@ -197,6 +279,36 @@ func (s ErrorOutput) GoString() string {
 	return s.String()
 }
 
+type HealthCheck struct {
+	_ struct{} `type:"structure"`
+
+	Command []*string `locationName:"command" type:"list"`
+
+	Interval *int64 `locationName:"interval" type:"integer"`
+
+	Path *string `locationName:"path" type:"string"`
+
+	Port *int64 `locationName:"port" type:"integer"`
+
+	Retries *int64 `locationName:"retries" type:"integer"`
+
+	StartPeriod *int64 `locationName:"startPeriod" type:"integer"`
+
+	Timeout *int64 `locationName:"timeout" type:"integer"`
+
+	Type *string `locationName:"type" type:"string"`
+}
+
+// String returns the string representation
+func (s HealthCheck) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s HealthCheck) GoString() string {
+	return s.String()
+}
+
 type HeartbeatMessage struct {
 	_ struct{} `type:"structure"`
 
@@ -230,6 +342,10 @@ func (s HeartbeatOutput) GoString() string {
 type HostVolumeProperties struct {
 	_ struct{} `type:"structure"`
 
+	Backing *string `locationName:"backing" type:"string" enum:"ScratchBacking"`
+
+	SizeLimit *int64 `locationName:"sizeLimit" type:"long"`
+
 	SourcePath *string `locationName:"sourcePath" type:"string"`
 }
 
@@ -357,6 +473,56 @@ func (s InvalidInstanceException) GoString() string {
 	return s.String()
 }
 
+type KernelCapabilities struct {
+	_ struct{} `type:"structure"`
+
+	Add []*string `locationName:"add" type:"list"`
+
+	Drop []*string `locationName:"drop" type:"list"`
+}
+
+// String returns the string representation
+func (s KernelCapabilities) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s KernelCapabilities) GoString() string {
+	return s.String()
+}
+
+type LinuxParameters struct {
+	_ struct{} `type:"structure"`
+
+	Capabilities *KernelCapabilities `locationName:"capabilities" type:"structure"`
+
+	Devices []*Device `locationName:"devices" type:"list"`
+
+	InitProcessEnabled *bool `locationName:"initProcessEnabled" type:"boolean"`
+
+	IpcMode *string `locationName:"ipcMode" type:"string"`
+
+	PidMode *string `locationName:"pidMode" type:"string"`
+
+	SharedMemorySize *int64 `locationName:"sharedMemorySize" type:"long"`
+
+	Sysctls map[string]*string `locationName:"sysctls" type:"map"`
+
+	Tmpfs []*Tmpfs `locationName:"tmpfs" type:"list"`
+
+	Ulimits []*Ulimit `locationName:"ulimits" type:"list"`
+}
+
+// String returns the string representation
+func (s LinuxParameters) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s LinuxParameters) GoString() string {
+	return s.String()
+}
+
 type MountPoint struct {
 	_ struct{} `type:"structure"`
 
@@ -509,6 +675,44 @@ func (s RegistryAuthenticationData) GoString() string {
 	return s.String()
 }
 
+type RestartPolicy struct {
+	_ struct{} `type:"structure"`
+
+	Backoff *int64 `locationName:"backoff" type:"integer"`
+
+	MaxAttempts *int64 `locationName:"maxAttempts" type:"integer"`
+
+	Type *string `locationName:"type" type:"string"`
+}
+
+// String returns the string representation
+func (s RestartPolicy) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s RestartPolicy) GoString() string {
+	return s.String()
+}
+
+type Secret struct {
+	_ struct{} `type:"structure"`
+
+	Name *string `locationName:"name" type:"string"`
+
+	ValueFrom *string `locationName:"valueFrom" type:"string"`
+}
+
+// String returns the string representation
+func (s Secret) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s Secret) GoString() string {
+	return s.String()
+}
+
 type ServerException struct {
 	_ struct{} `type:"structure"`
 
@@ -579,6 +783,46 @@ func (s Task) GoString() string {
 	return s.String()
 }
 
+type Tmpfs struct {
+	_ struct{} `type:"structure"`
+
+	ContainerPath *string `locationName:"containerPath" type:"string"`
+
+	MountOptions []*string `locationName:"mountOptions" type:"list"`
+
+	Size *int64 `locationName:"size" type:"long"`
+}
+
+// String returns the string representation
+func (s Tmpfs) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s Tmpfs) GoString() string {
+	return s.String()
+}
+
+type Ulimit struct {
+	_ struct{} `type:"structure"`
+
+	HardLimit *int64 `locationName:"hardLimit" type:"long"`
+
+	Name *string `locationName:"name" type:"string"`
+
+	SoftLimit *int64 `locationName:"softLimit" type:"long"`
+}
+
+// String returns the string representation
+func (s Ulimit) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s Ulimit) GoString() string {
+	return s.String()
+}
+
 type UpdateFailureOutput struct {
 	_ struct{} `type:"structure"`
 }
@@ -634,6 +878,8 @@ func (s VersionInfo) GoString() string {
 type Volume struct {
 	_ struct{} `type:"structure"`
 
+	DockerVolumeConfiguration *DockerVolumeConfiguration `locationName:"dockerVolumeConfiguration" type:"structure"`
+
 	Host *HostVolumeProperties `locationName:"host" type:"structure"`
 
 	Name *string `locationName:"name" type:"string"`
@@ -38,6 +38,7 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/sighandlers"
 	"github.com/aws/amazon-ecs-agent/agent/sighandlers/exitcodes"
 	"github.com/aws/amazon-ecs-agent/agent/statemanager"
+	"github.com/aws/amazon-ecs-agent/agent/stats"
 	"github.com/aws/amazon-ecs-agent/agent/tcs/handler"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
 	"github.com/aws/amazon-ecs-agent/agent/version"
@@ -233,6 +234,8 @@ func _main() int {
 		}
 	}
 
+	taskEngine.SetContainerInstanceArn(containerInstanceArn)
+
 	// Begin listening to the docker daemon and saving changes
 	taskEngine.SetSaver(stateManager)
 	imageManager.SetSaver(stateManager)
@@ -243,13 +246,30 @@ func _main() int {
 		go imageManager.StartImageCleanupProcess(ctx)
 	}
 
+	// Remove images straight away when the disk fills up
+	go imageManager.StartDiskPressureProcess(ctx)
+
+	// Pull the images to pre-warm and keep them up to date
+	go imageManager.StartImagePrewarmProcess(ctx)
+
 	go sighandlers.StartTerminationHandler(stateManager, taskEngine)
 
 	// Agent introspection api
 	go handlers.ServeHttp(&containerInstanceArn, taskEngine, cfg)
 
-	// Start serving the endpoint to fetch IAM Role credentials
-	go credentialshandler.ServeHTTP(credentialsManager, containerInstanceArn, cfg)
+	// The stats engine is shared by the metrics session and the task metadata
+	// endpoint. It only collects stats if metrics are enabled
+	statsEngine := stats.NewDockerStatsEngine(cfg, dockerClient, containerChangeEventStream)
+	if !cfg.DisableMetrics {
+		err = statsEngine.MustInit(taskEngine, cfg.Cluster, containerInstanceArn)
+		if err != nil {
+			log.Criticalf("Error initializing stats engine: %v", err)
+			return exitcodes.ExitTerminal
+		}
+	}
+
+	// Start serving the endpoints to fetch IAM Role credentials and task metadata
+	go credentialshandler.ServeHTTP(credentialsManager, containerInstanceArn, cfg, taskEngine.(handlers.DockerStateResolver), statsEngine)
 
 	// Start sending events to the backend
 	go eventhandler.HandleEngineEvents(taskEngine, client, stateManager)
@@ -267,6 +287,7 @@ func _main() int {
 		AcceptInvalidCert:             *acceptInsecureCert,
 		ECSClient:                     client,
 		TaskEngine:                    taskEngine,
+		StatsEngine:                   statsEngine,
 	}
 
 	// Start metrics session in a go routine
@@ -13,6 +13,13 @@
 
 package api
 
+import (
+	"strings"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
 const DOCKER_MINIMUM_MEMORY = 4 * 1024 * 1024 // 4MB
 
 // Overriden returns
@@ -62,3 +69,157 @@ func (c *Container) SetDesiredStatus(status ContainerStatus) {
 
 	c.DesiredStatus = status
 }
+
+// GetHealthStatus returns the result of the most recent health check
+func (c *Container) GetHealthStatus() HealthStatus {
+	c.healthLock.RLock()
+	defer c.healthLock.RUnlock()
+
+	return c.Health
+}
+
+// SetHealthStatus records the result of a health check
+func (c *Container) SetHealthStatus(health HealthStatus) {
+	c.healthLock.Lock()
+	defer c.healthLock.Unlock()
+
+	c.Health = health
+}
+
+// GetSentHealthStatus returns the health status last reported to the backend
+func (c *Container) GetSentHealthStatus() ContainerHealthStatus {
+	c.healthLock.RLock()
+	defer c.healthLock.RUnlock()
+
+	return c.sentHealthStatus
+}
+
+// SetSentHealthStatus records the health status reported to the backend
+func (c *Container) SetSentHealthStatus(status ContainerHealthStatus) {
+	c.healthLock.Lock()
+	defer c.healthLock.Unlock()
+
+	c.sentHealthStatus = status
+}
+
+// GetKnownPortBindings returns the host ports the container's ports are
+// bound to
+func (c *Container) GetKnownPortBindings() []PortBinding {
+	c.knownPortBindingsLock.RLock()
+	defer c.knownPortBindingsLock.RUnlock()
+
+	return c.KnownPortBindings
+}
+
+// SetKnownPortBindings records the host ports the container's ports are
+// bound to
+func (c *Container) SetKnownPortBindings(portBindings []PortBinding) {
+	c.knownPortBindingsLock.Lock()
+	defer c.knownPortBindingsLock.Unlock()
+
+	c.KnownPortBindings = portBindings
+}
+
+// GetRestartCount returns the number of times the container has been restarted
+func (c *Container) GetRestartCount() int {
+	c.restartCountLock.RLock()
+	defer c.restartCountLock.RUnlock()
+
+	return c.RestartCount
+}
+
+// IncrementRestartCount records that the container is being restarted
+func (c *Container) IncrementRestartCount() {
+	c.restartCountLock.Lock()
+	defer c.restartCountLock.Unlock()
+
+	c.RestartCount++
+}
+
+// GetSentRestartCount returns the restart count last reported to the backend
+func (c *Container) GetSentRestartCount() int {
+	c.restartCountLock.RLock()
+	defer c.restartCountLock.RUnlock()
+
+	return c.sentRestartCount
+}
+
+// SetSentRestartCount records the restart count reported to the backend
+func (c *Container) SetSentRestartCount(restartCount int) {
+	c.restartCountLock.Lock()
+	defer c.restartCountLock.Unlock()
+
+	c.sentRestartCount = restartCount
+}
+
+// GetTransitionTimes returns when the container last reached each of its
+// known statuses
+func (c *Container) GetTransitionTimes() ContainerTransitionTimes {
+	c.transitionTimesLock.RLock()
+	defer c.transitionTimesLock.RUnlock()
+
+	return c.TransitionTimes
+}
+
+// SetTransitionTime records that the container reached a known status at the
+// given time
+func (c *Container) SetTransitionTime(status ContainerStatus, at time.Time) {
+	c.transitionTimesLock.Lock()
+	defer c.transitionTimesLock.Unlock()
+
+	switch status {
+	case ContainerPulled:
+		c.TransitionTimes.Pulled = at
+	case ContainerCreated:
+		c.TransitionTimes.Created = at
+	case ContainerRunning:
+		c.TransitionTimes.Running = at
+	case ContainerStopped:
+		c.TransitionTimes.Stopped = at
+	}
+}
+
+// StopTimeoutDuration returns the time the container is given to exit after
+// being sent its stop signal, or nil if it has none of its own
+func (c *Container) StopTimeoutDuration() *time.Duration {
+	if c.StopTimeout == nil {
+		return nil
+	}
+	stopTimeout := time.Duration(*c.StopTimeout) * time.Second
+	return &stopTimeout
+}
+
+// ImageReference returns the reference the image of the container is pulled
+// and created from. A container with an ExpectedImageDigest uses the
+// repository at that digest, as a tag can be moved to another image at any
+// time, including between the pull and the creation of the container.
+func (c *Container) ImageReference() string {
+	if c.ExpectedImageDigest == "" {
+		return c.Image
+	}
+	repository, _ := docker.ParseRepositoryTag(c.Image)
+	digest := c.ExpectedImageDigest
+	if n := strings.LastIndex(digest, "@"); n >= 0 {
+		digest = digest[n+1:]
+	}
+	return repository + "@" + digest
+}
+
+// ShouldRestart returns true if the container's restart policy asks for it to
+// be started again after exiting with the given exit code. Essential
+// containers are never restarted, since their exit stops the task.
+func (c *Container) ShouldRestart(exitCode *int) bool {
+	if c.Essential || c.RestartPolicy == nil {
+		return false
+	}
+	switch c.RestartPolicy.Type {
+	case RestartPolicyAlways:
+		return true
+	case RestartPolicyOnFailure:
+		if exitCode != nil && *exitCode == 0 {
+			return false
+		}
+		return c.RestartPolicy.MaxAttempts == 0 || uint(c.GetRestartCount()) < c.RestartPolicy.MaxAttempts
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	securityOptNoNewPrivileges = "no-new-privileges"
+	securityOptSeccomp         = "seccomp"
+	// SeccompProfileCustom stands for a seccomp profile given by a task
+	SeccompProfileCustom = "custom"
+)
+
+// ContainerDefaults are instance-wide settings of containers. Each applies
+// only if the container's task does not set the same setting, either through
+// the container's LinuxParameters or through DockerConfig.HostConfig.
+type ContainerDefaults struct {
+	LogDriver  string
+	LogOptions map[string]string
+	Ulimits    []Ulimit
+	// NoNewPrivileges prevents container processes from gaining privileges
+	NoNewPrivileges bool
+	// SeccompProfile is "unconfined" or the path of the default profile, as
+	// configured
+	SeccompProfile string
+	// SeccompProfileJSON is the profile given to Docker, which is either
+	// "unconfined" or the contents of the profile's file
+	SeccompProfileJSON string
+}
+
+// ContainerPolicies are the log configuration, ulimits and security options
+// a container is created with once ContainerDefaults are applied
+type ContainerPolicies struct {
+	LogDriver       string
+	LogOptions      map[string]string
+	Ulimits         []Ulimit
+	NoNewPrivileges bool
+	// SeccompProfile is empty for Docker's default profile, the configured
+	// default profile, or "custom" for a profile given by the task
+	SeccompProfile string
+}
+
+// apply sets the defaults that the host config does not override and returns
+// the settings it added, to check that Docker supports them
+func (defaults *ContainerDefaults) apply(hostConfig *docker.HostConfig) []dockerclient.LinuxParameter {
+	var parameters []dockerclient.LinuxParameter
+	if defaults.LogDriver != "" && hostConfig.LogConfig.Type == "" {
+		hostConfig.LogConfig = docker.LogConfig{Type: defaults.LogDriver}
+		if len(defaults.LogOptions) > 0 {
+			hostConfig.LogConfig.Config = make(map[string]string)
+			for key, value := range defaults.LogOptions {
+				hostConfig.LogConfig.Config[key] = value
+			}
+		}
+	}
+	ulimitsAdded := false
+	for _, ulimit := range defaults.Ulimits {
+		if !hasUlimit(hostConfig.Ulimits, ulimit.Name) {
+			hostConfig.Ulimits = append(hostConfig.Ulimits, docker.ULimit{Name: ulimit.Name, Soft: ulimit.SoftLimit, Hard: ulimit.HardLimit})
+			ulimitsAdded = true
+		}
+	}
+	if ulimitsAdded {
+		parameters = append(parameters, dockerclient.LinuxUlimitsParameter)
+	}
+	if defaults.NoNewPrivileges {
+		if _, ok := securityOpt(hostConfig.SecurityOpt, securityOptNoNewPrivileges); !ok {
+			hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, securityOptNoNewPrivileges)
+			parameters = append(parameters, dockerclient.LinuxNoNewPrivilegesParameter)
+		}
+	}
+	// Docker ignores seccomp profiles for privileged containers
+	if defaults.SeccompProfileJSON != "" && !hostConfig.Privileged {
+		if _, ok := securityOpt(hostConfig.SecurityOpt, securityOptSeccomp); !ok {
+			hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, securityOptSeccomp+"="+defaults.SeccompProfileJSON)
+			parameters = append(parameters, dockerclient.LinuxSeccompParameter)
+		}
+	}
+	return parameters
+}
+
+func hasUlimit(ulimits []docker.ULimit, name string) bool {
+	for _, ulimit := range ulimits {
+		if ulimit.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// securityOpt returns the value of the named security option. Docker
+// accepts both "name=value" and the older "name:value".
+func securityOpt(opts []string, name string) (string, bool) {
+	for _, opt := range opts {
+		if opt == name {
+			return "", true
+		}
+		if strings.HasPrefix(opt, name+"=") || strings.HasPrefix(opt, name+":") {
+			return opt[len(name)+1:], true
+		}
+	}
+	return "", false
+}
+
+// hostConfigWithDefaults returns the container's own host config, with its
+// Linux parameters and the defaults applied, along with the settings that the
+// defaults added
+func (c *Container) hostConfigWithDefaults(defaults *ContainerDefaults) (*docker.HostConfig, []dockerclient.LinuxParameter, error) {
+	container := c.Overridden()
+	hostConfig := &docker.HostConfig{}
+	if container.DockerConfig.HostConfig != nil {
+		err := json.Unmarshal([]byte(*container.DockerConfig.HostConfig), hostConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if container.LinuxParameters != nil {
+		applyLinuxParameters(hostConfig, container.LinuxParameters)
+	}
+	var parameters []dockerclient.LinuxParameter
+	if defaults != nil {
+		parameters = defaults.apply(hostConfig)
+	}
+	return hostConfig, parameters, nil
+}
+
+// DefaultParameters returns the settings that the defaults add to the
+// container, to check that Docker supports them
+func (c *Container) DefaultParameters(defaults *ContainerDefaults) ([]dockerclient.LinuxParameter, error) {
+	_, parameters, err := c.hostConfigWithDefaults(defaults)
+	return parameters, err
+}
+
+// Policies returns the log configuration, ulimits and security options the
+// container is created with
+func (c *Container) Policies(defaults *ContainerDefaults) (*ContainerPolicies, error) {
+	hostConfig, _, err := c.hostConfigWithDefaults(defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := &ContainerPolicies{
+		LogDriver:  hostConfig.LogConfig.Type,
+		LogOptions: hostConfig.LogConfig.Config,
+	}
+	for _, ulimit := range hostConfig.Ulimits {
+		policies.Ulimits = append(policies.Ulimits, Ulimit{Name: ulimit.Name, SoftLimit: ulimit.Soft, HardLimit: ulimit.Hard})
+	}
+	if value, ok := securityOpt(hostConfig.SecurityOpt, securityOptNoNewPrivileges); ok {
+		policies.NoNewPrivileges = value == "" || value == "true"
+	}
+	if profile, ok := securityOpt(hostConfig.SecurityOpt, securityOptSeccomp); ok {
+		switch {
+		case defaults != nil && profile == defaults.SeccompProfileJSON:
+			policies.SeccompProfile = defaults.SeccompProfile
+		case strings.HasPrefix(profile, "{"):
+			policies.SeccompProfile = SeccompProfileCustom
+		default:
+			policies.SeccompProfile = profile
+		}
+	}
+	return policies, nil
+}
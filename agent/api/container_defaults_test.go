@@ -0,0 +1,111 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+var testContainerDefaults = &ContainerDefaults{
+	LogDriver:          "json-file",
+	LogOptions:         map[string]string{"max-size": "10m", "max-file": "3"},
+	Ulimits:            []Ulimit{{Name: "nofile", SoftLimit: 1024, HardLimit: 4096}},
+	NoNewPrivileges:    true,
+	SeccompProfile:     "/etc/ecs/seccomp.json",
+	SeccompProfileJSON: `{"defaultAction":"SCMP_ACT_ERRNO"}`,
+}
+
+func TestDockerHostConfigContainerDefaults(t *testing.T) {
+	container := &Container{Name: "app"}
+	task := &Task{Containers: []*Container{container}}
+
+	hostConfig, err := task.DockerHostConfig(container, dockerMap(task), testContainerDefaults)
+	assert.Nil(t, err)
+	assert.Equal(t, docker.LogConfig{Type: "json-file", Config: map[string]string{"max-size": "10m", "max-file": "3"}}, hostConfig.LogConfig)
+	assert.Equal(t, []docker.ULimit{{Name: "nofile", Soft: 1024, Hard: 4096}}, hostConfig.Ulimits)
+	assert.Equal(t, []string{"no-new-privileges", `seccomp={"defaultAction":"SCMP_ACT_ERRNO"}`}, hostConfig.SecurityOpt)
+}
+
+func TestDockerHostConfigContainerDefaultsOverriddenByTask(t *testing.T) {
+	rawHostConfig := `{"LogConfig":{"Type":"syslog"},"Ulimits":[{"Name":"nproc","Soft":10,"Hard":20}],"SecurityOpt":["seccomp:unconfined"]}`
+	container := &Container{
+		Name:         "app",
+		DockerConfig: DockerConfig{HostConfig: &rawHostConfig},
+		LinuxParameters: &LinuxParameters{
+			Ulimits: []Ulimit{{Name: "nofile", SoftLimit: 10, HardLimit: 10}},
+		},
+	}
+	task := &Task{Containers: []*Container{container}}
+
+	hostConfig, err := task.DockerHostConfig(container, dockerMap(task), testContainerDefaults)
+	assert.Nil(t, err)
+	assert.Equal(t, docker.LogConfig{Type: "syslog"}, hostConfig.LogConfig, "default log options should not apply to another driver")
+	assert.Equal(t, []docker.ULimit{{Name: "nofile", Soft: 10, Hard: 10}}, hostConfig.Ulimits)
+	assert.Equal(t, []string{"seccomp:unconfined", "no-new-privileges"}, hostConfig.SecurityOpt)
+}
+
+func TestDockerHostConfigContainerDefaultsPrivileged(t *testing.T) {
+	rawHostConfig := `{"Privileged":true}`
+	container := &Container{Name: "app", DockerConfig: DockerConfig{HostConfig: &rawHostConfig}}
+	task := &Task{Containers: []*Container{container}}
+
+	hostConfig, err := task.DockerHostConfig(container, dockerMap(task), testContainerDefaults)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"no-new-privileges"}, hostConfig.SecurityOpt)
+}
+
+func TestContainerDefaultParameters(t *testing.T) {
+	container := &Container{Name: "app"}
+	parameters, err := container.DefaultParameters(testContainerDefaults)
+	assert.Nil(t, err)
+	assert.Equal(t, []dockerclient.LinuxParameter{
+		dockerclient.LinuxUlimitsParameter,
+		dockerclient.LinuxNoNewPrivilegesParameter,
+		dockerclient.LinuxSeccompParameter,
+	}, parameters)
+
+	// Defaults that the task overrides are not sent to Docker
+	rawHostConfig := `{"Privileged":true,"SecurityOpt":["no-new-privileges"]}`
+	container.DockerConfig.HostConfig = &rawHostConfig
+	container.LinuxParameters = &LinuxParameters{
+		Ulimits: []Ulimit{{Name: "nofile", SoftLimit: 10, HardLimit: 10}},
+	}
+	parameters, err = container.DefaultParameters(testContainerDefaults)
+	assert.Nil(t, err)
+	assert.Empty(t, parameters)
+}
+
+func TestContainerPolicies(t *testing.T) {
+	container := &Container{Name: "app"}
+	policies, err := container.Policies(testContainerDefaults)
+	assert.Nil(t, err)
+	assert.Equal(t, &ContainerPolicies{
+		LogDriver:       "json-file",
+		LogOptions:      map[string]string{"max-size": "10m", "max-file": "3"},
+		Ulimits:         []Ulimit{{Name: "nofile", SoftLimit: 1024, HardLimit: 4096}},
+		NoNewPrivileges: true,
+		SeccompProfile:  "/etc/ecs/seccomp.json",
+	}, policies)
+
+	rawHostConfig := `{"SecurityOpt":["seccomp={\"defaultAction\":\"SCMP_ACT_ALLOW\"}"]}`
+	container.DockerConfig.HostConfig = &rawHostConfig
+	policies, err = container.Policies(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, &ContainerPolicies{SeccompProfile: SeccompProfileCustom}, policies)
+}
@@ -14,8 +14,10 @@
 package api
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/utils"
 	"github.com/fsouza/go-dockerclient"
@@ -82,3 +84,94 @@ func (pair configPair) Equal() bool {
 
 	return true
 }
+
+func TestShouldRestart(t *testing.T) {
+	exitCode := func(code int) *int { return &code }
+	testCases := []struct {
+		name      string
+		container *Container
+		exitCode  *int
+		restart   bool
+	}{
+		{"no policy", &Container{}, exitCode(1), false},
+		{"never", &Container{RestartPolicy: &RestartPolicy{Type: RestartPolicyNever}}, exitCode(1), false},
+		{"always on success", &Container{RestartPolicy: &RestartPolicy{Type: RestartPolicyAlways}}, exitCode(0), true},
+		{"essential", &Container{Essential: true, RestartPolicy: &RestartPolicy{Type: RestartPolicyAlways}}, exitCode(1), false},
+		{"on-failure with success", &Container{RestartPolicy: &RestartPolicy{Type: RestartPolicyOnFailure}}, exitCode(0), false},
+		{"on-failure with failure", &Container{RestartPolicy: &RestartPolicy{Type: RestartPolicyOnFailure}}, exitCode(2), true},
+		{"on-failure without exit code", &Container{RestartPolicy: &RestartPolicy{Type: RestartPolicyOnFailure}}, nil, true},
+		{"on-failure under limit", &Container{RestartCount: 1, RestartPolicy: &RestartPolicy{Type: RestartPolicyOnFailure, MaxAttempts: 2}}, exitCode(1), true},
+		{"on-failure at limit", &Container{RestartCount: 2, RestartPolicy: &RestartPolicy{Type: RestartPolicyOnFailure, MaxAttempts: 2}}, exitCode(1), false},
+	}
+	for _, tc := range testCases {
+		if restart := tc.container.ShouldRestart(tc.exitCode); restart != tc.restart {
+			t.Errorf("%s: expected ShouldRestart to be %v, got %v", tc.name, tc.restart, restart)
+		}
+	}
+}
+
+func TestRestartPolicyBackoffDuration(t *testing.T) {
+	policy := &RestartPolicy{Type: RestartPolicyAlways}
+	if backoff := policy.BackoffDuration(0); backoff != 10*time.Second {
+		t.Errorf("Expected default backoff of 10s, got %s", backoff)
+	}
+	policy.Backoff = 5
+	if backoff := policy.BackoffDuration(2); backoff != 20*time.Second {
+		t.Errorf("Expected backoff of 20s, got %s", backoff)
+	}
+	if backoff := policy.BackoffDuration(100); backoff != 5*time.Minute {
+		t.Errorf("Expected backoff to be capped at 5m, got %s", backoff)
+	}
+}
+
+func TestSetTransitionTime(t *testing.T) {
+	container := &Container{}
+	created := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	stopped := created.Add(time.Minute)
+	container.SetTransitionTime(ContainerCreated, created)
+	container.SetTransitionTime(ContainerStopped, stopped)
+	container.SetTransitionTime(ContainerStatusNone, stopped)
+
+	expected := ContainerTransitionTimes{Created: created, Stopped: stopped}
+	if times := container.GetTransitionTimes(); times != expected {
+		t.Errorf("Expected transition times %+v, got %+v", expected, times)
+	}
+}
+
+func TestStopTimeoutDuration(t *testing.T) {
+	for _, tc := range []struct {
+		json     string
+		expected *time.Duration
+	}{
+		{`{}`, nil},
+		{`{"stopTimeout":0}`, new(time.Duration)},
+		{`{"stopTimeout":30}`, func() *time.Duration { d := 30 * time.Second; return &d }()},
+	} {
+		var container Container
+		if err := json.Unmarshal([]byte(tc.json), &container); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tc.expected, container.StopTimeoutDuration()) {
+			t.Errorf("Expected stop timeout %v for %s, got %v", tc.expected, tc.json, container.StopTimeoutDuration())
+		}
+	}
+}
+
+func TestImageReference(t *testing.T) {
+	digest := "sha256:bc8813ea7b3603864987522f02a76101c17ad122e1c46d790efc0fca78ca7bfb"
+	for _, tc := range []struct {
+		image          string
+		expectedDigest string
+		expected       string
+	}{
+		{"busybox:latest", "", "busybox:latest"},
+		{"busybox:latest", digest, "busybox@" + digest},
+		{"registry:5000/busybox", digest, "registry:5000/busybox@" + digest},
+		{"busybox@sha256:other", "busybox@" + digest, "busybox@" + digest},
+	} {
+		container := &Container{Image: tc.image, ExpectedImageDigest: tc.expectedDigest}
+		if reference := container.ImageReference(); reference != tc.expected {
+			t.Errorf("Expected reference %s for %s pinned to %q, got %s", tc.expected, tc.image, tc.expectedDigest, reference)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// HealthCheckTypeCommand runs a command inside the container; an exit code
+	// of 0 means healthy
+	HealthCheckTypeCommand HealthCheckType = "CMD"
+	// HealthCheckTypeHTTP issues an HTTP GET against a port of the container;
+	// any 2xx or 3xx response means healthy
+	HealthCheckTypeHTTP HealthCheckType = "HTTP"
+	// HealthCheckTypeTCP opens a TCP connection to a port of the container;
+	// a successful connect means healthy
+	HealthCheckTypeTCP HealthCheckType = "TCP"
+
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultHealthCheckTimeout  = 5 * time.Second
+	defaultHealthCheckRetries  = 3
+)
+
+// HealthCheckType is the mechanism used by the task engine to probe a
+// container
+type HealthCheckType string
+
+// HealthCheck describes how the task engine should decide whether a running
+// container is healthy. Durations are expressed in seconds, matching the task
+// definition; zero values are replaced with defaults.
+type HealthCheck struct {
+	Type    HealthCheckType `json:"type"`
+	Command []string        `json:"command"`
+	// Path is the path requested by an HTTP health check
+	Path string `json:"path"`
+	// Port is the container port probed by HTTP and TCP health checks
+	Port        uint16 `json:"port"`
+	Interval    uint   `json:"interval"`
+	Timeout     uint   `json:"timeout"`
+	Retries     uint   `json:"retries"`
+	StartPeriod uint   `json:"startPeriod"`
+}
+
+// Validate returns an error if the health check cannot be evaluated
+func (hc *HealthCheck) Validate() error {
+	switch hc.Type {
+	case HealthCheckTypeCommand:
+		if len(hc.Command) == 0 {
+			return errors.New("health check of type " + string(hc.Type) + " requires a command")
+		}
+	case HealthCheckTypeHTTP, HealthCheckTypeTCP:
+		if hc.Port == 0 {
+			return errors.New("health check of type " + string(hc.Type) + " requires a port")
+		}
+	default:
+		return errors.New("unrecognized health check type '" + string(hc.Type) + "'")
+	}
+	return nil
+}
+
+// IntervalDuration returns the time to wait between two checks
+func (hc *HealthCheck) IntervalDuration() time.Duration {
+	if hc.Interval == 0 {
+		return defaultHealthCheckInterval
+	}
+	return time.Duration(hc.Interval) * time.Second
+}
+
+// TimeoutDuration returns the time after which a single check is considered
+// to have failed
+func (hc *HealthCheck) TimeoutDuration() time.Duration {
+	if hc.Timeout == 0 {
+		return defaultHealthCheckTimeout
+	}
+	return time.Duration(hc.Timeout) * time.Second
+}
+
+// RetryCount returns the number of consecutive failures after which the
+// container is considered unhealthy
+func (hc *HealthCheck) RetryCount() int {
+	if hc.Retries == 0 {
+		return defaultHealthCheckRetries
+	}
+	return int(hc.Retries)
+}
+
+// StartPeriodDuration returns the grace period after the container starts
+// during which failed checks are not counted
+func (hc *HealthCheck) StartPeriodDuration() time.Duration {
+	return time.Duration(hc.StartPeriod) * time.Second
+}
+
+// HealthStatus is the result of the most recent evaluation of a container's
+// health check
+type HealthStatus struct {
+	Status ContainerHealthStatus `json:"status"`
+	Since  time.Time             `json:"statusSince"`
+	Output string                `json:"output,omitempty"`
+}
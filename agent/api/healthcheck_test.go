@@ -0,0 +1,71 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheckValidate(t *testing.T) {
+	testCases := []struct {
+		healthCheck HealthCheck
+		valid       bool
+	}{
+		{HealthCheck{Type: HealthCheckTypeCommand, Command: []string{"true"}}, true},
+		{HealthCheck{Type: HealthCheckTypeCommand}, false},
+		{HealthCheck{Type: HealthCheckTypeHTTP, Port: 80, Path: "/"}, true},
+		{HealthCheck{Type: HealthCheckTypeHTTP}, false},
+		{HealthCheck{Type: HealthCheckTypeTCP, Port: 22}, true},
+		{HealthCheck{Type: HealthCheckTypeTCP}, false},
+		{HealthCheck{Type: "UDP", Port: 53}, false},
+	}
+	for _, tc := range testCases {
+		err := tc.healthCheck.Validate()
+		if tc.valid {
+			assert.NoError(t, err, "%v", tc.healthCheck)
+		} else {
+			assert.Error(t, err, "%v", tc.healthCheck)
+		}
+	}
+}
+
+func TestHealthCheckDefaults(t *testing.T) {
+	healthCheck := &HealthCheck{}
+	assert.Equal(t, 30*time.Second, healthCheck.IntervalDuration())
+	assert.Equal(t, 5*time.Second, healthCheck.TimeoutDuration())
+	assert.Equal(t, 3, healthCheck.RetryCount())
+	assert.Equal(t, time.Duration(0), healthCheck.StartPeriodDuration())
+
+	healthCheck = &HealthCheck{Interval: 10, Timeout: 2, Retries: 5, StartPeriod: 60}
+	assert.Equal(t, 10*time.Second, healthCheck.IntervalDuration())
+	assert.Equal(t, 2*time.Second, healthCheck.TimeoutDuration())
+	assert.Equal(t, 5, healthCheck.RetryCount())
+	assert.Equal(t, time.Minute, healthCheck.StartPeriodDuration())
+}
+
+func TestContainerHealthStatusJSON(t *testing.T) {
+	status := HealthStatus{Status: ContainerUnhealthy, Output: "exit 1"}
+	data, err := json.Marshal(&status)
+	assert.NoError(t, err)
+
+	var unmarshalled HealthStatus
+	assert.NoError(t, json.Unmarshal(data, &unmarshalled))
+	assert.Equal(t, ContainerUnhealthy, unmarshalled.Status)
+	assert.Equal(t, "exit 1", unmarshalled.Output)
+}
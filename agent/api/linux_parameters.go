@@ -0,0 +1,299 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	"github.com/aws/amazon-ecs-agent/agent/utils"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// NamespaceModeHost shares the host's namespace with the container
+	NamespaceModeHost = "host"
+	// namespaceModeContainerPrefix prefixes the name of another container of
+	// the task whose namespace the container joins
+	namespaceModeContainerPrefix = "container:"
+)
+
+// LinuxParameters are Linux specific settings of a container. They are
+// applied over the host config given in DockerConfig.
+type LinuxParameters struct {
+	Capabilities *KernelCapabilities `json:"capabilities"`
+	Devices      []Device            `json:"devices"`
+	Ulimits      []Ulimit            `json:"ulimits"`
+	Sysctls      map[string]string   `json:"sysctls"`
+	// SharedMemorySize is the size, in MiB, of /dev/shm
+	SharedMemorySize int64   `json:"sharedMemorySize"`
+	Tmpfs            []Tmpfs `json:"tmpfs"`
+	// PidMode may only be "host"
+	PidMode string `json:"pidMode"`
+	// IpcMode is either "host" or "container:" followed by the name of
+	// another container of the task
+	IpcMode string `json:"ipcMode"`
+	// InitProcessEnabled is rejected, as the Docker client of the agent
+	// cannot run an init process in containers
+	InitProcessEnabled bool `json:"initProcessEnabled"`
+}
+
+// KernelCapabilities are the Linux capabilities added to, or dropped from,
+// Docker's default set. "ALL" stands for every capability.
+type KernelCapabilities struct {
+	Add  []string `json:"add"`
+	Drop []string `json:"drop"`
+}
+
+// Device is a host device exposed to a container
+type Device struct {
+	HostPath string `json:"hostPath"`
+	// ContainerPath defaults to HostPath
+	ContainerPath string `json:"containerPath"`
+	// Permissions is a combination of "r", "w" and "m", and defaults to all
+	// of them
+	Permissions string `json:"permissions"`
+}
+
+// Ulimit is a resource limit of a container
+type Ulimit struct {
+	Name      string `json:"name"`
+	SoftLimit int64  `json:"softLimit"`
+	HardLimit int64  `json:"hardLimit"`
+}
+
+// Tmpfs is a tmpfs filesystem mounted in a container
+type Tmpfs struct {
+	ContainerPath string `json:"containerPath"`
+	// Size is the size of the filesystem in MiB
+	Size         int64    `json:"size"`
+	MountOptions []string `json:"mountOptions"`
+}
+
+var kernelCapabilities = map[string]bool{
+	"ALL": true, "AUDIT_CONTROL": true, "AUDIT_READ": true, "AUDIT_WRITE": true, "BLOCK_SUSPEND": true,
+	"CHOWN": true, "DAC_OVERRIDE": true, "DAC_READ_SEARCH": true, "FOWNER": true, "FSETID": true,
+	"IPC_LOCK": true, "IPC_OWNER": true, "KILL": true, "LEASE": true, "LINUX_IMMUTABLE": true,
+	"MAC_ADMIN": true, "MAC_OVERRIDE": true, "MKNOD": true, "NET_ADMIN": true, "NET_BIND_SERVICE": true,
+	"NET_BROADCAST": true, "NET_RAW": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true,
+	"SETUID": true, "SYS_ADMIN": true, "SYS_BOOT": true, "SYS_CHROOT": true, "SYS_MODULE": true,
+	"SYS_NICE": true, "SYS_PACCT": true, "SYS_PTRACE": true, "SYS_RAWIO": true, "SYS_RESOURCE": true,
+	"SYS_TIME": true, "SYS_TTY_CONFIG": true, "SYSLOG": true, "WAKE_ALARM": true,
+}
+
+var tmpfsMountOptions = map[string]bool{
+	"defaults": true, "ro": true, "rw": true, "suid": true, "nosuid": true, "dev": true, "nodev": true,
+	"exec": true, "noexec": true, "sync": true, "async": true, "dirsync": true, "remount": true,
+	"mand": true, "nomand": true, "atime": true, "noatime": true, "diratime": true, "nodiratime": true,
+	"relatime": true, "norelatime": true, "strictatime": true, "nostrictatime": true, "mode": true,
+	"uid": true, "gid": true, "nr_inodes": true, "nr_blocks": true, "mpol": true,
+}
+
+// Validate returns an error describing the first setting that Docker would
+// not accept
+func (lp *LinuxParameters) Validate() error {
+	if lp.Capabilities != nil {
+		for _, capability := range append(lp.Capabilities.Add, lp.Capabilities.Drop...) {
+			if !kernelCapabilities[strings.TrimPrefix(strings.ToUpper(capability), "CAP_")] {
+				return fmt.Errorf("unknown capability %q", capability)
+			}
+		}
+	}
+	for _, device := range lp.Devices {
+		if !path.IsAbs(device.HostPath) {
+			return fmt.Errorf("device host path %q is not absolute", device.HostPath)
+		}
+		if device.ContainerPath != "" && !path.IsAbs(device.ContainerPath) {
+			return fmt.Errorf("device container path %q is not absolute", device.ContainerPath)
+		}
+		if strings.Trim(device.Permissions, "rwm") != "" {
+			return fmt.Errorf("device permissions %q are not a combination of r, w and m", device.Permissions)
+		}
+	}
+	for _, ulimit := range lp.Ulimits {
+		if !dockerclient.IsUlimitName(ulimit.Name) {
+			return fmt.Errorf("unknown ulimit %q", ulimit.Name)
+		}
+		if ulimit.SoftLimit > ulimit.HardLimit {
+			return fmt.Errorf("soft limit of ulimit %q exceeds its hard limit", ulimit.Name)
+		}
+	}
+	for name := range lp.Sysctls {
+		if !namespacedSysctl(name) {
+			return fmt.Errorf("sysctl %q is not namespaced, so it would apply to the whole host", name)
+		}
+	}
+	if lp.InitProcessEnabled {
+		return errors.New("an init process is not supported by this agent")
+	}
+	if lp.SharedMemorySize < 0 {
+		return fmt.Errorf("invalid shared memory size %d", lp.SharedMemorySize)
+	}
+	for _, tmpfs := range lp.Tmpfs {
+		if !path.IsAbs(tmpfs.ContainerPath) {
+			return fmt.Errorf("tmpfs container path %q is not absolute", tmpfs.ContainerPath)
+		}
+		if tmpfs.Size < 0 {
+			return fmt.Errorf("invalid size %d for tmpfs %s", tmpfs.Size, tmpfs.ContainerPath)
+		}
+		for _, option := range tmpfs.MountOptions {
+			if !tmpfsMountOptions[strings.SplitN(option, "=", 2)[0]] {
+				return fmt.Errorf("unknown mount option %q for tmpfs %s", option, tmpfs.ContainerPath)
+			}
+		}
+	}
+	if lp.PidMode != "" && lp.PidMode != NamespaceModeHost {
+		return fmt.Errorf("unknown pid mode %q", lp.PidMode)
+	}
+	if lp.IpcMode != "" && lp.IpcMode != NamespaceModeHost && lp.ipcContainerName() == "" {
+		return fmt.Errorf("unknown ipc mode %q", lp.IpcMode)
+	}
+	return nil
+}
+
+// namespacedSysctl returns true for the kernel parameters that Docker sets
+// within the container's namespaces
+func namespacedSysctl(name string) bool {
+	switch name {
+	case "kernel.msgmax", "kernel.msgmnb", "kernel.msgmni", "kernel.sem",
+		"kernel.shmall", "kernel.shmmax", "kernel.shmmni", "kernel.shm_rmid_forced":
+		return true
+	}
+	return strings.HasPrefix(name, "fs.mqueue.") || strings.HasPrefix(name, "net.")
+}
+
+// checkNetworkMode returns an error if network sysctls are set for a
+// container in the host's network namespace, where they would apply to the
+// whole host
+func (lp *LinuxParameters) checkNetworkMode(networkMode string) error {
+	if networkMode != NamespaceModeHost {
+		return nil
+	}
+	for name := range lp.Sysctls {
+		if strings.HasPrefix(name, "net.") {
+			return fmt.Errorf("sysctl %q cannot be set with the host network mode", name)
+		}
+	}
+	return nil
+}
+
+// ipcContainerName returns the name of the container whose IPC namespace is
+// joined, if any
+func (lp *LinuxParameters) ipcContainerName() string {
+	return strings.TrimPrefix(strings.TrimPrefix(lp.IpcMode, namespaceModeContainerPrefix), lp.IpcMode)
+}
+
+// Parameters returns the settings in use, to check that Docker supports them
+func (lp *LinuxParameters) Parameters() []dockerclient.LinuxParameter {
+	var parameters []dockerclient.LinuxParameter
+	if lp.Capabilities != nil && len(lp.Capabilities.Add)+len(lp.Capabilities.Drop) > 0 {
+		parameters = append(parameters, dockerclient.LinuxCapabilitiesParameter)
+	}
+	if len(lp.Devices) > 0 {
+		parameters = append(parameters, dockerclient.LinuxDevicesParameter)
+	}
+	if len(lp.Ulimits) > 0 {
+		parameters = append(parameters, dockerclient.LinuxUlimitsParameter)
+	}
+	if len(lp.Sysctls) > 0 {
+		parameters = append(parameters, dockerclient.LinuxSysctlsParameter)
+	}
+	if lp.SharedMemorySize > 0 {
+		parameters = append(parameters, dockerclient.LinuxSharedMemorySizeParameter)
+	}
+	if len(lp.Tmpfs) > 0 {
+		parameters = append(parameters, dockerclient.LinuxTmpfsParameter)
+	}
+	if lp.PidMode != "" {
+		parameters = append(parameters, dockerclient.LinuxPidModeParameter)
+	}
+	if lp.IpcMode != "" {
+		parameters = append(parameters, dockerclient.LinuxIpcModeParameter)
+	}
+	return parameters
+}
+
+// NamespaceContainerNames returns the names of the containers whose
+// namespaces the container joins, which must be running before it starts
+func (c *Container) NamespaceContainerNames() []string {
+	if c.LinuxParameters == nil {
+		return nil
+	}
+	if name := c.LinuxParameters.ipcContainerName(); name != "" {
+		return []string{name}
+	}
+	return nil
+}
+
+// applyLinuxParameters sets the container's Linux parameters in its host
+// config
+func applyLinuxParameters(hostConfig *docker.HostConfig, lp *LinuxParameters) {
+	if lp.Capabilities != nil {
+		hostConfig.CapAdd = lp.Capabilities.Add
+		hostConfig.CapDrop = lp.Capabilities.Drop
+	}
+	if len(lp.Devices) > 0 {
+		hostConfig.Devices = make([]docker.Device, len(lp.Devices))
+		for i, device := range lp.Devices {
+			hostConfig.Devices[i] = docker.Device{
+				PathOnHost:        device.HostPath,
+				PathInContainer:   utils.DefaultIfBlank(device.ContainerPath, device.HostPath),
+				CgroupPermissions: utils.DefaultIfBlank(device.Permissions, "rwm"),
+			}
+		}
+	}
+	if len(lp.Ulimits) > 0 {
+		hostConfig.Ulimits = make([]docker.ULimit, len(lp.Ulimits))
+		for i, ulimit := range lp.Ulimits {
+			hostConfig.Ulimits[i] = docker.ULimit{Name: ulimit.Name, Soft: ulimit.SoftLimit, Hard: ulimit.HardLimit}
+		}
+	}
+	if len(lp.Sysctls) > 0 {
+		hostConfig.Sysctls = lp.Sysctls
+	}
+	if lp.SharedMemorySize > 0 {
+		hostConfig.ShmSize = lp.SharedMemorySize * 1024 * 1024
+	}
+	if len(lp.Tmpfs) > 0 {
+		hostConfig.Tmpfs = make(map[string]string)
+		for _, tmpfs := range lp.Tmpfs {
+			options := append([]string{}, tmpfs.MountOptions...)
+			if tmpfs.Size > 0 {
+				options = append(options, fmt.Sprintf("size=%dm", tmpfs.Size))
+			}
+			hostConfig.Tmpfs[tmpfs.ContainerPath] = strings.Join(options, ",")
+		}
+	}
+	if lp.PidMode != "" {
+		hostConfig.PidMode = lp.PidMode
+	}
+}
+
+// dockerIpcMode returns the IPC mode of the container in Docker, in which
+// the name of the container whose namespace is joined is replaced by its
+// Docker name
+func (task *Task) dockerIpcMode(container *Container, dockerContainerMap map[string]*DockerContainer) (string, error) {
+	name := container.LinuxParameters.ipcContainerName()
+	if name == "" {
+		return container.LinuxParameters.IpcMode, nil
+	}
+	target, ok := dockerContainerMap[name]
+	if !ok {
+		return "", fmt.Errorf("IPC namespace container not available: %s", name)
+	}
+	return namespaceModeContainerPrefix + target.DockerName, nil
+}
@@ -0,0 +1,145 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinuxParametersValidate(t *testing.T) {
+	testCases := []struct {
+		parameters LinuxParameters
+		valid      bool
+	}{
+		{LinuxParameters{}, true},
+		{LinuxParameters{Capabilities: &KernelCapabilities{Add: []string{"SYS_PTRACE", "cap_net_admin"}, Drop: []string{"ALL"}}}, true},
+		{LinuxParameters{Capabilities: &KernelCapabilities{Add: []string{"SUPERPOWERS"}}}, false},
+		{LinuxParameters{Devices: []Device{{HostPath: "/dev/fuse", Permissions: "rw"}}}, true},
+		{LinuxParameters{Devices: []Device{{HostPath: "dev/fuse"}}}, false},
+		{LinuxParameters{Devices: []Device{{HostPath: "/dev/fuse", ContainerPath: "fuse"}}}, false},
+		{LinuxParameters{Devices: []Device{{HostPath: "/dev/fuse", Permissions: "rwx"}}}, false},
+		{LinuxParameters{Ulimits: []Ulimit{{Name: "nofile", SoftLimit: 1024, HardLimit: 4096}}}, true},
+		{LinuxParameters{Ulimits: []Ulimit{{Name: "files", SoftLimit: 1024, HardLimit: 4096}}}, false},
+		{LinuxParameters{Ulimits: []Ulimit{{Name: "nofile", SoftLimit: 4096, HardLimit: 1024}}}, false},
+		{LinuxParameters{Sysctls: map[string]string{"net.core.somaxconn": "1024", "kernel.shmmax": "1"}}, true},
+		{LinuxParameters{Sysctls: map[string]string{"vm.swappiness": "0"}}, false},
+		{LinuxParameters{InitProcessEnabled: true}, false},
+		{LinuxParameters{SharedMemorySize: -1}, false},
+		{LinuxParameters{Tmpfs: []Tmpfs{{ContainerPath: "/run", Size: 64, MountOptions: []string{"noexec", "mode=1777"}}}}, true},
+		{LinuxParameters{Tmpfs: []Tmpfs{{ContainerPath: "run"}}}, false},
+		{LinuxParameters{Tmpfs: []Tmpfs{{ContainerPath: "/run", Size: -1}}}, false},
+		{LinuxParameters{Tmpfs: []Tmpfs{{ContainerPath: "/run", MountOptions: []string{"bind"}}}}, false},
+		{LinuxParameters{PidMode: "host", IpcMode: "host"}, true},
+		{LinuxParameters{PidMode: "container:app"}, false},
+		{LinuxParameters{IpcMode: "container:app"}, true},
+		{LinuxParameters{IpcMode: "container:"}, false},
+		{LinuxParameters{IpcMode: "shareable"}, false},
+	}
+
+	for _, tc := range testCases {
+		err := tc.parameters.Validate()
+		if tc.valid {
+			assert.NoError(t, err, "%+v", tc.parameters)
+		} else {
+			assert.Error(t, err, "%+v", tc.parameters)
+		}
+	}
+}
+
+func TestLinuxParametersParameters(t *testing.T) {
+	lp := &LinuxParameters{
+		Capabilities: &KernelCapabilities{},
+		Ulimits:      []Ulimit{{Name: "nofile"}},
+		Tmpfs:        []Tmpfs{{ContainerPath: "/run"}},
+		IpcMode:      "host",
+	}
+	assert.Equal(t, []dockerclient.LinuxParameter{
+		dockerclient.LinuxUlimitsParameter,
+		dockerclient.LinuxTmpfsParameter,
+		dockerclient.LinuxIpcModeParameter,
+	}, lp.Parameters())
+}
+
+func TestDockerHostConfigLinuxParameters(t *testing.T) {
+	rawHostConfig := `{"CapAdd":["NET_ADMIN"],"ShmSize":1024,"Privileged":true}`
+	container := &Container{
+		Name: "app",
+		DockerConfig: DockerConfig{
+			HostConfig: &rawHostConfig,
+		},
+		LinuxParameters: &LinuxParameters{
+			Capabilities:     &KernelCapabilities{Add: []string{"SYS_PTRACE"}},
+			Devices:          []Device{{HostPath: "/dev/fuse"}},
+			Ulimits:          []Ulimit{{Name: "nofile", SoftLimit: 1024, HardLimit: 4096}},
+			Sysctls:          map[string]string{"net.core.somaxconn": "1024"},
+			SharedMemorySize: 64,
+			Tmpfs:            []Tmpfs{{ContainerPath: "/run", Size: 16, MountOptions: []string{"noexec"}}},
+			IpcMode:          "container:sidecar",
+		},
+	}
+	task := &Task{Containers: []*Container{container, {Name: "sidecar"}}}
+
+	_, err := task.DockerHostConfig(container, map[string]*DockerContainer{}, nil)
+	assert.Error(t, err, "expected an error while the IPC namespace container does not exist")
+
+	hostConfig, err := task.DockerHostConfig(container, map[string]*DockerContainer{
+		"sidecar": {DockerName: "ecs-sidecar"},
+	}, nil)
+	assert.Nil(t, err)
+	assert.True(t, hostConfig.Privileged, "settings only in the raw host config should be kept")
+	assert.Equal(t, []string{"SYS_PTRACE"}, hostConfig.CapAdd)
+	assert.Equal(t, []docker.Device{{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse", CgroupPermissions: "rwm"}}, hostConfig.Devices)
+	assert.Equal(t, []docker.ULimit{{Name: "nofile", Soft: 1024, Hard: 4096}}, hostConfig.Ulimits)
+	assert.Equal(t, map[string]string{"net.core.somaxconn": "1024"}, hostConfig.Sysctls)
+	assert.Equal(t, int64(64*1024*1024), hostConfig.ShmSize)
+	assert.Equal(t, map[string]string{"/run": "noexec,size=16m"}, hostConfig.Tmpfs)
+	assert.Equal(t, "container:ecs-sidecar", hostConfig.IpcMode)
+}
+
+func TestDockerHostConfigHostNetworkSysctls(t *testing.T) {
+	rawHostConfig := `{"NetworkMode":"host"}`
+	container := &Container{
+		Name: "app",
+		DockerConfig: DockerConfig{
+			HostConfig: &rawHostConfig,
+		},
+		LinuxParameters: &LinuxParameters{
+			Sysctls: map[string]string{"kernel.shmmax": "1"},
+		},
+	}
+	task := &Task{Containers: []*Container{container}}
+
+	_, err := task.DockerHostConfig(container, map[string]*DockerContainer{}, nil)
+	assert.Nil(t, err)
+
+	container.LinuxParameters.Sysctls["net.core.somaxconn"] = "1024"
+	_, err = task.DockerHostConfig(container, map[string]*DockerContainer{}, nil)
+	assert.Error(t, err, "expected an error for a network sysctl with the host network mode")
+}
+
+func TestLinuxParametersUnmarshal(t *testing.T) {
+	var container Container
+	err := json.Unmarshal([]byte(`{"name":"app","linuxParameters":{"capabilities":{"drop":["ALL"]},"ulimits":[{"name":"nofile","softLimit":1,"hardLimit":2}],"pidMode":"host","initProcessEnabled":true}}`), &container)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ALL"}, container.LinuxParameters.Capabilities.Drop)
+	assert.Equal(t, []Ulimit{{Name: "nofile", SoftLimit: 1, HardLimit: 2}}, container.LinuxParameters.Ulimits)
+	assert.Equal(t, NamespaceModeHost, container.LinuxParameters.PidMode)
+	assert.True(t, container.LinuxParameters.InitProcessEnabled, "init should be kept, to be rejected")
+}
@@ -0,0 +1,65 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"sort"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+// defaultNetworkName names the only network of containers created by
+// versions of Docker which do not report networks by name
+const defaultNetworkName = "default"
+
+// Network is a network a container is attached to, with its addresses on it
+type Network struct {
+	Name        string `json:"name"`
+	IPv4Address string `json:"ipv4Address,omitempty"`
+	IPv6Address string `json:"ipv6Address,omitempty"`
+}
+
+// NetworksFromDockerNetworkSettings returns the networks of a container,
+// sorted by name, from its docker NetworkSettings
+func NetworksFromDockerNetworkSettings(settings *docker.NetworkSettings) []Network {
+	if settings == nil {
+		return nil
+	}
+	if len(settings.Networks) == 0 {
+		if settings.IPAddress == "" && settings.GlobalIPv6Address == "" {
+			return nil
+		}
+		return []Network{{
+			Name:        defaultNetworkName,
+			IPv4Address: settings.IPAddress,
+			IPv6Address: settings.GlobalIPv6Address,
+		}}
+	}
+	networks := make([]Network, 0, len(settings.Networks))
+	for name, network := range settings.Networks {
+		networks = append(networks, Network{
+			Name:        name,
+			IPv4Address: network.IPAddress,
+			IPv6Address: network.GlobalIPv6Address,
+		})
+	}
+	sort.Sort(networksByName(networks))
+	return networks
+}
+
+type networksByName []Network
+
+func (networks networksByName) Len() int           { return len(networks) }
+func (networks networksByName) Less(i, j int) bool { return networks[i].Name < networks[j].Name }
+func (networks networksByName) Swap(i, j int)      { networks[i], networks[j] = networks[j], networks[i] }
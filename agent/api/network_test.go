@@ -0,0 +1,42 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworksFromDockerNetworkSettings(t *testing.T) {
+	assert.Nil(t, NetworksFromDockerNetworkSettings(nil))
+	assert.Nil(t, NetworksFromDockerNetworkSettings(&docker.NetworkSettings{}))
+
+	assert.Equal(t, []Network{
+		{Name: "default", IPv4Address: "172.17.0.2"},
+	}, NetworksFromDockerNetworkSettings(&docker.NetworkSettings{IPAddress: "172.17.0.2"}))
+
+	assert.Equal(t, []Network{
+		{Name: "backend", IPv4Address: "10.0.0.3", IPv6Address: "fd00::3"},
+		{Name: "bridge", IPv4Address: "172.17.0.2"},
+	}, NetworksFromDockerNetworkSettings(&docker.NetworkSettings{
+		IPAddress: "172.17.0.2",
+		Networks: map[string]docker.ContainerNetwork{
+			"bridge":  {IPAddress: "172.17.0.2"},
+			"backend": {IPAddress: "10.0.0.3", GlobalIPv6Address: "fd00::3"},
+		},
+	}))
+}
@@ -0,0 +1,72 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// RestartPolicyNever leaves a container stopped once it exits
+	RestartPolicyNever RestartPolicyType = "never"
+	// RestartPolicyOnFailure restarts a container that exits with a non-zero
+	// exit code, up to MaxAttempts times
+	RestartPolicyOnFailure RestartPolicyType = "on-failure"
+	// RestartPolicyAlways restarts a container whenever it exits
+	RestartPolicyAlways RestartPolicyType = "always"
+
+	defaultRestartBackoff = 10 * time.Second
+	maxRestartBackoff     = 5 * time.Minute
+)
+
+// RestartPolicyType is the condition under which a container is restarted
+type RestartPolicyType string
+
+// RestartPolicy describes if and how the task engine restarts a non-essential
+// container after it exits
+type RestartPolicy struct {
+	Type RestartPolicyType `json:"type"`
+	// MaxAttempts bounds the number of restarts for the on-failure policy; 0
+	// means there is no limit
+	MaxAttempts uint `json:"maxAttempts"`
+	// Backoff is the number of seconds to wait before the first restart. The
+	// wait doubles with each subsequent restart.
+	Backoff uint `json:"backoff"`
+}
+
+// Validate returns an error if the restart policy is not understood
+func (rp *RestartPolicy) Validate() error {
+	switch rp.Type {
+	case RestartPolicyNever, RestartPolicyOnFailure, RestartPolicyAlways:
+		return nil
+	}
+	return errors.New("unrecognized restart policy type '" + string(rp.Type) + "'")
+}
+
+// BackoffDuration returns how long to wait before restarting a container that
+// has already been restarted restartCount times
+func (rp *RestartPolicy) BackoffDuration(restartCount int) time.Duration {
+	backoff := defaultRestartBackoff
+	if rp.Backoff != 0 {
+		backoff = time.Duration(rp.Backoff) * time.Second
+	}
+	for i := 0; i < restartCount && backoff < maxRestartBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRestartBackoff {
+		return maxRestartBackoff
+	}
+	return backoff
+}
@@ -100,3 +100,18 @@ func (cs ContainerStatus) Terminal() bool {
 func (ts TaskStatus) Terminal() bool {
 	return ts == TaskStopped
 }
+
+var containerHealthStatusMap = map[string]ContainerHealthStatus{
+	"UNKNOWN":   ContainerHealthUnknown,
+	"HEALTHY":   ContainerHealthy,
+	"UNHEALTHY": ContainerUnhealthy,
+}
+
+func (hs ContainerHealthStatus) String() string {
+	for k, v := range containerHealthStatusMap {
+		if v == hs {
+			return k
+		}
+	}
+	return "UNKNOWN"
+}
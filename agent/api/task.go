@@ -23,7 +23,7 @@ import (
 
 	"github.com/aws/amazon-ecs-agent/agent/acs/model/ecsacs"
 	"github.com/aws/amazon-ecs-agent/agent/credentials"
-	"github.com/aws/amazon-ecs-agent/agent/engine/emptyvolume"
+	"github.com/aws/amazon-ecs-agent/agent/utils"
 	"github.com/aws/amazon-ecs-agent/agent/utils/ttime"
 	"github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
 	"github.com/cihub/seelog"
@@ -46,55 +46,58 @@ func (task *Task) PostUnmarshalTask(credentialsManager credentials.Manager) {
 	// TODO, add rudimentary plugin support and call any plugins that want to
 	// hook into this
 	task.adjustForPlatform()
-	task.initializeEmptyVolumes()
+	task.initializeDockerVolumes()
 	task.initializeCredentialsEndpoint(credentialsManager)
-}
-
-func (task *Task) initializeEmptyVolumes() {
-	requiredEmptyVolumes := []string{}
-	for _, container := range task.Containers {
-		for _, mountPoint := range container.MountPoints {
-			vol, ok := task.HostVolumeByName(mountPoint.SourceVolume)
-			if !ok {
-				continue
-			}
-			if _, ok := vol.(*EmptyHostVolume); ok {
-				if container.RunDependencies == nil {
-					container.RunDependencies = make([]string, 0)
+	task.initializeMetadataEndpoint()
+}
+
+// RemoveEmptyVolumeContainer drops the internal container that older agents
+// added to tasks to back their empty volumes, along with the dependencies on
+// it. The container is kept if it has already been created, as its volumes
+// may be in use by the other containers of the task. It returns whether the
+// container was removed.
+func (task *Task) RemoveEmptyVolumeContainer() bool {
+	for i, container := range task.Containers {
+		if container.Name != emptyHostVolumeName || !container.IsInternal {
+			continue
+		}
+		if container.GetKnownStatus() >= ContainerCreated {
+			return false
+		}
+		task.Containers = append(task.Containers[:i], task.Containers[i+1:]...)
+		for _, cont := range task.Containers {
+			dependencies := cont.RunDependencies[:0]
+			for _, dependency := range cont.RunDependencies {
+				if dependency != emptyHostVolumeName {
+					dependencies = append(dependencies, dependency)
 				}
-				container.RunDependencies = append(container.RunDependencies, emptyHostVolumeName)
-				requiredEmptyVolumes = append(requiredEmptyVolumes, mountPoint.SourceVolume)
 			}
+			cont.RunDependencies = dependencies
 		}
+		return true
 	}
+	return false
+}
 
-	if len(requiredEmptyVolumes) == 0 {
-		// No need to create the auxiliary 'empty-volumes' container
-		return
-	}
-
-	// If we have required empty volumes, add an 'internal' container that handles all
-	// of them
-	_, ok := task.ContainerByName(emptyHostVolumeName)
-	if !ok {
-		mountPoints := make([]MountPoint, len(requiredEmptyVolumes))
-		for i, volume := range requiredEmptyVolumes {
-			// BUG(samuelkarp) On Windows, volumes with names that differ only by case will collide
-			containerPath := getCanonicalPath(emptyvolume.ContainerPathPrefix + volume)
-			mountPoints[i] = MountPoint{SourceVolume: volume, ContainerPath: containerPath}
+// initializeDockerVolumes names the Docker volumes of the task. Task scoped
+// volumes get a name of their own so that tasks using the same task
+// definition do not share them. Shared volumes are named after the task
+// family, so that only tasks of the same family share them.
+func (task *Task) initializeDockerVolumes() {
+	for _, taskVolume := range task.Volumes {
+		volume, ok := taskVolume.Volume.(*DockerVolume)
+		if !ok || volume.DockerVolumeName != "" {
+			continue
+		}
+		if volume.Scope == "" {
+			volume.Scope = DockerVolumeTaskScope
 		}
-		sourceContainer := &Container{
-			Name:          emptyHostVolumeName,
-			Image:         emptyvolume.Image + ":" + emptyvolume.Tag,
-			Command:       []string{emptyvolume.Command}, // Command required, but this only gets created so N/A
-			MountPoints:   mountPoints,
-			Essential:     false,
-			IsInternal:    true,
-			DesiredStatus: ContainerRunning,
+		if volume.Scope == DockerVolumeSharedScope {
+			volume.DockerVolumeName = "ecs-" + task.Family + "-" + taskVolume.Name
+		} else {
+			volume.DockerVolumeName = "ecs-" + task.Family + "-" + task.Version + "-" + taskVolume.Name + "-" + utils.RandHex()
 		}
-		task.Containers = append(task.Containers, sourceContainer)
 	}
-
 }
 
 // initializeCredentialsEndpoint sets the credentials endpoint for all containers in a task if needed.
@@ -148,6 +151,49 @@ func (task *Task) HostVolumeByName(name string) (HostVolume, bool) {
 	return nil, false
 }
 
+// tmpfsScratchVolume returns the empty volume of a task volume if it is held
+// in memory
+func tmpfsScratchVolume(volume TaskVolume) (*EmptyHostVolume, bool) {
+	emptyVolume, ok := volume.Volume.(*EmptyHostVolume)
+	if !ok || emptyVolume.SizeLimit <= 0 || emptyVolume.Backing == ScratchBackingLoop {
+		return nil, false
+	}
+	return emptyVolume, true
+}
+
+// ScratchVolumeMemory returns the memory, in MiB, taken up by the empty volumes
+// of the task that are held in memory once they are full. Each volume is
+// counted once, however many containers mount it.
+func (task *Task) ScratchVolumeMemory() int64 {
+	var memory int64
+	for _, volume := range task.Volumes {
+		if emptyVolume, ok := tmpfsScratchVolume(volume); ok {
+			memory += emptyVolume.SizeLimit
+		}
+	}
+	return memory
+}
+
+// containerScratchVolumeMemory returns the memory, in MiB, taken up by the
+// empty volumes held in memory that the container mounts. Writes to these are
+// charged to the memory of the container, so they count against its limit.
+func (task *Task) containerScratchVolumeMemory(container *Container) int64 {
+	var memory int64
+	mounted := make(map[string]bool)
+	for _, mountPoint := range container.MountPoints {
+		mounted[mountPoint.SourceVolume] = true
+	}
+	for _, volume := range task.Volumes {
+		if !mounted[volume.Name] {
+			continue
+		}
+		if emptyVolume, ok := tmpfsScratchVolume(volume); ok {
+			memory += emptyVolume.SizeLimit
+		}
+	}
+	return memory
+}
+
 // UpdateMountPoints updates the mount points of volumes that were created
 // without specifying a host path.  This is used as part of the empty host
 // volume feature.
@@ -246,8 +292,11 @@ func (task *Task) dockerConfig(container *Container) (*docker.Config, *DockerCli
 		dockerEnv = append(dockerEnv, envKey+"="+envVal)
 	}
 
-	// Convert MB to B
+	// Convert MB to B. Containers without a memory limit are left without one.
 	dockerMem := int64(container.Memory * 1024 * 1024)
+	if dockerMem != 0 {
+		dockerMem += task.containerScratchVolumeMemory(container) * 1024 * 1024
+	}
 	if dockerMem != 0 && dockerMem < DOCKER_MINIMUM_MEMORY {
 		dockerMem = DOCKER_MINIMUM_MEMORY
 	}
@@ -258,7 +307,7 @@ func (task *Task) dockerConfig(container *Container) (*docker.Config, *DockerCli
 	}
 
 	config := &docker.Config{
-		Image:        container.Image,
+		Image:        container.ImageReference(),
 		Cmd:          container.Command,
 		Entrypoint:   entryPoint,
 		ExposedPorts: task.dockerExposedPorts(container),
@@ -325,11 +374,11 @@ func (task *Task) dockerConfigVolumes(container *Container) (map[string]struct{}
 	return volumeMap, nil
 }
 
-func (task *Task) DockerHostConfig(container *Container, dockerContainerMap map[string]*DockerContainer) (*docker.HostConfig, *HostConfigError) {
-	return task.Overridden().dockerHostConfig(container.Overridden(), dockerContainerMap)
+func (task *Task) DockerHostConfig(container *Container, dockerContainerMap map[string]*DockerContainer, defaults *ContainerDefaults) (*docker.HostConfig, *HostConfigError) {
+	return task.Overridden().dockerHostConfig(container.Overridden(), dockerContainerMap, defaults)
 }
 
-func (task *Task) dockerHostConfig(container *Container, dockerContainerMap map[string]*DockerContainer) (*docker.HostConfig, *HostConfigError) {
+func (task *Task) dockerHostConfig(container *Container, dockerContainerMap map[string]*DockerContainer, defaults *ContainerDefaults) (*docker.HostConfig, *HostConfigError) {
 	dockerLinkArr, err := task.dockerLinks(container, dockerContainerMap)
 	if err != nil {
 		return nil, &HostConfigError{err.Error()}
@@ -361,6 +410,23 @@ func (task *Task) dockerHostConfig(container *Container, dockerContainerMap map[
 		}
 	}
 
+	if container.LinuxParameters != nil {
+		if err := container.LinuxParameters.checkNetworkMode(hostConfig.NetworkMode); err != nil {
+			return nil, &HostConfigError{err.Error()}
+		}
+		applyLinuxParameters(hostConfig, container.LinuxParameters)
+		if container.LinuxParameters.IpcMode != "" {
+			hostConfig.IpcMode, err = task.dockerIpcMode(container, dockerContainerMap)
+			if err != nil {
+				return nil, &HostConfigError{err.Error()}
+			}
+		}
+	}
+
+	if defaults != nil {
+		defaults.apply(hostConfig)
+	}
+
 	return hostConfig, nil
 }
 
@@ -530,6 +596,22 @@ func (task *Task) GetKnownStatusTime() time.Time {
 	return task.KnownStatusTime
 }
 
+// GetStopDeadline gets the StopDeadline of the task
+func (task *Task) GetStopDeadline() time.Time {
+	task.stopDeadlineLock.RLock()
+	defer task.stopDeadlineLock.RUnlock()
+
+	return task.StopDeadline
+}
+
+// SetStopDeadline sets the StopDeadline of the task
+func (task *Task) SetStopDeadline(deadline time.Time) {
+	task.stopDeadlineLock.Lock()
+	defer task.stopDeadlineLock.Unlock()
+
+	task.StopDeadline = deadline
+}
+
 func (task *Task) setKnownStatus(status TaskStatus) {
 	task.knownStatusLock.Lock()
 	defer task.knownStatusLock.Unlock()
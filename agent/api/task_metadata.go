@@ -0,0 +1,49 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import "github.com/pborman/uuid"
+
+const (
+	// TaskMetadataPath is the path under which the metadata endpoint of each
+	// task is served, next to the credentials endpoint
+	TaskMetadataPath = "/v1/metadata"
+
+	// metadataRelativeURIEnvironmentVariableName is the name of the
+	// environment variable holding the relative URI of the task's metadata
+	// endpoint
+	metadataRelativeURIEnvironmentVariableName = "ECS_CONTAINER_METADATA_RELATIVE_URI"
+)
+
+// initializeMetadataEndpoint gives the task the unguessable ID of its
+// metadata endpoint, if it does not have one yet, and sets the endpoint's
+// URI in the environment of all its containers.
+func (task *Task) initializeMetadataEndpoint() {
+	if task.MetadataEndpointID == "" {
+		// Random UUIDs are generated from crypto/rand
+		task.MetadataEndpointID = uuid.New()
+	}
+	for _, container := range task.Containers {
+		if container.Environment == nil {
+			container.Environment = make(map[string]string)
+		}
+		container.Environment[metadataRelativeURIEnvironmentVariableName] = task.MetadataEndpointRelativeURI()
+	}
+}
+
+// MetadataEndpointRelativeURI returns the relative URI of the task's
+// metadata endpoint
+func (task *Task) MetadataEndpointRelativeURI() string {
+	return TaskMetadataPath + "/" + task.MetadataEndpointID
+}
@@ -0,0 +1,47 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeMetadataEndpoint(t *testing.T) {
+	task := &Task{
+		Arn: "arn",
+		Containers: []*Container{
+			{Name: "app", Environment: map[string]string{"KEY": "value"}},
+			{Name: "sidecar"},
+		},
+	}
+	task.PostUnmarshalTask(nil)
+
+	id := task.MetadataEndpointID
+	assert.Len(t, id, 36, "expected a random UUID")
+	for _, container := range task.Containers {
+		assert.Equal(t, "/v1/metadata/"+id, container.Environment["ECS_CONTAINER_METADATA_RELATIVE_URI"])
+	}
+	assert.Equal(t, "value", task.Containers[0].Environment["KEY"])
+
+	// The ID must not change once containers have been given it
+	task.PostUnmarshalTask(nil)
+	assert.Equal(t, id, task.MetadataEndpointID)
+
+	other := &Task{Arn: "other"}
+	other.PostUnmarshalTask(nil)
+	assert.NotEqual(t, id, other.MetadataEndpointID)
+}
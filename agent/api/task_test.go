@@ -79,6 +79,73 @@ func TestDockerConfigPortBinding(t *testing.T) {
 	}
 }
 
+func TestDockerConfigImageExpectedDigest(t *testing.T) {
+	testTask := &Task{
+		Containers: []*Container{
+			&Container{
+				Name:                "c1",
+				Image:               "busybox:latest",
+				ExpectedImageDigest: "sha256:expected",
+			},
+		},
+	}
+
+	config, err := testTask.DockerConfig(testTask.Containers[0])
+	if err != nil {
+		t.Error(err)
+	}
+
+	if config.Image != "busybox@sha256:expected" {
+		t.Error("Container pinned to a digest is not created from the digest:", config.Image)
+	}
+}
+
+func TestDockerConfigMemoryIncludesTmpfsScratchVolumes(t *testing.T) {
+	testTask := &Task{
+		Containers: []*Container{
+			&Container{
+				Name:   "c1",
+				Memory: 256,
+				MountPoints: []MountPoint{
+					{SourceVolume: "tmpfs", ContainerPath: "/tmpfs"},
+					{SourceVolume: "loop", ContainerPath: "/loop"},
+				},
+			},
+			&Container{
+				Name: "c2",
+				MountPoints: []MountPoint{
+					{SourceVolume: "tmpfs", ContainerPath: "/tmpfs"},
+				},
+			},
+		},
+		Volumes: []TaskVolume{
+			{Name: "tmpfs", Volume: &EmptyHostVolume{SizeLimit: 64}},
+			{Name: "loop", Volume: &EmptyHostVolume{SizeLimit: 128, Backing: ScratchBackingLoop}},
+			{Name: "unused", Volume: &EmptyHostVolume{SizeLimit: 32, Backing: ScratchBackingTmpfs}},
+		},
+	}
+
+	config, err := testTask.DockerConfig(testTask.Containers[0])
+	if err != nil {
+		t.Error(err)
+	}
+	if config.Memory != (256+64)*1024*1024 {
+		t.Error("Memory limit does not include the tmpfs volume of the container:", config.Memory)
+	}
+
+	config, err = testTask.DockerConfig(testTask.Containers[1])
+	if err != nil {
+		t.Error(err)
+	}
+	if config.Memory != 0 {
+		t.Error("Container without a memory limit was given one:", config.Memory)
+	}
+
+	if testTask.ScratchVolumeMemory() != 64+32 {
+		t.Error("Wrong memory for the tmpfs volumes of the task:", testTask.ScratchVolumeMemory())
+	}
+}
+
 func TestDockerConfigCPUShareZero(t *testing.T) {
 	testTask := &Task{
 		Containers: []*Container{
@@ -149,7 +216,7 @@ func TestDockerHostConfigPortBinding(t *testing.T) {
 		},
 	}
 
-	config, err := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask))
+	config, err := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask), nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -180,7 +247,7 @@ func TestDockerHostConfigVolumesFrom(t *testing.T) {
 		},
 	}
 
-	config, err := testTask.DockerHostConfig(testTask.Containers[1], dockerMap(testTask))
+	config, err := testTask.DockerHostConfig(testTask.Containers[1], dockerMap(testTask), nil)
 	if err != nil {
 		t.Fatal("Error creating config: ", err)
 	}
@@ -223,7 +290,7 @@ func TestDockerHostConfigRawConfig(t *testing.T) {
 		},
 	}
 
-	config, configErr := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask))
+	config, configErr := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask), nil)
 	if configErr != nil {
 		t.Fatal(configErr)
 	}
@@ -270,7 +337,7 @@ func TestDockerHostConfigRawConfigMerging(t *testing.T) {
 		},
 	}
 
-	hostConfig, configErr := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask))
+	hostConfig, configErr := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask), nil)
 	if configErr != nil {
 		t.Fatal(configErr)
 	}
@@ -299,7 +366,7 @@ func TestBadDockerHostConfigRawConfig(t *testing.T) {
 				},
 			},
 		}
-		_, err := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(&testTask))
+		_, err := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(&testTask), nil)
 		if err == nil {
 			t.Fatal("Expected error, was none for: " + badHostConfig)
 		}
@@ -550,20 +617,138 @@ func TestPostUnmarshalTaskWithEmptyVolumes(t *testing.T) {
 	assert.Equal(t, 2, len(task.Containers)) // before PostUnmarshalTask
 	task.PostUnmarshalTask(nil)
 
-	assert.Equal(t, 3, len(task.Containers), "Should include new container for volumes")
-	emptyContainer, ok := task.ContainerByName(emptyHostVolumeName)
-	assert.True(t, ok, "Should find empty volume container")
-	assert.Equal(t, 2, len(emptyContainer.MountPoints), "Should have two mount points")
-	assert.Equal(t, []MountPoint{
-		{
-			SourceVolume:  emptyVolumeName1,
-			ContainerPath: expectedEmptyVolumeGeneratedPath1,
-		}, {
-			SourceVolume:  emptyVolumeName2,
-			ContainerPath: expectedEmptyVolumeGeneratedPath2,
+	assert.Equal(t, 2, len(task.Containers), "Should not add a container for volumes")
+	for _, container := range task.Containers {
+		assert.Empty(t, container.RunDependencies, "Should not depend on other containers")
+	}
+	for _, volume := range task.Volumes {
+		emptyVolume, ok := volume.Volume.(*EmptyHostVolume)
+		assert.True(t, ok, "Should be an empty volume")
+		assert.Empty(t, emptyVolume.HostPath, "Host path should be set by the engine")
+	}
+}
+
+func TestRemoveEmptyVolumeContainer(t *testing.T) {
+	newTask := func(internalStatus ContainerStatus) *Task {
+		return &Task{
+			Arn: "myArn",
+			Containers: []*Container{
+				{
+					Name:            "myName",
+					MountPoints:     []MountPoint{{SourceVolume: "empty", ContainerPath: "/empty"}},
+					RunDependencies: []string{emptyHostVolumeName},
+				},
+				{
+					Name:        emptyHostVolumeName,
+					MountPoints: []MountPoint{{SourceVolume: "empty", ContainerPath: "/ecs-empty-volume/empty"}},
+					IsInternal:  true,
+					KnownStatus: internalStatus,
+				},
+			},
+			Volumes: []TaskVolume{{Name: "empty", Volume: &EmptyHostVolume{}}},
+		}
+	}
+
+	task := newTask(ContainerPulled)
+	assert.True(t, task.RemoveEmptyVolumeContainer(), "Should remove a container that was not created")
+	assert.Equal(t, 1, len(task.Containers))
+	assert.Equal(t, "myName", task.Containers[0].Name)
+	assert.Empty(t, task.Containers[0].RunDependencies)
+	assert.False(t, task.RemoveEmptyVolumeContainer(), "Should be idempotent")
+
+	task = newTask(ContainerCreated)
+	assert.False(t, task.RemoveEmptyVolumeContainer(), "Should keep a container that was created")
+	assert.Equal(t, 2, len(task.Containers))
+	assert.Equal(t, []string{emptyHostVolumeName}, task.Containers[0].RunDependencies)
+}
+
+func TestPostUnmarshalTaskWithDockerVolumes(t *testing.T) {
+	boolptr := func(b bool) *bool {
+		return &b
+	}
+	taskFromACS := ecsacs.Task{
+		Arn:           strptr("myArn"),
+		DesiredStatus: strptr("RUNNING"),
+		Family:        strptr("myFamily"),
+		Version:       strptr("1"),
+		Containers: []*ecsacs.Container{
+			&ecsacs.Container{
+				Name: strptr("myName"),
+				MountPoints: []*ecsacs.MountPoint{
+					&ecsacs.MountPoint{
+						ContainerPath: strptr("/scratch"),
+						SourceVolume:  strptr("scratch"),
+					},
+					&ecsacs.MountPoint{
+						ContainerPath: strptr("/data"),
+						SourceVolume:  strptr("data"),
+					},
+				},
+			},
 		},
-	}, emptyContainer.MountPoints)
+		Volumes: []*ecsacs.Volume{
+			&ecsacs.Volume{
+				Name: strptr("scratch"),
+				DockerVolumeConfiguration: &ecsacs.DockerVolumeConfiguration{
+					Driver: strptr("local"),
+					DriverOpts: map[string]*string{
+						"type":   strptr("tmpfs"),
+						"device": strptr("tmpfs"),
+					},
+				},
+			},
+			&ecsacs.Volume{
+				Name: strptr("data"),
+				DockerVolumeConfiguration: &ecsacs.DockerVolumeConfiguration{
+					Scope:         strptr("shared"),
+					Autoprovision: boolptr(true),
+					Driver:        strptr("rexray/ebs"),
+					Labels:        map[string]*string{"team": strptr("storage")},
+				},
+			},
+		},
+	}
+	seqNum := int64(42)
+	task, err := TaskFromACS(&taskFromACS, &ecsacs.PayloadMessage{SeqNum: &seqNum})
+	assert.NoError(t, err, "Should be able to handle acs task")
+	task.PostUnmarshalTask(nil)
+	assert.Len(t, task.Containers, 1, "Docker volumes do not need an internal container")
+
+	hostVolume, ok := task.HostVolumeByName("scratch")
+	assert.True(t, ok)
+	scratch, ok := hostVolume.(*DockerVolume)
+	if assert.True(t, ok, "Expected a Docker volume") {
+		assert.Equal(t, DockerVolumeTaskScope, scratch.Scope, "Scope should default to task")
+		assert.Equal(t, map[string]string{"type": "tmpfs", "device": "tmpfs"}, scratch.DriverOpts)
+		assert.Regexp(t, "^ecs-myFamily-1-scratch-[0-9a-f]+$", scratch.DockerVolumeName)
+		assert.NoError(t, scratch.Validate())
+	}
+	hostVolume, ok = task.HostVolumeByName("data")
+	assert.True(t, ok)
+	data, ok := hostVolume.(*DockerVolume)
+	if assert.True(t, ok, "Expected a Docker volume") {
+		assert.Equal(t, DockerVolumeSharedScope, data.Scope)
+		assert.True(t, data.Autoprovision)
+		assert.Equal(t, "rexray/ebs", data.Driver)
+		assert.Equal(t, map[string]string{"team": "storage"}, data.Labels)
+		assert.Equal(t, "ecs-myFamily-data", data.DockerVolumeName, "Shared volumes are named after the task family and volume")
+	}
+
+	// Names are kept when the task is unmarshalled again
+	scratchName := scratch.DockerVolumeName
+	task.PostUnmarshalTask(nil)
+	assert.Equal(t, scratchName, scratch.DockerVolumeName)
+
+	binds, err := task.dockerHostBinds(task.Containers[0])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{scratchName + ":/scratch", "ecs-myFamily-data:/data"}, binds)
+}
 
+func TestDockerVolumeValidate(t *testing.T) {
+	assert.Error(t, (&DockerVolume{Scope: "cluster", DockerVolumeName: "name"}).Validate())
+	assert.Error(t, (&DockerVolume{Scope: DockerVolumeTaskScope, Autoprovision: true, DockerVolumeName: "name"}).Validate())
+	assert.Error(t, (&DockerVolume{Scope: DockerVolumeSharedScope}).Validate())
+	assert.NoError(t, (&DockerVolume{Scope: DockerVolumeSharedScope, Autoprovision: true, DockerVolumeName: "name"}).Validate())
 }
 
 func TestTaskFromACS(t *testing.T) {
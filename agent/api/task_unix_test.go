@@ -16,15 +16,9 @@
 package api
 
 const (
-	emptyVolumeName1                  = "empty-volume-1"
-	emptyVolumeContainerPath1         = "/my/empty-volume-1"
-	expectedEmptyVolumeGeneratedPath1 = "/ecs-empty-volume/" + emptyVolumeName1
+	emptyVolumeName1          = "empty-volume-1"
+	emptyVolumeContainerPath1 = "/my/empty-volume-1"
 
-	emptyVolumeName2                  = "empty-volume-2"
-	emptyVolumeContainerPath2         = "/my/empty-volume-2"
-	expectedEmptyVolumeGeneratedPath2 = "/ecs-empty-volume/" + emptyVolumeName2
-
-	expectedEmptyVolumeContainerImage = "amazon/ecs-emptyvolume-base"
-	expectedEmptyVolumeContainerTag   = "autogenerated"
-	expectedEmptyVoluemContainerCmd   = "not-applicable"
+	emptyVolumeName2          = "empty-volume-2"
+	emptyVolumeContainerPath2 = "/my/empty-volume-2"
 )
@@ -16,7 +16,9 @@ package api
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -43,6 +45,14 @@ const (
 	ContainerZombie // Impossible status to use as a virtual 'max'
 )
 
+type ContainerHealthStatus int32
+
+const (
+	ContainerHealthUnknown ContainerHealthStatus = iota
+	ContainerHealthy
+	ContainerUnhealthy
+)
+
 type TransportProtocol int32
 
 const (
@@ -111,6 +121,16 @@ type Task struct {
 	// used to look up the credentials for task in the credentials manager
 	credentialsId     string
 	credentialsIdLock sync.RWMutex
+
+	// MetadataEndpointID is the unguessable ID under which the metadata of
+	// the task is served to its containers
+	MetadataEndpointID string `json:"metadataEndpointId"`
+
+	// StopDeadline is the time after which the task's containers are stopped
+	// without waiting for the containers that depend on them to stop first.
+	// It is saved so that a restarted agent keeps to it.
+	StopDeadline     time.Time `json:"stopDeadline"`
+	stopDeadlineLock sync.RWMutex
 }
 
 // TaskVolume is a definition of all the volumes available for containers to
@@ -145,14 +165,101 @@ func (fs *FSHostVolume) SourcePath() string {
 	return fs.FSSourcePath
 }
 
+// ScratchBacking is the kind of filesystem that enforces the size limit of
+// an empty volume
+type ScratchBacking string
+
+const (
+	// ScratchBackingLoop volumes are backed by a filesystem image, in the
+	// task's directory under the empty volume data root, mounted through a
+	// loop device. The agent needs the mkfs.ext4 and mount commands for it.
+	ScratchBackingLoop ScratchBacking = "loop"
+	// ScratchBackingTmpfs volumes are held in memory
+	ScratchBackingTmpfs ScratchBacking = "tmpfs"
+)
+
 type EmptyHostVolume struct {
 	HostPath string `json:"hostPath"`
+	// SizeLimit is the size, in MiB, of the filesystem mounted at HostPath
+	// to hold the volume. Zero means the volume shares the filesystem of the
+	// empty volume data root.
+	SizeLimit int64 `json:"sizeLimit,omitempty"`
+	// Backing determines the filesystem used when SizeLimit is set. It
+	// defaults to tmpfs, whose size counts as memory of the containers
+	// mounting the volume.
+	Backing ScratchBacking `json:"backing,omitempty"`
 }
 
 func (e *EmptyHostVolume) SourcePath() string {
 	return e.HostPath
 }
 
+// Validate returns an error if the size limit is negative or the backing is
+// not known
+func (e *EmptyHostVolume) Validate() error {
+	if e.SizeLimit < 0 {
+		return fmt.Errorf("invalid size limit %d", e.SizeLimit)
+	}
+	switch e.Backing {
+	case "", ScratchBackingLoop, ScratchBackingTmpfs:
+	default:
+		return fmt.Errorf("unknown backing %q", e.Backing)
+	}
+	return nil
+}
+
+// DockerVolumeScope determines how long a Docker volume lives
+type DockerVolumeScope string
+
+const (
+	// DockerVolumeTaskScope volumes are created for a single task and removed
+	// along with its containers
+	DockerVolumeTaskScope DockerVolumeScope = "task"
+	// DockerVolumeSharedScope volumes may be used by several tasks of the
+	// same family and are never removed by the agent
+	DockerVolumeSharedScope DockerVolumeScope = "shared"
+)
+
+// DockerVolume is a HostVolume backed by a Docker volume, which may be
+// provided by a volume driver
+type DockerVolume struct {
+	Scope DockerVolumeScope `json:"scope"`
+	// Autoprovision allows a shared volume to be created if it does not
+	// exist yet
+	Autoprovision bool              `json:"autoprovision"`
+	Driver        string            `json:"driver"`
+	DriverOpts    map[string]string `json:"driverOpts"`
+	Labels        map[string]string `json:"labels"`
+	// DockerVolumeName is the name of the volume in Docker. Task scoped
+	// volumes are given a name unique to the task, while shared volumes are
+	// named after the task family and the task volume.
+	DockerVolumeName string `json:"dockerVolumeName"`
+}
+
+// SourcePath returns the name of the Docker volume, which Docker accepts in
+// place of a host path when binding it into a container
+func (v *DockerVolume) SourcePath() string {
+	return v.DockerVolumeName
+}
+
+// Validate returns an error if the volume's scope is not known or a task
+// scoped volume is set to be autoprovisioned
+func (v *DockerVolume) Validate() error {
+	switch v.Scope {
+	case DockerVolumeTaskScope:
+		if v.Autoprovision {
+			return errors.New("autoprovision is only supported for shared volumes")
+		}
+	case DockerVolumeSharedScope:
+	default:
+		return fmt.Errorf("unknown volume scope %q", v.Scope)
+	}
+	if v.DockerVolumeName == "" {
+		return errors.New("volume has no name")
+	}
+	return nil
+}
+
 type ContainerStateChange struct {
 	TaskArn       string
 	ContainerName string
@@ -161,6 +268,7 @@ type ContainerStateChange struct {
 	Reason       string
 	ExitCode     *int
 	PortBindings []PortBinding
+	HealthStatus ContainerHealthStatus
 
 	// This bit is a little hacky; a pointer to the container's sentstatus which
 	// may be updated to indicate what status was sent. This is used to ensure
@@ -179,6 +287,9 @@ func (c *ContainerStateChange) String() string {
 	if len(c.PortBindings) != 0 {
 		res += fmt.Sprintf(", Ports %v", c.PortBindings)
 	}
+	if c.HealthStatus != ContainerHealthUnknown {
+		res += ", Health " + c.HealthStatus.String()
+	}
 	if c.SentStatus != nil {
 		res += ", Known Sent: " + c.SentStatus.String()
 	}
@@ -232,6 +343,8 @@ type Container struct {
 	Essential              bool
 	EntryPoint             *[]string
 	Environment            map[string]string           `json:"environment"`
+	Secrets                []Secret                    `json:"secrets"`
+	EnvironmentFiles       []string                    `json:"environmentFiles"`
 	Overrides              ContainerOverrides          `json:"overrides"`
 	DockerConfig           DockerConfig                `json:"dockerConfig"`
 	RegistryAuthentication *RegistryAuthenticationData `json:"registryAuthentication"`
@@ -255,13 +368,74 @@ type Container struct {
 
 	SentStatus ContainerStatus
 
-	KnownExitCode     *int
-	KnownPortBindings []PortBinding
+	KnownExitCode         *int
+	KnownPortBindings     []PortBinding
+	knownPortBindingsLock sync.RWMutex
+	KnownNetworks         []Network
+
+	// HealthCheck describes how the engine determines whether this container
+	// is healthy once it is running
+	HealthCheck *HealthCheck `json:"healthCheck"`
+	// Health is the result of the most recent evaluation of HealthCheck
+	Health HealthStatus `json:"health"`
+	// sentHealthStatus is the health status last reported to the backend
+	sentHealthStatus ContainerHealthStatus
+	healthLock       sync.RWMutex
+
+	// RestartPolicy controls whether a non-essential container is started
+	// again after it exits
+	RestartPolicy *RestartPolicy `json:"restartPolicy"`
+	// RestartCount is the number of times the engine has restarted this
+	// container
+	RestartCount int `json:"restartCount"`
+	// sentRestartCount is the restart count last reported to the backend
+	sentRestartCount int
+	restartCountLock sync.RWMutex
+
+	// TransitionTimes records when the container last reached each of its
+	// known statuses
+	TransitionTimes     ContainerTransitionTimes `json:"transitionTimes"`
+	transitionTimesLock sync.RWMutex
+
+	// DependsOn lists containers that must reach a given condition before this
+	// container is started
+	DependsOn []ContainerDependency `json:"dependsOn"`
+
+	// StopTimeout is the number of seconds the container is given to exit
+	// after being sent its stop signal before it is killed. If it is not set,
+	// the instance-wide DockerStopTimeout applies.
+	StopTimeout *uint `json:"stopTimeout"`
+	// StopSignal is the signal, such as SIGQUIT, sent to stop the container in
+	// place of Docker's default
+	StopSignal string `json:"stopSignal"`
+
+	// LinuxParameters are Linux specific settings of the container, which
+	// take precedence over the same settings in DockerConfig.HostConfig
+	LinuxParameters *LinuxParameters `json:"linuxParameters"`
+
+	// ImagePullBehavior, if set, overrides the instance-wide setting that
+	// decides whether the image is pulled before the container is created
+	ImagePullBehavior string `json:"imagePullBehavior"`
+	// ExpectedImageDigest, if set, is the digest, such as "sha256:...", that
+	// the pulled image must have for the container to be created
+	ExpectedImageDigest string `json:"expectedImageDigest"`
+	// ImageDigest is the repository digest of the image the container was
+	// created from
+	ImageDigest string `json:"imageDigest"`
 
 	// Not upstream; todo move this out into a wrapper type
 	StatusLock sync.Mutex
 }
 
+// ContainerTransitionTimes are the times at which a container last reached
+// each known status. Statuses it has not reached have zero times.
+type ContainerTransitionTimes struct {
+	Pulled  time.Time `json:"pulled"`
+	Created time.Time `json:"created"`
+	Running time.Time `json:"running"`
+	Stopped time.Time `json:"stopped"`
+}
+
 // DockerConfig represents additional metadata about a container to run. It's
 // remodeled from the `ecsacs` api model file. Eventually it should not exist
 // once this remodeling is refactored out.
@@ -271,6 +445,51 @@ type DockerConfig struct {
 	Version    *string `json:"version"`
 }
 
+// DependencyCondition is the state another container must reach before a
+// container that depends on it may start
+type DependencyCondition string
+
+const (
+	// DependencyConditionStart is met once the dependency has started
+	DependencyConditionStart DependencyCondition = "START"
+	// DependencyConditionComplete is met once the dependency has exited
+	DependencyConditionComplete DependencyCondition = "COMPLETE"
+	// DependencyConditionSuccess is met once the dependency has exited with
+	// an exit code of 0
+	DependencyConditionSuccess DependencyCondition = "SUCCESS"
+	// DependencyConditionHealthy is met once the dependency's health check
+	// reports it as healthy
+	DependencyConditionHealthy DependencyCondition = "HEALTHY"
+)
+
+// ContainerDependency declares that a container may only start once the named
+// container satisfies the condition
+type ContainerDependency struct {
+	ContainerName string              `json:"containerName"`
+	Condition     DependencyCondition `json:"condition"`
+}
+
+// Secret is an environment variable whose value is a secret, referenced by a
+// URI such as file:///etc/ecs/secrets/db#password. The value is resolved when
+// the container is created and is never saved with the task.
+type Secret struct {
+	Name      string `json:"name"`
+	ValueFrom string `json:"valueFrom"`
+}
+
+// Validate returns an error if the secret has no name or its reference is
+// not an absolute URI
+func (secret *Secret) Validate() error {
+	if secret.Name == "" || strings.ContainsAny(secret.Name, "=\x00") {
+		return fmt.Errorf("invalid secret name %q", secret.Name)
+	}
+	ref, err := url.Parse(secret.ValueFrom)
+	if err != nil || !ref.IsAbs() {
+		return fmt.Errorf("secret %s does not reference a secret by URI", secret.Name)
+	}
+	return nil
+}
+
 // VolumeFrom is a volume which references another container as its source.
 type VolumeFrom struct {
 	SourceContainer string `json:"sourceContainer"`
@@ -36,6 +36,30 @@ func TestEmptyHostVolumeUnmarshal(t *testing.T) {
 	}
 }
 
+func TestSizeLimitedEmptyHostVolumeUnmarshal(t *testing.T) {
+	var task Task
+	err := json.Unmarshal([]byte(`{"volumes":[{"name":"test","host":{"sizeLimit":512,"backing":"tmpfs"}}]}`), &task)
+	if err != nil {
+		t.Fatal("Could not unmarshal: ", err)
+	}
+	ev, ok := task.Volumes[0].Volume.(*EmptyHostVolume)
+	if !ok {
+		t.Fatal("Wrong type")
+	}
+	if ev.SizeLimit != 512 || ev.Backing != ScratchBackingTmpfs {
+		t.Error("Wrong size limit: ", ev.SizeLimit, ev.Backing)
+	}
+	if err := ev.Validate(); err != nil {
+		t.Error("Should be valid: ", err)
+	}
+	if err := (&EmptyHostVolume{SizeLimit: 512, Backing: "zram"}).Validate(); err == nil {
+		t.Error("Unknown backings should be rejected")
+	}
+	if err := (&EmptyHostVolume{SizeLimit: -1}).Validate(); err == nil {
+		t.Error("Negative size limits should be rejected")
+	}
+}
+
 func TestHostHostVolumeUnmarshal(t *testing.T) {
 	var task Task
 	err := json.Unmarshal([]byte(`{"volumes":[{"name":"test","host":{"sourcePath":"/path"}}]}`), &task)
@@ -14,13 +14,18 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -54,6 +59,14 @@ const (
 	// DefaultDockerStopTimeout specifies the value for container stop timeout duration
 	DefaultDockerStopTimeout = 30 * time.Second
 
+	// DefaultTaskStopTimeout specifies the default value for the time allowed
+	// for all of a task's containers to be stopped in dependency order
+	DefaultTaskStopTimeout = 5 * time.Minute
+
+	// DefaultImagePrewarmInterval specifies the default time between pulls of
+	// the images that are pre-warmed
+	DefaultImagePrewarmInterval = 1 * time.Hour
+
 	// DefaultImageCleanupTimeInterval specifies the default value for image cleanup duration. It is used to
 	// remove the images pulled by agent.
 	DefaultImageCleanupTimeInterval = 30 * time.Minute
@@ -66,6 +79,18 @@ const (
 	// has been pulled before it can be deleted.
 	DefaultImageDeletionAge = 1 * time.Hour
 
+	// DefaultUnmanagedImageMinimumAge specifies the default minimum age of an
+	// image the agent did not pull before it can be removed
+	DefaultUnmanagedImageMinimumAge = 24 * time.Hour
+
+	// DefaultUnmanagedContainerMinimumAge specifies the default minimum time
+	// since a container not started by the agent exited before it can be removed
+	DefaultUnmanagedContainerMinimumAge = 24 * time.Hour
+
+	// SeccompProfileUnconfined disables seccomp filtering when used as the
+	// default seccomp profile
+	SeccompProfileUnconfined = "unconfined"
+
 	// minimumTaskCleanupWaitDuration specifies the minimum duration to wait before cleaning up
 	// a task's container. This is used to enforce sane values for the config.TaskCleanupWaitDuration field.
 	minimumTaskCleanupWaitDuration = 1 * time.Minute
@@ -73,6 +98,14 @@ const (
 	// minimumDockerStopTimeout specifies the minimum value for docker StopContainer API
 	minimumDockerStopTimeout = 1 * time.Second
 
+	// minimumTaskStopTimeout specifies the minimum time allowed for a task's
+	// containers to be stopped in dependency order
+	minimumTaskStopTimeout = 1 * time.Second
+
+	// minimumImagePrewarmInterval specifies the minimum time between pulls of
+	// the images that are pre-warmed
+	minimumImagePrewarmInterval = 10 * time.Minute
+
 	// minimumImageCleanupInterval specifies the minimum time for agent to wait before performing
 	// image cleanup.
 	minimumImageCleanupInterval = 10 * time.Minute
@@ -80,6 +113,10 @@ const (
 	// minimumNumImagesToDeletePerCycle specifies the minimum number of images that to be deleted when
 	// performing image cleanup.
 	minimumNumImagesToDeletePerCycle = 1
+
+	// minimumUnmanagedResourceAge specifies the minimum value allowed for the
+	// ages of unmanaged images and containers before they are removed
+	minimumUnmanagedResourceAge = 1 * time.Minute
 )
 
 // Merge merges two config files, preferring the ones on the left. Any nil or
@@ -267,6 +304,7 @@ func environmentConfig() Config {
 		seelog.Warnf("Discarded invalid value for docker stop timeout, parsed as: %v", parsedStopTimeout)
 	}
 
+	taskStopTimeout := parseEnvVariableDuration("ECS_TASK_STOP_TIMEOUT")
 	taskCleanupWaitDuration := parseEnvVariableDuration("ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION")
 	availableLoggingDriversEnv := os.Getenv("ECS_AVAILABLE_LOGGING_DRIVERS")
 	loggingDriverDecoder := json.NewDecoder(strings.NewReader(availableLoggingDriversEnv))
@@ -279,6 +317,29 @@ func environmentConfig() Config {
 		seelog.Warnf("Invalid format for \"ECS_AVAILABLE_LOGGING_DRIVERS\" environment variable; expected a JSON array like [\"json-file\",\"syslog\"]. err %v", err)
 	}
 
+	dockerOperationLimitsEnv := os.Getenv("ECS_DOCKER_OPERATION_LIMITS")
+	dockerOperationLimitsDecoder := json.NewDecoder(strings.NewReader(dockerOperationLimitsEnv))
+	var dockerOperationLimits map[dockerclient.DockerOperation]int
+	err = dockerOperationLimitsDecoder.Decode(&dockerOperationLimits)
+	// Blank is not a warning; operations are not limited by default
+	if err != io.EOF && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_DOCKER_OPERATION_LIMITS\" environment variable; expected a JSON object like {\"pull\":2,\"create\":10}. err %v", err)
+	}
+
+	imagePullBehavior := dockerclient.ImagePullBehavior(os.Getenv("ECS_IMAGE_PULL_BEHAVIOR"))
+
+	imagePrewarmListEnv := os.Getenv("ECS_IMAGE_PREWARM_LIST")
+	imagePrewarmListDecoder := json.NewDecoder(strings.NewReader(imagePrewarmListEnv))
+	var imagePrewarmList []string
+	err = imagePrewarmListDecoder.Decode(&imagePrewarmList)
+	// Blank is not a warning; no images are pre-warmed by default
+	if err != io.EOF && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_IMAGE_PREWARM_LIST\" environment variable; expected a JSON array like [\"nginx:latest\",\"busybox:latest\"]. err %v", err)
+	}
+	imagePrewarmInterval := parseEnvVariableDuration("ECS_IMAGE_PREWARM_INTERVAL")
+	imagePrewarmECRAuth := utils.ParseBool(os.Getenv("ECS_IMAGE_PREWARM_ECR_AUTH"), false)
+	imagePinAPIEnabled := utils.ParseBool(os.Getenv("ECS_ENABLE_IMAGE_PIN_API"), false)
+
 	privilegedDisabled := utils.ParseBool(os.Getenv("ECS_DISABLE_PRIVILEGED"), false)
 	seLinuxCapable := utils.ParseBool(os.Getenv("ECS_SELINUX_CAPABLE"), false)
 	appArmorCapable := utils.ParseBool(os.Getenv("ECS_APPARMOR_CAPABLE"), false)
@@ -297,6 +358,62 @@ func environmentConfig() Config {
 		seelog.Warnf("Invalid format for \"ECS_NUM_IMAGES_DELETE_PER_CYCLE\", expected an integer. err %v", err)
 	}
 
+	imageCleanupStrategy := dockerclient.ImageCleanupStrategy(os.Getenv("ECS_IMAGE_CLEANUP_STRATEGY"))
+
+	imageCleanupExclusionListEnv := os.Getenv("ECS_IMAGE_CLEANUP_EXCLUDE")
+	imageCleanupExclusionListDecoder := json.NewDecoder(strings.NewReader(imageCleanupExclusionListEnv))
+	var imageCleanupExclusionList []string
+	err = imageCleanupExclusionListDecoder.Decode(&imageCleanupExclusionList)
+	// Blank is not a warning; no images are excluded by default
+	if err != io.EOF && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_IMAGE_CLEANUP_EXCLUDE\" environment variable; expected a JSON array like [\"amazon/amazon-ecs-agent:latest\",\"myregistry/base:*\"]. err %v", err)
+	}
+	imageCleanupHighWatermark := parseEnvVariableUint16("ECS_IMAGE_CLEANUP_HIGH_WATERMARK")
+	imageCleanupLowWatermark := parseEnvVariableUint16("ECS_IMAGE_CLEANUP_LOW_WATERMARK")
+	dockerDataRoot := os.Getenv("ECS_DOCKER_DATA_ROOT")
+
+	unmanagedCleanupEnabled := utils.ParseBool(os.Getenv("ECS_ENABLE_UNMANAGED_CLEANUP"), false)
+	unmanagedImageMinimumAge := parseEnvVariableDuration("ECS_UNMANAGED_IMAGE_MINIMUM_AGE")
+	unmanagedContainerMinimumAge := parseEnvVariableDuration("ECS_UNMANAGED_CONTAINER_MINIMUM_AGE")
+	unmanagedCleanupExclusionListEnv := os.Getenv("ECS_UNMANAGED_CLEANUP_EXCLUDE")
+	unmanagedCleanupExclusionListDecoder := json.NewDecoder(strings.NewReader(unmanagedCleanupExclusionListEnv))
+	var unmanagedCleanupExclusionList []string
+	err = unmanagedCleanupExclusionListDecoder.Decode(&unmanagedCleanupExclusionList)
+	// Blank is not a warning; nothing is excluded by default
+	if err != io.EOF && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_UNMANAGED_CLEANUP_EXCLUDE\" environment variable; expected a JSON array like [\"mybuilds/*\",\"datadog-agent\"]. err %v", err)
+	}
+	unmanagedCleanupAuditLogFile := os.Getenv("ECS_UNMANAGED_CLEANUP_AUDIT_LOGFILE")
+
+	taskAdmissionControlEnabled := utils.ParseBool(os.Getenv("ECS_ENABLE_TASK_ADMISSION_CONTROL"), false)
+	emptyVolumeDataRoot := os.Getenv("ECS_EMPTY_VOLUME_DATA_ROOT")
+	dataDirOnHost := os.Getenv("ECS_HOST_DATA_DIR")
+
+	defaultLogDriver := dockerclient.LoggingDriver(os.Getenv("ECS_DEFAULT_LOG_DRIVER"))
+	defaultLogOptionsEnv := os.Getenv("ECS_DEFAULT_LOG_OPTIONS")
+	defaultLogOptionsDecoder := json.NewDecoder(strings.NewReader(defaultLogOptionsEnv))
+	var defaultLogOptions map[string]string
+	err = defaultLogOptionsDecoder.Decode(&defaultLogOptions)
+	// Blank is not a warning; the log driver is given no options by default
+	if err != io.EOF && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_DEFAULT_LOG_OPTIONS\" environment variable; expected a JSON object like {\"max-size\":\"10m\",\"max-file\":\"3\"}. err %v", err)
+	}
+	defaultUlimitsEnv := os.Getenv("ECS_DEFAULT_ULIMITS")
+	defaultUlimitsDecoder := json.NewDecoder(strings.NewReader(defaultUlimitsEnv))
+	var defaultUlimits map[string]Ulimit
+	err = defaultUlimitsDecoder.Decode(&defaultUlimits)
+	// Blank is not a warning; Docker's ulimits apply by default
+	if err != io.EOF && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_DEFAULT_ULIMITS\" environment variable; expected a JSON object like {\"nofile\":{\"Soft\":1024,\"Hard\":4096}}. err %v", err)
+	}
+	defaultNoNewPrivileges := utils.ParseBool(os.Getenv("ECS_DEFAULT_NO_NEW_PRIVILEGES"), false)
+	defaultSeccompProfile := os.Getenv("ECS_DEFAULT_SECCOMP_PROFILE")
+	secretsFileRoot := os.Getenv("ECS_SECRETS_FILE_ROOT")
+	secretsHTTPEndpoint := os.Getenv("ECS_SECRETS_HTTP_ENDPOINT")
+	environmentFilesRoot := os.Getenv("ECS_ENVIRONMENT_FILES_ROOT")
+	containerMetadataEnabled := utils.ParseBool(os.Getenv("ECS_ENABLE_CONTAINER_METADATA"), false)
+	containerMetadataDataRoot := os.Getenv("ECS_CONTAINER_METADATA_DATA_ROOT")
+
 	return Config{
 		Cluster:                          clusterRef,
 		APIEndpoint:                      endpoint,
@@ -313,12 +430,18 @@ func environmentConfig() Config {
 		DisableMetrics:                   disableMetrics,
 		ReservedMemory:                   reservedMemory,
 		AvailableLoggingDrivers:          availableLoggingDrivers,
+		DockerOperationLimits:            dockerOperationLimits,
+		ImagePullBehavior:                imagePullBehavior,
+		ImagePrewarmList:                 imagePrewarmList,
+		ImagePrewarmInterval:             imagePrewarmInterval,
+		ImagePrewarmECRAuth:              imagePrewarmECRAuth,
 		PrivilegedDisabled:               privilegedDisabled,
 		SELinuxCapable:                   seLinuxCapable,
 		AppArmorCapable:                  appArmorCapable,
 		TaskCleanupWaitDuration:          taskCleanupWaitDuration,
 		TaskIAMRoleEnabled:               taskIAMRoleEnabled,
 		DockerStopTimeout:                dockerStopTimeout,
+		TaskStopTimeout:                  taskStopTimeout,
 		CredentialsAuditLogFile:          credentialsAuditLogFile,
 		CredentialsAuditLogDisabled:      credentialsAuditLogDisabled,
 		TaskIAMRoleEnabledForNetworkHost: taskIAMRoleEnabledForNetworkHost,
@@ -326,7 +449,50 @@ func environmentConfig() Config {
 		MinimumImageDeletionAge:          minimumImageDeletionAge,
 		ImageCleanupInterval:             imageCleanupInterval,
 		NumImagesToDeletePerCycle:        numImagesToDeletePerCycle,
+		ImageCleanupStrategy:             imageCleanupStrategy,
+		ImageCleanupExclusionList:        imageCleanupExclusionList,
+		ImageCleanupHighWatermark:        imageCleanupHighWatermark,
+		ImageCleanupLowWatermark:         imageCleanupLowWatermark,
+		DockerDataRoot:                   dockerDataRoot,
+		UnmanagedCleanupEnabled:          unmanagedCleanupEnabled,
+		ImagePinAPIEnabled:               imagePinAPIEnabled,
+		UnmanagedImageMinimumAge:         unmanagedImageMinimumAge,
+		UnmanagedContainerMinimumAge:     unmanagedContainerMinimumAge,
+		UnmanagedCleanupExclusionList:    unmanagedCleanupExclusionList,
+		UnmanagedCleanupAuditLogFile:     unmanagedCleanupAuditLogFile,
+		TaskAdmissionControlEnabled:      taskAdmissionControlEnabled,
+		EmptyVolumeDataRoot:              emptyVolumeDataRoot,
+		DataDirOnHost:                    dataDirOnHost,
+		DefaultLogDriver:                 defaultLogDriver,
+		DefaultLogOptions:                defaultLogOptions,
+		DefaultUlimits:                   defaultUlimits,
+		DefaultNoNewPrivileges:           defaultNoNewPrivileges,
+		DefaultSeccompProfile:            defaultSeccompProfile,
+		SecretsFileRoot:                  secretsFileRoot,
+		SecretsHTTPEndpoint:              secretsHTTPEndpoint,
+		EnvironmentFilesRoot:             environmentFilesRoot,
+		ContainerMetadataEnabled:         containerMetadataEnabled,
+		ContainerMetadataDataRoot:        containerMetadataDataRoot,
+	}
+}
+
+// isSubPath returns true if path is dir or a path under it
+// checkWritableDir creates the directory if needed, and a file in it
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
 	}
+	file, err := ioutil.TempFile(dir, ".ecs-write-check")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+func isSubPath(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
 
 func parseEnvVariableUint16(envVar string) uint16 {
@@ -407,6 +573,11 @@ func (config *Config) validateAndOverrideBounds() error {
 	if config.DockerStopTimeout < minimumDockerStopTimeout {
 		return fmt.Errorf("Invalid negative DockerStopTimeout: %v", config.DockerStopTimeout.String())
 	}
+	if config.TaskStopTimeout < minimumTaskStopTimeout {
+		seelog.Warnf("Invalid value for task stop timeout, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v.", DefaultTaskStopTimeout.String(), config.TaskStopTimeout, minimumTaskStopTimeout)
+		config.TaskStopTimeout = DefaultTaskStopTimeout
+	}
+
 	var badDrivers []string
 	for _, driver := range config.AvailableLoggingDrivers {
 		_, ok := dockerclient.LoggingDriverMinimumVersion[driver]
@@ -418,6 +589,123 @@ func (config *Config) validateAndOverrideBounds() error {
 		return errors.New("Invalid logging drivers: " + strings.Join(badDrivers, ", "))
 	}
 
+	if config.DefaultLogDriver != "" {
+		available := false
+		for _, driver := range config.AvailableLoggingDrivers {
+			available = available || driver == config.DefaultLogDriver
+		}
+		if !available {
+			return errors.New("Default log driver is not an available logging driver: " + string(config.DefaultLogDriver))
+		}
+	} else if len(config.DefaultLogOptions) > 0 {
+		return errors.New("Default log options are set without a default log driver")
+	}
+
+	var badUlimits []string
+	for name, ulimit := range config.DefaultUlimits {
+		if !dockerclient.IsUlimitName(name) || ulimit.Soft < 0 || ulimit.Soft > ulimit.Hard {
+			badUlimits = append(badUlimits, name)
+		}
+	}
+	if len(badUlimits) > 0 {
+		sort.Strings(badUlimits)
+		return errors.New("Invalid default ulimits: " + strings.Join(badUlimits, ", "))
+	}
+
+	if config.DataDirOnHost != "" && !filepath.IsAbs(config.DataDirOnHost) {
+		return errors.New("Host data directory is not an absolute path: " + config.DataDirOnHost)
+	}
+
+	switch config.DefaultSeccompProfile {
+	case "":
+		config.DefaultSeccompProfileJSON = ""
+	case SeccompProfileUnconfined:
+		config.DefaultSeccompProfileJSON = SeccompProfileUnconfined
+	default:
+		if !filepath.IsAbs(config.DefaultSeccompProfile) {
+			return errors.New("Default seccomp profile is neither \"unconfined\" nor an absolute path: " + config.DefaultSeccompProfile)
+		}
+		profile, err := ioutil.ReadFile(config.DefaultSeccompProfile)
+		if err != nil {
+			return fmt.Errorf("Unable to read the default seccomp profile: %v", err)
+		}
+		// Docker takes the profile inline, so it is kept on a single line
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, profile); err != nil {
+			return fmt.Errorf("Invalid default seccomp profile %s: %v", config.DefaultSeccompProfile, err)
+		}
+		config.DefaultSeccompProfileJSON = compacted.String()
+	}
+
+	if config.SecretsFileRoot != "" && !filepath.IsAbs(config.SecretsFileRoot) {
+		return errors.New("Secrets file root is not an absolute path: " + config.SecretsFileRoot)
+	}
+	if config.SecretsHTTPEndpoint != "" {
+		endpoint, err := url.Parse(config.SecretsHTTPEndpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return errors.New("Secrets HTTP endpoint is not an http or https URL: " + config.SecretsHTTPEndpoint)
+		}
+	}
+
+	if config.EnvironmentFilesRoot != "" {
+		if !filepath.IsAbs(config.EnvironmentFilesRoot) {
+			return errors.New("Environment files root is not an absolute path: " + config.EnvironmentFilesRoot)
+		}
+		// Environment files are not confidential, so they must not give
+		// tasks a way around the confinement of secrets
+		if config.SecretsFileRoot != "" && (isSubPath(config.EnvironmentFilesRoot, config.SecretsFileRoot) || isSubPath(config.SecretsFileRoot, config.EnvironmentFilesRoot)) {
+			return errors.New("Environment files root and secrets file root overlap: " + config.EnvironmentFilesRoot + ", " + config.SecretsFileRoot)
+		}
+	}
+
+	if config.ContainerMetadataEnabled {
+		if !filepath.IsAbs(config.ContainerMetadataDataRoot) {
+			return errors.New("Container metadata data root is not an absolute path: " + config.ContainerMetadataDataRoot)
+		}
+		if err := checkWritableDir(config.ContainerMetadataDataRoot); err != nil {
+			return fmt.Errorf("Container metadata data root is not writable: %v", err)
+		}
+	}
+
+	var badOperations []string
+	for operation, limit := range config.DockerOperationLimits {
+		if !operation.IsValid() || limit < 0 {
+			badOperations = append(badOperations, string(operation))
+		}
+	}
+	if len(badOperations) > 0 {
+		sort.Strings(badOperations)
+		return errors.New("Invalid docker operation limits: " + strings.Join(badOperations, ", "))
+	}
+
+	var badPatterns []string
+	for _, pattern := range config.ImageCleanupExclusionList {
+		if _, err := path.Match(pattern, ""); err != nil {
+			badPatterns = append(badPatterns, pattern)
+		}
+	}
+	if len(badPatterns) > 0 {
+		return errors.New("Invalid image cleanup exclusion patterns: " + strings.Join(badPatterns, ", "))
+	}
+
+	badPatterns = nil
+	for _, pattern := range config.UnmanagedCleanupExclusionList {
+		if _, err := path.Match(pattern, ""); err != nil {
+			badPatterns = append(badPatterns, pattern)
+		}
+	}
+	if len(badPatterns) > 0 {
+		return errors.New("Invalid unmanaged cleanup exclusion patterns: " + strings.Join(badPatterns, ", "))
+	}
+
+	if !config.ImagePullBehavior.IsValid() {
+		return errors.New("Invalid image pull behavior: " + string(config.ImagePullBehavior))
+	}
+
+	if !config.ImageCleanupStrategy.IsValid() {
+		return errors.New("Invalid image cleanup strategy: " + string(config.ImageCleanupStrategy))
+	}
+
 	// If a value has been set for taskCleanupWaitDuration and the value is less than the minimum allowed cleanup duration,
 	// print a warning and override it
 	if config.TaskCleanupWaitDuration < minimumTaskCleanupWaitDuration {
@@ -425,6 +713,11 @@ func (config *Config) validateAndOverrideBounds() error {
 		config.TaskCleanupWaitDuration = DefaultTaskCleanupWaitDuration
 	}
 
+	if config.ImagePrewarmInterval < minimumImagePrewarmInterval {
+		seelog.Warnf("Invalid value for image prewarm interval, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v.", DefaultImagePrewarmInterval.String(), config.ImagePrewarmInterval, minimumImagePrewarmInterval)
+		config.ImagePrewarmInterval = DefaultImagePrewarmInterval
+	}
+
 	if config.ImageCleanupInterval < minimumImageCleanupInterval {
 		seelog.Warnf("Invalid value for image cleanup duration, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v.", DefaultImageCleanupTimeInterval.String(), config.ImageCleanupInterval, minimumImageCleanupInterval)
 		config.ImageCleanupInterval = DefaultImageCleanupTimeInterval
@@ -435,6 +728,27 @@ func (config *Config) validateAndOverrideBounds() error {
 		config.NumImagesToDeletePerCycle = DefaultNumImagesToDeletePerCycle
 	}
 
+	if config.ImageCleanupHighWatermark != 0 && (config.ImageCleanupHighWatermark > 100 || config.ImageCleanupLowWatermark == 0 || config.ImageCleanupLowWatermark >= config.ImageCleanupHighWatermark) {
+		seelog.Warnf("Invalid values for image cleanup watermarks, disk usage will not be checked. Parsed high watermark: %d%%, low watermark: %d%%; expected 0 < low < high <= 100.", config.ImageCleanupHighWatermark, config.ImageCleanupLowWatermark)
+		config.ImageCleanupHighWatermark = 0
+		config.ImageCleanupLowWatermark = 0
+	}
+	if config.ImageCleanupHighWatermark != 0 && config.DockerDataRoot == "" {
+		seelog.Errorf("Image cleanup watermarks are set but ECS_DOCKER_DATA_ROOT is not, disk usage will not be checked. Mount Docker's data root into the agent container and set ECS_DOCKER_DATA_ROOT to its path.")
+		config.ImageCleanupHighWatermark = 0
+		config.ImageCleanupLowWatermark = 0
+	}
+
+	if config.UnmanagedImageMinimumAge < minimumUnmanagedResourceAge {
+		seelog.Warnf("Invalid value for unmanaged image minimum age, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v.", DefaultUnmanagedImageMinimumAge.String(), config.UnmanagedImageMinimumAge, minimumUnmanagedResourceAge)
+		config.UnmanagedImageMinimumAge = DefaultUnmanagedImageMinimumAge
+	}
+
+	if config.UnmanagedContainerMinimumAge < minimumUnmanagedResourceAge {
+		seelog.Warnf("Invalid value for unmanaged container minimum age, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v.", DefaultUnmanagedContainerMinimumAge.String(), config.UnmanagedContainerMinimumAge, minimumUnmanagedResourceAge)
+		config.UnmanagedContainerMinimumAge = DefaultUnmanagedContainerMinimumAge
+	}
+
 	config.platformOverrides()
 
 	return nil
@@ -15,7 +15,9 @@ package config
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -82,7 +84,14 @@ func TestEnvironmentConfig(t *testing.T) {
 	os.Setenv("ECS_RESERVED_PORTS_UDP", "[42,99]")
 	os.Setenv("ECS_RESERVED_MEMORY", "20")
 	os.Setenv("ECS_CONTAINER_STOP_TIMEOUT", "60s")
+	os.Setenv("ECS_TASK_STOP_TIMEOUT", "10m")
 	os.Setenv("ECS_AVAILABLE_LOGGING_DRIVERS", "[\""+string(dockerclient.SyslogDriver)+"\"]")
+	os.Setenv("ECS_DOCKER_OPERATION_LIMITS", "{\"pull\":2,\"create\":10}")
+	os.Setenv("ECS_IMAGE_PULL_BEHAVIOR", "prefer-cached")
+	os.Setenv("ECS_IMAGE_PREWARM_LIST", "[\"nginx:latest\",\"busybox:latest\"]")
+	os.Setenv("ECS_IMAGE_PREWARM_INTERVAL", "3h")
+	os.Setenv("ECS_IMAGE_PREWARM_ECR_AUTH", "true")
+	os.Setenv("ECS_ENABLE_IMAGE_PIN_API", "true")
 	os.Setenv("ECS_SELINUX_CAPABLE", "true")
 	os.Setenv("ECS_APPARMOR_CAPABLE", "true")
 	os.Setenv("ECS_DISABLE_PRIVILEGED", "true")
@@ -93,6 +102,37 @@ func TestEnvironmentConfig(t *testing.T) {
 	os.Setenv("ECS_IMAGE_CLEANUP_INTERVAL", "2h")
 	os.Setenv("ECS_IMAGE_MINIMUM_CLEANUP_AGE", "30m")
 	os.Setenv("ECS_NUM_IMAGES_DELETE_PER_CYCLE", "2")
+	os.Setenv("ECS_IMAGE_CLEANUP_EXCLUDE", "[\"amazon/amazon-ecs-agent:latest\",\"myregistry/base:*\"]")
+	os.Setenv("ECS_IMAGE_CLEANUP_STRATEGY", "largest-first")
+	os.Setenv("ECS_IMAGE_CLEANUP_HIGH_WATERMARK", "85")
+	os.Setenv("ECS_IMAGE_CLEANUP_LOW_WATERMARK", "70")
+	os.Setenv("ECS_DOCKER_DATA_ROOT", "/host/var/lib/docker")
+	os.Setenv("ECS_ENABLE_UNMANAGED_CLEANUP", "true")
+	os.Setenv("ECS_UNMANAGED_IMAGE_MINIMUM_AGE", "48h")
+	os.Setenv("ECS_UNMANAGED_CONTAINER_MINIMUM_AGE", "6h")
+	os.Setenv("ECS_UNMANAGED_CLEANUP_EXCLUDE", "[\"mybuilds/*\",\"datadog-agent\"]")
+	os.Setenv("ECS_UNMANAGED_CLEANUP_AUDIT_LOGFILE", "/log/removed.log")
+	os.Setenv("ECS_ENABLE_TASK_ADMISSION_CONTROL", "true")
+	os.Setenv("ECS_EMPTY_VOLUME_DATA_ROOT", "/ecs/volumes")
+	os.Setenv("ECS_HOST_DATA_DIR", "/ecs/data")
+	os.Setenv("ECS_DEFAULT_LOG_DRIVER", "syslog")
+	os.Setenv("ECS_DEFAULT_LOG_OPTIONS", "{\"tag\":\"ecs\"}")
+	os.Setenv("ECS_DEFAULT_ULIMITS", "{\"nofile\":{\"Soft\":1024,\"Hard\":4096}}")
+	os.Setenv("ECS_DEFAULT_NO_NEW_PRIVILEGES", "true")
+	os.Setenv("ECS_DEFAULT_SECCOMP_PROFILE", "/etc/ecs/seccomp.json")
+	os.Setenv("ECS_SECRETS_FILE_ROOT", "/srv/secrets")
+	os.Setenv("ECS_SECRETS_HTTP_ENDPOINT", "http://127.0.0.1:8200/secrets/")
+	os.Setenv("ECS_ENVIRONMENT_FILES_ROOT", "/srv/environment-files")
+	os.Setenv("ECS_ENABLE_CONTAINER_METADATA", "true")
+	os.Setenv("ECS_CONTAINER_METADATA_DATA_ROOT", "/ecs/metadata")
+	// The default log driver would fail the validation of later tests, which
+	// do not make syslog available, and so would the seccomp profile, which
+	// does not exist, and the container metadata data root, which would be
+	// created
+	defer os.Unsetenv("ECS_DEFAULT_LOG_DRIVER")
+	defer os.Unsetenv("ECS_DEFAULT_LOG_OPTIONS")
+	defer os.Unsetenv("ECS_DEFAULT_SECCOMP_PROFILE")
+	defer os.Unsetenv("ECS_ENABLE_CONTAINER_METADATA")
 
 	conf := environmentConfig()
 	if conf.Cluster != "myCluster" {
@@ -111,10 +151,32 @@ func TestEnvironmentConfig(t *testing.T) {
 	if conf.DockerStopTimeout != expectedDuration {
 		t.Error("Wrong value for DockerStopTimeout", conf.DockerStopTimeout)
 	}
+	if conf.TaskStopTimeout != 10*time.Minute {
+		t.Error("Wrong value for TaskStopTimeout", conf.TaskStopTimeout)
+	}
 
 	if !reflect.DeepEqual(conf.AvailableLoggingDrivers, []dockerclient.LoggingDriver{dockerclient.SyslogDriver}) {
 		t.Error("Wrong value for AvailableLoggingDrivers", conf.AvailableLoggingDrivers)
 	}
+	expectedLimits := map[dockerclient.DockerOperation]int{dockerclient.PullOperation: 2, dockerclient.CreateOperation: 10}
+	if !reflect.DeepEqual(conf.DockerOperationLimits, expectedLimits) {
+		t.Error("Wrong value for DockerOperationLimits", conf.DockerOperationLimits)
+	}
+	if conf.ImagePullBehavior != dockerclient.ImagePullPreferCachedBehavior {
+		t.Error("Wrong value for ImagePullBehavior", conf.ImagePullBehavior)
+	}
+	if !reflect.DeepEqual(conf.ImagePrewarmList, []string{"nginx:latest", "busybox:latest"}) {
+		t.Error("Wrong value for ImagePrewarmList", conf.ImagePrewarmList)
+	}
+	if conf.ImagePrewarmInterval != 3*time.Hour {
+		t.Error("Wrong value for ImagePrewarmInterval", conf.ImagePrewarmInterval)
+	}
+	if !conf.ImagePrewarmECRAuth {
+		t.Error("Wrong value for ImagePrewarmECRAuth")
+	}
+	if !conf.ImagePinAPIEnabled {
+		t.Error("Wrong value for ImagePinAPIEnabled")
+	}
 	if !conf.PrivilegedDisabled {
 		t.Error("Wrong value for PrivilegedDisabled")
 	}
@@ -145,6 +207,69 @@ func TestEnvironmentConfig(t *testing.T) {
 	if conf.NumImagesToDeletePerCycle != 2 {
 		t.Error("Wrong value for NumImagesToDeletePerCycle")
 	}
+	if !reflect.DeepEqual(conf.ImageCleanupExclusionList, []string{"amazon/amazon-ecs-agent:latest", "myregistry/base:*"}) {
+		t.Error("Wrong value for ImageCleanupExclusionList", conf.ImageCleanupExclusionList)
+	}
+	if conf.ImageCleanupStrategy != dockerclient.ImageCleanupLargestFirstStrategy {
+		t.Error("Wrong value for ImageCleanupStrategy", conf.ImageCleanupStrategy)
+	}
+	if conf.ImageCleanupHighWatermark != 85 || conf.ImageCleanupLowWatermark != 70 {
+		t.Error("Wrong value for image cleanup watermarks", conf.ImageCleanupHighWatermark, conf.ImageCleanupLowWatermark)
+	}
+	if conf.DockerDataRoot != "/host/var/lib/docker" {
+		t.Error("Wrong value for DockerDataRoot", conf.DockerDataRoot)
+	}
+	if !conf.UnmanagedCleanupEnabled {
+		t.Error("Wrong value for UnmanagedCleanupEnabled")
+	}
+	if conf.UnmanagedImageMinimumAge != 48*time.Hour || conf.UnmanagedContainerMinimumAge != 6*time.Hour {
+		t.Error("Wrong value for unmanaged resource minimum ages", conf.UnmanagedImageMinimumAge, conf.UnmanagedContainerMinimumAge)
+	}
+	if !reflect.DeepEqual(conf.UnmanagedCleanupExclusionList, []string{"mybuilds/*", "datadog-agent"}) {
+		t.Error("Wrong value for UnmanagedCleanupExclusionList", conf.UnmanagedCleanupExclusionList)
+	}
+	if conf.UnmanagedCleanupAuditLogFile != "/log/removed.log" {
+		t.Error("Wrong value for UnmanagedCleanupAuditLogFile", conf.UnmanagedCleanupAuditLogFile)
+	}
+	if !conf.TaskAdmissionControlEnabled {
+		t.Error("Wrong value for TaskAdmissionControlEnabled")
+	}
+	if conf.EmptyVolumeDataRoot != "/ecs/volumes" {
+		t.Error("Wrong value for EmptyVolumeDataRoot", conf.EmptyVolumeDataRoot)
+	}
+	if conf.DataDirOnHost != "/ecs/data" {
+		t.Error("Wrong value for DataDirOnHost", conf.DataDirOnHost)
+	}
+	if conf.DefaultLogDriver != dockerclient.SyslogDriver {
+		t.Error("Wrong value for DefaultLogDriver", conf.DefaultLogDriver)
+	}
+	if !reflect.DeepEqual(conf.DefaultLogOptions, map[string]string{"tag": "ecs"}) {
+		t.Error("Wrong value for DefaultLogOptions", conf.DefaultLogOptions)
+	}
+	if !reflect.DeepEqual(conf.DefaultUlimits, map[string]Ulimit{"nofile": {Soft: 1024, Hard: 4096}}) {
+		t.Error("Wrong value for DefaultUlimits", conf.DefaultUlimits)
+	}
+	if !conf.DefaultNoNewPrivileges {
+		t.Error("Wrong value for DefaultNoNewPrivileges")
+	}
+	if conf.DefaultSeccompProfile != "/etc/ecs/seccomp.json" {
+		t.Error("Wrong value for DefaultSeccompProfile", conf.DefaultSeccompProfile)
+	}
+	if conf.SecretsFileRoot != "/srv/secrets" {
+		t.Error("Wrong value for SecretsFileRoot", conf.SecretsFileRoot)
+	}
+	if conf.SecretsHTTPEndpoint != "http://127.0.0.1:8200/secrets/" {
+		t.Error("Wrong value for SecretsHTTPEndpoint", conf.SecretsHTTPEndpoint)
+	}
+	if conf.EnvironmentFilesRoot != "/srv/environment-files" {
+		t.Error("Wrong value for EnvironmentFilesRoot", conf.EnvironmentFilesRoot)
+	}
+	if !conf.ContainerMetadataEnabled {
+		t.Error("Wrong value for ContainerMetadataEnabled")
+	}
+	if conf.ContainerMetadataDataRoot != "/ecs/metadata" {
+		t.Error("Wrong value for ContainerMetadataDataRoot", conf.ContainerMetadataDataRoot)
+	}
 }
 
 func TestTrimWhitespace(t *testing.T) {
@@ -290,6 +415,65 @@ func TestTaskCleanupTimeout(t *testing.T) {
 	}
 }
 
+func TestInvalidDockerOperationLimits(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.DockerOperationLimits = map[dockerclient.DockerOperation]int{
+		dockerclient.PullOperation: -1,
+		"build":                    1,
+		dockerclient.StopOperation: 4,
+	}
+	err := conf.validateAndOverrideBounds()
+	if err == nil || err.Error() != "Invalid docker operation limits: build, pull" {
+		t.Error("Expected an error naming the invalid operations, got", err)
+	}
+}
+
+func TestInvalidImagePullBehavior(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.ImagePullBehavior = "sometimes"
+	err := conf.validateAndOverrideBounds()
+	if err == nil || err.Error() != "Invalid image pull behavior: sometimes" {
+		t.Error("Expected an error naming the invalid pull behavior, got", err)
+	}
+}
+
+func TestInvalidImageCleanupStrategy(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.ImageCleanupStrategy = "mru"
+	err := conf.validateAndOverrideBounds()
+	if err == nil || err.Error() != "Invalid image cleanup strategy: mru" {
+		t.Error("Expected an error naming the invalid strategy, got", err)
+	}
+}
+
+func TestInvalidImagePrewarmInterval(t *testing.T) {
+	os.Setenv("ECS_IMAGE_PREWARM_INTERVAL", "1m")
+	defer os.Unsetenv("ECS_IMAGE_PREWARM_INTERVAL")
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.ImagePrewarmInterval != DefaultImagePrewarmInterval {
+		t.Errorf("Image prewarm interval set incorrectly. Expected %v, got %v", DefaultImagePrewarmInterval, cfg.ImagePrewarmInterval)
+	}
+}
+
+func TestInvalidTaskStopTimeout(t *testing.T) {
+	os.Setenv("ECS_TASK_STOP_TIMEOUT", "-1s")
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.TaskStopTimeout != DefaultTaskStopTimeout {
+		t.Errorf("Task stop timeout set incorrectly. Expected %v, got %v", DefaultTaskStopTimeout, cfg.TaskStopTimeout)
+	}
+}
+
 func TestInvalidReservedMemory(t *testing.T) {
 	os.Setenv("ECS_RESERVED_MEMORY", "-1")
 	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
@@ -379,6 +563,179 @@ func TestImageCleanupMinimumInterval(t *testing.T) {
 	}
 }
 
+func TestInvalidImageCleanupExclusionList(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.ImageCleanupExclusionList = []string{"base:*", "[unclosed", "other[", "busybox"}
+	err := conf.validateAndOverrideBounds()
+	if err == nil || err.Error() != "Invalid image cleanup exclusion patterns: [unclosed, other[" {
+		t.Error("Expected an error naming the invalid patterns, got", err)
+	}
+}
+
+func TestInvalidImageCleanupWatermarks(t *testing.T) {
+	for _, watermarks := range [][2]uint16{{101, 50}, {80, 0}, {80, 80}, {70, 85}} {
+		conf := DefaultConfig()
+		conf.AWSRegion = "us-west-2"
+		conf.ImageCleanupHighWatermark = watermarks[0]
+		conf.ImageCleanupLowWatermark = watermarks[1]
+		conf.DockerDataRoot = "/host/var/lib/docker"
+		err := conf.validateAndOverrideBounds()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conf.ImageCleanupHighWatermark != 0 || conf.ImageCleanupLowWatermark != 0 {
+			t.Errorf("Expected watermarks %v to be discarded, got %d and %d", watermarks, conf.ImageCleanupHighWatermark, conf.ImageCleanupLowWatermark)
+		}
+	}
+}
+
+func TestImageCleanupWatermarksWithoutDockerDataRoot(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.ImageCleanupHighWatermark = 85
+	conf.ImageCleanupLowWatermark = 70
+	conf.DockerDataRoot = ""
+	err := conf.validateAndOverrideBounds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.ImageCleanupHighWatermark != 0 || conf.ImageCleanupLowWatermark != 0 {
+		t.Errorf("Expected watermarks to be discarded without a Docker data root, got %d and %d", conf.ImageCleanupHighWatermark, conf.ImageCleanupLowWatermark)
+	}
+}
+
+func TestInvalidUnmanagedCleanupExclusionList(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.UnmanagedCleanupExclusionList = []string{"builds/*", "[unclosed"}
+	err := conf.validateAndOverrideBounds()
+	if err == nil || err.Error() != "Invalid unmanaged cleanup exclusion patterns: [unclosed" {
+		t.Error("Expected an error naming the invalid patterns, got", err)
+	}
+}
+
+func TestInvalidDefaultLogDriver(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.DefaultLogDriver = dockerclient.SyslogDriver
+	err := conf.validateAndOverrideBounds()
+	if err == nil || err.Error() != "Default log driver is not an available logging driver: syslog" {
+		t.Error("Expected an error for an unavailable default log driver, got", err)
+	}
+
+	conf.DefaultLogDriver = ""
+	conf.DefaultLogOptions = map[string]string{"max-size": "10m"}
+	if err := conf.validateAndOverrideBounds(); err == nil {
+		t.Error("Expected an error for default log options without a default log driver")
+	}
+}
+
+func TestInvalidDefaultUlimits(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.DefaultUlimits = map[string]Ulimit{
+		"nofile": {Soft: 4096, Hard: 1024},
+		"nproc":  {Soft: -1, Hard: 10},
+		"core":   {Soft: 0, Hard: 0},
+		"files":  {Soft: 0, Hard: 0},
+	}
+	err := conf.validateAndOverrideBounds()
+	if err == nil || err.Error() != "Invalid default ulimits: files, nofile, nproc" {
+		t.Error("Expected an error naming the invalid ulimits, got", err)
+	}
+}
+
+func TestInvalidDefaultSeccompProfile(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.DefaultSeccompProfile = "seccomp.json"
+	if err := conf.validateAndOverrideBounds(); err == nil {
+		t.Error("Expected an error for a relative seccomp profile path")
+	}
+	conf.DefaultSeccompProfile = SeccompProfileUnconfined
+	if err := conf.validateAndOverrideBounds(); err != nil {
+		t.Error("Unexpected error for an unconfined seccomp profile", err)
+	}
+
+	dir, err := ioutil.TempDir("", "ecs-seccomp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf.DefaultSeccompProfile = filepath.Join(dir, "seccomp.json")
+	if err := conf.validateAndOverrideBounds(); err == nil {
+		t.Error("Expected an error for a missing seccomp profile")
+	}
+	if err := ioutil.WriteFile(conf.DefaultSeccompProfile, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.validateAndOverrideBounds(); err == nil {
+		t.Error("Expected an error for an invalid seccomp profile")
+	}
+}
+
+func TestDefaultSeccompProfileLoaded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs-seccomp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	profile := filepath.Join(dir, "seccomp.json")
+	if err := ioutil.WriteFile(profile, []byte("{\n  \"defaultAction\": \"SCMP_ACT_ERRNO\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.DefaultSeccompProfile = profile
+	if err := conf.validateAndOverrideBounds(); err != nil {
+		t.Fatal("Unexpected error for a valid seccomp profile", err)
+	}
+	if conf.DefaultSeccompProfileJSON != `{"defaultAction":"SCMP_ACT_ERRNO"}` {
+		t.Error("Wrong value for DefaultSeccompProfileJSON", conf.DefaultSeccompProfileJSON)
+	}
+}
+
+func TestInvalidSecretsConfig(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.SecretsFileRoot = "secrets"
+	if err := conf.validateAndOverrideBounds(); err == nil {
+		t.Error("Expected an error for a relative secrets file root")
+	}
+
+	conf = DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	for _, endpoint := range []string{"ftp://example.com/", "127.0.0.1:8200", "http://"} {
+		conf.SecretsHTTPEndpoint = endpoint
+		if err := conf.validateAndOverrideBounds(); err == nil {
+			t.Error("Expected an error for secrets HTTP endpoint", endpoint)
+		}
+	}
+	conf.SecretsHTTPEndpoint = "https://secrets.example.com/v1/"
+	if err := conf.validateAndOverrideBounds(); err != nil {
+		t.Error("Unexpected error for an https secrets endpoint", err)
+	}
+}
+
+func TestInvalidUnmanagedResourceMinimumAges(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.UnmanagedImageMinimumAge = time.Second
+	conf.UnmanagedContainerMinimumAge = -time.Hour
+	err := conf.validateAndOverrideBounds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.UnmanagedImageMinimumAge != DefaultUnmanagedImageMinimumAge {
+		t.Errorf("Wrong value for UnmanagedImageMinimumAge: %v", conf.UnmanagedImageMinimumAge)
+	}
+	if conf.UnmanagedContainerMinimumAge != DefaultUnmanagedContainerMinimumAge {
+		t.Errorf("Wrong value for UnmanagedContainerMinimumAge: %v", conf.UnmanagedContainerMinimumAge)
+	}
+}
+
 func TestImageCleanupMinimumNumImagesToDeletePerCycle(t *testing.T) {
 	os.Setenv("ECS_NUM_IMAGES_DELETE_PER_CYCLE", "-1")
 	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
@@ -390,3 +747,64 @@ func TestImageCleanupMinimumNumImagesToDeletePerCycle(t *testing.T) {
 		t.Errorf("Wrong value for NumImagesToDeletePerCycle: %v", cfg.NumImagesToDeletePerCycle)
 	}
 }
+
+func TestInvalidEnvironmentFilesConfig(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.EnvironmentFilesRoot = "environment-files"
+	if err := conf.validateAndOverrideBounds(); err == nil {
+		t.Error("Expected an error for a relative environment files root")
+	}
+
+	conf.SecretsFileRoot = "/etc/ecs/secrets"
+	for _, root := range []string{"/etc/ecs", "/etc/ecs/secrets", "/etc/ecs/secrets/app"} {
+		conf.EnvironmentFilesRoot = root
+		if err := conf.validateAndOverrideBounds(); err == nil {
+			t.Error("Expected an error for an environment files root overlapping the secrets file root", root)
+		}
+	}
+	conf.EnvironmentFilesRoot = "/etc/ecs/secrets-env"
+	if err := conf.validateAndOverrideBounds(); err != nil {
+		t.Error("Unexpected error for an environment files root next to the secrets file root", err)
+	}
+}
+
+func TestInvalidContainerMetadataConfig(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.ContainerMetadataDataRoot = "metadata"
+	if err := conf.validateAndOverrideBounds(); err != nil {
+		t.Error("Unexpected error for a relative data root with container metadata disabled", err)
+	}
+	conf.ContainerMetadataEnabled = true
+	if err := conf.validateAndOverrideBounds(); err == nil {
+		t.Error("Expected an error for a relative container metadata data root")
+	}
+
+	dir, err := ioutil.TempDir("", "ecs-container-metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf.ContainerMetadataDataRoot = filepath.Join(dir, "metadata")
+	if err := conf.validateAndOverrideBounds(); err != nil {
+		t.Error("Unexpected error for a writable container metadata data root", err)
+	}
+	// A file cannot be used as a directory, even by root
+	if err := ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf.ContainerMetadataDataRoot = filepath.Join(dir, "file", "metadata")
+	if err := conf.validateAndOverrideBounds(); err == nil {
+		t.Error("Expected an error for a container metadata data root that cannot be created")
+	}
+}
+
+func TestInvalidDataDirOnHost(t *testing.T) {
+	conf := DefaultConfig()
+	conf.AWSRegion = "us-west-2"
+	conf.DataDirOnHost = "var/lib/ecs/data"
+	if err := conf.validateAndOverrideBounds(); err == nil {
+		t.Error("Expected an error for a relative host data directory")
+	}
+}
@@ -1,4 +1,6 @@
+//go:build !windows
 // +build !windows
+
 // Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License"). You may
@@ -19,26 +21,57 @@ import "github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
 const (
 	// defaultAuditLogFile specifies the default audit log filename
 	defaultCredentialsAuditLogFile = "/log/audit.log"
+	// defaultUnmanagedCleanupAuditLogFile specifies the default filename of
+	// the log of unmanaged images and containers that were removed
+	defaultUnmanagedCleanupAuditLogFile = "/log/cleanup-audit.log"
+	// defaultDataDirOnHost specifies the default host directory that ecs-init
+	// mounts as the data directory of the agent
+	defaultDataDirOnHost = "/var/lib/ecs/data"
+	// defaultEmptyVolumeDataRoot specifies the default directory holding the
+	// empty volumes of tasks, which is in the data directory so that it is
+	// mounted in the agent's container
+	defaultEmptyVolumeDataRoot = defaultDataDirOnHost + "/volumes"
+	// defaultSecretsFileRoot specifies the default directory holding the
+	// secrets that tasks may reference by file
+	defaultSecretsFileRoot = "/etc/ecs/secrets"
+	// defaultEnvironmentFilesRoot specifies the default directory holding the
+	// environment files that tasks may reference by path
+	defaultEnvironmentFilesRoot = "/etc/ecs/environment-files"
+	// defaultContainerMetadataDataRoot specifies the default directory
+	// holding the metadata files of containers
+	defaultContainerMetadataDataRoot = "/var/lib/ecs/metadata"
 )
 
 // DefaultConfig returns the default configuration for Linux
 func DefaultConfig() Config {
 	return Config{
-		DockerEndpoint:              "unix:///var/run/docker.sock",
-		ReservedPorts:               []uint16{SSHPort, DockerReservedPort, DockerReservedSSLPort, AgentIntrospectionPort, AgentCredentialsPort},
-		ReservedPortsUDP:            []uint16{},
-		DataDir:                     "/data/",
-		DisableMetrics:              false,
-		ReservedMemory:              0,
-		AvailableLoggingDrivers:     []dockerclient.LoggingDriver{dockerclient.JsonFileDriver},
-		TaskCleanupWaitDuration:     DefaultTaskCleanupWaitDuration,
-		DockerStopTimeout:           DefaultDockerStopTimeout,
-		CredentialsAuditLogFile:     defaultCredentialsAuditLogFile,
-		CredentialsAuditLogDisabled: false,
-		ImageCleanupDisabled:        false,
-		MinimumImageDeletionAge:     DefaultImageDeletionAge,
-		ImageCleanupInterval:        DefaultImageCleanupTimeInterval,
-		NumImagesToDeletePerCycle:   DefaultNumImagesToDeletePerCycle,
+		DockerEndpoint:               "unix:///var/run/docker.sock",
+		ReservedPorts:                []uint16{SSHPort, DockerReservedPort, DockerReservedSSLPort, AgentIntrospectionPort, AgentCredentialsPort},
+		ReservedPortsUDP:             []uint16{},
+		DataDir:                      "/data/",
+		DisableMetrics:               false,
+		ReservedMemory:               0,
+		AvailableLoggingDrivers:      []dockerclient.LoggingDriver{dockerclient.JsonFileDriver},
+		TaskCleanupWaitDuration:      DefaultTaskCleanupWaitDuration,
+		DockerStopTimeout:            DefaultDockerStopTimeout,
+		TaskStopTimeout:              DefaultTaskStopTimeout,
+		ImagePullBehavior:            dockerclient.ImagePullAlwaysBehavior,
+		ImagePrewarmInterval:         DefaultImagePrewarmInterval,
+		CredentialsAuditLogFile:      defaultCredentialsAuditLogFile,
+		CredentialsAuditLogDisabled:  false,
+		ImageCleanupDisabled:         false,
+		MinimumImageDeletionAge:      DefaultImageDeletionAge,
+		ImageCleanupInterval:         DefaultImageCleanupTimeInterval,
+		NumImagesToDeletePerCycle:    DefaultNumImagesToDeletePerCycle,
+		ImageCleanupStrategy:         dockerclient.ImageCleanupLeastRecentlyUsedStrategy,
+		UnmanagedImageMinimumAge:     DefaultUnmanagedImageMinimumAge,
+		UnmanagedContainerMinimumAge: DefaultUnmanagedContainerMinimumAge,
+		UnmanagedCleanupAuditLogFile: defaultUnmanagedCleanupAuditLogFile,
+		EmptyVolumeDataRoot:          defaultEmptyVolumeDataRoot,
+		DataDirOnHost:                defaultDataDirOnHost,
+		SecretsFileRoot:              defaultSecretsFileRoot,
+		EnvironmentFilesRoot:         defaultEnvironmentFilesRoot,
+		ContainerMetadataDataRoot:    defaultContainerMetadataDataRoot,
 	}
 }
 
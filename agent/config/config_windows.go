@@ -1,4 +1,6 @@
+//go:build windows
 // +build windows
+
 // Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License"). You may
@@ -23,7 +25,8 @@ import (
 )
 
 const (
-	defaultCredentialsAuditLogFile = `log\audit.log`
+	defaultCredentialsAuditLogFile      = `log\audit.log`
+	defaultUnmanagedCleanupAuditLogFile = `log\cleanup-audit.log`
 	// When using IAM roles for tasks on Windows, the credential proxy consumes port 80
 	httpPort = 80
 	// Remote Desktop / Terminal Services
@@ -61,17 +64,30 @@ func DefaultConfig() Config {
 		ReservedPortsUDP: []uint16{},
 		DataDir:          filepath.Join(ecsRoot, "data"),
 		// DisableMetrics is set to true on Windows as docker stats does not work
-		DisableMetrics:              true,
-		ReservedMemory:              0,
-		AvailableLoggingDrivers:     []dockerclient.LoggingDriver{dockerclient.JsonFileDriver},
-		TaskCleanupWaitDuration:     DefaultTaskCleanupWaitDuration,
-		DockerStopTimeout:           DefaultDockerStopTimeout,
-		CredentialsAuditLogFile:     filepath.Join(ecsRoot, defaultCredentialsAuditLogFile),
-		CredentialsAuditLogDisabled: false,
-		ImageCleanupDisabled:        false,
-		MinimumImageDeletionAge:     DefaultImageDeletionAge,
-		ImageCleanupInterval:        DefaultImageCleanupTimeInterval,
-		NumImagesToDeletePerCycle:   DefaultNumImagesToDeletePerCycle,
+		DisableMetrics:               true,
+		ReservedMemory:               0,
+		AvailableLoggingDrivers:      []dockerclient.LoggingDriver{dockerclient.JsonFileDriver},
+		TaskCleanupWaitDuration:      DefaultTaskCleanupWaitDuration,
+		DockerStopTimeout:            DefaultDockerStopTimeout,
+		TaskStopTimeout:              DefaultTaskStopTimeout,
+		ImagePullBehavior:            dockerclient.ImagePullAlwaysBehavior,
+		ImagePrewarmInterval:         DefaultImagePrewarmInterval,
+		CredentialsAuditLogFile:      filepath.Join(ecsRoot, defaultCredentialsAuditLogFile),
+		CredentialsAuditLogDisabled:  false,
+		ImageCleanupDisabled:         false,
+		MinimumImageDeletionAge:      DefaultImageDeletionAge,
+		ImageCleanupInterval:         DefaultImageCleanupTimeInterval,
+		NumImagesToDeletePerCycle:    DefaultNumImagesToDeletePerCycle,
+		ImageCleanupStrategy:         dockerclient.ImageCleanupLeastRecentlyUsedStrategy,
+		DockerDataRoot:               filepath.Join(programData, "docker"),
+		UnmanagedImageMinimumAge:     DefaultUnmanagedImageMinimumAge,
+		UnmanagedContainerMinimumAge: DefaultUnmanagedContainerMinimumAge,
+		UnmanagedCleanupAuditLogFile: filepath.Join(ecsRoot, defaultUnmanagedCleanupAuditLogFile),
+		EmptyVolumeDataRoot:          filepath.Join(ecsRoot, "volumes"),
+		DataDirOnHost:                filepath.Join(ecsRoot, "data"),
+		SecretsFileRoot:              filepath.Join(ecsRoot, "secrets"),
+		EnvironmentFilesRoot:         filepath.Join(ecsRoot, "environment-files"),
+		ContainerMetadataDataRoot:    filepath.Join(ecsRoot, "metadata"),
 	}
 }
 
@@ -30,16 +30,46 @@ func TestConfigDefault(t *testing.T) {
 	os.Unsetenv("ECS_RESERVED_MEMORY")
 	os.Unsetenv("ECS_DISABLE_PRIVILEGED")
 	os.Unsetenv("ECS_AVAILABLE_LOGGING_DRIVERS")
+	os.Unsetenv("ECS_DOCKER_OPERATION_LIMITS")
+	os.Unsetenv("ECS_IMAGE_PULL_BEHAVIOR")
+	os.Unsetenv("ECS_IMAGE_PREWARM_LIST")
+	os.Unsetenv("ECS_IMAGE_PREWARM_INTERVAL")
+	os.Unsetenv("ECS_IMAGE_PREWARM_ECR_AUTH")
+	os.Unsetenv("ECS_ENABLE_IMAGE_PIN_API")
 	os.Unsetenv("ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION")
 	os.Unsetenv("ECS_ENABLE_TASK_IAM_ROLE")
 	os.Unsetenv("ECS_ENABLE_TASK_IAM_ROLE_NETWORK_HOST")
 	os.Unsetenv("ECS_CONTAINER_STOP_TIMEOUT")
+	os.Unsetenv("ECS_TASK_STOP_TIMEOUT")
 	os.Unsetenv("ECS_AUDIT_LOGFILE")
 	os.Unsetenv("ECS_AUDIT_LOGFILE_DISABLED")
 	os.Unsetenv("ECS_DISABLE_IMAGE_CLEANUP")
 	os.Unsetenv("ECS_NUM_IMAGES_DELETE_PER_CYCLE")
 	os.Unsetenv("ECS_IMAGE_MINIMUM_CLEANUP_AGE")
 	os.Unsetenv("ECS_IMAGE_CLEANUP_INTERVAL")
+	os.Unsetenv("ECS_IMAGE_CLEANUP_STRATEGY")
+	os.Unsetenv("ECS_IMAGE_CLEANUP_EXCLUDE")
+	os.Unsetenv("ECS_IMAGE_CLEANUP_HIGH_WATERMARK")
+	os.Unsetenv("ECS_IMAGE_CLEANUP_LOW_WATERMARK")
+	os.Unsetenv("ECS_DOCKER_DATA_ROOT")
+	os.Unsetenv("ECS_ENABLE_UNMANAGED_CLEANUP")
+	os.Unsetenv("ECS_UNMANAGED_IMAGE_MINIMUM_AGE")
+	os.Unsetenv("ECS_UNMANAGED_CONTAINER_MINIMUM_AGE")
+	os.Unsetenv("ECS_UNMANAGED_CLEANUP_EXCLUDE")
+	os.Unsetenv("ECS_UNMANAGED_CLEANUP_AUDIT_LOGFILE")
+	os.Unsetenv("ECS_ENABLE_TASK_ADMISSION_CONTROL")
+	os.Unsetenv("ECS_EMPTY_VOLUME_DATA_ROOT")
+	os.Unsetenv("ECS_HOST_DATA_DIR")
+	os.Unsetenv("ECS_DEFAULT_LOG_DRIVER")
+	os.Unsetenv("ECS_DEFAULT_LOG_OPTIONS")
+	os.Unsetenv("ECS_DEFAULT_ULIMITS")
+	os.Unsetenv("ECS_DEFAULT_NO_NEW_PRIVILEGES")
+	os.Unsetenv("ECS_DEFAULT_SECCOMP_PROFILE")
+	os.Unsetenv("ECS_SECRETS_FILE_ROOT")
+	os.Unsetenv("ECS_SECRETS_HTTP_ENDPOINT")
+	os.Unsetenv("ECS_ENVIRONMENT_FILES_ROOT")
+	os.Unsetenv("ECS_ENABLE_CONTAINER_METADATA")
+	os.Unsetenv("ECS_CONTAINER_METADATA_DATA_ROOT")
 
 	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
 	assert.Nil(t, err)
@@ -50,8 +80,15 @@ func TestConfigDefault(t *testing.T) {
 	assert.Equal(t, 10, len(cfg.ReservedPorts), "Default reserved ports set incorrectly")
 	assert.Equal(t, uint16(0), cfg.ReservedMemory, "Default reserved memory set incorrectly")
 	assert.Equal(t, 30*time.Second, cfg.DockerStopTimeout, "Default docker stop container timeout set incorrectly")
+	assert.Equal(t, 5*time.Minute, cfg.TaskStopTimeout, "Default task stop timeout set incorrectly")
 	assert.False(t, cfg.PrivilegedDisabled, "Default PrivilegedDisabled set incorrectly")
 	assert.Equal(t, []dockerclient.LoggingDriver{dockerclient.JsonFileDriver}, cfg.AvailableLoggingDrivers, "Default logging drivers set incorrectly")
+	assert.Empty(t, cfg.DockerOperationLimits, "Default docker operation limits set incorrectly")
+	assert.Equal(t, dockerclient.ImagePullAlwaysBehavior, cfg.ImagePullBehavior, "Default image pull behavior set incorrectly")
+	assert.Empty(t, cfg.ImagePrewarmList, "Default image prewarm list set incorrectly")
+	assert.Equal(t, time.Hour, cfg.ImagePrewarmInterval, "Default image prewarm interval set incorrectly")
+	assert.False(t, cfg.ImagePrewarmECRAuth, "Default image prewarm ECR auth set incorrectly")
+	assert.False(t, cfg.ImagePinAPIEnabled, "Default image pin API set incorrectly")
 	assert.Equal(t, 3*time.Hour, cfg.TaskCleanupWaitDuration, "Default task cleanup wait duration set incorrectly")
 	assert.False(t, cfg.TaskIAMRoleEnabled, "TaskIAMRoleEnabled set incorrectly")
 	assert.False(t, cfg.TaskIAMRoleEnabledForNetworkHost, "TaskIAMRoleEnabledForNetworkHost set incorrectly")
@@ -61,6 +98,29 @@ func TestConfigDefault(t *testing.T) {
 	assert.Equal(t, DefaultImageDeletionAge, cfg.MinimumImageDeletionAge, "MinimumImageDeletionAge default is set incorrectly")
 	assert.Equal(t, DefaultImageCleanupTimeInterval, cfg.ImageCleanupInterval, "ImageCleanupInterval default is set incorrectly")
 	assert.Equal(t, DefaultNumImagesToDeletePerCycle, cfg.NumImagesToDeletePerCycle, "NumImagesToDeletePerCycle default is set incorrectly")
+	assert.Equal(t, dockerclient.ImageCleanupLeastRecentlyUsedStrategy, cfg.ImageCleanupStrategy, "ImageCleanupStrategy default is set incorrectly")
+	assert.Empty(t, cfg.ImageCleanupExclusionList, "ImageCleanupExclusionList default is set incorrectly")
+	assert.Zero(t, cfg.ImageCleanupHighWatermark, "ImageCleanupHighWatermark default is set incorrectly")
+	assert.Zero(t, cfg.ImageCleanupLowWatermark, "ImageCleanupLowWatermark default is set incorrectly")
+	assert.Equal(t, `C:\ProgramData\docker`, cfg.DockerDataRoot, "DockerDataRoot default is set incorrectly")
+	assert.False(t, cfg.UnmanagedCleanupEnabled, "UnmanagedCleanupEnabled default is set incorrectly")
+	assert.Equal(t, DefaultUnmanagedImageMinimumAge, cfg.UnmanagedImageMinimumAge, "UnmanagedImageMinimumAge default is set incorrectly")
+	assert.Equal(t, DefaultUnmanagedContainerMinimumAge, cfg.UnmanagedContainerMinimumAge, "UnmanagedContainerMinimumAge default is set incorrectly")
+	assert.Empty(t, cfg.UnmanagedCleanupExclusionList, "UnmanagedCleanupExclusionList default is set incorrectly")
+	assert.Equal(t, `C:\ProgramData\Amazon\ECS\log\cleanup-audit.log`, cfg.UnmanagedCleanupAuditLogFile, "UnmanagedCleanupAuditLogFile default is set incorrectly")
+	assert.False(t, cfg.TaskAdmissionControlEnabled, "TaskAdmissionControlEnabled default is set incorrectly")
+	assert.Equal(t, `C:\ProgramData\Amazon\ECS\volumes`, cfg.EmptyVolumeDataRoot, "EmptyVolumeDataRoot default is set incorrectly")
+	assert.Equal(t, `C:\ProgramData\Amazon\ECS\data`, cfg.DataDirOnHost, "DataDirOnHost default is set incorrectly")
+	assert.Zero(t, cfg.DefaultLogDriver, "DefaultLogDriver default is set incorrectly")
+	assert.Empty(t, cfg.DefaultLogOptions, "DefaultLogOptions default is set incorrectly")
+	assert.Empty(t, cfg.DefaultUlimits, "DefaultUlimits default is set incorrectly")
+	assert.False(t, cfg.DefaultNoNewPrivileges, "DefaultNoNewPrivileges default is set incorrectly")
+	assert.Empty(t, cfg.DefaultSeccompProfile, "DefaultSeccompProfile default is set incorrectly")
+	assert.Equal(t, `C:\ProgramData\Amazon\ECS\secrets`, cfg.SecretsFileRoot, "SecretsFileRoot default is set incorrectly")
+	assert.Equal(t, `C:\ProgramData\Amazon\ECS\environment-files`, cfg.EnvironmentFilesRoot, "EnvironmentFilesRoot default is set incorrectly")
+	assert.Equal(t, `C:\ProgramData\Amazon\ECS\metadata`, cfg.ContainerMetadataDataRoot, "ContainerMetadataDataRoot default is set incorrectly")
+	assert.False(t, cfg.ContainerMetadataEnabled, "ContainerMetadataEnabled default is set incorrectly")
+	assert.Empty(t, cfg.SecretsHTTPEndpoint, "SecretsHTTPEndpoint default is set incorrectly")
 }
 
 func TestConfigIAMTaskRolesReserves80(t *testing.T) {
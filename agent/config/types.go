@@ -85,6 +85,34 @@ type Config struct {
 	// containers managed by ECS
 	DockerStopTimeout time.Duration
 
+	// TaskStopTimeout bounds the time spent stopping a task's containers in
+	// reverse dependency order. Once it elapses, all remaining containers are
+	// stopped at once.
+	TaskStopTimeout time.Duration
+
+	// DockerOperationLimits specifies the maximum number of concurrent calls
+	// made to Docker for each type of operation. Operations that are not
+	// listed are not limited.
+	DockerOperationLimits map[dockerclient.DockerOperation]int
+
+	// ImagePullBehavior determines when images are pulled before containers
+	// are created. Containers may override it. It defaults to "always".
+	ImagePullBehavior dockerclient.ImagePullBehavior
+
+	// ImagePrewarmList lists images that are pulled when the agent starts and
+	// again every ImagePrewarmInterval. They are never removed by the image
+	// cleanup.
+	ImagePrewarmList []string
+
+	// ImagePrewarmInterval is the time between pulls of the images in
+	// ImagePrewarmList
+	ImagePrewarmInterval time.Duration
+
+	// ImagePrewarmECRAuth specifies whether images in ImagePrewarmList that
+	// are hosted in ECR are pulled with ECR credentials obtained through the
+	// instance's role, rather than with EngineAuthData
+	ImagePrewarmECRAuth bool
+
 	// AvailableLoggingDrivers specifies the logging drivers available for use
 	// with Docker.  If not set, it defaults to ["json-file"].
 	AvailableLoggingDrivers []dockerclient.LoggingDriver
@@ -134,6 +162,125 @@ type Config struct {
 	// NumImagesToDeletePerCycle specifies the num of image to delete every time
 	// when Agent performs cleanup
 	NumImagesToDeletePerCycle int
+
+	// ImageCleanupStrategy determines which of the images eligible for
+	// deletion are deleted first. It defaults to "lru".
+	ImageCleanupStrategy dockerclient.ImageCleanupStrategy
+
+	// ImageCleanupExclusionList lists image names, or glob patterns matching
+	// image names, that are never removed by the image cleanup
+	ImageCleanupExclusionList []string
+
+	// ImageCleanupHighWatermark is the usage, as a percentage, of the
+	// filesystem holding DockerDataRoot above which unused images are deleted
+	// straight away. Zero disables the check.
+	ImageCleanupHighWatermark uint16
+
+	// ImageCleanupLowWatermark is the usage, as a percentage, that images are
+	// deleted down to once ImageCleanupHighWatermark has been exceeded
+	ImageCleanupLowWatermark uint16
+
+	// DockerDataRoot is the path, as seen by the agent, of the directory in
+	// which Docker stores images. When the agent runs in a container, Docker's
+	// data root must be mounted into it. The watermarks are ignored if it is
+	// not set.
+	DockerDataRoot string
+
+	// UnmanagedCleanupEnabled specifies whether the image cleanup also removes
+	// images the agent did not pull, dangling images and exited containers
+	// that were not started by the agent
+	UnmanagedCleanupEnabled bool
+
+	// ImagePinAPIEnabled specifies whether the introspection server accepts
+	// requests to pin and unpin images. These requests change what the image
+	// cleanup removes and are not authenticated, so they are off by default
+	ImagePinAPIEnabled bool
+
+	// UnmanagedImageMinimumAge is the minimum time since an image the agent
+	// did not pull was created before it can be removed
+	UnmanagedImageMinimumAge time.Duration
+
+	// UnmanagedContainerMinimumAge is the minimum time since a container not
+	// started by the agent exited before it can be removed
+	UnmanagedContainerMinimumAge time.Duration
+
+	// UnmanagedCleanupExclusionList lists image names and container names, or
+	// glob patterns matching them, that are never removed by the cleanup of
+	// unmanaged resources. Containers are also kept if their image matches.
+	UnmanagedCleanupExclusionList []string
+
+	// UnmanagedCleanupAuditLogFile specifies the path/filename of the log of
+	// images and containers removed by the cleanup of unmanaged resources
+	UnmanagedCleanupAuditLogFile string
+
+	// TaskAdmissionControlEnabled specifies whether new tasks are checked
+	// against the CPU, memory and host ports left on the instance, and
+	// stopped before any of their containers are created if they do not fit
+	TaskAdmissionControlEnabled bool
+
+	// EmptyVolumeDataRoot is the directory under which the agent creates the
+	// empty volumes of tasks, one directory per task. Containers bind-mount
+	// them, so the path must be the same for the agent and for Docker.
+	EmptyVolumeDataRoot string
+
+	// DataDirOnHost is the directory on the host that is mounted as DataDir
+	// when the agent runs in a container. The agent creates the directories
+	// below it that it gives to Docker, such as those of empty volumes, below
+	// DataDir instead.
+	DataDirOnHost string
+
+	// DefaultLogDriver is the log driver of containers whose task does not
+	// set one. It must be one of AvailableLoggingDrivers.
+	DefaultLogDriver dockerclient.LoggingDriver
+
+	// DefaultLogOptions are the options, such as max-size and max-file, given
+	// to DefaultLogDriver
+	DefaultLogOptions map[string]string
+
+	// DefaultUlimits are the ulimits, by name, of containers whose task does
+	// not set them
+	DefaultUlimits map[string]Ulimit
+
+	// DefaultNoNewPrivileges prevents the processes of every container from
+	// gaining privileges through setuid binaries
+	DefaultNoNewPrivileges bool
+
+	// DefaultSeccompProfile is either "unconfined" or the path of a JSON
+	// seccomp profile applied to containers whose task does not set one
+	DefaultSeccompProfile string
+
+	// DefaultSeccompProfileJSON is the content of DefaultSeccompProfile, or
+	// "unconfined". It is loaded from the file when the config is validated.
+	DefaultSeccompProfileJSON string
+
+	// SecretsFileRoot is the directory that file:// secret references must
+	// point into. Secrets outside of it cannot be read by tasks.
+	SecretsFileRoot string
+
+	// SecretsHTTPEndpoint is the base URL of a service that serves secrets
+	// over HTTP. Secret references must be URLs below it. If not set,
+	// secrets cannot be fetched over HTTP.
+	SecretsHTTPEndpoint string
+
+	// EnvironmentFilesRoot is the directory that environment files on the
+	// host must be in. Files outside of it cannot be read by tasks.
+	EnvironmentFilesRoot string
+
+	// ContainerMetadataEnabled specifies whether the agent writes a JSON file
+	// describing each container into a directory bind-mounted in it
+	ContainerMetadataEnabled bool
+
+	// ContainerMetadataDataRoot is the directory under which the agent writes
+	// the metadata files of containers, one directory per container.
+	// Containers bind-mount them, so the path must be the same for the agent
+	// and for Docker.
+	ContainerMetadataDataRoot string
+}
+
+// Ulimit is the soft and hard limit of a resource
+type Ulimit struct {
+	Soft int64
+	Hard int64
 }
 
 // SensitiveRawMessage is a struct to store some data that should not be logged
@@ -0,0 +1,50 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"sort"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/config"
+)
+
+// newContainerDefaults returns the instance-wide container settings of the
+// config, which has already loaded and validated them
+func newContainerDefaults(cfg *config.Config) *api.ContainerDefaults {
+	defaults := &api.ContainerDefaults{
+		LogDriver:          string(cfg.DefaultLogDriver),
+		LogOptions:         cfg.DefaultLogOptions,
+		NoNewPrivileges:    cfg.DefaultNoNewPrivileges,
+		SeccompProfile:     cfg.DefaultSeccompProfile,
+		SeccompProfileJSON: cfg.DefaultSeccompProfileJSON,
+	}
+	for name, ulimit := range cfg.DefaultUlimits {
+		defaults.Ulimits = append(defaults.Ulimits, api.Ulimit{Name: name, SoftLimit: ulimit.Soft, HardLimit: ulimit.Hard})
+	}
+	sort.Sort(ulimitsByName(defaults.Ulimits))
+	return defaults
+}
+
+type ulimitsByName []api.Ulimit
+
+func (u ulimitsByName) Len() int           { return len(u) }
+func (u ulimitsByName) Less(i, j int) bool { return u[i].Name < u[j].Name }
+func (u ulimitsByName) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+
+// ContainerDefaults returns the instance-wide settings applied to the
+// containers of every task
+func (engine *DockerTaskEngine) ContainerDefaults() *api.ContainerDefaults {
+	return engine.containerDefaults
+}
@@ -0,0 +1,46 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContainerDefaults(t *testing.T) {
+	defaults := newContainerDefaults(&config.Config{
+		DefaultLogDriver:  "json-file",
+		DefaultLogOptions: map[string]string{"max-file": "3"},
+		DefaultUlimits: map[string]config.Ulimit{
+			"nproc":  {Soft: 100, Hard: 200},
+			"nofile": {Soft: 1024, Hard: 4096},
+		},
+		DefaultSeccompProfile:     "/etc/ecs/seccomp.json",
+		DefaultSeccompProfileJSON: `{"defaultAction":"SCMP_ACT_ERRNO"}`,
+	})
+	assert.Equal(t, &api.ContainerDefaults{
+		LogDriver:  "json-file",
+		LogOptions: map[string]string{"max-file": "3"},
+		Ulimits: []api.Ulimit{
+			{Name: "nofile", SoftLimit: 1024, HardLimit: 4096},
+			{Name: "nproc", SoftLimit: 100, HardLimit: 200},
+		},
+		SeccompProfile:     "/etc/ecs/seccomp.json",
+		SeccompProfileJSON: `{"defaultAction":"SCMP_ACT_ERRNO"}`,
+	}, defaults)
+}
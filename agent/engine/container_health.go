@@ -0,0 +1,113 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+)
+
+const (
+	// maxHealthCheckOutputLength bounds the amount of probe output retained on
+	// the container's health status
+	maxHealthCheckOutputLength = 1024
+
+	healthCheckLoopbackAddress = "127.0.0.1"
+)
+
+// checkContainerHealth runs a single evaluation of the container's health
+// check. It returns whether the container is healthy along with any output
+// that helps explain the result.
+func (engine *DockerTaskEngine) checkContainerHealth(task *api.Task, container *api.Container) (bool, string) {
+	healthCheck := container.HealthCheck
+	containerMap, ok := engine.state.ContainerMapByArn(task.Arn)
+	if !ok {
+		return false, "container belongs to unrecognized task " + task.Arn
+	}
+	dockerContainer, ok := containerMap[container.Name]
+	if !ok {
+		return false, "container not recorded as created"
+	}
+
+	switch healthCheck.Type {
+	case api.HealthCheckTypeCommand:
+		result := engine.client.ExecContainer(dockerContainer.DockerId, healthCheck.Command, healthCheck.TimeoutDuration())
+		if result.Error != nil {
+			return false, truncateHealthCheckOutput(result.Error.Error())
+		}
+		return result.ExitCode == 0, truncateHealthCheckOutput(result.Output)
+	case api.HealthCheckTypeHTTP, api.HealthCheckTypeTCP:
+		address, err := engine.healthCheckAddress(container, dockerContainer.DockerId, healthCheck.Port)
+		if err != nil {
+			return false, truncateHealthCheckOutput(err.Error())
+		}
+		if healthCheck.Type == api.HealthCheckTypeTCP {
+			conn, err := net.DialTimeout("tcp", address, healthCheck.TimeoutDuration())
+			if err != nil {
+				return false, truncateHealthCheckOutput(err.Error())
+			}
+			conn.Close()
+			return true, ""
+		}
+		// The probe targets the container directly, so it deliberately does not
+		// go through any proxy configured for the agent
+		client := &http.Client{Timeout: healthCheck.TimeoutDuration()}
+		resp, err := client.Get("http://" + address + healthCheck.Path)
+		if err != nil {
+			return false, truncateHealthCheckOutput(err.Error())
+		}
+		resp.Body.Close()
+		output := fmt.Sprintf("HTTP status %d", resp.StatusCode)
+		return resp.StatusCode >= 200 && resp.StatusCode < 400, output
+	}
+	return false, "unrecognized health check type " + string(healthCheck.Type)
+}
+
+// healthCheckAddress determines the host:port at which the given container
+// port is reachable from the agent. Published ports are preferred; otherwise
+// the container's own IP address is used.
+func (engine *DockerTaskEngine) healthCheckAddress(container *api.Container, dockerID string, port uint16) (string, error) {
+	for _, binding := range container.GetKnownPortBindings() {
+		if binding.ContainerPort != port || binding.Protocol != api.TransportProtocolTCP {
+			continue
+		}
+		host := binding.BindIp
+		if host == "" || host == "0.0.0.0" {
+			host = healthCheckLoopbackAddress
+		}
+		return net.JoinHostPort(host, strconv.Itoa(int(binding.HostPort))), nil
+	}
+
+	dockerContainer, err := engine.client.InspectContainer(dockerID, inspectContainerTimeout)
+	if err != nil {
+		return "", err
+	}
+	host := healthCheckLoopbackAddress
+	// Containers on the host network have no address of their own
+	if dockerContainer.NetworkSettings != nil && dockerContainer.NetworkSettings.IPAddress != "" {
+		host = dockerContainer.NetworkSettings.IPAddress
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port))), nil
+}
+
+func truncateHealthCheckOutput(output string) string {
+	if len(output) > maxHealthCheckOutputLength {
+		return output[:maxHealthCheckOutputLength]
+	}
+	return output
+}
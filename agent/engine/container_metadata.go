@@ -0,0 +1,208 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/cihub/seelog"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// containerMetadataFileName is the name of the metadata file in the
+	// directory bind-mounted in each container
+	containerMetadataFileName = "ecs-container-metadata.json"
+
+	// containerMetadataEnvironmentVariableName is the name of the
+	// environment variable holding the path of the metadata file in the
+	// container
+	containerMetadataEnvironmentVariableName = "ECS_CONTAINER_METADATA_FILE"
+
+	containerMetadataDirPermissions  = 0755
+	containerMetadataFilePermissions = 0644
+
+	// metadataFileNotReady is the status of a metadata file written before
+	// the container starts, which lacks the Docker ID, image ID and port
+	// mappings of the container
+	metadataFileNotReady = "NOT_READY"
+	// metadataFileReady is the status of a metadata file rewritten once the
+	// container is running
+	metadataFileReady = "READY"
+)
+
+// containerMetadata is the content of the metadata file of a container
+type containerMetadata struct {
+	Cluster              string
+	ContainerInstanceARN string `json:",omitempty"`
+	TaskARN              string
+	ContainerName        string
+	DockerContainerName  string                  `json:",omitempty"`
+	DockerID             string                  `json:"DockerId,omitempty"`
+	ImageName            string                  `json:",omitempty"`
+	ImageID              string                  `json:",omitempty"`
+	PortMappings         []containerMetadataPort `json:",omitempty"`
+	MetadataFileStatus   string
+}
+
+// containerMetadataPort is a container port bound to a port of the host
+type containerMetadataPort struct {
+	ContainerPort uint16
+	HostPort      uint16
+	BindIP        string `json:",omitempty"`
+	Protocol      string
+}
+
+// SetContainerInstanceArn sets the ARN of the container instance, which is
+// written in the metadata files of containers
+func (engine *DockerTaskEngine) SetContainerInstanceArn(containerInstanceArn string) {
+	engine.containerInstanceArn = containerInstanceArn
+}
+
+// containerMetadataTaskDir returns the directory holding the metadata files
+// of the containers of a task
+func (engine *DockerTaskEngine) containerMetadataTaskDir(task *api.Task) string {
+	return filepath.Join(engine.cfg.ContainerMetadataDataRoot, taskDirName(task))
+}
+
+// containerMetadataDir returns the directory holding the metadata file of a
+// container, which is bind-mounted in it
+func (engine *DockerTaskEngine) containerMetadataDir(task *api.Task, container *api.Container) string {
+	return filepath.Join(engine.containerMetadataTaskDir(task), dirName(container.Name))
+}
+
+// createContainerMetadataFile writes the metadata file of a container that is
+// about to be created under the given Docker name. It returns the bind mount
+// of the file's directory and the environment variable pointing to the file.
+func (engine *DockerTaskEngine) createContainerMetadataFile(task *api.Task, container *api.Container, dockerName string) (string, string, error) {
+	dir := engine.containerMetadataDir(task, container)
+	if err := os.MkdirAll(dir, containerMetadataDirPermissions); err != nil {
+		return "", "", err
+	}
+	metadata := engine.newContainerMetadata(task, container)
+	metadata.DockerContainerName = dockerName
+	metadata.MetadataFileStatus = metadataFileNotReady
+	if err := writeContainerMetadataFile(dir, metadata); err != nil {
+		return "", "", err
+	}
+	bind := dir + ":" + containerMetadataContainerDir + ":ro"
+	env := containerMetadataEnvironmentVariableName + "=" + filepath.Join(containerMetadataContainerDir, containerMetadataFileName)
+	return bind, env, nil
+}
+
+// containerMetadataDirInUse returns true if something is already mounted in
+// the container where the directory of its metadata file would be mounted
+func containerMetadataDirInUse(config *docker.Config, hostConfig *docker.HostConfig) bool {
+	if _, ok := config.Volumes[containerMetadataContainerDir]; ok {
+		return true
+	}
+	if _, ok := hostConfig.Tmpfs[containerMetadataContainerDir]; ok {
+		return true
+	}
+	for _, bind := range hostConfig.Binds {
+		if strings.HasSuffix(bind, ":"+containerMetadataContainerDir) || strings.Contains(bind, ":"+containerMetadataContainerDir+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// updateContainerMetadataFile rewrites the metadata file of a container that
+// is now running with its Docker ID, image ID and port mappings. Containers
+// created without a metadata file are left alone.
+func (engine *DockerTaskEngine) updateContainerMetadataFile(task *api.Task, container *api.Container) {
+	if !engine.cfg.ContainerMetadataEnabled {
+		return
+	}
+	dir := engine.containerMetadataDir(task, container)
+	if _, err := os.Stat(dir); err != nil {
+		return
+	}
+	metadata := engine.newContainerMetadata(task, container)
+	if containerMap, ok := engine.state.ContainerMapByArn(task.Arn); ok {
+		if dockerContainer, ok := containerMap[container.Name]; ok {
+			metadata.DockerContainerName = dockerContainer.DockerName
+			metadata.DockerID = dockerContainer.DockerId
+		}
+	}
+	metadata.ImageID = container.ImageID
+	for _, binding := range container.GetKnownPortBindings() {
+		metadata.PortMappings = append(metadata.PortMappings, containerMetadataPort{
+			ContainerPort: binding.ContainerPort,
+			HostPort:      binding.HostPort,
+			BindIP:        binding.BindIp,
+			Protocol:      binding.Protocol.String(),
+		})
+	}
+	metadata.MetadataFileStatus = metadataFileReady
+	if err := writeContainerMetadataFile(dir, metadata); err != nil {
+		seelog.Warnf("Task %s: unable to update the metadata file of container %s: %v", task.Arn, container.Name, err)
+	}
+}
+
+// newContainerMetadata returns the metadata of a container that is known
+// before it is created
+func (engine *DockerTaskEngine) newContainerMetadata(task *api.Task, container *api.Container) *containerMetadata {
+	return &containerMetadata{
+		Cluster:              engine.cfg.Cluster,
+		ContainerInstanceARN: engine.containerInstanceArn,
+		TaskARN:              task.Arn,
+		ContainerName:        container.Name,
+		ImageName:            container.Image,
+	}
+}
+
+// writeContainerMetadataFile replaces the metadata file in dir. The file is
+// renamed into place so that containers never read a partial file.
+func writeContainerMetadataFile(dir string, metadata *containerMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(dir, containerMetadataFileName)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), containerMetadataFilePermissions)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), filepath.Join(dir, containerMetadataFileName))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// removeContainerMetadataFiles deletes the metadata files of the containers
+// of a task whose containers have been removed
+func (engine *DockerTaskEngine) removeContainerMetadataFiles(task *api.Task) {
+	taskDir := engine.containerMetadataTaskDir(task)
+	if engine.cfg.ContainerMetadataDataRoot == "" || taskDir == filepath.Clean(engine.cfg.ContainerMetadataDataRoot) {
+		return
+	}
+	if err := os.RemoveAll(taskDir); err != nil {
+		seelog.Warnf("Task %s: unable to remove container metadata files in %s: %v", task.Arn, taskDir, err)
+	}
+}
@@ -0,0 +1,167 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readContainerMetadataFile(t *testing.T, dir string) containerMetadata {
+	data, err := ioutil.ReadFile(filepath.Join(dir, containerMetadataFileName))
+	require.NoError(t, err)
+	var metadata containerMetadata
+	require.NoError(t, json.Unmarshal(data, &metadata))
+	return metadata
+}
+
+func TestContainerMetadataFileLifecycle(t *testing.T) {
+	dataRoot, err := ioutil.TempDir("", "ecs-container-metadata")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataRoot)
+	cfg := defaultTestConfig()
+	cfg.Cluster = "cluster"
+	cfg.ContainerMetadataEnabled = true
+	cfg.ContainerMetadataDataRoot = dataRoot
+	ctrl, client, _, privateTaskEngine, _, _ := mocks(t, cfg)
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+	taskEngine.SetContainerInstanceArn("arn:aws:ecs:us-west-2:123456789012:container-instance/instance")
+
+	container := &api.Container{Name: "app", Image: "nginx:latest"}
+	task := &api.Task{
+		Arn:        "arn:aws:ecs:us-west-2:123456789012:task/f44b4fc9-adb0-4f4f-9dff-871512310588",
+		Family:     "web",
+		Version:    "1",
+		Containers: []*api.Container{container},
+	}
+	dir := filepath.Join(dataRoot, "f44b4fc9-adb0-4f4f-9dff-871512310588", "app")
+	mountedFile := filepath.Join(containerMetadataContainerDir, containerMetadataFileName)
+
+	client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(config *docker.Config, hostConfig *docker.HostConfig, name string, timeout interface{}) {
+			assert.Contains(t, config.Env, "ECS_CONTAINER_METADATA_FILE="+mountedFile)
+			assert.Contains(t, hostConfig.Binds, dir+":"+containerMetadataContainerDir+":ro")
+
+			metadata := readContainerMetadataFile(t, dir)
+			assert.Equal(t, containerMetadata{
+				Cluster:              "cluster",
+				ContainerInstanceARN: "arn:aws:ecs:us-west-2:123456789012:container-instance/instance",
+				TaskARN:              task.Arn,
+				ContainerName:        "app",
+				DockerContainerName:  name,
+				ImageName:            "nginx:latest",
+				MetadataFileStatus:   metadataFileNotReady,
+			}, metadata)
+		}).Return(DockerContainerMetadata{DockerID: "dockerID"})
+	metadata := taskEngine.createContainer(task, container)
+	require.Nil(t, metadata.Error)
+
+	container.ImageID = "sha256:abc"
+	container.KnownPortBindings = []api.PortBinding{{ContainerPort: 80, HostPort: 32768, BindIp: "0.0.0.0", Protocol: api.TransportProtocolTCP}}
+	taskEngine.updateContainerMetadataFile(task, container)
+	updated := readContainerMetadataFile(t, dir)
+	assert.Equal(t, metadataFileReady, updated.MetadataFileStatus)
+	assert.Equal(t, "dockerID", updated.DockerID)
+	assert.Equal(t, "sha256:abc", updated.ImageID)
+	assert.Equal(t, []containerMetadataPort{{ContainerPort: 80, HostPort: 32768, BindIP: "0.0.0.0", Protocol: "tcp"}}, updated.PortMappings)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "temporary file left behind")
+
+	taskEngine.removeContainerMetadataFiles(task)
+	_, err = os.Stat(filepath.Dir(dir))
+	assert.True(t, os.IsNotExist(err), "metadata files of the task not removed")
+}
+
+func TestContainerMetadataDirInUse(t *testing.T) {
+	dataRoot, err := ioutil.TempDir("", "ecs-container-metadata")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataRoot)
+	cfg := defaultTestConfig()
+	cfg.ContainerMetadataEnabled = true
+	cfg.ContainerMetadataDataRoot = dataRoot
+	ctrl, _, _, privateTaskEngine, _, _ := mocks(t, cfg)
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+
+	container := &api.Container{
+		Name:        "app",
+		MountPoints: []api.MountPoint{{SourceVolume: "metadata", ContainerPath: containerMetadataContainerDir}},
+	}
+	task := &api.Task{
+		Arn:        "arn:aws:ecs:us-west-2:123456789012:task/task",
+		Containers: []*api.Container{container},
+		Volumes:    []api.TaskVolume{{Name: "metadata", Volume: &api.FSHostVolume{FSSourcePath: "/srv/metadata"}}},
+	}
+	metadata := taskEngine.createContainer(task, container)
+	if assert.NotNil(t, metadata.Error) {
+		assert.Contains(t, metadata.Error.Error(), "already a mount point")
+	}
+	files, err := ioutil.ReadDir(dataRoot)
+	require.NoError(t, err)
+	assert.Empty(t, files, "no metadata file should be written")
+
+	testCases := []struct {
+		config     docker.Config
+		hostConfig docker.HostConfig
+		inUse      bool
+	}{
+		{docker.Config{}, docker.HostConfig{Binds: []string{"/srv:/srv"}}, false},
+		{docker.Config{}, docker.HostConfig{Binds: []string{"/srv:" + containerMetadataContainerDir + ":ro"}}, true},
+		{docker.Config{Volumes: map[string]struct{}{containerMetadataContainerDir: {}}}, docker.HostConfig{}, true},
+		{docker.Config{}, docker.HostConfig{Tmpfs: map[string]string{containerMetadataContainerDir: ""}}, true},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.inUse, containerMetadataDirInUse(&tc.config, &tc.hostConfig), "%+v %+v", tc.config, tc.hostConfig)
+	}
+}
+
+func TestContainerMetadataFileDisabled(t *testing.T) {
+	dataRoot, err := ioutil.TempDir("", "ecs-container-metadata")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataRoot)
+	cfg := defaultTestConfig()
+	cfg.ContainerMetadataDataRoot = dataRoot
+	ctrl, client, _, privateTaskEngine, _, _ := mocks(t, cfg)
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+
+	container := &api.Container{Name: "app"}
+	task := &api.Task{Arn: "arn:aws:ecs:us-west-2:123456789012:task/task", Containers: []*api.Container{container}}
+
+	client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(config *docker.Config, hostConfig *docker.HostConfig, name string, timeout interface{}) {
+			assert.Empty(t, config.Env)
+			assert.Empty(t, hostConfig.Binds)
+		})
+	metadata := taskEngine.createContainer(task, container)
+	require.Nil(t, metadata.Error)
+
+	taskEngine.updateContainerMetadataFile(task, container)
+	files, err := ioutil.ReadDir(dataRoot)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
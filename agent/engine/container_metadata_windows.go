@@ -1,5 +1,4 @@
 // +build windows
-
 // Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License"). You may
@@ -13,12 +12,8 @@
 // express or implied. See the License for the specific language governing
 // permissions and limitations under the License.
 
-// Package emptyvolume contains some information related to the 'emptyvolumes'
-package emptyvolume
+package engine
 
-const (
-	Image               = "microsoft/windowsservercore"
-	Tag                 = "latest"
-	ContainerPathPrefix = `C:\ecs-empty-volume\`
-	Command             = "not-applicable"
-)
+// containerMetadataContainerDir is the directory, in containers, where the
+// directory holding their metadata file is mounted
+const containerMetadataContainerDir = `C:\ProgramData\Amazon\ECS\metadata`
@@ -14,6 +14,7 @@
 package dependencygraph
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
@@ -25,12 +26,44 @@ var log = logger.ForModule("dependencygraph")
 // Because a container may depend on another container being created
 // (volumes-from) or running (links) it makes sense to abstract it out
 // to each container having dependencies on another container being in any
-// perticular state set. For now, these are resolved here and support only
-// volume/link (created/run)
+// perticular state set. For now, these are resolved here and support
+// volume/link (created/run) as well as the explicit conditions of a
+// container's DependsOn list
+
+// DependencyError describes why the dependencies of a container can not be
+// satisfied
+type DependencyError struct {
+	ContainerName string
+	msg           string
+}
+
+func (err *DependencyError) Error() string { return err.msg }
+
+// ErrorName returns the name of the error
+func (err *DependencyError) ErrorName() string { return "ContainerDependencyError" }
 
 // ValidDependencies takes a task and verifies that it is possible to allow all
 // containers within it to reach the desired status by proceeding in some order
 func ValidDependencies(task *api.Task) bool {
+	return ValidateDependencies(task) == nil
+}
+
+// ValidateDependencies behaves like ValidDependencies, but returns an error
+// explaining which container's dependencies can not be satisfied
+func ValidateDependencies(task *api.Task) error {
+	nameMap := make(map[string]*api.Container)
+	for _, cont := range task.Containers {
+		nameMap[cont.Name] = cont
+	}
+	for _, cont := range task.Containers {
+		if err := validateDependsOn(cont, nameMap); err != nil {
+			return err
+		}
+	}
+	if err := detectCycle(task.Containers, nameMap); err != nil {
+		return err
+	}
+
 	unresolved := make([]*api.Container, len(task.Containers))
 	resolved := make([]*api.Container, 0, len(task.Containers))
 
@@ -48,10 +81,130 @@ OuterLoop:
 			}
 		}
 		log.Warn("Could not resolve some containers", "task", task, "unresolved", unresolved)
-		return false
+		return &DependencyError{
+			ContainerName: unresolved[0].Name,
+			msg:           "dependencies of container " + unresolved[0].Name + " can not be resolved",
+		}
 	}
 
-	return true
+	return nil
+}
+
+// HasDependencies returns true if any container of the task links to, takes
+// volumes from or otherwise depends on another container
+func HasDependencies(task *api.Task) bool {
+	for _, cont := range task.Containers {
+		if len(containerDependencyNames(cont)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesDependsOn returns true if any container of the task has DependsOn
+// entries
+func UsesDependsOn(task *api.Task) bool {
+	for _, cont := range task.Containers {
+		if len(cont.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDependsOn verifies that every DependsOn entry of `target` refers to a
+// container of the task and uses a condition that can be met by it
+func validateDependsOn(target *api.Container, containers map[string]*api.Container) error {
+	for _, dependency := range target.DependsOn {
+		dependsOn, exists := containers[dependency.ContainerName]
+		if !exists || dependency.ContainerName == target.Name {
+			return &DependencyError{target.Name, "container " + target.Name + " depends on unknown container " + dependency.ContainerName}
+		}
+		switch dependency.Condition {
+		case api.DependencyConditionStart:
+		case api.DependencyConditionComplete, api.DependencyConditionSuccess:
+			if dependsOn.Essential {
+				return &DependencyError{target.Name, "container " + target.Name + " can never start: it waits for essential container " +
+					dependsOn.Name + " to exit, which stops the task"}
+			}
+		case api.DependencyConditionHealthy:
+			if dependsOn.HealthCheck == nil {
+				return &DependencyError{target.Name, "container " + target.Name + " waits for container " + dependsOn.Name +
+					" to be healthy, but it has no health check"}
+			}
+		default:
+			return &DependencyError{target.Name, "container " + target.Name + " uses unrecognized dependency condition '" +
+				string(dependency.Condition) + "'"}
+		}
+	}
+	return nil
+}
+
+// containerDependencyNames returns the names of all containers `target` depends
+// on, whatever the kind of dependency
+func containerDependencyNames(target *api.Container) []string {
+	names := linksToContainerNames(target.Links)
+	for _, volume := range target.VolumesFrom {
+		names = append(names, volume.SourceContainer)
+	}
+	names = append(names, target.NamespaceContainerNames()...)
+	names = append(names, target.RunDependencies...)
+	for _, dependency := range target.DependsOn {
+		names = append(names, dependency.ContainerName)
+	}
+	return names
+}
+
+// detectCycle returns an error naming the containers involved if the
+// dependencies between containers form a cycle
+func detectCycle(containers []*api.Container, nameMap map[string]*api.Container) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(cont *api.Container) error
+	visit = func(cont *api.Container) error {
+		state[cont.Name] = visiting
+		path = append(path, cont.Name)
+		for _, name := range containerDependencyNames(cont) {
+			dependency, exists := nameMap[name]
+			if !exists {
+				continue
+			}
+			switch state[name] {
+			case visiting:
+				cycle := path
+				for i, pathName := range path {
+					if pathName == name {
+						cycle = path[i:]
+						break
+					}
+				}
+				return &DependencyError{cont.Name, "container dependencies form a cycle: " +
+					strings.Join(append(cycle, name), " -> ")}
+			case unvisited:
+				if err := visit(dependency); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[cont.Name] = visited
+		return nil
+	}
+
+	for _, cont := range containers {
+		if state[cont.Name] == unvisited {
+			if err := visit(cont); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func linksToContainerNames(links []string) []string {
@@ -82,7 +235,9 @@ func dependenciesCanBeResolved(target *api.Container, by []*api.Container) bool
 	}
 
 	return verifyStatusResolveable(target, nameMap, neededVolumeContainers, volumeCanResolve) &&
-		verifyStatusResolveable(target, nameMap, linksToContainerNames(target.Links), linkCanResolve)
+		verifyStatusResolveable(target, nameMap, linksToContainerNames(target.Links), linkCanResolve) &&
+		verifyStatusResolveable(target, nameMap, target.NamespaceContainerNames(), linkCanResolve) &&
+		verifyStatusResolveable(target, nameMap, dependsOnNames(target), dependsOnCanResolve)
 }
 
 // DependenciesAreResolved validates that the `target` container can be started
@@ -100,7 +255,150 @@ func DependenciesAreResolved(target *api.Container, by []*api.Container) bool {
 
 	return verifyStatusResolveable(target, nameMap, neededVolumeContainers, volumeIsResolved) &&
 		verifyStatusResolveable(target, nameMap, linksToContainerNames(target.Links), linkIsResolved) &&
-		verifyStatusResolveable(target, nameMap, target.RunDependencies, onRunIsResolved)
+		verifyStatusResolveable(target, nameMap, target.NamespaceContainerNames(), linkIsResolved) &&
+		verifyStatusResolveable(target, nameMap, target.RunDependencies, onRunIsResolved) &&
+		DependsOnAreResolved(target, by)
+}
+
+// DependsOnAreResolved validates that the conditions in the `target`
+// container's DependsOn list are met by the current known state of the
+// containers in `by`
+func DependsOnAreResolved(target *api.Container, by []*api.Container) bool {
+	targetGoal := target.GetDesiredStatus()
+	if targetGoal != api.ContainerRunning && targetGoal != api.ContainerCreated {
+		return true
+	}
+	nameMap := make(map[string]*api.Container)
+	for _, cont := range by {
+		nameMap[cont.Name] = cont
+	}
+	for _, dependency := range target.DependsOn {
+		dependsOn, exists := nameMap[dependency.ContainerName]
+		if !exists || !conditionIsMet(dependency.Condition, dependsOn) {
+			return false
+		}
+	}
+	return true
+}
+
+// DependencyFailure returns an error if a condition in the `target`
+// container's DependsOn list can no longer be met given the current known
+// state of the containers in `by`, for example because a container that was
+// required to succeed exited with a non-zero exit code
+func DependencyFailure(target *api.Container, by []*api.Container) error {
+	nameMap := make(map[string]*api.Container)
+	for _, cont := range by {
+		nameMap[cont.Name] = cont
+	}
+	for _, dependency := range target.DependsOn {
+		dependsOn, exists := nameMap[dependency.ContainerName]
+		if !exists {
+			return &DependencyError{target.Name, "container " + target.Name + " depends on unknown container " + dependency.ContainerName}
+		}
+		if conditionIsMet(dependency.Condition, dependsOn) {
+			continue
+		}
+		if reason := conditionFailure(dependency.Condition, dependsOn); reason != "" {
+			return &DependencyError{target.Name, "container " + target.Name + " can not start: " + reason}
+		}
+	}
+	return nil
+}
+
+// DependentsAreStopped returns true once no container in `by` that links to
+// `target`, takes volumes from it or depends on it is still running on its way
+// to being stopped. Containers that are stopped should follow this order so
+// that the ones others rely on outlive them.
+func DependentsAreStopped(target *api.Container, by []*api.Container) bool {
+	for _, cont := range by {
+		if cont == target || !cont.DesiredTerminal() || cont.GetKnownStatus() != api.ContainerRunning {
+			continue
+		}
+		for _, name := range containerDependencyNames(cont) {
+			if name == target.Name {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func dependsOnNames(target *api.Container) []string {
+	names := make([]string, len(target.DependsOn))
+	for i, dependency := range target.DependsOn {
+		names[i] = dependency.ContainerName
+	}
+	return names
+}
+
+// hasExited returns true if the container ran and has since exited
+func hasExited(cont *api.Container) bool {
+	return cont.GetKnownStatus() == api.ContainerStopped && cont.KnownExitCode != nil
+}
+
+func conditionIsMet(condition api.DependencyCondition, dependsOn *api.Container) bool {
+	switch condition {
+	case api.DependencyConditionStart:
+		return dependsOn.GetKnownStatus() == api.ContainerRunning || hasExited(dependsOn)
+	case api.DependencyConditionComplete:
+		return hasExited(dependsOn)
+	case api.DependencyConditionSuccess:
+		return hasExited(dependsOn) && *dependsOn.KnownExitCode == 0
+	case api.DependencyConditionHealthy:
+		return dependsOn.GetKnownStatus() == api.ContainerRunning &&
+			dependsOn.GetHealthStatus().Status == api.ContainerHealthy
+	}
+	return false
+}
+
+// conditionFailure returns a description of why the condition, which is not
+// currently met, never will be, or an empty string if it still may be
+func conditionFailure(condition api.DependencyCondition, dependsOn *api.Container) string {
+	stopped := dependsOn.GetKnownStatus() == api.ContainerStopped
+	if stopped && dependsOn.KnownExitCode == nil {
+		return "container " + dependsOn.Name + " stopped without running"
+	}
+	switch condition {
+	case api.DependencyConditionStart:
+		if dependsOn.DesiredTerminal() && dependsOn.GetKnownStatus() < api.ContainerRunning {
+			return "container " + dependsOn.Name + " will not be started"
+		}
+	case api.DependencyConditionSuccess:
+		if hasExited(dependsOn) {
+			return "container " + dependsOn.Name + " exited with code " + strconv.Itoa(*dependsOn.KnownExitCode)
+		}
+	case api.DependencyConditionHealthy:
+		if dependsOn.HealthCheck == nil {
+			return "container " + dependsOn.Name + " has no health check"
+		}
+		if stopped {
+			return "container " + dependsOn.Name + " stopped before becoming healthy"
+		}
+	}
+	return ""
+}
+
+// dependsOnCanResolve reports whether a DependsOn condition on `dependsOn` can
+// be met once it has reached its desired status. Conditions on a container
+// exiting are met whenever it eventually stops.
+func dependsOnCanResolve(target *api.Container, dependsOn *api.Container) bool {
+	dependsOnDesiredStatus := dependsOn.GetDesiredStatus()
+	for _, dependency := range target.DependsOn {
+		if dependency.ContainerName != dependsOn.Name {
+			continue
+		}
+		switch dependency.Condition {
+		case api.DependencyConditionStart, api.DependencyConditionHealthy:
+			if dependsOnDesiredStatus != api.ContainerRunning {
+				return false
+			}
+		case api.DependencyConditionComplete, api.DependencyConditionSuccess:
+			if dependsOnDesiredStatus < api.ContainerRunning {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 // verifyStatusResolveable validates that `target` can be resolved given that
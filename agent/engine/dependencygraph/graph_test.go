@@ -202,3 +202,199 @@ func TestRunningependsOnDependencies(t *testing.T) {
 		t.Error("Dependencies should be resolved")
 	}
 }
+
+func TestIpcNamespaceDependencies(t *testing.T) {
+	c1 := runningContainer("a", nil, nil)
+	c2 := runningContainer("b", nil, nil)
+	c2.LinuxParameters = &api.LinuxParameters{IpcMode: "container:a"}
+	task := &api.Task{Containers: []*api.Container{c1, c2}}
+
+	if !ValidDependencies(task) {
+		t.Error("Task should be resolvable")
+	}
+	if DependenciesAreResolved(c2, task.Containers) {
+		t.Error("Dependencies should not be resolved before the IPC namespace container runs")
+	}
+	c1.KnownStatus = api.ContainerRunning
+	if !DependenciesAreResolved(c2, task.Containers) {
+		t.Error("Dependencies should be resolved")
+	}
+
+	c1.LinuxParameters = &api.LinuxParameters{IpcMode: "container:b"}
+	if ValidDependencies(task) {
+		t.Error("Containers sharing each other's IPC namespace should not be resolvable")
+	}
+}
+
+func TestValidateDependsOn(t *testing.T) {
+	migrate := runningContainer("migrate", nil, nil)
+	app := runningContainer("app", nil, nil)
+	app.DependsOn = []api.ContainerDependency{{ContainerName: "migrate", Condition: api.DependencyConditionSuccess}}
+	task := &api.Task{Containers: []*api.Container{migrate, app}}
+	if err := ValidateDependencies(task); err != nil {
+		t.Errorf("Expected dependencies to be valid, got %v", err)
+	}
+
+	migrate.Essential = true
+	if err := ValidateDependencies(task); err == nil {
+		t.Error("Expected waiting for an essential container to exit to be invalid")
+	}
+	migrate.Essential = false
+
+	app.DependsOn = []api.ContainerDependency{{ContainerName: "migrate", Condition: api.DependencyConditionHealthy}}
+	if err := ValidateDependencies(task); err == nil {
+		t.Error("Expected HEALTHY condition on a container without health check to be invalid")
+	}
+
+	app.DependsOn = []api.ContainerDependency{{ContainerName: "missing", Condition: api.DependencyConditionStart}}
+	err := ValidateDependencies(task)
+	if err == nil {
+		t.Fatal("Expected dependency on unknown container to be invalid")
+	}
+	if err.(*DependencyError).ContainerName != "app" {
+		t.Errorf("Expected error to concern container app, got %v", err)
+	}
+}
+
+func TestUsesDependsOn(t *testing.T) {
+	// A task from before DependsOn, whose links can not be resolved, is not
+	// validated when it starts
+	a := runningContainer("a", []string{"b:b"}, nil)
+	b := runningContainer("b", []string{"a:a"}, nil)
+	task := &api.Task{Containers: []*api.Container{a, b}}
+	if UsesDependsOn(task) {
+		t.Error("Expected a task without DependsOn entries not to use DependsOn")
+	}
+
+	b.Links = nil
+	b.DependsOn = []api.ContainerDependency{{ContainerName: "a", Condition: api.DependencyConditionStart}}
+	if !UsesDependsOn(task) {
+		t.Error("Expected a task with DependsOn entries to use DependsOn")
+	}
+}
+
+func TestHasDependencies(t *testing.T) {
+	a := runningContainer("a", nil, nil)
+	b := runningContainer("b", nil, nil)
+	task := &api.Task{Containers: []*api.Container{a, b}}
+	if HasDependencies(task) {
+		t.Error("Expected a task of independent containers to have no dependencies")
+	}
+
+	b.VolumesFrom = volumeStrToVol([]string{"a"})
+	if !HasDependencies(task) {
+		t.Error("Expected a task with volumes from another container to have dependencies")
+	}
+}
+
+func TestValidateDependenciesCycle(t *testing.T) {
+	a := runningContainer("a", []string{"b:b"}, nil)
+	b := runningContainer("b", nil, nil)
+	b.DependsOn = []api.ContainerDependency{{ContainerName: "a", Condition: api.DependencyConditionStart}}
+	task := &api.Task{Containers: []*api.Container{a, b}}
+
+	err := ValidateDependencies(task)
+	if err == nil {
+		t.Fatal("Expected cycle to be detected")
+	}
+	if err.Error() != "container dependencies form a cycle: a -> b -> a" {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+	if ValidDependencies(task) {
+		t.Error("Expected cycle to be invalid")
+	}
+}
+
+func TestDependsOnAreResolved(t *testing.T) {
+	exitCode := func(code int) *int { return &code }
+	dependency := runningContainer("dependency", nil, nil)
+	dependency.HealthCheck = &api.HealthCheck{Type: api.HealthCheckTypeTCP, Port: 80}
+	target := runningContainer("target", nil, nil)
+	containers := []*api.Container{dependency, target}
+
+	for _, condition := range []api.DependencyCondition{
+		api.DependencyConditionStart,
+		api.DependencyConditionComplete,
+		api.DependencyConditionSuccess,
+		api.DependencyConditionHealthy,
+	} {
+		target.DependsOn = []api.ContainerDependency{{ContainerName: "dependency", Condition: condition}}
+		if DependsOnAreResolved(target, containers) {
+			t.Errorf("%s: should not be resolved before the dependency runs", condition)
+		}
+		if err := DependencyFailure(target, containers); err != nil {
+			t.Errorf("%s: should still be resolvable, got %v", condition, err)
+		}
+	}
+
+	dependency.SetKnownStatus(api.ContainerRunning)
+	target.DependsOn = []api.ContainerDependency{{ContainerName: "dependency", Condition: api.DependencyConditionStart}}
+	if !DependenciesAreResolved(target, containers) {
+		t.Error("START should be resolved once the dependency is running")
+	}
+	target.DependsOn = []api.ContainerDependency{{ContainerName: "dependency", Condition: api.DependencyConditionHealthy}}
+	if DependenciesAreResolved(target, containers) {
+		t.Error("HEALTHY should not be resolved before the dependency is healthy")
+	}
+	dependency.SetHealthStatus(api.HealthStatus{Status: api.ContainerHealthy})
+	if !DependenciesAreResolved(target, containers) {
+		t.Error("HEALTHY should be resolved once the dependency is healthy")
+	}
+
+	dependency.SetKnownStatus(api.ContainerStopped)
+	dependency.KnownExitCode = exitCode(1)
+	target.DependsOn = []api.ContainerDependency{{ContainerName: "dependency", Condition: api.DependencyConditionComplete}}
+	if !DependenciesAreResolved(target, containers) {
+		t.Error("COMPLETE should be resolved once the dependency exited")
+	}
+	target.DependsOn = []api.ContainerDependency{{ContainerName: "dependency", Condition: api.DependencyConditionSuccess}}
+	if DependenciesAreResolved(target, containers) {
+		t.Error("SUCCESS should not be resolved when the dependency failed")
+	}
+	err := DependencyFailure(target, containers)
+	if err == nil || err.Error() != "container target can not start: container dependency exited with code 1" {
+		t.Errorf("Expected failure reason for non-zero exit, got %v", err)
+	}
+
+	dependency.KnownExitCode = exitCode(0)
+	if !DependenciesAreResolved(target, containers) {
+		t.Error("SUCCESS should be resolved once the dependency exited with 0")
+	}
+}
+
+func TestDependentsAreStopped(t *testing.T) {
+	db := runningContainer("db", nil, nil)
+	data := runningContainer("data", nil, nil)
+	app := runningContainer("app", []string{"db:database"}, []string{"data"})
+	proxy := runningContainer("proxy", nil, nil)
+	proxy.DependsOn = []api.ContainerDependency{{ContainerName: "app", Condition: api.DependencyConditionStart}}
+	containers := []*api.Container{db, data, app, proxy}
+	for _, cont := range containers {
+		cont.SetKnownStatus(api.ContainerRunning)
+		cont.SetDesiredStatus(api.ContainerStopped)
+	}
+
+	if DependentsAreStopped(db, containers) {
+		t.Error("db should wait for the app linking to it to stop")
+	}
+	if DependentsAreStopped(data, containers) {
+		t.Error("data should wait for the app taking volumes from it to stop")
+	}
+	if DependentsAreStopped(app, containers) {
+		t.Error("app should wait for the proxy depending on it to stop")
+	}
+	if !DependentsAreStopped(proxy, containers) {
+		t.Error("proxy has no dependents and should be stopped straight away")
+	}
+
+	proxy.SetKnownStatus(api.ContainerStopped)
+	if !DependentsAreStopped(app, containers) {
+		t.Error("app should be stopped once the proxy has stopped")
+	}
+
+	// A dependent that keeps running does not hold back its dependencies
+	app.SetDesiredStatus(api.ContainerRunning)
+	if !DependentsAreStopped(db, containers) {
+		t.Error("db should not wait for an app that is not being stopped")
+	}
+}
@@ -0,0 +1,34 @@
+// +build !windows
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import "syscall"
+
+// diskUsagePercent returns how full, as a percentage, the filesystem holding
+// the path is
+func diskUsagePercent(path string) (float64, error) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+	if stat.Blocks == 0 {
+		return 0, nil
+	}
+	// Blocks reserved for root are counted as used, as they are not available
+	// to Docker either
+	used := stat.Blocks - stat.Bavail
+	return float64(used) * 100 / float64(stat.Blocks), nil
+}
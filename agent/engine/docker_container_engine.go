@@ -14,8 +14,8 @@
 package engine
 
 import (
-	"archive/tar"
 	"bufio"
+	"bytes"
 	"io"
 	"strings"
 	"sync"
@@ -29,7 +29,6 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerauth"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockeriface"
-	"github.com/aws/amazon-ecs-agent/agent/engine/emptyvolume"
 	"github.com/aws/amazon-ecs-agent/agent/utils/ttime"
 	"github.com/cihub/seelog"
 
@@ -42,8 +41,10 @@ const (
 
 // Timelimits for docker operations enforced above docker
 const (
-	// ListContainersTimeout is the timeout for the ListContainers API.
+	// ListContainersTimeout and ListImagesTimeout are the timeouts for the
+	// ListContainers and ListImages APIs.
 	ListContainersTimeout   = 10 * time.Minute
+	ListImagesTimeout       = 10 * time.Minute
 	pullImageTimeout        = 2 * time.Hour
 	createContainerTimeout  = 3 * time.Minute
 	startContainerTimeout   = 1*time.Minute + 30*time.Second
@@ -51,6 +52,11 @@ const (
 	removeContainerTimeout  = 5 * time.Minute
 	inspectContainerTimeout = 30 * time.Second
 	removeImageTimeout      = 3 * time.Minute
+	// Volume drivers may provision or release storage when a volume is
+	// created or removed
+	createVolumeTimeout  = 5 * time.Minute
+	inspectVolumeTimeout = 30 * time.Second
+	removeVolumeTimeout  = 5 * time.Minute
 
 	// dockerPullBeginTimeout is the timeout from when a 'pull' is called to when
 	// we expect to see output on the pull progress stream. This is to work
@@ -64,6 +70,10 @@ const (
 	// statsInactivityTimeout controls the amount of time we hold open a
 	// connection to the Docker daemon waiting for stats data
 	statsInactivityTimeout = 5 * time.Second
+
+	// stopSignalPollInterval controls how often a container that was sent its
+	// stop signal is inspected to find out whether it has exited
+	stopSignalPollInterval = time.Second
 )
 
 // DockerClient interface to make testing it easier
@@ -79,17 +89,27 @@ type DockerClient interface {
 
 	CreateContainer(*docker.Config, *docker.HostConfig, string, time.Duration) DockerContainerMetadata
 	StartContainer(string, time.Duration) DockerContainerMetadata
-	StopContainer(string, time.Duration) DockerContainerMetadata
+	StopContainer(string, time.Duration, string, time.Duration) DockerContainerMetadata
 	DescribeContainer(string) (api.ContainerStatus, DockerContainerMetadata)
 	RemoveContainer(string, time.Duration) error
 
 	InspectContainer(string, time.Duration) (*docker.Container, error)
+	ExecContainer(string, []string, time.Duration) DockerExecResult
 	ListContainers(bool, time.Duration) ListContainersResponse
+	ListImages(time.Duration) ListImagesResponse
 	Stats(string, context.Context) (<-chan *docker.Stats, error)
 
 	Version() (string, error)
 	InspectImage(string) (*docker.Image, error)
 	RemoveImage(string, time.Duration) error
+
+	CreateVolume(string, string, map[string]string, map[string]string, time.Duration) VolumeResponse
+	InspectVolume(string, time.Duration) VolumeResponse
+	RemoveVolume(string, time.Duration) error
+
+	// OperationStats returns the concurrency limit, queue wait and call
+	// duration statistics of each type of Docker operation
+	OperationStats() []DockerOperationStats
 }
 
 // DockerGoClient wraps the underlying go-dockerclient library.
@@ -113,6 +133,7 @@ type dockerGoClient struct {
 	auth             dockerauth.DockerAuthProvider
 	ecrClientFactory ecr.ECRFactory
 	config           *config.Config
+	operationLimiter *dockerOperationLimiter
 
 	_time     ttime.Time
 	_timeOnce sync.Once
@@ -120,16 +141,14 @@ type dockerGoClient struct {
 
 func (dg *dockerGoClient) WithVersion(version dockerclient.DockerVersion) DockerClient {
 	return &dockerGoClient{
-		clientFactory: dg.clientFactory,
-		version:       version,
-		auth:          dg.auth,
-		config:        dg.config,
+		clientFactory:    dg.clientFactory,
+		version:          version,
+		auth:             dg.auth,
+		config:           dg.config,
+		operationLimiter: dg.operationLimiter,
 	}
 }
 
-// scratchCreateLock guards against multiple 'scratch' image creations at once
-var scratchCreateLock sync.Mutex
-
 // NewDockerGoClient creates a new DockerGoClient
 func NewDockerGoClient(clientFactory dockerclient.Factory, acceptInsecureCert bool, cfg *config.Config) (DockerClient, error) {
 	client, err := clientFactory.GetDefaultClient()
@@ -151,6 +170,7 @@ func NewDockerGoClient(clientFactory dockerclient.Factory, acceptInsecureCert bo
 		auth:             dockerauth.NewDockerAuthProvider(cfg.EngineAuthType, cfg.EngineAuthData.Contents()),
 		ecrClientFactory: ecr.NewECRFactory(acceptInsecureCert),
 		config:           cfg,
+		operationLimiter: newDockerOperationLimiter(cfg.DockerOperationLimits),
 	}, nil
 }
 
@@ -171,6 +191,8 @@ func (dg *dockerGoClient) time() ttime.Time {
 }
 
 func (dg *dockerGoClient) PullImage(image string, authData *api.RegistryAuthenticationData) DockerContainerMetadata {
+	done := dg.operationLimiter.begin(dockerclient.PullOperation)
+	defer done()
 	timeout := dg.time().After(pullImageTimeout)
 
 	response := make(chan DockerContainerMetadata, 1)
@@ -190,16 +212,6 @@ func (dg *dockerGoClient) pullImage(image string, authData *api.RegistryAuthenti
 		return DockerContainerMetadata{Error: CannotGetDockerClientError{version: dg.version, err: err}}
 	}
 
-	// Special case; this image is not one that should be pulled, but rather
-	// should be created locally if necessary
-	if image == emptyvolume.Image+":"+emptyvolume.Tag {
-		scratchErr := dg.createScratchImageIfNotExists()
-		if scratchErr != nil {
-			return DockerContainerMetadata{Error: &api.DefaultNamedError{Name: "CreateEmptyVolumeError", Err: "Could not create empty volume " + scratchErr.Error()}}
-		}
-		return DockerContainerMetadata{}
-	}
-
 	authConfig, err := dg.getAuthdata(image, authData)
 	if err != nil {
 		return DockerContainerMetadata{Error: CannotXContainerError{"Pull", err.Error()}}
@@ -283,40 +295,9 @@ func (dg *dockerGoClient) pullImage(image string, authData *api.RegistryAuthenti
 	return DockerContainerMetadata{}
 }
 
-func (dg *dockerGoClient) createScratchImageIfNotExists() error {
-	client, err := dg.dockerClient()
-	if err != nil {
-		return err
-	}
-
-	scratchCreateLock.Lock()
-	defer scratchCreateLock.Unlock()
-
-	_, err = client.InspectImage(emptyvolume.Image + ":" + emptyvolume.Tag)
-	if err == nil {
-		// Already exists; assume that it's okay to use it
-		return nil
-	}
-
-	reader, writer := io.Pipe()
-
-	emptytarball := tar.NewWriter(writer)
-	go func() {
-		emptytarball.Close()
-		writer.Close()
-	}()
-
-	// Create it from an empty tarball
-	err = client.ImportImage(docker.ImportImageOptions{
-		Repository:  emptyvolume.Image,
-		Tag:         emptyvolume.Tag,
-		Source:      "-",
-		InputStream: reader,
-	})
-	return err
-}
-
 func (dg *dockerGoClient) InspectImage(image string) (*docker.Image, error) {
+	done := dg.operationLimiter.begin(dockerclient.InspectOperation)
+	defer done()
 	client, err := dg.dockerClient()
 	if err != nil {
 		return nil, err
@@ -337,6 +318,8 @@ func (dg *dockerGoClient) getAuthdata(image string, authData *api.RegistryAuthen
 }
 
 func (dg *dockerGoClient) CreateContainer(config *docker.Config, hostConfig *docker.HostConfig, name string, timeout time.Duration) DockerContainerMetadata {
+	done := dg.operationLimiter.begin(dockerclient.CreateOperation)
+	defer done()
 	// Create a context that times out after the 'timeout' duration
 	// This is defined by the const 'createContainerTimeout'. Injecting the 'timeout'
 	// makes it easier to write tests.
@@ -387,6 +370,8 @@ func (dg *dockerGoClient) createContainer(ctx context.Context, config *docker.Co
 }
 
 func (dg *dockerGoClient) StartContainer(id string, timeout time.Duration) DockerContainerMetadata {
+	done := dg.operationLimiter.begin(dockerclient.StartOperation)
+	defer done()
 	// Create a context that times out after the 'timeout' duration
 	// This is defined by the const 'startContainerTimeout'. Injecting the 'timeout'
 	// makes it easier to write tests.
@@ -444,6 +429,16 @@ func (dg *dockerGoClient) DescribeContainer(dockerID string) (api.ContainerStatu
 }
 
 func (dg *dockerGoClient) InspectContainer(dockerID string, timeout time.Duration) (*docker.Container, error) {
+	done := dg.operationLimiter.begin(dockerclient.InspectOperation)
+	defer done()
+	return dg.inspectContainerWithTimeout(dockerID, timeout)
+}
+
+// inspectContainerWithTimeout inspects the container without waiting for an
+// inspect slot. It is used to read back the state of a container after a
+// create, start or stop, and when handling Docker events, so that those are
+// not held up behind other inspects.
+func (dg *dockerGoClient) inspectContainerWithTimeout(dockerID string, timeout time.Duration) (*docker.Container, error) {
 	type inspectResponse struct {
 		container *docker.Container
 		err       error
@@ -486,12 +481,73 @@ func (dg *dockerGoClient) inspectContainer(dockerID string, ctx context.Context)
 	return client.InspectContainerWithContext(dockerID, ctx)
 }
 
-func (dg *dockerGoClient) StopContainer(dockerID string, timeout time.Duration) DockerContainerMetadata {
-	timeout = timeout + dg.config.DockerStopTimeout
+// ExecContainer runs the given command inside a running container and waits
+// for it to exit, returning its exit code and combined output.
+func (dg *dockerGoClient) ExecContainer(dockerID string, cmd []string, timeout time.Duration) DockerExecResult {
+	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
+
+	// Buffered channel so in the case of timeout it takes one write, never gets
+	// read, and can still be GC'd
+	response := make(chan DockerExecResult, 1)
+	go func() { response <- dg.execContainer(ctx, dockerID, cmd) }()
+	select {
+	case resp := <-response:
+		return resp
+	case <-ctx.Done():
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			return DockerExecResult{Error: &DockerTimeoutError{timeout, "exec"}}
+		}
+		return DockerExecResult{Error: &CannotXContainerError{"Exec", err.Error()}}
+	}
+}
+
+func (dg *dockerGoClient) execContainer(ctx context.Context, dockerID string, cmd []string) DockerExecResult {
+	client, err := dg.dockerClient()
+	if err != nil {
+		return DockerExecResult{Error: CannotGetDockerClientError{version: dg.version, err: err}}
+	}
+
+	exec, err := client.CreateExec(docker.CreateExecOptions{
+		Container:    dockerID,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+		Context:      ctx,
+	})
+	if err != nil {
+		return DockerExecResult{Error: CannotXContainerError{"Exec", err.Error()}}
+	}
+
+	var output bytes.Buffer
+	err = client.StartExec(exec.ID, docker.StartExecOptions{
+		OutputStream: &output,
+		ErrorStream:  &output,
+		Context:      ctx,
+	})
+	if err != nil {
+		return DockerExecResult{Error: CannotXContainerError{"Exec", err.Error()}}
+	}
+
+	inspect, err := client.InspectExec(exec.ID)
+	if err != nil {
+		return DockerExecResult{Error: CannotXContainerError{"Exec", err.Error()}}
+	}
+	return DockerExecResult{ExitCode: inspect.ExitCode, Output: output.String()}
+}
+
+// StopContainer stops a container, allowing it 'stopTimeout' to exit before it
+// is killed. If 'stopSignal' is set, it is sent to the container in place of
+// Docker's default stop signal.
+func (dg *dockerGoClient) StopContainer(dockerID string, stopTimeout time.Duration, stopSignal string, timeout time.Duration) DockerContainerMetadata {
+	done := dg.operationLimiter.begin(dockerclient.StopOperation)
+	defer done()
+	timeout = timeout + stopTimeout
 
 	// Create a context that times out after the 'timeout' duration
 	// This is defined by the const 'stopContainerTimeout' and the
-	// 'DockerStopTimeout' in the config. Injecting the 'timeout'
+	// container's stop timeout. Injecting the 'timeout'
 	// makes it easier to write tests.
 	// Eventually, the context should be initialized from a parent root context
 	// instead of TODO.
@@ -501,7 +557,7 @@ func (dg *dockerGoClient) StopContainer(dockerID string, timeout time.Duration)
 	// Buffered channel so in the case of timeout it takes one write, never gets
 	// read, and can still be GC'd
 	response := make(chan DockerContainerMetadata, 1)
-	go func() { response <- dg.stopContainer(ctx, dockerID) }()
+	go func() { response <- dg.stopContainer(ctx, dockerID, stopTimeout, stopSignal) }()
 	select {
 	case resp := <-response:
 		return resp
@@ -516,13 +572,17 @@ func (dg *dockerGoClient) StopContainer(dockerID string, timeout time.Duration)
 	}
 }
 
-func (dg *dockerGoClient) stopContainer(ctx context.Context, dockerID string) DockerContainerMetadata {
+func (dg *dockerGoClient) stopContainer(ctx context.Context, dockerID string, stopTimeout time.Duration, stopSignal string) DockerContainerMetadata {
 	client, err := dg.dockerClient()
 	if err != nil {
 		return DockerContainerMetadata{Error: CannotGetDockerClientError{version: dg.version, err: err}}
 	}
 
-	err = client.StopContainerWithContext(dockerID, uint(dg.config.DockerStopTimeout/time.Second), ctx)
+	if stopSignal == "" {
+		err = client.StopContainerWithContext(dockerID, uint(stopTimeout/time.Second), ctx)
+	} else {
+		err = dg.signalContainer(ctx, client, dockerID, stopTimeout, stopSignal)
+	}
 	metadata := dg.containerMetadata(dockerID)
 	if err != nil {
 		log.Debug("Error stopping container", "err", err, "id", dockerID)
@@ -533,7 +593,50 @@ func (dg *dockerGoClient) stopContainer(ctx context.Context, dockerID string) Do
 	return metadata
 }
 
+// signalContainer sends the stop signal to the container and waits up to
+// 'stopTimeout' for it to exit before killing it. The container is inspected
+// every stopSignalPollInterval until it exits, so that the wait ends with ctx.
+func (dg *dockerGoClient) signalContainer(ctx context.Context, client dockeriface.Client, dockerID string, stopTimeout time.Duration, stopSignal string) error {
+	signal, err := dockerclient.ParseSignal(stopSignal)
+	if err != nil {
+		return err
+	}
+	err = client.KillContainer(docker.KillContainerOptions{
+		ID:      dockerID,
+		Signal:  signal,
+		Context: ctx,
+	})
+	if err != nil {
+		return err
+	}
+
+	killTimer := dg.time().After(stopTimeout)
+	for {
+		container, err := client.InspectContainerWithContext(dockerID, ctx)
+		if err != nil {
+			return err
+		}
+		if !container.State.Running {
+			return nil
+		}
+		select {
+		case <-dg.time().After(stopSignalPollInterval):
+		case <-killTimer:
+			log.Debug("Container did not exit after stop signal; killing it", "id", dockerID, "signal", stopSignal)
+			return client.KillContainer(docker.KillContainerOptions{
+				ID:      dockerID,
+				Signal:  docker.SIGKILL,
+				Context: ctx,
+			})
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 func (dg *dockerGoClient) RemoveContainer(dockerID string, timeout time.Duration) error {
+	done := dg.operationLimiter.begin(dockerclient.RemoveContainerOperation)
+	defer done()
 	// Remove a context that times out after the 'timeout' duration
 	// This is defined by 'removeContainerTimeout'. 'timeout' makes it
 	// easier to write tests
@@ -573,7 +676,7 @@ func (dg *dockerGoClient) removeContainer(dockerID string, ctx context.Context)
 }
 
 func (dg *dockerGoClient) containerMetadata(id string) DockerContainerMetadata {
-	dockerContainer, err := dg.InspectContainer(id, inspectContainerTimeout)
+	dockerContainer, err := dg.inspectContainerWithTimeout(id, inspectContainerTimeout)
 	if err != nil {
 		return DockerContainerMetadata{DockerID: id, Error: CannotXContainerError{"Inspect", err.Error()}}
 	}
@@ -594,6 +697,7 @@ func metadataFromContainer(dockerContainer *docker.Container) DockerContainerMet
 	metadata := DockerContainerMetadata{
 		DockerID:     dockerContainer.ID,
 		PortBindings: bindings,
+		Networks:     api.NetworksFromDockerNetworkSettings(dockerContainer.NetworkSettings),
 		Volumes:      dockerContainer.Volumes,
 	}
 	// Workaround for https://github.com/docker/docker/issues/27601
@@ -780,6 +884,39 @@ func (dg *dockerGoClient) listContainers(all bool, ctx context.Context) ListCont
 	return ListContainersResponse{DockerIDs: containerIDs, Error: nil}
 }
 
+// ListImages returns the images on the instance, excluding intermediate images.
+func (dg *dockerGoClient) ListImages(timeout time.Duration) ListImagesResponse {
+	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
+
+	// Buffered channel so in the case of timeout it takes one write, never gets
+	// read, and can still be GC'd
+	response := make(chan ListImagesResponse, 1)
+	go func() { response <- dg.listImages(ctx) }()
+	select {
+	case resp := <-response:
+		return resp
+	case <-ctx.Done():
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			return ListImagesResponse{Error: &DockerTimeoutError{timeout, "listing images"}}
+		}
+		return ListImagesResponse{Error: err}
+	}
+}
+
+func (dg *dockerGoClient) listImages(ctx context.Context) ListImagesResponse {
+	client, err := dg.dockerClient()
+	if err != nil {
+		return ListImagesResponse{Error: err}
+	}
+
+	images, err := client.ListImages(docker.ListImagesOptions{
+		Context: ctx,
+	})
+	return ListImagesResponse{Images: images, Error: err}
+}
+
 func (dg *dockerGoClient) SupportedVersions() []dockerclient.DockerVersion {
 	return dg.clientFactory.FindAvailableVersions()
 }
@@ -823,6 +960,8 @@ func (dg *dockerGoClient) Stats(id string, ctx context.Context) (<-chan *docker.
 }
 
 func (dg *dockerGoClient) RemoveImage(imageName string, imageRemovalTimeout time.Duration) error {
+	done := dg.operationLimiter.begin(dockerclient.RemoveImageOperation)
+	defer done()
 	ctx, cancel := context.WithTimeout(context.Background(), imageRemovalTimeout)
 	defer cancel()
 
@@ -843,3 +982,88 @@ func (dg *dockerGoClient) removeImage(imageName string) error {
 	}
 	return client.RemoveImage(imageName)
 }
+
+// CreateVolume creates a Docker volume with the given driver, driver options
+// and labels
+func (dg *dockerGoClient) CreateVolume(name, driver string, driverOpts, labels map[string]string, timeout time.Duration) VolumeResponse {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Buffered channel so in the case of timeout it takes one write, never gets
+	// read, and can still be GC'd
+	response := make(chan VolumeResponse, 1)
+	go func() { response <- dg.createVolume(ctx, name, driver, driverOpts, labels) }()
+	select {
+	case resp := <-response:
+		return resp
+	case <-ctx.Done():
+		return VolumeResponse{Error: &DockerTimeoutError{timeout, "creating volume"}}
+	}
+}
+
+func (dg *dockerGoClient) createVolume(ctx context.Context, name, driver string, driverOpts, labels map[string]string) VolumeResponse {
+	client, err := dg.dockerClient()
+	if err != nil {
+		return VolumeResponse{Error: err}
+	}
+	volume, err := client.CreateVolume(docker.CreateVolumeOptions{
+		Name:       name,
+		Driver:     driver,
+		DriverOpts: driverOpts,
+		Labels:     labels,
+		Context:    ctx,
+	})
+	return VolumeResponse{DockerVolume: volume, Error: err}
+}
+
+// InspectVolume returns the Docker volume with the given name. The error is
+// docker.ErrNoSuchVolume if there is none.
+func (dg *dockerGoClient) InspectVolume(name string, timeout time.Duration) VolumeResponse {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	response := make(chan VolumeResponse, 1)
+	go func() { response <- dg.inspectVolume(name) }()
+	select {
+	case resp := <-response:
+		return resp
+	case <-ctx.Done():
+		return VolumeResponse{Error: &DockerTimeoutError{timeout, "inspecting volume"}}
+	}
+}
+
+func (dg *dockerGoClient) inspectVolume(name string) VolumeResponse {
+	client, err := dg.dockerClient()
+	if err != nil {
+		return VolumeResponse{Error: err}
+	}
+	volume, err := client.InspectVolume(name)
+	return VolumeResponse{DockerVolume: volume, Error: err}
+}
+
+// RemoveVolume removes the Docker volume with the given name
+func (dg *dockerGoClient) RemoveVolume(name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	response := make(chan error, 1)
+	go func() { response <- dg.removeVolume(name) }()
+	select {
+	case resp := <-response:
+		return resp
+	case <-ctx.Done():
+		return &DockerTimeoutError{timeout, "removing volume"}
+	}
+}
+
+func (dg *dockerGoClient) removeVolume(name string) error {
+	client, err := dg.dockerClient()
+	if err != nil {
+		return err
+	}
+	return client.RemoveVolume(name)
+}
+
+func (dg *dockerGoClient) OperationStats() []DockerOperationStats {
+	return dg.operationLimiter.Stats()
+}
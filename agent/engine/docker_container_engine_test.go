@@ -38,7 +38,6 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient/mocks"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockeriface/mocks"
-	"github.com/aws/amazon-ecs-agent/agent/engine/emptyvolume"
 	"github.com/aws/amazon-ecs-agent/agent/utils/ttime/mocks"
 )
 
@@ -193,47 +192,6 @@ func TestPullImageDigest(t *testing.T) {
 	}
 }
 
-func TestPullEmptyvolumeImage(t *testing.T) {
-	mockDocker, client, testTime, done := dockerClientSetup(t)
-	defer done()
-
-	// The special emptyvolume image leads to a create, not pull
-	testTime.EXPECT().After(gomock.Any()).AnyTimes()
-	gomock.InOrder(
-		mockDocker.EXPECT().InspectImage(emptyvolume.Image+":"+emptyvolume.Tag).Return(nil, errors.New("Does not exist")),
-		mockDocker.EXPECT().ImportImage(gomock.Any()).Do(func(x interface{}) {
-			req := x.(docker.ImportImageOptions)
-			if req.Repository != emptyvolume.Image {
-				t.Fatal("Expected empty volume repository")
-			}
-			if req.Tag != emptyvolume.Tag {
-				t.Fatal("Expected empty volume repository")
-			}
-		}),
-	)
-
-	metadata := client.PullImage(emptyvolume.Image+":"+emptyvolume.Tag, nil)
-	if metadata.Error != nil {
-		t.Error(metadata.Error)
-	}
-}
-
-func TestPullExistingEmptyvolumeImage(t *testing.T) {
-	mockDocker, client, testTime, done := dockerClientSetup(t)
-	defer done()
-
-	// The special emptyvolume image leads to a create only if it doesn't exist
-	testTime.EXPECT().After(gomock.Any()).AnyTimes()
-	gomock.InOrder(
-		mockDocker.EXPECT().InspectImage(emptyvolume.Image+":"+emptyvolume.Tag).Return(&docker.Image{}, nil),
-	)
-
-	metadata := client.PullImage(emptyvolume.Image+":"+emptyvolume.Tag, nil)
-	if metadata.Error != nil {
-		t.Error(metadata.Error)
-	}
-}
-
 func TestPullImageECRSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -409,6 +367,25 @@ func TestCreateContainer(t *testing.T) {
 	}
 }
 
+func TestCreateContainerIsNotHeldUpByInspectLimit(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.DockerOperationLimits = map[dockerclient.DockerOperation]int{dockerclient.InspectOperation: 1}
+	mockDocker, client, _, done := dockerClientSetupWithConfig(t, conf)
+	defer done()
+
+	// Another caller holds the only inspect slot
+	doneInspect := client.operationLimiter.begin(dockerclient.InspectOperation)
+	defer doneInspect()
+
+	gomock.InOrder(
+		mockDocker.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "id"}, nil),
+		mockDocker.EXPECT().InspectContainerWithContext("id", gomock.Any()).Return(&docker.Container{ID: "id"}, nil),
+	)
+	metadata := client.CreateContainer(&docker.Config{}, nil, "containerName", 1*time.Second)
+	assert.NoError(t, metadata.Error)
+	assert.Equal(t, "id", metadata.DockerID)
+}
+
 func TestStartContainerTimeout(t *testing.T) {
 	mockDocker, client, _, done := dockerClientSetup(t)
 	defer done()
@@ -460,7 +437,7 @@ func TestStopContainerTimeout(t *testing.T) {
 		wait.Wait()
 		// Don't return, verify timeout happens
 	})
-	metadata := client.StopContainer("id", xContainerShortTimeout)
+	metadata := client.StopContainer("id", client.config.DockerStopTimeout, "", xContainerShortTimeout)
 	if metadata.Error == nil {
 		t.Error("Expected error for pull timeout")
 	}
@@ -478,7 +455,7 @@ func TestStopContainer(t *testing.T) {
 		mockDocker.EXPECT().StopContainerWithContext("id", uint(client.config.DockerStopTimeout/time.Second), gomock.Any()).Return(nil),
 		mockDocker.EXPECT().InspectContainerWithContext("id", gomock.Any()).Return(&docker.Container{ID: "id", State: docker.State{ExitCode: 10}}, nil),
 	)
-	metadata := client.StopContainer("id", stopContainerTimeout)
+	metadata := client.StopContainer("id", client.config.DockerStopTimeout, "", stopContainerTimeout)
 	if metadata.Error != nil {
 		t.Error("Did not expect error")
 	}
@@ -487,6 +464,49 @@ func TestStopContainer(t *testing.T) {
 	}
 }
 
+func TestStopContainerWithSignal(t *testing.T) {
+	mockDocker, client, testTime, done := dockerClientSetup(t)
+	defer done()
+
+	testTime.EXPECT().After(5 * time.Minute).Return(make(chan time.Time)).AnyTimes()
+	poll := make(chan time.Time, 1)
+	poll <- time.Now()
+	testTime.EXPECT().After(stopSignalPollInterval).Return(poll)
+	gomock.InOrder(
+		mockDocker.EXPECT().KillContainer(gomock.Any()).Do(func(opts docker.KillContainerOptions) {
+			assert.Equal(t, "id", opts.ID)
+			assert.Equal(t, docker.SIGQUIT, opts.Signal)
+		}).Return(nil),
+		mockDocker.EXPECT().InspectContainerWithContext("id", gomock.Any()).Return(&docker.Container{ID: "id", State: docker.State{Running: true}}, nil),
+		mockDocker.EXPECT().InspectContainerWithContext("id", gomock.Any()).Return(&docker.Container{ID: "id"}, nil),
+		mockDocker.EXPECT().InspectContainerWithContext("id", gomock.Any()).Return(&docker.Container{ID: "id"}, nil),
+	)
+	metadata := client.StopContainer("id", 5*time.Minute, "SIGQUIT", stopContainerTimeout)
+	assert.NoError(t, metadata.Error)
+}
+
+func TestStopContainerWithSignalKillsAfterTimeout(t *testing.T) {
+	mockDocker, client, testTime, done := dockerClientSetup(t)
+	defer done()
+
+	stopTimeout := make(chan time.Time, 1)
+	stopTimeout <- time.Now()
+	gomock.InOrder(
+		mockDocker.EXPECT().KillContainer(gomock.Any()).Return(nil),
+		// Never exits on its own
+		mockDocker.EXPECT().InspectContainerWithContext("id", gomock.Any()).Return(&docker.Container{ID: "id", State: docker.State{Running: true}}, nil),
+		mockDocker.EXPECT().KillContainer(gomock.Any()).Do(func(opts docker.KillContainerOptions) {
+			assert.Equal(t, docker.SIGKILL, opts.Signal)
+		}).Return(nil),
+		mockDocker.EXPECT().InspectContainerWithContext("id", gomock.Any()).Return(&docker.Container{ID: "id"}, nil),
+	)
+	testTime.EXPECT().After(2 * time.Second).Return(stopTimeout)
+	testTime.EXPECT().After(stopSignalPollInterval).Return(make(chan time.Time))
+
+	metadata := client.StopContainer("id", 2*time.Second, "USR1", stopContainerTimeout)
+	assert.NoError(t, metadata.Error)
+}
+
 func TestInspectContainerTimeout(t *testing.T) {
 	mockDocker, client, _, done := dockerClientSetup(t)
 	defer done()
@@ -526,6 +546,47 @@ func TestInspectContainer(t *testing.T) {
 	}
 }
 
+func TestExecContainer(t *testing.T) {
+	mockDocker, client, _, done := dockerClientSetup(t)
+	defer done()
+
+	gomock.InOrder(
+		mockDocker.EXPECT().CreateExec(gomock.Any()).Do(func(opts docker.CreateExecOptions) {
+			if opts.Container != "id" || !reflect.DeepEqual(opts.Cmd, []string{"true"}) {
+				t.Errorf("Unexpected exec options %v", opts)
+			}
+		}).Return(&docker.Exec{ID: "execid"}, nil),
+		mockDocker.EXPECT().StartExec("execid", gomock.Any()).Do(func(id string, opts docker.StartExecOptions) {
+			opts.OutputStream.Write([]byte("ok"))
+		}).Return(nil),
+		mockDocker.EXPECT().InspectExec("execid").Return(&docker.ExecInspect{ExitCode: 1}, nil),
+	)
+	result := client.ExecContainer("id", []string{"true"}, inspectContainerTimeout)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, 1, result.ExitCode)
+	assert.Equal(t, "ok", result.Output)
+}
+
+func TestExecContainerTimeout(t *testing.T) {
+	mockDocker, client, _, done := dockerClientSetup(t)
+	defer done()
+
+	wait := &sync.WaitGroup{}
+	wait.Add(1)
+	mockDocker.EXPECT().CreateExec(gomock.Any()).Do(func(x interface{}) {
+		wait.Wait()
+		// Don't return, verify timeout happens
+	}).Return(nil, errors.New("test error"))
+	result := client.ExecContainer("id", []string{"true"}, xContainerShortTimeout)
+	if result.Error == nil {
+		t.Error("Expected error for exec timeout")
+	}
+	if result.Error.(api.NamedError).ErrorName() != "DockerTimeoutError" {
+		t.Error("Wrong error type")
+	}
+	wait.Done()
+}
+
 func TestContainerEvents(t *testing.T) {
 	mockDocker, client, _, done := dockerClientSetup(t)
 	defer done()
@@ -933,6 +994,44 @@ func TestRemoveImage(t *testing.T) {
 	}
 }
 
+func TestCreateVolume(t *testing.T) {
+	mockDocker, client, _, done := dockerClientSetup(t)
+	defer done()
+
+	driverOpts := map[string]string{"type": "tmpfs"}
+	labels := map[string]string{"team": "storage"}
+	mockDocker.EXPECT().CreateVolume(gomock.Any()).Do(func(opts docker.CreateVolumeOptions) {
+		assert.Equal(t, "volume", opts.Name)
+		assert.Equal(t, "local", opts.Driver)
+		assert.Equal(t, driverOpts, opts.DriverOpts)
+		assert.Equal(t, labels, opts.Labels)
+	}).Return(&docker.Volume{Name: "volume", Driver: "local"}, nil)
+	resp := client.CreateVolume("volume", "local", driverOpts, labels, time.Second)
+	assert.NoError(t, resp.Error)
+	assert.Equal(t, "local", resp.DockerVolume.Driver)
+}
+
+func TestInspectVolumeNotFound(t *testing.T) {
+	mockDocker, client, _, done := dockerClientSetup(t)
+	defer done()
+
+	mockDocker.EXPECT().InspectVolume("volume").Return(nil, docker.ErrNoSuchVolume)
+	resp := client.InspectVolume("volume", time.Second)
+	assert.Equal(t, docker.ErrNoSuchVolume, resp.Error)
+}
+
+func TestRemoveVolumeTimeout(t *testing.T) {
+	mockDocker, client, _, _ := dockerClientSetup(t)
+	wait := sync.WaitGroup{}
+	wait.Add(1)
+	mockDocker.EXPECT().RemoveVolume("volume").Do(func(x interface{}) {
+		wait.Wait()
+	})
+	err := client.RemoveVolume("volume", 2*time.Millisecond)
+	assert.Error(t, err, "Expected error for remove volume timeout")
+	wait.Done()
+}
+
 func TestContainerMetadataWorkaroundIssue27601(t *testing.T) {
 	mockDocker, client, _, _ := dockerClientSetup(t)
 	mockDocker.EXPECT().InspectContainerWithContext("id", gomock.Any()).Return(&docker.Container{
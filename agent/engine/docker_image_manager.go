@@ -15,14 +15,19 @@ package engine
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+	"github.com/aws/amazon-ecs-agent/agent/logger/audit"
 	"github.com/aws/amazon-ecs-agent/agent/statemanager"
 	"github.com/cihub/seelog"
 	"golang.org/x/net/context"
@@ -30,6 +35,10 @@ import (
 
 const (
 	imageNotFoundForDeletionError = "no such image"
+
+	// diskPressureCheckInterval is the time between checks of the disk usage
+	// of Docker's data root against the image cleanup watermarks
+	diskPressureCheckInterval = time.Minute
 )
 
 // ImageManager is responsible for saving the Image states,
@@ -40,6 +49,12 @@ type ImageManager interface {
 	AddAllImageStates(imageStates []*image.ImageState)
 	GetImageStateFromImageName(containerImageName string) *image.ImageState
 	StartImageCleanupProcess(ctx context.Context)
+	StartImagePrewarmProcess(ctx context.Context)
+	StartDiskPressureProcess(ctx context.Context)
+	CheckDiskPressure()
+	SetImagePinned(imageRef string, pinned bool) (*image.ImageState, bool)
+	ImagePrewarmStatus() []ImagePrewarmStatus
+	ImageCleanupDryRun() ImageCleanupPlan
 	SetSaver(stateManager statemanager.Saver)
 }
 
@@ -56,20 +71,78 @@ type dockerImageManager struct {
 	minimumAgeBeforeDeletion         time.Duration
 	numImagesToDelete                int
 	imageCleanupTimeInterval         time.Duration
+	prewarmImages                    []string
+	prewarmInterval                  time.Duration
+	prewarmStatuses                  map[string]*ImagePrewarmStatus
+	prewarmLock                      sync.RWMutex
+	prewarmECRAuth                   bool
+	imagePulls                       *imagePullGroup
+	excludedImages                   []string
+	cleanupStrategy                  dockerclient.ImageCleanupStrategy
+	evictionStrategy                 ImageEvictionStrategy
+	// cleanupLock ensures only one pass of the image cleanup runs at a time
+	cleanupLock       sync.Mutex
+	highWatermark     float64
+	lowWatermark      float64
+	dockerDataRoot    string
+	diskUsage         func(path string) (float64, error)
+	diskPressureCheck chan struct{}
+	// unmanagedCleanupEnabled extends the image cleanup to the images and
+	// containers on the instance that the agent did not create
+	unmanagedCleanupEnabled      bool
+	unmanagedImageMinimumAge     time.Duration
+	unmanagedContainerMinimumAge time.Duration
+	unmanagedExcluded            []string
+	cleanupAuditLogger           audit.CleanupAuditLogger
+}
+
+// ImageCleanupPlan lists the images that the next image cleanup cycle would
+// delete, in the order they would be deleted, followed by the containers and
+// images that the cleanup of unmanaged resources would remove
+type ImageCleanupPlan struct {
+	Strategy            dockerclient.ImageCleanupStrategy
+	Images              []ImageDeletionCandidate
+	UnmanagedContainers []UnmanagedResourceCandidate
+	UnmanagedImages     []UnmanagedResourceCandidate
 }
 
-// ImageStatesForDeletion is used for implementing the sort interface
-type ImageStatesForDeletion []*image.ImageState
+// ImageDeletionCandidate is an image that the image cleanup would delete,
+// along with the reason it was chosen
+type ImageDeletionCandidate struct {
+	ImageState *image.ImageState
+	Reason     string
+}
 
 // NewImageManager returns a new ImageManager
 func NewImageManager(cfg *config.Config, client DockerClient, state *dockerstate.DockerTaskEngineState) ImageManager {
-	return &dockerImageManager{
-		client: client,
-		state:  state,
-		minimumAgeBeforeDeletion: cfg.MinimumImageDeletionAge,
-		numImagesToDelete:        cfg.NumImagesToDeletePerCycle,
-		imageCleanupTimeInterval: cfg.ImageCleanupInterval,
+	imageManager := &dockerImageManager{
+		client:                       client,
+		state:                        state,
+		minimumAgeBeforeDeletion:     cfg.MinimumImageDeletionAge,
+		numImagesToDelete:            cfg.NumImagesToDeletePerCycle,
+		imageCleanupTimeInterval:     cfg.ImageCleanupInterval,
+		prewarmImages:                cfg.ImagePrewarmList,
+		prewarmInterval:              cfg.ImagePrewarmInterval,
+		prewarmStatuses:              make(map[string]*ImagePrewarmStatus),
+		prewarmECRAuth:               cfg.ImagePrewarmECRAuth,
+		imagePulls:                   sharedImagePulls,
+		excludedImages:               cfg.ImageCleanupExclusionList,
+		cleanupStrategy:              cfg.ImageCleanupStrategy,
+		evictionStrategy:             NewImageEvictionStrategy(cfg.ImageCleanupStrategy),
+		highWatermark:                float64(cfg.ImageCleanupHighWatermark),
+		lowWatermark:                 float64(cfg.ImageCleanupLowWatermark),
+		dockerDataRoot:               cfg.DockerDataRoot,
+		diskUsage:                    dockerDataRootUsage,
+		diskPressureCheck:            make(chan struct{}, 1),
+		unmanagedCleanupEnabled:      cfg.UnmanagedCleanupEnabled,
+		unmanagedImageMinimumAge:     cfg.UnmanagedImageMinimumAge,
+		unmanagedContainerMinimumAge: cfg.UnmanagedContainerMinimumAge,
+		unmanagedExcluded:            cfg.UnmanagedCleanupExclusionList,
 	}
+	if cfg.UnmanagedCleanupEnabled {
+		imageManager.cleanupAuditLogger = newCleanupAuditLogger(cfg)
+	}
+	return imageManager
 }
 
 func (imageManager *dockerImageManager) SetSaver(stateManager statemanager.Saver) {
@@ -102,17 +175,26 @@ func (imageManager *dockerImageManager) RecordContainerReference(container *api.
 	}
 
 	// Inspect image for obtaining Container's Image ID
-	imageInspected, err := imageManager.client.InspectImage(container.Image)
+	imageInspected, err := imageManager.client.InspectImage(container.ImageReference())
 	if err != nil {
-		seelog.Errorf("Error inspecting image %v: %v", container.Image, err)
+		seelog.Errorf("Error inspecting image %v: %v", container.ImageReference(), err)
 		return err
 	}
 
 	container.ImageID = imageInspected.ID
+	container.ImageDigest = repoDigestForImage(container.ImageReference(), imageInspected.RepoDigests)
 	added := imageManager.addContainerReferenceToExistingImageState(container)
 	if !added {
 		imageManager.addContainerReferenceToNewImageState(container, imageInspected.Size)
 	}
+	// Containers restored from the state file already have an image ID, so
+	// only new containers count as a use of the image
+	imageManager.updateLock.RLock()
+	defer imageManager.updateLock.RUnlock()
+	if imageState, ok := imageManager.getImageState(container.ImageID); ok {
+		imageState.IncrementUseCount()
+		imageState.SetRepoDigests(imageInspected.RepoDigests)
+	}
 	return nil
 }
 
@@ -120,7 +202,7 @@ func (imageManager *dockerImageManager) addContainerReferenceToExistingImageStat
 	// this lock is used for reading the image states in the image manager
 	imageManager.updateLock.RLock()
 	defer imageManager.updateLock.RUnlock()
-	imageManager.removeExistingImageNameOfDifferentID(container.Image, container.ImageID)
+	imageManager.removeExistingImageNameOfDifferentID(container.ImageReference(), container.ImageID)
 	imageState, ok := imageManager.getImageState(container.ImageID)
 	if ok {
 		imageState.UpdateImageState(container)
@@ -132,7 +214,7 @@ func (imageManager *dockerImageManager) addContainerReferenceToNewImageState(con
 	// this lock is used while creating and adding new image state to image manager
 	imageManager.updateLock.Lock()
 	defer imageManager.updateLock.Unlock()
-	imageManager.removeExistingImageNameOfDifferentID(container.Image, container.ImageID)
+	imageManager.removeExistingImageNameOfDifferentID(container.ImageReference(), container.ImageID)
 	// check to see if a different thread added image state for same image ID
 	imageState, ok := imageManager.getImageState(container.ImageID)
 	if ok {
@@ -210,43 +292,78 @@ func (imageManager *dockerImageManager) getCandidateImagesForDeletion() []*image
 		// no image states present in image manager
 		return nil
 	}
-	var imagesForDeletion []*image.ImageState
+	imageStates := make([]*image.ImageState, 0, len(imageManager.imageStatesConsideredForDeletion))
 	for _, imageState := range imageManager.imageStatesConsideredForDeletion {
-		if imageManager.isImageOldEnough(imageState) && imageState.HasNoAssociatedContainers() {
-			seelog.Infof("Candidate image for deletion: %+v", imageState)
+		imageStates = append(imageStates, imageState)
+	}
+	imagesForDeletion := imageManager.imagesEligibleForDeletion(imageStates)
+	for _, imageState := range imagesForDeletion {
+		seelog.Infof("Candidate image for deletion: %+v", imageState)
+	}
+	return imagesForDeletion
+}
+
+// imagesEligibleForDeletion returns the images that the image cleanup, both
+// periodic and under disk pressure, is allowed to delete
+func (imageManager *dockerImageManager) imagesEligibleForDeletion(imageStates []*image.ImageState) []*image.ImageState {
+	var imagesForDeletion []*image.ImageState
+	for _, imageState := range imageStates {
+		if imageManager.isImageEligibleForDeletion(imageState) {
 			imagesForDeletion = append(imagesForDeletion, imageState)
 		}
 	}
 	return imagesForDeletion
 }
 
-func (imageManager *dockerImageManager) isImageOldEnough(imageState *image.ImageState) bool {
-	ageOfImage := time.Now().Sub(imageState.PulledAt)
-	return ageOfImage > imageManager.minimumAgeBeforeDeletion
+// isImageEligibleForDeletion returns true if the image is neither pinned nor
+// excluded, is old enough and is not used by any container
+func (imageManager *dockerImageManager) isImageEligibleForDeletion(imageState *image.ImageState) bool {
+	if imageState.IsPinned() || imageManager.isImageExcluded(imageState) {
+		return false
+	}
+	return imageManager.isImageOldEnough(imageState) && imageState.HasNoAssociatedContainers()
+}
+
+// isImageExcluded returns true if any name of the image matches a name or
+// pattern in the image cleanup exclusion list
+func (imageManager *dockerImageManager) isImageExcluded(imageState *image.ImageState) bool {
+	for _, imageName := range imageState.Image.Names {
+		if imageManager.isImageNameExcluded(imageName) {
+			return true
+		}
+	}
+	return false
 }
 
-// Implementing sort interface based on last used times of the images
-func (imageStates ImageStatesForDeletion) Len() int {
-	return len(imageStates)
+// isImageNameExcluded returns true if the image name matches a name or
+// pattern in the image cleanup exclusion list
+func (imageManager *dockerImageManager) isImageNameExcluded(imageName string) bool {
+	for _, pattern := range imageManager.excludedImages {
+		// Patterns are validated when the configuration is loaded
+		if matched, _ := path.Match(pattern, imageName); matched {
+			return true
+		}
+	}
+	return false
 }
 
-func (imageStates ImageStatesForDeletion) Less(i, j int) bool {
-	return imageStates[i].LastUsedAt.Before(imageStates[j].LastUsedAt)
+func (imageManager *dockerImageManager) isImageOldEnough(imageState *image.ImageState) bool {
+	ageOfImage := time.Now().Sub(imageState.PulledAt)
+	return ageOfImage > imageManager.minimumAgeBeforeDeletion
 }
 
-func (imageStates ImageStatesForDeletion) Swap(i, j int) {
-	imageStates[i], imageStates[j] = imageStates[j], imageStates[i]
+// sortImagesForDeletion orders the images in the order the eviction strategy
+// deletes them
+func (imageManager *dockerImageManager) sortImagesForDeletion(imagesForDeletion []*image.ImageState) []*image.ImageState {
+	candidateImages := make([]*image.ImageState, len(imagesForDeletion))
+	copy(candidateImages, imagesForDeletion)
+	sort.Stable(imageStatesByEvictionOrder{imageStates: candidateImages, strategy: imageManager.evictionStrategy})
+	return candidateImages
 }
 
-func (imageManager *dockerImageManager) getLeastRecentlyUsedImage(imagesForDeletion []*image.ImageState) *image.ImageState {
-	var candidateImages ImageStatesForDeletion
-	for _, imageState := range imagesForDeletion {
-		candidateImages = append(candidateImages, imageState)
-	}
-	// sort images in the order of last used times
-	sort.Sort(candidateImages)
-	// return only the top LRU image for deletion
-	return candidateImages[0]
+func (imageManager *dockerImageManager) getNextImageForDeletion(imagesForDeletion []*image.ImageState) *image.ImageState {
+	// return only the image the strategy deletes first
+	return imageManager.sortImagesForDeletion(imagesForDeletion)[0]
 }
 
 func (imageManager *dockerImageManager) removeExistingImageNameOfDifferentID(containerImageName string, inspectedImageID string) {
@@ -269,6 +386,7 @@ func (imageManager *dockerImageManager) performPeriodicImageCleanup(ctx context.
 		select {
 		case <-imageManager.imageCleanupTicker.C:
 			go imageManager.removeUnusedImages()
+			go imageManager.removeUnmanagedResources()
 		case <-ctx.Done():
 			imageManager.imageCleanupTicker.Stop()
 			return
@@ -277,12 +395,11 @@ func (imageManager *dockerImageManager) performPeriodicImageCleanup(ctx context.
 }
 
 func (imageManager *dockerImageManager) removeUnusedImages() {
-	imageManager.imageStatesConsideredForDeletion = make(map[string]*image.ImageState)
-	for _, imageState := range imageManager.getAllImageStates() {
-		imageManager.imageStatesConsideredForDeletion[imageState.Image.ImageID] = imageState
-	}
+	imageManager.cleanupLock.Lock()
+	defer imageManager.cleanupLock.Unlock()
+	imageManager.considerAllImagesForDeletion()
 	for i := 0; i < imageManager.numImagesToDelete; i++ {
-		err := imageManager.removeLeastRecentlyUsedImage()
+		err := imageManager.removeNextImageForDeletion()
 		if err != nil {
 			seelog.Infof("End of eligible images for deletion")
 			break
@@ -290,17 +407,122 @@ func (imageManager *dockerImageManager) removeUnusedImages() {
 	}
 }
 
-func (imageManager *dockerImageManager) removeLeastRecentlyUsedImage() error {
+// StartDiskPressureProcess compares the disk usage of the Docker data root
+// against the image cleanup watermarks straight away, then every
+// diskPressureCheckInterval and whenever CheckDiskPressure is called, until the
+// context is cancelled. It runs whether or not the periodic image cleanup is
+// enabled.
+func (imageManager *dockerImageManager) StartDiskPressureProcess(ctx context.Context) {
+	if imageManager.highWatermark == 0 {
+		return
+	}
+	imageManager.performPeriodicDiskPressureCheck(ctx, diskPressureCheckInterval)
+}
+
+func (imageManager *dockerImageManager) performPeriodicDiskPressureCheck(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		imageManager.removeImagesUnderDiskPressure()
+		select {
+		case <-ticker.C:
+		case <-imageManager.diskPressureCheck:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CheckDiskPressure requests that the disk usage of the Docker data root be
+// compared against the image cleanup watermarks. It returns without waiting
+// for the check, which is carried out by the disk pressure process.
+func (imageManager *dockerImageManager) CheckDiskPressure() {
+	if imageManager.highWatermark == 0 {
+		return
+	}
+	select {
+	case imageManager.diskPressureCheck <- struct{}{}:
+	default:
+		// A check has already been requested
+	}
+}
+
+// removeImagesUnderDiskPressure deletes unused images, in the order given by
+// the eviction strategy, once disk usage exceeds the high watermark and until it falls below
+// the low watermark
+func (imageManager *dockerImageManager) removeImagesUnderDiskPressure() {
+	if imageManager.highWatermark == 0 {
+		return
+	}
+	imageManager.cleanupLock.Lock()
+	defer imageManager.cleanupLock.Unlock()
+	usage, underPressure := imageManager.checkDiskPressure()
+	if !underPressure {
+		return
+	}
+
+	seelog.Infof("Disk usage of %s is %.1f%%, above the high watermark of %.0f%%; removing images until it is below %.0f%%",
+		imageManager.dockerDataRoot, usage, imageManager.highWatermark, imageManager.lowWatermark)
+	imageManager.considerAllImagesForDeletion()
+	for usage >= imageManager.lowWatermark {
+		err := imageManager.removeNextImageForDeletion()
+		if err != nil {
+			seelog.Warnf("Disk usage of %s is still %.1f%% but no more images are eligible for deletion", imageManager.dockerDataRoot, usage)
+			return
+		}
+		usage, err = imageManager.diskUsage(imageManager.dockerDataRoot)
+		if err != nil {
+			seelog.Errorf("Unable to get the disk usage of Docker's data root, no more images are removed under disk pressure: %v", err)
+			return
+		}
+	}
+	seelog.Infof("Disk usage of %s is down to %.1f%%", imageManager.dockerDataRoot, usage)
+}
+
+// checkDiskPressure returns the disk usage of Docker's data root and whether
+// it is above the high watermark. A failure to get the usage is logged and
+// reported as no pressure.
+func (imageManager *dockerImageManager) checkDiskPressure() (float64, bool) {
+	if imageManager.highWatermark == 0 {
+		return 0, false
+	}
+	usage, err := imageManager.diskUsage(imageManager.dockerDataRoot)
+	if err != nil {
+		seelog.Errorf("Unable to get the disk usage of Docker's data root, no images are removed under disk pressure until this is fixed: %v", err)
+		return 0, false
+	}
+	return usage, usage >= imageManager.highWatermark
+}
+
+// dockerDataRootUsage returns how full, as a percentage, the filesystem holding
+// Docker's data root is. The path is first checked to hold Docker's image
+// store, since any other directory, such as the mount point of a missing
+// mount, may be on a different filesystem.
+func dockerDataRootUsage(dataRoot string) (float64, error) {
+	if _, err := os.Stat(filepath.Join(dataRoot, "image")); err != nil {
+		return 0, fmt.Errorf("%s does not look like Docker's data root, which must be mounted into the agent container: %v", dataRoot, err)
+	}
+	return diskUsagePercent(dataRoot)
+}
+
+func (imageManager *dockerImageManager) considerAllImagesForDeletion() {
+	imageManager.imageStatesConsideredForDeletion = make(map[string]*image.ImageState)
+	for _, imageState := range imageManager.getAllImageStates() {
+		imageManager.imageStatesConsideredForDeletion[imageState.Image.ImageID] = imageState
+	}
+}
+
+func (imageManager *dockerImageManager) removeNextImageForDeletion() error {
 	seelog.Debug("Attempting to obtain ImagePullDeleteLock for removing images")
 	ImagePullDeleteLock.Lock()
 	seelog.Debug("Obtained ImagePullDeleteLock for removing images")
 	defer seelog.Debug("Released ImagePullDeleteLock after removing images")
 	defer ImagePullDeleteLock.Unlock()
-	leastRecentlyUsedImage := imageManager.getUnusedImageForDeletion()
-	if leastRecentlyUsedImage == nil {
+	nextImage := imageManager.getUnusedImageForDeletion()
+	if nextImage == nil {
 		return fmt.Errorf("No more eligible images for deletion")
 	}
-	imageManager.removeImage(leastRecentlyUsedImage)
+	imageManager.removeImage(nextImage)
 	return nil
 }
 
@@ -313,7 +535,7 @@ func (imageManager *dockerImageManager) getUnusedImageForDeletion() *image.Image
 		return nil
 	}
 	seelog.Infof("Found %d eligible images for deletion", len(candidateImageStatesForDeletion))
-	return imageManager.getLeastRecentlyUsedImage(candidateImageStatesForDeletion)
+	return imageManager.getNextImageForDeletion(candidateImageStatesForDeletion)
 }
 
 func (imageManager *dockerImageManager) removeImage(leastRecentlyUsedImage *image.ImageState) {
@@ -369,3 +591,69 @@ func (imageManager *dockerImageManager) GetImageStateFromImageName(containerImag
 	}
 	return nil
 }
+
+// ImageCleanupDryRun returns the images the next image cleanup cycle would
+// delete and why, without deleting anything. When disk usage is above the
+// high watermark every eligible image is listed, as images are then deleted
+// until usage falls below the low watermark. The containers and images that
+// the cleanup of unmanaged resources would remove are listed as well.
+func (imageManager *dockerImageManager) ImageCleanupDryRun() ImageCleanupPlan {
+	plan := ImageCleanupPlan{Strategy: imageManager.cleanupStrategy}
+	usage, underPressure := imageManager.checkDiskPressure()
+
+	imageManager.updateLock.RLock()
+	imagesForDeletion := imageManager.sortImagesForDeletion(imageManager.imagesEligibleForDeletion(imageManager.getAllImageStates()))
+	reason := fmt.Sprintf("unused and pulled more than %v ago", imageManager.minimumAgeBeforeDeletion)
+	if underPressure {
+		reason = fmt.Sprintf("%s, and disk usage of %s is %.1f%%, above the high watermark of %.0f%%",
+			reason, imageManager.dockerDataRoot, usage, imageManager.highWatermark)
+	} else if len(imagesForDeletion) > imageManager.numImagesToDelete {
+		imagesForDeletion = imagesForDeletion[:imageManager.numImagesToDelete]
+	}
+	for _, imageState := range imagesForDeletion {
+		plan.Images = append(plan.Images, ImageDeletionCandidate{
+			ImageState: imageState,
+			Reason:     reason + "; " + imageManager.evictionStrategy.Reason(imageState),
+		})
+	}
+	imageManager.updateLock.RUnlock()
+
+	if imageManager.unmanagedCleanupEnabled {
+		containers, imagesInUse, err := imageManager.unmanagedContainersForRemoval()
+		if err != nil {
+			seelog.Warnf("Error listing containers, the dry run leaves out unmanaged resources: %v", err)
+			return plan
+		}
+		plan.UnmanagedContainers = containers
+		plan.UnmanagedImages, err = imageManager.unmanagedImagesForRemoval(imagesInUse)
+		if err != nil {
+			seelog.Warnf("Error listing images, the dry run leaves out unmanaged images: %v", err)
+		}
+	}
+	return plan
+}
+
+// SetImagePinned pins or unpins the image with the given ID or name. Pinned
+// images are never removed by the image cleanup. It returns false if the
+// image is not known to the agent.
+func (imageManager *dockerImageManager) SetImagePinned(imageRef string, pinned bool) (*image.ImageState, bool) {
+	imageManager.updateLock.RLock()
+	imageState, ok := imageManager.getImageState(imageRef)
+	if !ok {
+		for _, candidate := range imageManager.getAllImageStates() {
+			if candidate.HasImageName(imageRef) {
+				imageState, ok = candidate, true
+				break
+			}
+		}
+	}
+	imageManager.updateLock.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	seelog.Infof("Setting pinned to %t for image %s", pinned, imageState.Image.ImageID)
+	imageState.SetPinned(pinned)
+	imageManager.saver.Save()
+	return imageState, true
+}
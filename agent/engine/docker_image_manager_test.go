@@ -16,17 +16,22 @@ package engine
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/engine/image"
 	"github.com/aws/amazon-ecs-agent/agent/statemanager"
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
 )
 
@@ -87,6 +92,7 @@ func TestRecordContainerReferenceInspectError(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
 
@@ -121,6 +127,7 @@ func TestRecordContainerReferenceWithNoImageName(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
 
@@ -348,6 +355,7 @@ func TestRemoveContainerReferenceFromImageStateWithNoReference(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
 
@@ -384,6 +392,7 @@ func TestGetCandidateImagesForDeletionImageNoImageState(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 
 	imageStates := imageManager.getCandidateImagesForDeletion()
@@ -404,6 +413,7 @@ func TestGetCandidateImagesForDeletionImageJustPulled(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 
 	sourceImage := &image.Image{}
@@ -429,6 +439,7 @@ func TestGetCandidateImagesForDeletionImageHasContainerReference(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
 
@@ -470,6 +481,7 @@ func TestGetCandidateImagesForDeletionImageHasMoreContainerReferences(t *testing
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
 
@@ -546,7 +558,7 @@ func TestGetLeastRecentlyUsedImages(t *testing.T) {
 	expectedLeastRecentlyUsedImages := []*image.ImageState{
 		imageStateD, imageStateA, imageStateE, imageStateB, imageStateC,
 	}
-	leastRecentlyUsedImage := imageManager.(*dockerImageManager).getLeastRecentlyUsedImage(candidateImagesForDeletion)
+	leastRecentlyUsedImage := imageManager.(*dockerImageManager).getNextImageForDeletion(candidateImagesForDeletion)
 	if !reflect.DeepEqual(leastRecentlyUsedImage, expectedLeastRecentlyUsedImages[0]) {
 		t.Error("Incorrect order of least recently used images")
 	}
@@ -563,6 +575,7 @@ func TestGetLeastRecentlyUsedImagesLessThanFive(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 
 	imageStateA := &image.ImageState{
@@ -580,7 +593,7 @@ func TestGetLeastRecentlyUsedImagesLessThanFive(t *testing.T) {
 	expectedLeastRecentlyUsedImages := []*image.ImageState{
 		imageStateA, imageStateB, imageStateC,
 	}
-	leastRecentlyUsedImage := imageManager.getLeastRecentlyUsedImage(candidateImagesForDeletion)
+	leastRecentlyUsedImage := imageManager.getNextImageForDeletion(candidateImagesForDeletion)
 	if !reflect.DeepEqual(leastRecentlyUsedImage, expectedLeastRecentlyUsedImages[0]) {
 		t.Error("Incorrect order of least recently used images")
 	}
@@ -597,6 +610,7 @@ func TestRemoveAlreadyExistingImageNameWithDifferentID(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
 
@@ -648,6 +662,7 @@ func TestImageCleanupHappyPath(t *testing.T) {
 		minimumAgeBeforeDeletion: 1 * time.Millisecond,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
@@ -700,6 +715,7 @@ func TestImageCleanupCannotRemoveImage(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
@@ -750,6 +766,7 @@ func TestImageCleanupRemoveImageById(t *testing.T) {
 		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
 		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
 	}
 
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
@@ -889,7 +906,7 @@ func TestRemoveLeastRecentlyUsedImageNoImage(t *testing.T) {
 	client := NewMockDockerClient(ctrl)
 	imageManager := &dockerImageManager{client: client, state: dockerstate.NewDockerTaskEngineState()}
 	imageManager.SetSaver(statemanager.NewNoopStateManager())
-	err := imageManager.removeLeastRecentlyUsedImage()
+	err := imageManager.removeNextImageForDeletion()
 	if err == nil {
 		t.Error("Expected Error for no LRU image to remove")
 	}
@@ -951,3 +968,323 @@ func TestGetImageStateFromImageNameNoImageState(t *testing.T) {
 		t.Error("Incorrect image state retrieved by image name")
 	}
 }
+
+func newDiskPressureTestImageManager(client DockerClient, usages ...float64) *dockerImageManager {
+	imageManager := &dockerImageManager{
+		client: client,
+		state:  dockerstate.NewDockerTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		evictionStrategy:         &leastRecentlyUsedStrategy{},
+		highWatermark:            85,
+		lowWatermark:             70,
+		dockerDataRoot:           "/var/lib/docker",
+		diskPressureCheck:        make(chan struct{}, 1),
+	}
+	imageManager.diskUsage = func(path string) (float64, error) {
+		if path != "/var/lib/docker" {
+			return 0, errors.New("unexpected path " + path)
+		}
+		usage := usages[0]
+		if len(usages) > 1 {
+			usages = usages[1:]
+		}
+		return usage, nil
+	}
+	imageManager.SetSaver(statemanager.NewNoopStateManager())
+	return imageManager
+}
+
+func addUnusedImageState(imageManager *dockerImageManager, imageID string, imageName string, lastUsedAt time.Time) *image.ImageState {
+	imageState := &image.ImageState{
+		Image:      &image.Image{ImageID: imageID, Names: []string{imageName}},
+		PulledAt:   time.Now().AddDate(0, -2, 0),
+		LastUsedAt: lastUsedAt,
+	}
+	imageManager.addImageState(imageState)
+	return imageState
+}
+
+func TestRemoveImagesUnderDiskPressure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	imageManager := newDiskPressureTestImageManager(client, 90, 80, 65)
+	addUnusedImageState(imageManager, "sha256:oldest", "oldest", time.Now().AddDate(0, -2, 0))
+	addUnusedImageState(imageManager, "sha256:older", "older", time.Now().AddDate(0, -1, 0))
+	newest := addUnusedImageState(imageManager, "sha256:newest", "newest", time.Now())
+	justPulled := addUnusedImageState(imageManager, "sha256:justpulled", "justpulled", time.Now().AddDate(0, -3, 0))
+	justPulled.PulledAt = time.Now()
+
+	gomock.InOrder(
+		client.EXPECT().RemoveImage("oldest", removeImageTimeout).Return(nil),
+		client.EXPECT().RemoveImage("older", removeImageTimeout).Return(nil),
+	)
+	imageManager.removeImagesUnderDiskPressure()
+
+	assert.Equal(t, []*image.ImageState{newest, justPulled}, imageManager.getAllImageStates())
+}
+
+func TestRemoveImagesUnderDiskPressureBelowHighWatermark(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	imageManager := newDiskPressureTestImageManager(client, 84)
+	addUnusedImageState(imageManager, "sha256:oldest", "oldest", time.Now().AddDate(0, -2, 0))
+
+	// No images are expected to be removed
+	imageManager.removeImagesUnderDiskPressure()
+	assert.Len(t, imageManager.getAllImageStates(), 1)
+}
+
+func TestRemoveImagesUnderDiskPressureRunsOutOfImages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	imageManager := newDiskPressureTestImageManager(client, 95, 90)
+	addUnusedImageState(imageManager, "sha256:oldest", "oldest", time.Now().AddDate(0, -2, 0))
+	pinned := addUnusedImageState(imageManager, "sha256:pinned", "pinned", time.Now().AddDate(0, -3, 0))
+	pinned.SetPinned(true)
+
+	client.EXPECT().RemoveImage("oldest", removeImageTimeout).Return(nil)
+	imageManager.removeImagesUnderDiskPressure()
+	assert.Equal(t, []*image.ImageState{pinned}, imageManager.getAllImageStates())
+}
+
+func TestCheckDiskPressureTriggersCleanup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	// Usage is below the high watermark when the process starts
+	imageManager := newDiskPressureTestImageManager(client, 50, 90, 60)
+	addUnusedImageState(imageManager, "sha256:oldest", "oldest", time.Now().AddDate(0, -2, 0))
+	removed := make(chan struct{})
+	client.EXPECT().RemoveImage("oldest", removeImageTimeout).Do(func(string, time.Duration) {
+		close(removed)
+	}).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go imageManager.performPeriodicDiskPressureCheck(ctx, time.Hour)
+	imageManager.CheckDiskPressure()
+	select {
+	case <-removed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the disk pressure check to remove an image")
+	}
+}
+
+func TestDockerDataRootUsageRequiresDockerDataRoot(t *testing.T) {
+	dataRoot, err := ioutil.TempDir("", "docker-data-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataRoot)
+
+	// An empty directory, such as where a mount is missing, is refused
+	_, err = dockerDataRootUsage(dataRoot)
+	assert.Error(t, err)
+
+	if err := os.Mkdir(filepath.Join(dataRoot, "image"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := dockerDataRootUsage(dataRoot)
+	assert.NoError(t, err)
+	assert.True(t, usage >= 0 && usage <= 100, "usage should be a percentage, got %v", usage)
+}
+
+func TestGetCandidateImagesForDeletionSkipsExcludedImages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	cfg := defaultTestConfig()
+	cfg.ImageCleanupExclusionList = []string{"amazon/amazon-ecs-agent:latest", "myregistry/base:*"}
+	imageManager := NewImageManager(cfg, client, dockerstate.NewDockerTaskEngineState()).(*dockerImageManager)
+	oldTime := time.Now().AddDate(0, -2, 0)
+	addUnusedImageState(imageManager, "sha256:agent", "amazon/amazon-ecs-agent:latest", oldTime)
+	addUnusedImageState(imageManager, "sha256:base", "myregistry/base:2016.09", oldTime)
+	other := addUnusedImageState(imageManager, "sha256:other", "myregistry/other:2016.09", oldTime)
+	imageManager.considerAllImagesForDeletion()
+
+	assert.Equal(t, []*image.ImageState{other}, imageManager.getCandidateImagesForDeletion())
+}
+
+func TestSetImagePinned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	imageManager := NewImageManager(defaultTestConfig(), client, dockerstate.NewDockerTaskEngineState()).(*dockerImageManager)
+	imageManager.SetSaver(statemanager.NewNoopStateManager())
+	base := addUnusedImageState(imageManager, "sha256:base", "myregistry/base:latest", time.Now().AddDate(0, -2, 0))
+
+	imageState, ok := imageManager.SetImagePinned("myregistry/base:latest", true)
+	assert.True(t, ok)
+	assert.Equal(t, base, imageState)
+	assert.True(t, base.IsPinned())
+	imageManager.considerAllImagesForDeletion()
+	assert.Empty(t, imageManager.getCandidateImagesForDeletion())
+
+	_, ok = imageManager.SetImagePinned("sha256:base", false)
+	assert.True(t, ok)
+	assert.False(t, base.IsPinned())
+	assert.Equal(t, []*image.ImageState{base}, imageManager.getCandidateImagesForDeletion())
+
+	_, ok = imageManager.SetImagePinned("unknown:latest", true)
+	assert.False(t, ok)
+}
+
+func TestRecordContainerReferenceCountsNewContainers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	imageManager := &dockerImageManager{client: client, state: dockerstate.NewDockerTaskEngineState()}
+	imageManager.SetSaver(statemanager.NewNoopStateManager())
+
+	client.EXPECT().InspectImage("busybox").Return(&docker.Image{ID: "sha256:busybox"}, nil).Times(2)
+	assert.NoError(t, imageManager.RecordContainerReference(&api.Container{Name: "first", Image: "busybox"}))
+	assert.NoError(t, imageManager.RecordContainerReference(&api.Container{Name: "second", Image: "busybox"}))
+	// A container restored from the state file has already been counted
+	assert.NoError(t, imageManager.RecordContainerReference(&api.Container{Name: "first", Image: "busybox", ImageID: "sha256:busybox"}))
+
+	imageState, ok := imageManager.getImageState("sha256:busybox")
+	assert.True(t, ok)
+	assert.Equal(t, 2, imageState.GetUseCount())
+}
+
+func TestRecordContainerReferenceRecordsImageDigest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	imageManager := &dockerImageManager{client: client, state: dockerstate.NewDockerTaskEngineState()}
+	imageManager.SetSaver(statemanager.NewNoopStateManager())
+
+	repoDigests := []string{"mirror.example.com/busybox@sha256:mirror", "busybox@sha256:hub"}
+	client.EXPECT().InspectImage("busybox:latest").Return(&docker.Image{ID: "sha256:busybox", RepoDigests: repoDigests}, nil)
+	container := &api.Container{Name: "first", Image: "busybox:latest"}
+	assert.NoError(t, imageManager.RecordContainerReference(container))
+	assert.Equal(t, "sha256:hub", container.ImageDigest)
+
+	imageState, ok := imageManager.getImageState("sha256:busybox")
+	assert.True(t, ok)
+	assert.Equal(t, repoDigests, imageState.GetRepoDigests())
+}
+
+func TestRecordContainerReferenceWithExpectedImageDigest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	imageManager := &dockerImageManager{client: client, state: dockerstate.NewDockerTaskEngineState()}
+	imageManager.SetSaver(statemanager.NewNoopStateManager())
+
+	// The image is pulled by digest, so the tag may belong to another image
+	client.EXPECT().InspectImage("busybox@sha256:hub").Return(&docker.Image{ID: "sha256:busybox", RepoDigests: []string{"busybox@sha256:hub"}}, nil)
+	container := &api.Container{Name: "first", Image: "busybox:latest", ExpectedImageDigest: "sha256:hub"}
+	assert.NoError(t, imageManager.RecordContainerReference(container))
+	assert.Equal(t, "sha256:busybox", container.ImageID)
+	assert.Equal(t, "sha256:hub", container.ImageDigest)
+
+	imageState, ok := imageManager.getImageState("sha256:busybox")
+	if assert.True(t, ok) {
+		assert.Equal(t, []string{"busybox@sha256:hub"}, imageState.Image.Names)
+	}
+}
+
+func TestImageCleanupDryRun(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.ImageCleanupStrategy = dockerclient.ImageCleanupLargestFirstStrategy
+	cfg.NumImagesToDeletePerCycle = 2
+	imageManager := NewImageManager(cfg, nil, dockerstate.NewDockerTaskEngineState()).(*dockerImageManager)
+
+	small := addUnusedImageState(imageManager, "sha256:small", "small", time.Now())
+	small.Image.Size = 10
+	large := addUnusedImageState(imageManager, "sha256:large", "large", time.Now())
+	large.Image.Size = 30
+	medium := addUnusedImageState(imageManager, "sha256:medium", "medium", time.Now())
+	medium.Image.Size = 20
+	pinned := addUnusedImageState(imageManager, "sha256:pinned", "pinned", time.Now())
+	pinned.Image.Size = 40
+	pinned.SetPinned(true)
+	inUse := addUnusedImageState(imageManager, "sha256:inuse", "inuse", time.Now())
+	inUse.Image.Size = 50
+	inUse.UpdateContainerReference(&api.Container{Name: "running"})
+
+	plan := imageManager.ImageCleanupDryRun()
+	assert.Equal(t, dockerclient.ImageCleanupLargestFirstStrategy, plan.Strategy)
+	if assert.Len(t, plan.Images, 2) {
+		assert.Equal(t, large, plan.Images[0].ImageState)
+		assert.Equal(t, "unused and pulled more than 1h0m0s ago; largest image, 30 bytes", plan.Images[0].Reason)
+		assert.Equal(t, medium, plan.Images[1].ImageState)
+	}
+	// Nothing is deleted
+	assert.Len(t, imageManager.getAllImageStates(), 5)
+}
+
+func TestImageCleanupDryRunUnderDiskPressure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	// Under disk pressure every eligible image is listed, not just
+	// numImagesToDelete of them
+	imageManager := newDiskPressureTestImageManager(client, 90)
+	imageManager.numImagesToDelete = 1
+	older := addUnusedImageState(imageManager, "sha256:older", "older", time.Now().Add(-2*time.Hour))
+	newer := addUnusedImageState(imageManager, "sha256:newer", "newer", time.Now().Add(-time.Hour))
+
+	plan := imageManager.ImageCleanupDryRun()
+	if assert.Len(t, plan.Images, 2) {
+		assert.Equal(t, older, plan.Images[0].ImageState)
+		assert.Contains(t, plan.Images[0].Reason, "disk usage of /var/lib/docker is 90.0%, above the high watermark of 85%")
+		assert.Equal(t, newer, plan.Images[1].ImageState)
+	}
+	assert.Len(t, imageManager.getAllImageStates(), 2)
+}
+
+func TestImageCleanupDryRunListsUnmanagedResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	imageManager := &dockerImageManager{
+		client:                       client,
+		state:                        dockerstate.NewDockerTaskEngineState(),
+		evictionStrategy:             &leastRecentlyUsedStrategy{},
+		unmanagedCleanupEnabled:      true,
+		unmanagedContainerMinimumAge: time.Hour,
+	}
+	stray := exitedContainer("stray", "sha256:stray", 2*time.Hour)
+	running := exitedContainer("running", "sha256:running", 0)
+	running.State = docker.State{Running: true}
+
+	// Nothing is removed, and the image of the container that would be
+	// removed is listed as well
+	client.EXPECT().ListContainers(true, ListContainersTimeout).Return(ListContainersResponse{DockerIDs: []string{"stray-id", "running-id"}})
+	client.EXPECT().InspectContainer("stray-id", inspectContainerTimeout).Return(stray, nil)
+	client.EXPECT().InspectContainer("running-id", inspectContainerTimeout).Return(running, nil)
+	client.EXPECT().ListImages(ListImagesTimeout).Return(ListImagesResponse{Images: []docker.APIImages{
+		{ID: "sha256:stray", RepoTags: []string{danglingImageTag}},
+		{ID: "sha256:running", RepoTags: []string{danglingImageTag}},
+	}})
+
+	plan := imageManager.ImageCleanupDryRun()
+	assert.Empty(t, plan.Images)
+	if assert.Len(t, plan.UnmanagedContainers, 1) {
+		assert.Equal(t, "stray-id", plan.UnmanagedContainers[0].ID)
+		assert.Equal(t, []string{"stray"}, plan.UnmanagedContainers[0].Names)
+		assert.Equal(t, "exited 2h0m0s ago", plan.UnmanagedContainers[0].Reason)
+	}
+	if assert.Len(t, plan.UnmanagedImages, 1) {
+		assert.Equal(t, "sha256:stray", plan.UnmanagedImages[0].ID)
+		assert.Equal(t, "dangling image", plan.UnmanagedImages[0].Reason)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+	"github.com/cihub/seelog"
+	"golang.org/x/net/context"
+)
+
+// ImagePrewarmState is the stage a pre-warmed image has reached
+type ImagePrewarmState string
+
+const (
+	// ImagePrewarmPending means the image has not been pulled yet
+	ImagePrewarmPending ImagePrewarmState = "PENDING"
+	// ImagePrewarmPulling means the image is being pulled
+	ImagePrewarmPulling ImagePrewarmState = "PULLING"
+	// ImagePrewarmPulled means the most recent pull of the image succeeded
+	ImagePrewarmPulled ImagePrewarmState = "PULLED"
+	// ImagePrewarmFailed means the most recent pull of the image failed
+	ImagePrewarmFailed ImagePrewarmState = "FAILED"
+)
+
+// ecrImagePattern matches the names of images hosted in ECR, capturing the
+// registry ID and the region of the registry
+var ecrImagePattern = regexp.MustCompile(`^([0-9]{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?/`)
+
+// ImagePrewarmStatus describes the progress of pulling one of the images that
+// are pre-warmed on the instance
+type ImagePrewarmStatus struct {
+	Image         string
+	State         ImagePrewarmState
+	LastAttemptAt time.Time
+	LastPulledAt  time.Time
+	// Error is the reason the most recent pull failed
+	Error string
+}
+
+// StartImagePrewarmProcess pulls the pre-warmed images and then pulls them
+// again periodically, until the context is cancelled
+func (imageManager *dockerImageManager) StartImagePrewarmProcess(ctx context.Context) {
+	if len(imageManager.prewarmImages) == 0 {
+		return
+	}
+	imageManager.prewarmAllImages()
+	ticker := time.NewTicker(imageManager.prewarmInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			imageManager.prewarmAllImages()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ImagePrewarmStatus returns the progress of each pre-warmed image, in the
+// order they are configured
+func (imageManager *dockerImageManager) ImagePrewarmStatus() []ImagePrewarmStatus {
+	imageManager.prewarmLock.RLock()
+	defer imageManager.prewarmLock.RUnlock()
+	statuses := make([]ImagePrewarmStatus, 0, len(imageManager.prewarmImages))
+	for _, imageName := range imageManager.prewarmImages {
+		status, ok := imageManager.prewarmStatuses[imageName]
+		if !ok {
+			status = &ImagePrewarmStatus{Image: imageName, State: ImagePrewarmPending}
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses
+}
+
+func (imageManager *dockerImageManager) prewarmAllImages() {
+	for _, imageName := range imageManager.prewarmImages {
+		imageManager.prewarmImage(imageName)
+	}
+}
+
+func (imageManager *dockerImageManager) prewarmImage(imageName string) {
+	imageManager.updatePrewarmStatus(imageName, func(status *ImagePrewarmStatus) {
+		status.State = ImagePrewarmPulling
+		status.LastAttemptAt = time.Now()
+	})
+
+	err := imageManager.pullPinnedImage(imageName)
+	if err != nil {
+		seelog.Warnf("Error pre-warming image %s: %v", imageName, err)
+		imageManager.updatePrewarmStatus(imageName, func(status *ImagePrewarmStatus) {
+			status.State = ImagePrewarmFailed
+			status.Error = err.Error()
+		})
+		return
+	}
+	seelog.Infof("Pre-warmed image %s", imageName)
+	imageManager.updatePrewarmStatus(imageName, func(status *ImagePrewarmStatus) {
+		status.State = ImagePrewarmPulled
+		status.LastPulledAt = time.Now()
+		status.Error = ""
+	})
+}
+
+// pullPinnedImage pulls the image and records it as pinned so that it is
+// never removed by the image cleanup. An image is pinned once for each ID the
+// name resolves to, so that an unpin at runtime is kept until the name moves
+// to a new image
+func (imageManager *dockerImageManager) pullPinnedImage(imageName string) error {
+	// Containers that need the image while it is being pulled share the pull
+	authData := imageManager.prewarmRegistryAuth(imageName)
+	metadata, _ := imageManager.imagePulls.pull(context.Background(), imagePullKey(imageName, authData), func(ctx context.Context) DockerContainerMetadata {
+		return imageManager.client.PullImage(imageName, authData)
+	})
+	if metadata.Error != nil {
+		return metadata.Error
+	}
+
+	// The image is pinned under the lock, so that image cleanup can't delete
+	// it in between
+	ImagePullDeleteLock.Lock()
+	defer ImagePullDeleteLock.Unlock()
+	imageInspected, err := imageManager.client.InspectImage(imageName)
+	if err != nil {
+		return err
+	}
+
+	imageManager.updateLock.Lock()
+	var previousImageStates []*image.ImageState
+	for _, imageState := range imageManager.getAllImageStates() {
+		if imageState.Image.ImageID != imageInspected.ID && imageState.HasImageName(imageName) {
+			previousImageStates = append(previousImageStates, imageState)
+		}
+	}
+	imageManager.removeExistingImageNameOfDifferentID(imageName, imageInspected.ID)
+	imageManager.unpinImagesWithoutPrewarmNames(previousImageStates)
+	imageState, ok := imageManager.getImageState(imageInspected.ID)
+	if !ok {
+		imageState = &image.ImageState{
+			Image: &image.Image{
+				ImageID: imageInspected.ID,
+				Size:    imageInspected.Size,
+			},
+			PulledAt:   time.Now(),
+			LastUsedAt: time.Now(),
+		}
+		imageManager.addImageState(imageState)
+	}
+	imageState.AddImageName(imageName)
+	imageState.PinForPrewarm()
+	imageManager.updateLock.Unlock()
+
+	imageManager.state.AddImageState(imageState)
+	imageManager.saver.Save()
+	imageManager.CheckDiskPressure()
+	return nil
+}
+
+// prewarmRegistryAuth returns the registry credentials the image is pulled
+// with. Images hosted in ECR are pulled with ECR credentials if
+// ImagePrewarmECRAuth is set; otherwise the credentials come from the
+// instance's engine auth configuration.
+func (imageManager *dockerImageManager) prewarmRegistryAuth(imageName string) *api.RegistryAuthenticationData {
+	if !imageManager.prewarmECRAuth {
+		return nil
+	}
+	match := ecrImagePattern.FindStringSubmatch(imageName)
+	if match == nil {
+		return nil
+	}
+	return &api.RegistryAuthenticationData{
+		Type: "ecr",
+		ECRAuthData: &api.ECRAuthData{
+			RegistryId: match[1],
+			Region:     match[2],
+		},
+	}
+}
+
+// unpinImagesWithoutPrewarmNames releases the pin on images that the name of
+// a pre-warmed image has moved away from, unless they are still named by
+// another pre-warmed image, so that they can be cleaned up
+func (imageManager *dockerImageManager) unpinImagesWithoutPrewarmNames(imageStates []*image.ImageState) {
+	for _, imageState := range imageStates {
+		hasPrewarmName := false
+		for _, imageName := range imageManager.prewarmImages {
+			if imageState.HasImageName(imageName) {
+				hasPrewarmName = true
+				break
+			}
+		}
+		if !hasPrewarmName && imageState.ReleasePrewarmPin() {
+			seelog.Infof("Image %s is no longer pre-warmed, unpinned it", imageState.Image.ImageID)
+		}
+	}
+}
+
+func (imageManager *dockerImageManager) updatePrewarmStatus(imageName string, update func(*ImagePrewarmStatus)) {
+	imageManager.prewarmLock.Lock()
+	defer imageManager.prewarmLock.Unlock()
+	status, ok := imageManager.prewarmStatuses[imageName]
+	if !ok {
+		status = &ImagePrewarmStatus{Image: imageName}
+		imageManager.prewarmStatuses[imageName] = status
+	}
+	update(status)
+}
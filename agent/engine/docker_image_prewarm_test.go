@@ -0,0 +1,234 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+	"github.com/aws/amazon-ecs-agent/agent/statemanager"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func newPrewarmTestImageManager(t *testing.T, images ...string) (*gomock.Controller, *MockDockerClient, *dockerImageManager) {
+	ctrl := gomock.NewController(t)
+	client := NewMockDockerClient(ctrl)
+	cfg := defaultTestConfig()
+	cfg.ImagePrewarmList = images
+	imageManager := NewImageManager(cfg, client, dockerstate.NewDockerTaskEngineState()).(*dockerImageManager)
+	imageManager.SetSaver(statemanager.NewNoopStateManager())
+	imageManager.imagePulls = newImagePullGroup()
+	return ctrl, client, imageManager
+}
+
+func TestImagePrewarmPinsPulledImage(t *testing.T) {
+	ctrl, client, imageManager := newPrewarmTestImageManager(t, "nginx:latest", "busybox:latest")
+	defer ctrl.Finish()
+
+	assert.Equal(t, []ImagePrewarmStatus{
+		{Image: "nginx:latest", State: ImagePrewarmPending},
+		{Image: "busybox:latest", State: ImagePrewarmPending},
+	}, imageManager.ImagePrewarmStatus())
+
+	gomock.InOrder(
+		client.EXPECT().PullImage("nginx:latest", nil).Return(DockerContainerMetadata{}),
+		client.EXPECT().InspectImage("nginx:latest").Return(&docker.Image{ID: "sha256:nginx", Size: 100}, nil),
+		client.EXPECT().PullImage("busybox:latest", nil).Return(DockerContainerMetadata{Error: CannotXContainerError{"Pull", "not found"}}),
+	)
+	imageManager.prewarmAllImages()
+
+	imageState := imageManager.GetImageStateFromImageName("nginx:latest")
+	if assert.NotNil(t, imageState) {
+		assert.True(t, imageState.IsPinned())
+		assert.Equal(t, int64(100), imageState.Image.Size)
+	}
+	assert.Nil(t, imageManager.GetImageStateFromImageName("busybox:latest"))
+	assert.Len(t, imageManager.state.AllImageStates(), 1)
+
+	statuses := imageManager.ImagePrewarmStatus()
+	assert.Equal(t, ImagePrewarmPulled, statuses[0].State)
+	assert.False(t, statuses[0].LastPulledAt.IsZero())
+	assert.Equal(t, ImagePrewarmFailed, statuses[1].State)
+	assert.Equal(t, "not found", statuses[1].Error)
+	assert.True(t, statuses[1].LastPulledAt.IsZero())
+}
+
+func TestImagePrewarmUnpinsImageWhenNameMoves(t *testing.T) {
+	ctrl, client, imageManager := newPrewarmTestImageManager(t, "nginx:latest")
+	defer ctrl.Finish()
+
+	oldImageState := &image.ImageState{
+		Image:         &image.Image{ImageID: "sha256:old", Names: []string{"nginx:latest"}},
+		PulledAt:      time.Now().AddDate(0, -2, 0),
+		Pinned:        true,
+		PrewarmPinned: true,
+	}
+	imageManager.addImageState(oldImageState)
+	userPinnedImageState := &image.ImageState{
+		Image:  &image.Image{ImageID: "sha256:base", Names: []string{"base:latest"}},
+		Pinned: true,
+	}
+	imageManager.addImageState(userPinnedImageState)
+
+	client.EXPECT().PullImage("nginx:latest", nil).Return(DockerContainerMetadata{})
+	client.EXPECT().InspectImage("nginx:latest").Return(&docker.Image{ID: "sha256:new"}, nil)
+	imageManager.prewarmAllImages()
+
+	assert.False(t, oldImageState.IsPinned(), "image no longer named by the prewarm list should be unpinned")
+	assert.Empty(t, oldImageState.Image.Names)
+	assert.True(t, userPinnedImageState.IsPinned(), "images pinned for other reasons should stay pinned")
+	newImageState := imageManager.GetImageStateFromImageName("nginx:latest")
+	if assert.NotNil(t, newImageState) {
+		assert.Equal(t, "sha256:new", newImageState.Image.ImageID)
+		assert.True(t, newImageState.IsPinned())
+	}
+}
+
+func TestImagePrewarmKeepsRuntimeUnpin(t *testing.T) {
+	ctrl, client, imageManager := newPrewarmTestImageManager(t, "nginx:latest")
+	defer ctrl.Finish()
+
+	client.EXPECT().PullImage("nginx:latest", nil).Return(DockerContainerMetadata{}).Times(3)
+	gomock.InOrder(
+		client.EXPECT().InspectImage("nginx:latest").Return(&docker.Image{ID: "sha256:nginx"}, nil).Times(2),
+		client.EXPECT().InspectImage("nginx:latest").Return(&docker.Image{ID: "sha256:new"}, nil),
+	)
+	imageManager.prewarmAllImages()
+	_, ok := imageManager.SetImagePinned("nginx:latest", false)
+	assert.True(t, ok)
+
+	imageManager.prewarmAllImages()
+	imageState := imageManager.GetImageStateFromImageName("nginx:latest")
+	if assert.NotNil(t, imageState) {
+		assert.False(t, imageState.IsPinned(), "an unpin at runtime should survive the next pre-warm")
+	}
+
+	imageManager.prewarmAllImages()
+	imageState = imageManager.GetImageStateFromImageName("nginx:latest")
+	if assert.NotNil(t, imageState) {
+		assert.Equal(t, "sha256:new", imageState.Image.ImageID)
+		assert.True(t, imageState.IsPinned(), "a new image for the name should be pinned")
+	}
+}
+
+func TestImagePrewarmKeepsRuntimeUnpinAcrossRestart(t *testing.T) {
+	ctrl, client, imageManager := newPrewarmTestImageManager(t, "nginx:latest")
+	defer ctrl.Finish()
+
+	// The image was unpinned at runtime before the agent restarted
+	imageManager.AddAllImageStates([]*image.ImageState{{
+		Image:         &image.Image{ImageID: "sha256:nginx", Names: []string{"nginx:latest"}},
+		PrewarmPinned: true,
+	}})
+
+	client.EXPECT().PullImage("nginx:latest", nil).Return(DockerContainerMetadata{})
+	client.EXPECT().InspectImage("nginx:latest").Return(&docker.Image{ID: "sha256:nginx"}, nil)
+	imageManager.prewarmAllImages()
+
+	imageState := imageManager.GetImageStateFromImageName("nginx:latest")
+	if assert.NotNil(t, imageState) {
+		assert.False(t, imageState.IsPinned(), "an unpin at runtime should survive a restart")
+	}
+}
+
+func TestImagePrewarmPullsECRImagesWithECRAuth(t *testing.T) {
+	const ecrImage = "123456789012.dkr.ecr.us-west-2.amazonaws.com/app:latest"
+	ctrl, client, imageManager := newPrewarmTestImageManager(t, ecrImage, "nginx:latest")
+	defer ctrl.Finish()
+	imageManager.prewarmECRAuth = true
+
+	ecrAuth := &api.RegistryAuthenticationData{
+		Type: "ecr",
+		ECRAuthData: &api.ECRAuthData{
+			RegistryId: "123456789012",
+			Region:     "us-west-2",
+		},
+	}
+	gomock.InOrder(
+		client.EXPECT().PullImage(ecrImage, ecrAuth).Return(DockerContainerMetadata{}),
+		client.EXPECT().InspectImage(ecrImage).Return(&docker.Image{ID: "sha256:app"}, nil),
+		client.EXPECT().PullImage("nginx:latest", nil).Return(DockerContainerMetadata{}),
+		client.EXPECT().InspectImage("nginx:latest").Return(&docker.Image{ID: "sha256:nginx"}, nil),
+	)
+	imageManager.prewarmAllImages()
+}
+
+func TestImagePrewarmJoinsPullInProgress(t *testing.T) {
+	ctrl, client, imageManager := newPrewarmTestImageManager(t, "nginx:latest")
+	defer ctrl.Finish()
+
+	// A container is pulling the image already
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go imageManager.imagePulls.pull(context.Background(), "nginx:latest", func(ctx context.Context) DockerContainerMetadata {
+		close(started)
+		<-release
+		return DockerContainerMetadata{}
+	})
+	<-started
+
+	// No pull is expected for the pre-warm
+	client.EXPECT().InspectImage("nginx:latest").Return(&docker.Image{ID: "sha256:nginx"}, nil)
+	pinned := make(chan error)
+	go func() { pinned <- imageManager.pullPinnedImage("nginx:latest") }()
+	for joined := false; !joined; {
+		imageManager.imagePulls.lock.Lock()
+		joined = imageManager.imagePulls.pulls["nginx:latest"].waiters == 2
+		imageManager.imagePulls.lock.Unlock()
+	}
+	close(release)
+
+	assert.NoError(t, <-pinned)
+	imageState := imageManager.GetImageStateFromImageName("nginx:latest")
+	if assert.NotNil(t, imageState) {
+		assert.True(t, imageState.IsPinned())
+	}
+}
+
+func TestGetCandidateImagesForDeletionSkipsPinnedImage(t *testing.T) {
+	ctrl, _, imageManager := newPrewarmTestImageManager(t)
+	defer ctrl.Finish()
+
+	pinnedImageState := &image.ImageState{
+		Image:    &image.Image{ImageID: "sha256:pinned", Names: []string{"nginx:latest"}},
+		PulledAt: time.Now().AddDate(0, -2, 0),
+		Pinned:   true,
+	}
+	unpinnedImageState := &image.ImageState{
+		Image:    &image.Image{ImageID: "sha256:unpinned", Names: []string{"busybox:latest"}},
+		PulledAt: time.Now().AddDate(0, -2, 0),
+	}
+	imageManager.imageStatesConsideredForDeletion = map[string]*image.ImageState{
+		pinnedImageState.Image.ImageID:   pinnedImageState,
+		unpinnedImageState.Image.ImageID: unpinnedImageState,
+	}
+
+	assert.Equal(t, []*image.ImageState{unpinnedImageState}, imageManager.getCandidateImagesForDeletion())
+}
+
+func TestStartImagePrewarmProcessWithoutImages(t *testing.T) {
+	ctrl, _, imageManager := newPrewarmTestImageManager(t)
+	defer ctrl.Finish()
+
+	// Returns straight away without pulling anything
+	imageManager.StartImagePrewarmProcess(context.Background())
+	assert.Empty(t, imageManager.ImagePrewarmStatus())
+}
@@ -0,0 +1,116 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+)
+
+// DockerOperationStats summarizes the calls made to the Docker daemon for one
+// type of operation. Time spent waiting for a free slot is reported
+// separately from time spent in the call itself.
+type DockerOperationStats struct {
+	Operation dockerclient.DockerOperation
+	// Limit is the maximum number of concurrent calls; zero means unlimited
+	Limit    int
+	InFlight int
+	Queued   int
+	// Calls is the number of calls that have completed
+	Calls             int
+	TotalQueueWait    time.Duration
+	MaxQueueWait      time.Duration
+	TotalCallDuration time.Duration
+	MaxCallDuration   time.Duration
+}
+
+// dockerOperationLimiter bounds the number of concurrent calls made to the
+// Docker daemon for each type of operation, so that a burst of task placements
+// does not overwhelm the daemon
+type dockerOperationLimiter struct {
+	slots map[dockerclient.DockerOperation]chan struct{}
+
+	statsLock sync.Mutex
+	stats     map[dockerclient.DockerOperation]*DockerOperationStats
+}
+
+func newDockerOperationLimiter(limits map[dockerclient.DockerOperation]int) *dockerOperationLimiter {
+	limiter := &dockerOperationLimiter{
+		slots: make(map[dockerclient.DockerOperation]chan struct{}),
+		stats: make(map[dockerclient.DockerOperation]*DockerOperationStats),
+	}
+	for _, op := range dockerclient.DockerOperations {
+		limit := limits[op]
+		if limit > 0 {
+			limiter.slots[op] = make(chan struct{}, limit)
+		}
+		limiter.stats[op] = &DockerOperationStats{Operation: op, Limit: limit}
+	}
+	return limiter
+}
+
+// begin blocks until the operation may be performed and returns a function
+// that must be called once the call to the daemon has returned
+func (limiter *dockerOperationLimiter) begin(op dockerclient.DockerOperation) func() {
+	stats := limiter.stats[op]
+	limiter.statsLock.Lock()
+	stats.Queued++
+	limiter.statsLock.Unlock()
+
+	queuedAt := time.Now()
+	slot := limiter.slots[op]
+	if slot != nil {
+		slot <- struct{}{}
+	}
+	startedAt := time.Now()
+	queueWait := startedAt.Sub(queuedAt)
+
+	limiter.statsLock.Lock()
+	stats.Queued--
+	stats.InFlight++
+	limiter.statsLock.Unlock()
+
+	return func() {
+		callDuration := time.Since(startedAt)
+		if slot != nil {
+			<-slot
+		}
+		limiter.statsLock.Lock()
+		stats.InFlight--
+		stats.Calls++
+		stats.TotalQueueWait += queueWait
+		if queueWait > stats.MaxQueueWait {
+			stats.MaxQueueWait = queueWait
+		}
+		stats.TotalCallDuration += callDuration
+		if callDuration > stats.MaxCallDuration {
+			stats.MaxCallDuration = callDuration
+		}
+		limiter.statsLock.Unlock()
+		log.Debug("Docker operation completed", "operation", op, "queueWait", queueWait, "callDuration", callDuration)
+	}
+}
+
+// Stats returns a snapshot of the statistics of every operation
+func (limiter *dockerOperationLimiter) Stats() []DockerOperationStats {
+	limiter.statsLock.Lock()
+	defer limiter.statsLock.Unlock()
+	stats := make([]DockerOperationStats, 0, len(dockerclient.DockerOperations))
+	for _, op := range dockerclient.DockerOperations {
+		stats = append(stats, *limiter.stats[op])
+	}
+	return stats
+}
@@ -0,0 +1,80 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func operationStats(limiter *dockerOperationLimiter, op dockerclient.DockerOperation) DockerOperationStats {
+	for _, stats := range limiter.Stats() {
+		if stats.Operation == op {
+			return stats
+		}
+	}
+	return DockerOperationStats{}
+}
+
+func TestDockerOperationLimiterQueuesBeyondLimit(t *testing.T) {
+	limiter := newDockerOperationLimiter(map[dockerclient.DockerOperation]int{dockerclient.PullOperation: 1})
+
+	donePull := limiter.begin(dockerclient.PullOperation)
+	// Other operations are not held up by pulls
+	limiter.begin(dockerclient.CreateOperation)()
+
+	started := make(chan func())
+	go func() { started <- limiter.begin(dockerclient.PullOperation) }()
+
+	select {
+	case <-started:
+		t.Fatal("Second pull should wait for the first to complete")
+	case <-time.After(10 * time.Millisecond):
+	}
+	stats := operationStats(limiter, dockerclient.PullOperation)
+	assert.Equal(t, 1, stats.Limit)
+	assert.Equal(t, 1, stats.InFlight)
+	assert.Equal(t, 1, stats.Queued)
+
+	donePull()
+	(<-started)()
+
+	stats = operationStats(limiter, dockerclient.PullOperation)
+	assert.Equal(t, 0, stats.InFlight)
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, 2, stats.Calls)
+	assert.True(t, stats.MaxQueueWait >= 10*time.Millisecond, "queue wait should be recorded")
+	assert.True(t, stats.TotalCallDuration >= 10*time.Millisecond, "call duration should be recorded")
+	assert.Equal(t, 1, operationStats(limiter, dockerclient.CreateOperation).Calls)
+}
+
+func TestDockerOperationLimiterUnlimited(t *testing.T) {
+	limiter := newDockerOperationLimiter(nil)
+
+	var done []func()
+	for i := 0; i < 10; i++ {
+		done = append(done, limiter.begin(dockerclient.StartOperation))
+	}
+	stats := operationStats(limiter, dockerclient.StartOperation)
+	assert.Equal(t, 0, stats.Limit)
+	assert.Equal(t, 10, stats.InFlight)
+	for _, fn := range done {
+		fn()
+	}
+	assert.Len(t, limiter.Stats(), len(dockerclient.DockerOperations))
+}
@@ -16,6 +16,7 @@ package engine
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -26,7 +27,9 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/credentials"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
 	"github.com/aws/amazon-ecs-agent/agent/eventstream"
+	"github.com/aws/amazon-ecs-agent/agent/secrets"
 	"github.com/aws/amazon-ecs-agent/agent/statemanager"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
 	utilsync "github.com/aws/amazon-ecs-agent/agent/utils/sync"
@@ -42,6 +45,8 @@ const (
 	capabilityPrefix             = "com.amazonaws.ecs.capability."
 	capabilityTaskIAMRole        = "task-iam-role"
 	capabilityTaskIAMRoleNetHost = "task-iam-role-network-host"
+	capabilityContainerHealth    = "container-health-check"
+	capabilityDockerVolumes      = "docker-volumes"
 	labelPrefix                  = "com.amazonaws.ecs."
 )
 
@@ -85,9 +90,31 @@ type DockerTaskEngine struct {
 	processTasks sync.RWMutex
 
 	credentialsManager credentials.Manager
+	imagePulls         *imagePullGroup
 	_time              ttime.Time
 	_timeOnce          sync.Once
 	imageManager       ImageManager
+	admission          *taskAdmission
+
+	// containerDefaults are the instance-wide settings of containers
+	containerDefaults *api.ContainerDefaults
+
+	// secretProvider resolves the secrets of containers, unless it could not
+	// be created, in which case secretProviderErr explains why and no
+	// container with secrets is created
+	secretProvider    secrets.SecretProvider
+	secretProviderErr error
+
+	environmentFiles *environmentFiles
+
+	// containerInstanceArn is written in the metadata files of containers
+	containerInstanceArn string
+
+	// dockerVolumeLocks serialize the creation of each Docker volume, so that
+	// containers sharing a volume do not both try to create it
+	dockerVolumeLocks *dockerVolumeLocks
+	// emptyVolumeLock serializes the mounting of size limited empty volumes
+	emptyVolumeLock sync.Mutex
 }
 
 // NewDockerTaskEngine returns a created, but uninitialized, DockerTaskEngine.
@@ -108,9 +135,18 @@ func NewDockerTaskEngine(cfg *config.Config, client DockerClient, credentialsMan
 		taskEvents:      make(chan api.TaskStateChange),
 
 		credentialsManager: credentialsManager,
+		imagePulls:         sharedImagePulls,
+		dockerVolumeLocks:  newDockerVolumeLocks(),
 
 		containerChangeEventStream: containerChangeEventStream,
 		imageManager:               imageManager,
+		admission:                  newTaskAdmission(cfg, state),
+		environmentFiles:           newEnvironmentFiles(cfg.EnvironmentFilesRoot),
+		containerDefaults:          newContainerDefaults(cfg),
+	}
+	dockerTaskEngine.secretProvider, dockerTaskEngine.secretProviderErr = secrets.NewSecretProvider(cfg.SecretsFileRoot, cfg.SecretsHTTPEndpoint)
+	if dockerTaskEngine.secretProviderErr != nil {
+		seelog.Errorf("Unable to create the secret providers, no container with secrets will be created: %v", dockerTaskEngine.secretProviderErr)
 	}
 
 	return dockerTaskEngine
@@ -215,6 +251,7 @@ func (engine *DockerTaskEngine) synchronizeState() {
 	for _, task := range tasks {
 		conts, ok := engine.state.ContainerMapByArn(task.Arn)
 		if !ok {
+			engine.initializeEmptyVolumes(task)
 			engine.startTask(task)
 			continue
 		}
@@ -249,6 +286,7 @@ func (engine *DockerTaskEngine) synchronizeState() {
 				}
 			}
 		}
+		engine.initializeEmptyVolumes(task)
 		engine.startTask(task)
 	}
 	engine.saver.Save()
@@ -296,6 +334,10 @@ func (engine *DockerTaskEngine) sweepTask(task *api.Task) {
 			seelog.Errorf("Error removing container reference from image state: %v", err)
 		}
 	}
+	engine.removeDockerVolumes(task)
+	engine.removeEmptyVolumes(task)
+	engine.environmentFiles.remove(task)
+	engine.removeContainerMetadataFiles(task)
 	engine.saver.Save()
 }
 
@@ -352,7 +394,10 @@ func (engine *DockerTaskEngine) emitContainerEvent(task *api.Task, cont *api.Con
 	if cont.IsInternal {
 		return
 	}
-	if cont.SentStatus >= contKnownStatus {
+	healthStatus := cont.GetHealthStatus().Status
+	restartCount := cont.GetRestartCount()
+	changed := healthStatus != cont.GetSentHealthStatus() || restartCount != cont.GetSentRestartCount()
+	if cont.SentStatus >= contKnownStatus && !changed {
 		log.Debug("Already sent container event; no need to re-send", "task", task.Arn, "container", cont.Name, "event", contKnownStatus.String())
 		return
 	}
@@ -360,15 +405,47 @@ func (engine *DockerTaskEngine) emitContainerEvent(task *api.Task, cont *api.Con
 	if reason == "" && cont.ApplyingError != nil {
 		reason = cont.ApplyingError.Error()
 	}
+	if cont.ImageDigest != "" {
+		digestReason := "Image digest " + cont.ImageDigest
+		if reason == "" {
+			reason = digestReason
+		} else {
+			reason = reason + "; " + digestReason
+		}
+	}
+	if healthStatus != api.ContainerHealthUnknown {
+		// The backend has no field for the health of a container
+		healthReason := "Container health " + healthStatus.String()
+		if reason == "" {
+			reason = healthReason
+		} else {
+			reason = reason + "; " + healthReason
+		}
+	}
+	if restartCount > 0 {
+		restartReason := fmt.Sprintf("Container restarted %d times", restartCount)
+		if reason == "" {
+			reason = restartReason
+		} else {
+			reason = reason + "; " + restartReason
+		}
+	}
 	event := api.ContainerStateChange{
 		TaskArn:       task.Arn,
 		ContainerName: cont.Name,
 		Status:        contKnownStatus,
 		ExitCode:      cont.KnownExitCode,
-		PortBindings:  cont.KnownPortBindings,
+		PortBindings:  cont.GetKnownPortBindings(),
 		Reason:        reason,
-		SentStatus:    &cont.SentStatus,
+		HealthStatus:  healthStatus,
 	}
+	// A change in health or a restart alone is sent without a SentStatus, so
+	// that it is not dropped as a redundant event
+	if cont.SentStatus < contKnownStatus {
+		event.SentStatus = &cont.SentStatus
+	}
+	cont.SetSentHealthStatus(healthStatus)
+	cont.SetSentRestartCount(restartCount)
 	log.Debug("Container change event", "event", event)
 	engine.containerEvents <- event
 	log.Debug("Container change event passed on", "event", event)
@@ -434,12 +511,19 @@ func (engine *DockerTaskEngine) TaskEvents() (<-chan api.TaskStateChange, <-chan
 // AddTask starts tracking a task
 func (engine *DockerTaskEngine) AddTask(task *api.Task) error {
 	task.PostUnmarshalTask(engine.credentialsManager)
+	engine.initializeEmptyVolumes(task)
 
 	engine.processTasks.Lock()
 	defer engine.processTasks.Unlock()
 
 	existingTask, exists := engine.state.TaskByArn(task.Arn)
 	if !exists {
+		if engine.cfg.TaskAdmissionControlEnabled && !task.GetDesiredStatus().Terminal() {
+			if err := engine.admission.admit(task); err != nil {
+				seelog.Warnf("Task %s does not fit on the instance and will not be started: %v", task.Arn, err)
+				rejectTask(task, err)
+			}
+		}
 		engine.state.AddTask(task)
 		engine.startTask(task)
 	} else {
@@ -466,31 +550,166 @@ func (engine *DockerTaskEngine) GetTaskByArn(arn string) (*api.Task, bool) {
 }
 
 func (engine *DockerTaskEngine) pullContainer(task *api.Task, container *api.Container) DockerContainerMetadata {
+	behavior, err := engine.imagePullBehavior(container)
+	if err != nil {
+		return DockerContainerMetadata{Error: CannotXContainerError{"Pull", err.Error()}}
+	}
+	imageRef := container.ImageReference()
+	if engine.useCachedImage(container, behavior) {
+		seelog.Infof("Using image %s already on the instance for container %s of task %s, pull behavior: %s", imageRef, container.Name, task.Arn, behavior)
+		return DockerContainerMetadata{}
+	}
+
 	log.Info("Pulling container", "task", task, "container", container)
-	seelog.Debugf("Attempting to obtain ImagePullDeleteLock to pull image - %s", container.Image)
 
-	ImagePullDeleteLock.Lock()
-	seelog.Debugf("Obtained ImagePullDeleteLock to pull image - %s", container.Image)
-	defer seelog.Debugf("Released ImagePullDeleteLock after pulling image - %s", container.Image)
-	defer ImagePullDeleteLock.Unlock()
+	// Containers of other tasks that need the same image while it is being
+	// pulled share the pull. The pull is abandoned if every task waiting for
+	// it is stopped before it begins.
+	key := imagePullKey(imageRef, container.RegistryAuthentication)
+	pulledForContainer := false
+	var recordErr error
+	metadata, ok := engine.imagePulls.pull(engine.taskStopping(task), key, func(ctx context.Context) DockerContainerMetadata {
+		seelog.Debugf("Attempting to obtain ImagePullDeleteLock to pull image - %s", imageRef)
+		ImagePullDeleteLock.Lock()
+		seelog.Debugf("Obtained ImagePullDeleteLock to pull image - %s", imageRef)
+		defer seelog.Debugf("Released ImagePullDeleteLock after pulling image - %s", imageRef)
+		defer ImagePullDeleteLock.Unlock()
+
+		// If a pull is blocked here for some time, and before it starts pulling
+		// the image every task waiting for it is stopped, then don't pull it
+		if ctx.Err() != nil {
+			return DockerContainerMetadata{Error: TaskStoppedBeforePullBeginError{task.Arn}}
+		}
+		metadata := engine.client.PullImage(imageRef, container.RegistryAuthentication)
+		// The container that started the pull is recorded as using the image
+		// before the lock is released, so that image cleanup can't delete
+		// the image in between
+		pulledForContainer = true
+		recordErr = engine.recordContainerReference(container)
+		return metadata
+	})
+	if !ok {
+		seelog.Infof("Task desired status is stopped, no longer waiting to pull container: %v, task %v", container, task)
+		container.SetDesiredStatus(api.ContainerStopped)
+		return DockerContainerMetadata{Error: TaskStoppedDuringPullError{task.Arn}}
+	}
+	if !pulledForContainer {
+		// The image may have been deleted since the pull this container
+		// joined completed, so it is checked again under the lock
+		ImagePullDeleteLock.Lock()
+		_, err := engine.client.InspectImage(imageRef)
+		if err == nil {
+			recordErr = engine.recordContainerReference(container)
+		}
+		ImagePullDeleteLock.Unlock()
+		if err != nil {
+			if metadata.Error != nil {
+				return metadata
+			}
+			return DockerContainerMetadata{Error: CannotXContainerError{"Pull", "image " + imageRef + " is no longer on the instance: " + err.Error()}}
+		}
+	}
 
-	// If a task is blocked here for some time, and before it starts pulling image,
-	// the task's desired status is set to stopped, then don't pull the image
-	if task.GetDesiredStatus() == api.TaskStopped {
-		seelog.Infof("Task desired status is stopped, skip pull container: %v, task %v", container, task)
+	if metadata.Error == nil {
+		// The new image may have pushed the disk over the high watermark
+		engine.imageManager.CheckDiskPressure()
+	}
+	if recordErr == nil && container.ExpectedImageDigest != "" && !imageDigestMatches(container.ExpectedImageDigest, container.ImageDigest) {
+		seelog.Errorf("Image %s of container %s in task %s has digest %q, expected %q; stopping the container",
+			container.Image, container.Name, task.Arn, container.ImageDigest, container.ExpectedImageDigest)
 		container.SetDesiredStatus(api.ContainerStopped)
-		return DockerContainerMetadata{Error: TaskStoppedBeforePullBeginError{task.Arn}}
+		return DockerContainerMetadata{Error: ImageDigestMismatchError{
+			image:    container.Image,
+			expected: container.ExpectedImageDigest,
+			actual:   container.ImageDigest,
+		}}
 	}
+	return metadata
+}
 
-	metadata := engine.client.PullImage(container.Image, container.RegistryAuthentication)
+// recordContainerReference records the container as using its image, which
+// keeps image cleanup from deleting the image. It must be called with
+// ImagePullDeleteLock held.
+func (engine *DockerTaskEngine) recordContainerReference(container *api.Container) error {
 	err := engine.imageManager.RecordContainerReference(container)
 	if err != nil {
 		seelog.Errorf("Error adding container reference to image state: %v", err)
 	}
-	imageState := engine.imageManager.GetImageStateFromImageName(container.Image)
+	imageState := engine.imageManager.GetImageStateFromImageName(container.ImageReference())
 	engine.state.AddImageState(imageState)
 	engine.saver.Save()
-	return metadata
+	return err
+}
+
+// imagePullBehavior returns the pull behavior that applies to the container,
+// which is its own if set and the instance's otherwise
+func (engine *DockerTaskEngine) imagePullBehavior(container *api.Container) (dockerclient.ImagePullBehavior, error) {
+	if container.ImagePullBehavior != "" {
+		behavior := dockerclient.ImagePullBehavior(container.ImagePullBehavior)
+		if !behavior.IsValid() {
+			return "", fmt.Errorf("Invalid image pull behavior: %s", container.ImagePullBehavior)
+		}
+		return behavior, nil
+	}
+	if engine.cfg.ImagePullBehavior == "" {
+		return dockerclient.ImagePullAlwaysBehavior, nil
+	}
+	return engine.cfg.ImagePullBehavior, nil
+}
+
+// useCachedImage returns true if the pull behavior allows the container to use
+// an image that is already on the instance and one was found. The container is
+// then recorded as using that image, just as it would be after a pull.
+func (engine *DockerTaskEngine) useCachedImage(container *api.Container, behavior dockerclient.ImagePullBehavior) bool {
+	switch behavior {
+	case dockerclient.ImagePullOnceBehavior:
+		// Ask Docker rather than the image manager, whose records do not
+		// cover images pulled before its state was saved
+		if _, err := engine.client.InspectImage(container.ImageReference()); err != nil {
+			return false
+		}
+	case dockerclient.ImagePullPreferCachedBehavior:
+	default:
+		return false
+	}
+
+	ImagePullDeleteLock.Lock()
+	defer ImagePullDeleteLock.Unlock()
+	if container.ExpectedImageDigest != "" && !engine.cachedImageHasDigest(container) {
+		seelog.Infof("Image %s on the instance does not have digest %s, pulling it", container.ImageReference(), container.ExpectedImageDigest)
+		return false
+	}
+	// Recording the reference inspects the image, which fails if it is no
+	// longer on the instance
+	err := engine.imageManager.RecordContainerReference(container)
+	if err != nil {
+		seelog.Infof("Image %s not found on the instance, pulling it: %v", container.ImageReference(), err)
+		return false
+	}
+	imageState := engine.imageManager.GetImageStateFromImageName(container.ImageReference())
+	engine.state.AddImageState(imageState)
+	engine.saver.Save()
+	return true
+}
+
+// cachedImageHasDigest returns true if the image on the instance has the
+// digest the container is pinned to
+func (engine *DockerTaskEngine) cachedImageHasDigest(container *api.Container) bool {
+	imageInspected, err := engine.client.InspectImage(container.ImageReference())
+	if err != nil {
+		return false
+	}
+	return imageDigestMatches(container.ExpectedImageDigest, repoDigestForImage(container.ImageReference(), imageInspected.RepoDigests))
+}
+
+// taskStopping returns a context that is done once the task is meant to stop
+func (engine *DockerTaskEngine) taskStopping(task *api.Task) context.Context {
+	engine.processTasks.RLock()
+	defer engine.processTasks.RUnlock()
+	if mtask, ok := engine.managedTasks[task.Arn]; ok {
+		return mtask.stopping
+	}
+	return context.Background()
 }
 
 func (engine *DockerTaskEngine) createContainer(task *api.Task, container *api.Container) DockerContainerMetadata {
@@ -509,7 +728,57 @@ func (engine *DockerTaskEngine) createContainer(task *api.Task, container *api.C
 		containerMap = make(map[string]*api.DockerContainer)
 	}
 
-	hostConfig, hcerr := task.DockerHostConfig(container, containerMap)
+	if container.HealthCheck != nil {
+		if err := container.HealthCheck.Validate(); err != nil {
+			return DockerContainerMetadata{Error: CannotXContainerError{"Create", "Invalid health check: " + err.Error()}}
+		}
+	}
+	if container.StopSignal != "" {
+		if _, err := dockerclient.ParseSignal(container.StopSignal); err != nil {
+			return DockerContainerMetadata{Error: CannotXContainerError{"Create", "Invalid stop signal: " + err.Error()}}
+		}
+	}
+	if container.RestartPolicy != nil {
+		if err := container.RestartPolicy.Validate(); err != nil {
+			return DockerContainerMetadata{Error: CannotXContainerError{"Create", "Invalid restart policy: " + err.Error()}}
+		}
+	}
+	var fileEnv map[string]string
+	if len(container.EnvironmentFiles) > 0 {
+		var err error
+		fileEnv, err = engine.environmentFiles.environment(task, container)
+		if err != nil {
+			return DockerContainerMetadata{Error: CannotXContainerError{"Create", "Invalid environment file " + err.Error()}}
+		}
+	}
+	// The container defaults go through the same version check as the Linux
+	// parameters. A host config that cannot be decoded is reported when it is
+	// resolved below.
+	defaultParameters, _ := container.DefaultParameters(engine.containerDefaults)
+	if container.LinuxParameters != nil || len(defaultParameters) > 0 {
+		version, err := engine.checkLinuxParameters(container, defaultParameters)
+		if err != nil {
+			return DockerContainerMetadata{Error: CannotXContainerError{"Create", "Invalid Linux parameters: " + err.Error()}}
+		}
+		// The requested API version, which defaults to the oldest supported
+		// one, is raised if it is too old for the parameters in use
+		requested := dockerclient.Version_1_17
+		if container.DockerConfig.Version != nil {
+			requested = dockerclient.DockerVersion(*container.DockerConfig.Version)
+		}
+		if !requested.AtLeast(version) {
+			client = engine.client.WithVersion(version)
+		}
+	}
+
+	if err := engine.createEmptyVolumes(task, container); err != nil {
+		return DockerContainerMetadata{Error: err}
+	}
+	if err := engine.createDockerVolumes(task, container); err != nil {
+		return DockerContainerMetadata{Error: err}
+	}
+
+	hostConfig, hcerr := task.DockerHostConfig(container, containerMap, engine.containerDefaults)
 	if hcerr != nil {
 		return DockerContainerMetadata{Error: api.NamedError(hcerr)}
 	}
@@ -518,15 +787,27 @@ func (engine *DockerTaskEngine) createContainer(task *api.Task, container *api.C
 	if err != nil {
 		return DockerContainerMetadata{Error: api.NamedError(err)}
 	}
+	// Secrets take precedence over the container's environment, which takes
+	// precedence over its environment files
+	if len(fileEnv) > 0 {
+		config.Env = withEnvironmentFiles(config.Env, fileEnv)
+	}
+	if len(container.Secrets) > 0 {
+		// The values are only ever held in the Docker config, which is
+		// neither saved nor logged
+		values, err := engine.resolveSecrets(container)
+		if err != nil {
+			return DockerContainerMetadata{Error: CannotXContainerError{"Create", "Invalid secrets: " + err.Error()}}
+		}
+		config.Env = withSecrets(config.Env, values)
+	}
 
 	// Augment labels with some metadata from the agent. Explicitly do this last
 	// such that it will always override duplicates in the provided raw config
 	// data.
-	config.Labels[labelPrefix+"task-arn"] = task.Arn
-	config.Labels[labelPrefix+"container-name"] = container.Name
-	config.Labels[labelPrefix+"task-definition-family"] = task.Family
-	config.Labels[labelPrefix+"task-definition-version"] = task.Version
-	config.Labels[labelPrefix+"cluster"] = engine.cfg.Cluster
+	for key, value := range agentLabels(task, container, engine.cfg.Cluster) {
+		config.Labels[key] = value
+	}
 
 	name := ""
 	for i := 0; i < len(container.Name); i++ {
@@ -539,6 +820,18 @@ func (engine *DockerTaskEngine) createContainer(task *api.Task, container *api.C
 
 	containerName := "ecs-" + task.Family + "-" + task.Version + "-" + name + "-" + utils.RandHex()
 
+	if engine.cfg.ContainerMetadataEnabled {
+		if containerMetadataDirInUse(config, hostConfig) {
+			return DockerContainerMetadata{Error: CannotXContainerError{"Create", "Unable to mount the metadata file: " + containerMetadataContainerDir + " is already a mount point of the container"}}
+		}
+		bind, env, err := engine.createContainerMetadataFile(task, container, containerName)
+		if err != nil {
+			return DockerContainerMetadata{Error: CannotXContainerError{"Create", "Unable to write metadata file: " + err.Error()}}
+		}
+		hostConfig.Binds = append(hostConfig.Binds, bind)
+		config.Env = append(config.Env, env)
+	}
+
 	// Pre-add the container in case we stop before the next, more useful,
 	// AddContainer call. This ensures we have a way to get the container if
 	// we die before 'createContainer' returns because we can inspect by
@@ -555,6 +848,30 @@ func (engine *DockerTaskEngine) createContainer(task *api.Task, container *api.C
 	return metadata
 }
 
+// agentLabels returns the labels the agent adds to the containers it creates
+func agentLabels(task *api.Task, container *api.Container, cluster string) map[string]string {
+	return map[string]string{
+		labelPrefix + "task-arn":                task.Arn,
+		labelPrefix + "container-name":          container.Name,
+		labelPrefix + "task-definition-family":  task.Family,
+		labelPrefix + "task-definition-version": task.Version,
+		labelPrefix + "cluster":                 cluster,
+	}
+}
+
+// ContainerLabels returns the labels of a container created by the agent in
+// the given cluster: those of its Docker config along with the agent's own
+func ContainerLabels(task *api.Task, container *api.Container, cluster string) (map[string]string, error) {
+	config, err := task.DockerConfig(container)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range agentLabels(task, container, cluster) {
+		config.Labels[key] = value
+	}
+	return config.Labels, nil
+}
+
 func (engine *DockerTaskEngine) startContainer(task *api.Task, container *api.Container) DockerContainerMetadata {
 	log.Info("Starting container", "task", task, "container", container)
 	client := engine.client
@@ -586,7 +903,11 @@ func (engine *DockerTaskEngine) stopContainer(task *api.Task, container *api.Con
 		return DockerContainerMetadata{Error: CannotXContainerError{"Stop", "Container not recorded as created"}}
 	}
 
-	return engine.client.StopContainer(dockerContainer.DockerId, stopContainerTimeout)
+	stopTimeout := engine.cfg.DockerStopTimeout
+	if containerStopTimeout := container.StopTimeoutDuration(); containerStopTimeout != nil {
+		stopTimeout = *containerStopTimeout
+	}
+	return engine.client.StopContainer(dockerContainer.DockerId, stopTimeout, container.StopSignal, stopContainerTimeout)
 }
 
 func (engine *DockerTaskEngine) removeContainer(task *api.Task, container *api.Container) error {
@@ -684,6 +1005,31 @@ func (engine *DockerTaskEngine) State() *dockerstate.DockerTaskEngineState {
 	return engine.state
 }
 
+// DockerOperationStats returns the concurrency limit, queue wait and call
+// duration statistics of each type of operation made to Docker
+func (engine *DockerTaskEngine) DockerOperationStats() []DockerOperationStats {
+	return engine.client.OperationStats()
+}
+
+// ImagePrewarmStatus returns the progress of pulling each of the images that
+// are pre-warmed on the instance
+func (engine *DockerTaskEngine) ImagePrewarmStatus() []ImagePrewarmStatus {
+	return engine.imageManager.ImagePrewarmStatus()
+}
+
+// ImageCleanupDryRun returns the images the next image cleanup cycle would
+// delete and why
+func (engine *DockerTaskEngine) ImageCleanupDryRun() ImageCleanupPlan {
+	return engine.imageManager.ImageCleanupDryRun()
+}
+
+// SetImagePinned pins or unpins the image with the given ID or name, so that
+// it is kept or again considered by the image cleanup. It returns false if the
+// image is not known to the agent.
+func (engine *DockerTaskEngine) SetImagePinned(imageRef string, pinned bool) (*image.ImageState, bool) {
+	return engine.imageManager.SetImagePinned(imageRef, pinned)
+}
+
 // Capabilities returns the supported capabilities of this agent / docker-client pair.
 // Currently, the following capabilities are possible:
 //
@@ -697,11 +1043,20 @@ func (engine *DockerTaskEngine) State() *dockerstate.DockerTaskEngineState {
 //    com.amazonaws.ecs.capability.logging-driver.fluentd
 //    com.amazonaws.ecs.capability.logging-driver.journald
 //    com.amazonaws.ecs.capability.logging-driver.gelf
+//    com.amazonaws.ecs.capability.linux-parameters.capabilities
+//    com.amazonaws.ecs.capability.linux-parameters.devices
+//    com.amazonaws.ecs.capability.linux-parameters.ulimits
+//    com.amazonaws.ecs.capability.linux-parameters.sysctls
+//    com.amazonaws.ecs.capability.linux-parameters.shm-size
+//    com.amazonaws.ecs.capability.linux-parameters.tmpfs
+//    com.amazonaws.ecs.capability.linux-parameters.pid-mode
+//    com.amazonaws.ecs.capability.linux-parameters.ipc-mode
 //    com.amazonaws.ecs.capability.selinux
 //    com.amazonaws.ecs.capability.apparmor
 //    com.amazonaws.ecs.capability.ecr-auth
 //    com.amazonaws.ecs.capability.task-iam-role
 //    com.amazonaws.ecs.capability.task-iam-role-network-host
+//    com.amazonaws.ecs.capability.container-health-check
 func (engine *DockerTaskEngine) Capabilities() []string {
 	capabilities := []string{}
 	if !engine.cfg.PrivilegedDisabled {
@@ -720,6 +1075,8 @@ func (engine *DockerTaskEngine) Capabilities() []string {
 		}
 	}
 
+	capabilities = append(capabilities, linuxParameterCapabilities(versions)...)
+
 	if engine.cfg.SELinuxCapable {
 		capabilities = append(capabilities, capabilityPrefix+"selinux")
 	}
@@ -731,6 +1088,11 @@ func (engine *DockerTaskEngine) Capabilities() []string {
 		capabilities = append(capabilities, capabilityPrefix+"ecr-auth")
 	}
 
+	// Docker volumes are created and removed through this API version
+	if _, ok := versions[dockerVolumeAPIVersion]; ok {
+		capabilities = append(capabilities, capabilityPrefix+capabilityDockerVolumes)
+	}
+
 	if engine.cfg.TaskIAMRoleEnabled {
 		// The "task-iam-role" capability is supported for docker v1.7.x onwards
 		// Refer https://github.com/docker/docker/blob/master/docs/reference/api/docker_remote_api.md
@@ -751,6 +1113,10 @@ func (engine *DockerTaskEngine) Capabilities() []string {
 		}
 	}
 
+	// Health checks are evaluated by the agent itself and so do not depend on
+	// the Docker version
+	capabilities = append(capabilities, capabilityPrefix+capabilityContainerHealth)
+
 	return capabilities
 }
 
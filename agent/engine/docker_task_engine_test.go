@@ -15,7 +15,10 @@
 package engine
 
 import (
+	"encoding/json"
+	"errors"
 	"reflect"
+	"sort"
 	"sync"
 	"testing"
 	"time"
@@ -26,6 +29,7 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/credentials/mocks"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
 	"github.com/aws/amazon-ecs-agent/agent/engine/testdata"
 	"github.com/aws/amazon-ecs-agent/agent/eventstream"
 	"github.com/aws/amazon-ecs-agent/agent/statemanager/mocks"
@@ -51,8 +55,12 @@ func mocks(t *testing.T, cfg *config.Config) (*gomock.Controller, *MockDockerCli
 	containerChangeEventStream := eventstream.NewEventStream("TESTTASKENGINE", context.Background())
 	containerChangeEventStream.StartListening()
 	imageManager := NewMockImageManager(ctrl)
+	// Disk usage is checked after every successful pull
+	imageManager.EXPECT().CheckDiskPressure().AnyTimes()
 	taskEngine := NewTaskEngine(cfg, client, credentialsManager, containerChangeEventStream, imageManager, dockerstate.NewDockerTaskEngineState())
 	taskEngine.(*DockerTaskEngine)._time = mockTime
+	// Pulls left in progress by other tests are not joined
+	taskEngine.(*DockerTaskEngine).imagePulls = newImagePullGroup()
 	return ctrl, client, mockTime, taskEngine, credentialsManager, imageManager
 }
 
@@ -103,7 +111,11 @@ func TestBatchContainerHappyPath(t *testing.T) {
 		dockerConfig.Labels["com.amazonaws.ecs.cluster"] = ""
 		client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(
 			func(config *docker.Config, y interface{}, containerName string, z time.Duration) {
-
+				// The metadata endpoint is also set during PostUnmarshalTask,
+				// under an ID generated when the task is added
+				dockerConfig.Env = append(dockerConfig.Env, "ECS_CONTAINER_METADATA_RELATIVE_URI="+sleepTask.MetadataEndpointRelativeURI())
+				sort.Strings(dockerConfig.Env)
+				sort.Strings(config.Env)
 				if !reflect.DeepEqual(dockerConfig, config) {
 					t.Errorf("Mismatch in container config; expected: %v, got: %v", dockerConfig, config)
 				}
@@ -344,6 +356,7 @@ func TestStartTimeoutThenStart(t *testing.T) {
 	defer ctrl.Finish()
 
 	sleepTask := testdata.LoadTask("sleep5")
+	sleepTask.MetadataEndpointID = "metadataEndpointId"
 
 	eventStream := make(chan DockerContainerChangeEvent)
 	testTime.EXPECT().After(gomock.Any())
@@ -373,6 +386,8 @@ func TestStartTimeoutThenStart(t *testing.T) {
 		dockerConfig.Labels["com.amazonaws.ecs.task-definition-family"] = sleepTask.Family
 		dockerConfig.Labels["com.amazonaws.ecs.task-definition-version"] = sleepTask.Version
 		dockerConfig.Labels["com.amazonaws.ecs.cluster"] = ""
+		// and with the URI of the task's metadata endpoint during AddTask
+		dockerConfig.Env = append(dockerConfig.Env, "ECS_CONTAINER_METADATA_RELATIVE_URI="+sleepTask.MetadataEndpointRelativeURI())
 
 		client.EXPECT().CreateContainer(dockerConfig, gomock.Any(), gomock.Any(), gomock.Any()).Do(
 			func(x, y, z, timeout interface{}) {
@@ -413,7 +428,7 @@ func TestStartTimeoutThenStart(t *testing.T) {
 	}
 
 	// Expect it to try to stop it once now
-	client.EXPECT().StopContainer("containerId", gomock.Any()).Return(DockerContainerMetadata{
+	client.EXPECT().StopContainer("containerId", gomock.Any(), gomock.Any(), gomock.Any()).Return(DockerContainerMetadata{
 		Error: CannotXContainerError{
 			transition: "start",
 			msg:        "Cannot start",
@@ -441,6 +456,7 @@ func TestSteadyStatePoll(t *testing.T) {
 
 	wait := &sync.WaitGroup{}
 	sleepTask := testdata.LoadTask("sleep5")
+	sleepTask.MetadataEndpointID = "metadataEndpointId"
 
 	eventStream := make(chan DockerContainerChangeEvent)
 
@@ -467,6 +483,8 @@ func TestSteadyStatePoll(t *testing.T) {
 		dockerConfig.Labels["com.amazonaws.ecs.task-definition-family"] = sleepTask.Family
 		dockerConfig.Labels["com.amazonaws.ecs.task-definition-version"] = sleepTask.Version
 		dockerConfig.Labels["com.amazonaws.ecs.cluster"] = ""
+		// and with the URI of the task's metadata endpoint during AddTask
+		dockerConfig.Env = append(dockerConfig.Env, "ECS_CONTAINER_METADATA_RELATIVE_URI="+sleepTask.MetadataEndpointRelativeURI())
 
 		client.EXPECT().CreateContainer(dockerConfig, gomock.Any(), gomock.Any(), gomock.Any()).Do(
 			func(x, y, z, timeout interface{}) {
@@ -526,7 +544,7 @@ func TestSteadyStatePoll(t *testing.T) {
 				DockerID: "containerId",
 			}).AnyTimes(),
 		// the engine *may* call StopContainer even though it's already stopped
-		client.EXPECT().StopContainer("containerId", stopContainerTimeout).AnyTimes(),
+		client.EXPECT().StopContainer("containerId", defaultConfig.DockerStopTimeout, "", stopContainerTimeout).AnyTimes(),
 	)
 	// trigger steady state verification
 	for i := 0; i < 10; i++ {
@@ -679,6 +697,7 @@ func TestTaskTransitionWhenStopContainerTimesout(t *testing.T) {
 	defer ctrl.Finish()
 
 	sleepTask := testdata.LoadTask("sleep5")
+	sleepTask.MetadataEndpointID = "metadataEndpointId"
 
 	eventStream := make(chan DockerContainerChangeEvent)
 
@@ -713,6 +732,8 @@ func TestTaskTransitionWhenStopContainerTimesout(t *testing.T) {
 		dockerConfig.Labels["com.amazonaws.ecs.task-definition-family"] = sleepTask.Family
 		dockerConfig.Labels["com.amazonaws.ecs.task-definition-version"] = sleepTask.Version
 		dockerConfig.Labels["com.amazonaws.ecs.cluster"] = ""
+		// and with the URI of the task's metadata endpoint during AddTask
+		dockerConfig.Env = append(dockerConfig.Env, "ECS_CONTAINER_METADATA_RELATIVE_URI="+sleepTask.MetadataEndpointRelativeURI())
 
 		client.EXPECT().CreateContainer(dockerConfig, gomock.Any(), gomock.Any(), gomock.Any()).Do(
 			func(x, y, z, timeout interface{}) {
@@ -731,13 +752,13 @@ func TestTaskTransitionWhenStopContainerTimesout(t *testing.T) {
 
 		gomock.InOrder(
 			// StopContainer times out as well
-			client.EXPECT().StopContainer("containerId", gomock.Any()).Return(containerStopTimeoutError),
+			client.EXPECT().StopContainer("containerId", gomock.Any(), gomock.Any(), gomock.Any()).Return(containerStopTimeoutError),
 			// Since task is not in steady state, progressContainers causes
 			// another invocation of StopContainer. Return a timeout error
 			// for that as well
 			// TODO change AnyTimes() to MinTimes(1) after updating gomock
-			client.EXPECT().StopContainer("containerId", gomock.Any()).Do(
-				func(id string, timeout time.Duration) {
+			client.EXPECT().StopContainer("containerId", gomock.Any(), gomock.Any(), gomock.Any()).Do(
+				func(id string, stopTimeout time.Duration, stopSignal string, timeout time.Duration) {
 					go func() {
 						dockerEventSent <- 1
 						eventStream <- dockerEvent(api.ContainerStopped)
@@ -838,9 +859,21 @@ func TestCapabilities(t *testing.T) {
 		"com.amazonaws.ecs.capability.docker-remote-api.1.18",
 		"com.amazonaws.ecs.capability.logging-driver.json-file",
 		"com.amazonaws.ecs.capability.logging-driver.syslog",
+	}
+	if linuxParametersSupported {
+		expectedCapabilities = append(expectedCapabilities,
+			"com.amazonaws.ecs.capability.linux-parameters.capabilities",
+			"com.amazonaws.ecs.capability.linux-parameters.devices",
+			"com.amazonaws.ecs.capability.linux-parameters.ulimits",
+			"com.amazonaws.ecs.capability.linux-parameters.pid-mode",
+			"com.amazonaws.ecs.capability.linux-parameters.ipc-mode",
+		)
+	}
+	expectedCapabilities = append(expectedCapabilities,
 		"com.amazonaws.ecs.capability.selinux",
 		"com.amazonaws.ecs.capability.apparmor",
-	}
+		"com.amazonaws.ecs.capability.container-health-check",
+	)
 
 	if !reflect.DeepEqual(capabilities, expectedCapabilities) {
 		t.Errorf("Expected capabilities %v, but got capabilities %v", expectedCapabilities, capabilities)
@@ -868,6 +901,30 @@ func TestCapabilitiesECR(t *testing.T) {
 	}
 }
 
+func TestCapabilitiesDockerVolumes(t *testing.T) {
+	for _, tc := range []struct {
+		versions []dockerclient.DockerVersion
+		expected bool
+	}{
+		{[]dockerclient.DockerVersion{dockerclient.Version_1_19, dockerclient.Version_1_21}, true},
+		{[]dockerclient.DockerVersion{dockerclient.Version_1_19}, false},
+	} {
+		ctrl, client, _, taskEngine, _, _ := mocks(t, &config.Config{})
+		client.EXPECT().SupportedVersions().Return(tc.versions)
+
+		found := false
+		for _, capability := range taskEngine.Capabilities() {
+			if capability == "com.amazonaws.ecs.capability.docker-volumes" {
+				found = true
+			}
+		}
+		if found != tc.expected {
+			t.Errorf("Expected Docker volume capability %v with versions %v", tc.expected, tc.versions)
+		}
+		ctrl.Finish()
+	}
+}
+
 func TestCapabilitiesTaskIAMRoleForSupportedDockerVersion(t *testing.T) {
 	conf := &config.Config{
 		TaskIAMRoleEnabled: true,
@@ -989,3 +1046,499 @@ func TestGetTaskByArn(t *testing.T) {
 		t.Fatal("Task with invalid arn found in the task engine")
 	}
 }
+
+func TestHandleHealthCheckResultStopsUnhealthyEssentialContainer(t *testing.T) {
+	ctrl, _, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	sleepTask.SetDesiredStatus(api.TaskRunning)
+	container := sleepTask.Containers[0]
+	container.Essential = true
+	container.HealthCheck = &api.HealthCheck{Type: api.HealthCheckTypeCommand, Command: []string{"true"}}
+	container.SetDesiredStatus(api.ContainerRunning)
+	container.SetKnownStatus(api.ContainerRunning)
+	container.SentStatus = api.ContainerRunning
+
+	mtask := taskEngine.(*DockerTaskEngine).newManagedTask(sleepTask)
+	_, contEvents := taskEngine.TaskEvents()
+	handleHealthCheckResult := func(status api.HealthStatus) api.ContainerStateChange {
+		done := make(chan struct{})
+		go func() {
+			mtask.handleHealthCheckResult(healthCheckResult{container, status})
+			close(done)
+		}()
+		event := <-contEvents
+		<-done
+		return event
+	}
+
+	event := handleHealthCheckResult(api.HealthStatus{Status: api.ContainerHealthy})
+	assert.Equal(t, api.ContainerHealthy, event.HealthStatus)
+	assert.Nil(t, event.SentStatus, "A change in health alone should not be dropped as redundant")
+	assert.Equal(t, "Container health HEALTHY", event.Reason)
+	assert.Equal(t, api.ContainerHealthy, container.GetHealthStatus().Status)
+	assert.Equal(t, api.ContainerRunning, container.GetDesiredStatus())
+
+	event = handleHealthCheckResult(api.HealthStatus{Status: api.ContainerUnhealthy, Output: "failed"})
+	assert.Equal(t, api.ContainerUnhealthy, event.HealthStatus)
+	assert.Equal(t, api.ContainerUnhealthy, container.GetHealthStatus().Status)
+	assert.Equal(t, api.ContainerStopped, container.GetDesiredStatus())
+	assert.Equal(t, api.TaskStopped, sleepTask.GetDesiredStatus())
+	assert.Equal(t, "ContainerUnhealthyError", container.ApplyingError.ErrorName())
+}
+
+func TestHandleHealthCheckResultIgnoresStoppedContainer(t *testing.T) {
+	ctrl, _, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	container.SetKnownStatus(api.ContainerStopped)
+
+	mtask := taskEngine.(*DockerTaskEngine).newManagedTask(sleepTask)
+	mtask.handleHealthCheckResult(healthCheckResult{container, api.HealthStatus{Status: api.ContainerUnhealthy}})
+	assert.Equal(t, api.ContainerHealthUnknown, container.GetHealthStatus().Status)
+}
+
+func TestCheckContainerHealthCommand(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	container.HealthCheck = &api.HealthCheck{Type: api.HealthCheckTypeCommand, Command: []string{"check"}, Timeout: 2}
+	dockerTaskEngine.state.AddTask(sleepTask)
+	dockerTaskEngine.state.AddContainer(&api.DockerContainer{DockerId: "id", DockerName: "name", Container: container}, sleepTask)
+
+	gomock.InOrder(
+		client.EXPECT().ExecContainer("id", []string{"check"}, 2*time.Second).Return(DockerExecResult{ExitCode: 0, Output: "fine"}),
+		client.EXPECT().ExecContainer("id", []string{"check"}, 2*time.Second).Return(DockerExecResult{ExitCode: 1, Output: "broken"}),
+	)
+	healthy, output := dockerTaskEngine.checkContainerHealth(sleepTask, container)
+	assert.True(t, healthy)
+	assert.Equal(t, "fine", output)
+
+	healthy, output = dockerTaskEngine.checkContainerHealth(sleepTask, container)
+	assert.False(t, healthy)
+	assert.Equal(t, "broken", output)
+}
+
+func TestRestartNonEssentialContainer(t *testing.T) {
+	ctrl, client, mockTime, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	sleepTask.SetKnownStatus(api.TaskRunning)
+	container := sleepTask.Containers[0]
+	container.Essential = false
+	container.RestartPolicy = &api.RestartPolicy{Type: api.RestartPolicyOnFailure, MaxAttempts: 1}
+	container.SetDesiredStatus(api.ContainerRunning)
+	container.SetKnownStatus(api.ContainerRunning)
+	container.SentStatus = api.ContainerRunning
+
+	backoff := make(chan time.Time, 1)
+	mockTime.EXPECT().After(10 * time.Second).Return(backoff)
+	mockTime.EXPECT().Now().AnyTimes()
+
+	taskEvents, containerEvents := taskEngine.TaskEvents()
+	go func() {
+		for {
+			select {
+			case <-taskEvents:
+			case <-containerEvents:
+			}
+		}
+	}()
+
+	mtask := taskEngine.(*DockerTaskEngine).newManagedTask(sleepTask)
+	mtask._time = mockTime
+	exitCode := 1
+	mtask.handleContainerChange(dockerContainerChange{container, DockerContainerChangeEvent{
+		Status:                  api.ContainerStopped,
+		DockerContainerMetadata: DockerContainerMetadata{ExitCode: &exitCode},
+	}})
+
+	// While waiting to be restarted the container keeps the task running
+	assert.Equal(t, api.ContainerRunning, container.GetKnownStatus())
+	assert.True(t, mtask.steadyState())
+
+	// The container is created and started again without its known status
+	// going backwards
+	gomock.InOrder(
+		client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(DockerContainerMetadata{DockerID: "restarted"}),
+		client.EXPECT().StartContainer("restarted", startContainerTimeout).Return(DockerContainerMetadata{DockerID: "restarted"}),
+	)
+	backoff <- time.Now()
+	mtask.handleContainerRestart(<-mtask.restartMessages)
+
+	assert.Equal(t, 1, container.GetRestartCount())
+	assert.Equal(t, api.ContainerRunning, container.GetKnownStatus())
+	assert.Nil(t, container.KnownExitCode)
+	assert.True(t, mtask.steadyState())
+
+	// Once the restart limit is reached the container stays stopped
+	mtask.handleContainerChange(dockerContainerChange{container, DockerContainerChangeEvent{
+		Status:                  api.ContainerStopped,
+		DockerContainerMetadata: DockerContainerMetadata{ExitCode: &exitCode},
+	}})
+	assert.Equal(t, api.ContainerStopped, container.GetKnownStatus())
+	assert.Empty(t, mtask.pendingRestarts)
+}
+
+func TestFailedContainerRestartIsHandledAsExit(t *testing.T) {
+	ctrl, client, mockTime, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	sleepTask.SetKnownStatus(api.TaskRunning)
+	container := sleepTask.Containers[0]
+	container.Essential = false
+	container.RestartPolicy = &api.RestartPolicy{Type: api.RestartPolicyOnFailure, MaxAttempts: 2}
+	container.SetDesiredStatus(api.ContainerRunning)
+	container.SetKnownStatus(api.ContainerRunning)
+	container.SentStatus = api.ContainerRunning
+	container.ApplyingError = api.NewNamedError(CannotXContainerError{"Start", "first"})
+
+	backoff := make(chan time.Time, 1)
+	mockTime.EXPECT().After(gomock.Any()).Return(backoff).AnyTimes()
+	mockTime.EXPECT().Now().AnyTimes()
+
+	taskEvents, containerEvents := taskEngine.TaskEvents()
+	go func() {
+		for {
+			select {
+			case <-taskEvents:
+			case <-containerEvents:
+			}
+		}
+	}()
+
+	mtask := taskEngine.(*DockerTaskEngine).newManagedTask(sleepTask)
+	mtask._time = mockTime
+	mtask.handleContainerChange(dockerContainerChange{container, DockerContainerChangeEvent{
+		Status:                  api.ContainerStopped,
+		DockerContainerMetadata: DockerContainerMetadata{Error: CannotXContainerError{"Stop", "second"}},
+	}})
+	assert.Equal(t, "CannotStartContainerError: first", container.ApplyingError.Error(), "The first error should be kept")
+
+	client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(DockerContainerMetadata{
+		Error: CannotXContainerError{"Create", "failed"},
+	})
+	backoff <- time.Now()
+	mtask.handleContainerRestart(<-mtask.restartMessages)
+
+	assert.Equal(t, 1, container.GetRestartCount())
+	assert.Equal(t, api.ContainerRunning, container.GetKnownStatus())
+	assert.Contains(t, mtask.pendingRestarts, container.Name, "A failed restart should be retried within the restart limit")
+	mtask.cancelPendingRestarts()
+}
+
+func TestContainerNextStateStopsContainerWithFailedDependency(t *testing.T) {
+	ctrl, _, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	exitCode := 1
+	migrate := &api.Container{
+		Name:          "migrate",
+		DesiredStatus: api.ContainerRunning,
+		KnownStatus:   api.ContainerStopped,
+		KnownExitCode: &exitCode,
+	}
+	app := &api.Container{
+		Name:          "app",
+		Essential:     true,
+		DesiredStatus: api.ContainerRunning,
+		DependsOn:     []api.ContainerDependency{{ContainerName: "migrate", Condition: api.DependencyConditionSuccess}},
+	}
+	task := &api.Task{
+		Arn:           "arn",
+		DesiredStatus: api.TaskRunning,
+		Containers:    []*api.Container{migrate, app},
+	}
+	mtask := taskEngine.(*DockerTaskEngine).newManagedTask(task)
+
+	nextState, shouldTransition, canTransition := mtask.containerNextState(app)
+	assert.Equal(t, api.ContainerStopped, nextState)
+	assert.False(t, shouldTransition)
+	assert.True(t, canTransition)
+	assert.Equal(t, "ContainerDependencyError", app.ApplyingError.ErrorName())
+	assert.Equal(t, api.TaskStopped, task.GetDesiredStatus())
+}
+
+func TestStopContainerUsesContainerStopSettings(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	dockerTaskEngine.state.AddTask(sleepTask)
+	dockerTaskEngine.state.AddContainer(&api.DockerContainer{DockerId: "id", DockerName: "name", Container: container}, sleepTask)
+
+	client.EXPECT().StopContainer("id", defaultConfig.DockerStopTimeout, "", stopContainerTimeout)
+	dockerTaskEngine.stopContainer(sleepTask, container)
+
+	stopTimeout := uint(300)
+	container.StopTimeout = &stopTimeout
+	container.StopSignal = "SIGQUIT"
+	client.EXPECT().StopContainer("id", 5*time.Minute, "SIGQUIT", stopContainerTimeout)
+	dockerTaskEngine.stopContainer(sleepTask, container)
+
+	// An explicit stop timeout of zero kills the container straight away
+	stopTimeout = 0
+	client.EXPECT().StopContainer("id", time.Duration(0), "SIGQUIT", stopContainerTimeout)
+	dockerTaskEngine.stopContainer(sleepTask, container)
+}
+
+func TestContainerNextStateStopsDependentsFirst(t *testing.T) {
+	ctrl, _, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	db := &api.Container{
+		Name:          "db",
+		DesiredStatus: api.ContainerStopped,
+		KnownStatus:   api.ContainerRunning,
+	}
+	app := &api.Container{
+		Name:          "app",
+		Essential:     true,
+		Links:         []string{"db:database"},
+		DesiredStatus: api.ContainerStopped,
+		KnownStatus:   api.ContainerRunning,
+	}
+	task := &api.Task{
+		Arn:           "arn",
+		DesiredStatus: api.TaskStopped,
+		Containers:    []*api.Container{db, app},
+	}
+	mtask := taskEngine.(*DockerTaskEngine).newManagedTask(task)
+	mtask.SetStopDeadline(time.Now().Add(defaultConfig.TaskStopTimeout))
+
+	_, _, canTransition := mtask.containerNextState(db)
+	assert.False(t, canTransition, "db should not be stopped while app is running")
+	nextState, shouldTransition, canTransition := mtask.containerNextState(app)
+	assert.Equal(t, api.ContainerStopped, nextState)
+	assert.True(t, shouldTransition)
+	assert.True(t, canTransition)
+	assert.True(t, mtask.waitingOnDependents())
+
+	app.SetKnownStatus(api.ContainerStopped)
+	nextState, shouldTransition, canTransition = mtask.containerNextState(db)
+	assert.Equal(t, api.ContainerStopped, nextState)
+	assert.True(t, shouldTransition)
+	assert.True(t, canTransition)
+}
+
+func TestContainerNextStateIgnoresStopOrderAfterDeadline(t *testing.T) {
+	ctrl, _, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	db := &api.Container{
+		Name:          "db",
+		DesiredStatus: api.ContainerStopped,
+		KnownStatus:   api.ContainerRunning,
+	}
+	app := &api.Container{
+		Name:          "app",
+		VolumesFrom:   []api.VolumeFrom{{SourceContainer: "db"}},
+		DesiredStatus: api.ContainerStopped,
+		KnownStatus:   api.ContainerRunning,
+	}
+	task := &api.Task{
+		Arn:           "arn",
+		DesiredStatus: api.TaskStopped,
+		Containers:    []*api.Container{db, app},
+	}
+	mtask := taskEngine.(*DockerTaskEngine).newManagedTask(task)
+	mtask.SetStopDeadline(time.Now())
+
+	nextState, shouldTransition, canTransition := mtask.containerNextState(db)
+	assert.Equal(t, api.ContainerStopped, nextState)
+	assert.True(t, shouldTransition)
+	assert.True(t, canTransition)
+	assert.False(t, mtask.waitingOnDependents())
+}
+
+func TestStopDeadlineIsKeptInSavedTask(t *testing.T) {
+	ctrl, _, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := &api.Task{
+		Arn:           "arn",
+		DesiredStatus: api.TaskStopped,
+		Containers: []*api.Container{
+			{
+				Name:          "db",
+				DesiredStatus: api.ContainerStopped,
+				KnownStatus:   api.ContainerRunning,
+			},
+			{
+				Name:          "app",
+				Links:         []string{"db:database"},
+				DesiredStatus: api.ContainerStopped,
+				KnownStatus:   api.ContainerRunning,
+			},
+		},
+	}
+	task.SetStopDeadline(time.Now().Add(defaultConfig.TaskStopTimeout))
+	saved, err := json.Marshal(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restored api.Task
+	if err := json.Unmarshal(saved, &restored); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, task.GetStopDeadline().Equal(restored.GetStopDeadline()))
+	mtask := taskEngine.(*DockerTaskEngine).newManagedTask(&restored)
+	assert.True(t, mtask.waitingOnDependents(), "A restored task should still stop its containers in order")
+}
+
+func TestPullContainerPreferCachedUsesImageOnInstance(t *testing.T) {
+	cfg := defaultConfig
+	cfg.ImagePullBehavior = dockerclient.ImagePullPreferCachedBehavior
+	ctrl, _, _, taskEngine, _, imageManager := mocks(t, &cfg)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	imageState := &image.ImageState{Image: &image.Image{ImageID: "sha256:1234"}}
+	// No pull is expected since the image is already on the instance
+	imageManager.EXPECT().RecordContainerReference(container).Return(nil)
+	imageManager.EXPECT().GetImageStateFromImageName(container.Image).Return(imageState)
+
+	metadata := taskEngine.(*DockerTaskEngine).pullContainer(sleepTask, container)
+	assert.NoError(t, metadata.Error)
+}
+
+func TestPullContainerOncePullsImageNotPulledBefore(t *testing.T) {
+	cfg := defaultConfig
+	cfg.ImagePullBehavior = dockerclient.ImagePullOnceBehavior
+	ctrl, client, _, taskEngine, _, imageManager := mocks(t, &cfg)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	imageState := &image.ImageState{Image: &image.Image{ImageID: "sha256:1234"}}
+	gomock.InOrder(
+		client.EXPECT().InspectImage(container.Image).Return(nil, errors.New("no such image")),
+		client.EXPECT().PullImage(container.Image, nil).Return(DockerContainerMetadata{}),
+		imageManager.EXPECT().RecordContainerReference(container).Return(nil),
+		imageManager.EXPECT().GetImageStateFromImageName(container.Image).Return(imageState),
+	)
+
+	metadata := taskEngine.(*DockerTaskEngine).pullContainer(sleepTask, container)
+	assert.NoError(t, metadata.Error)
+}
+
+func TestPullContainerOnceUsesImageOnInstance(t *testing.T) {
+	cfg := defaultConfig
+	cfg.ImagePullBehavior = dockerclient.ImagePullOnceBehavior
+	ctrl, client, _, taskEngine, _, imageManager := mocks(t, &cfg)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	imageState := &image.ImageState{Image: &image.Image{ImageID: "sha256:1234"}}
+	// No pull is expected since Docker has the image
+	gomock.InOrder(
+		client.EXPECT().InspectImage(container.Image).Return(&docker.Image{ID: "sha256:1234"}, nil),
+		imageManager.EXPECT().RecordContainerReference(container).Return(nil),
+		imageManager.EXPECT().GetImageStateFromImageName(container.Image).Return(imageState),
+	)
+
+	metadata := taskEngine.(*DockerTaskEngine).pullContainer(sleepTask, container)
+	assert.NoError(t, metadata.Error)
+}
+
+func TestPullContainerBehaviorOverriddenByContainer(t *testing.T) {
+	cfg := defaultConfig
+	cfg.ImagePullBehavior = dockerclient.ImagePullPreferCachedBehavior
+	ctrl, client, _, taskEngine, _, imageManager := mocks(t, &cfg)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	container.ImagePullBehavior = string(dockerclient.ImagePullAlwaysBehavior)
+	imageState := &image.ImageState{Image: &image.Image{ImageID: "sha256:1234"}}
+	gomock.InOrder(
+		client.EXPECT().PullImage(container.Image, nil).Return(DockerContainerMetadata{}),
+		imageManager.EXPECT().RecordContainerReference(container).Return(nil),
+		imageManager.EXPECT().GetImageStateFromImageName(container.Image).Return(imageState),
+	)
+	metadata := taskEngine.(*DockerTaskEngine).pullContainer(sleepTask, container)
+	assert.NoError(t, metadata.Error)
+
+	container.ImagePullBehavior = "never"
+	metadata = taskEngine.(*DockerTaskEngine).pullContainer(sleepTask, container)
+	assert.Error(t, metadata.Error)
+}
+
+func TestPullContainerFailsOnImageDigestMismatch(t *testing.T) {
+	ctrl, client, _, taskEngine, _, imageManager := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	container.ExpectedImageDigest = "busybox@sha256:expected"
+	imageState := &image.ImageState{Image: &image.Image{ImageID: "sha256:1234"}}
+	gomock.InOrder(
+		client.EXPECT().PullImage("busybox@sha256:expected", nil).Return(DockerContainerMetadata{}),
+		imageManager.EXPECT().RecordContainerReference(container).Do(func(container *api.Container) {
+			container.ImageDigest = "sha256:actual"
+		}).Return(nil),
+		imageManager.EXPECT().GetImageStateFromImageName("busybox@sha256:expected").Return(imageState),
+	)
+
+	metadata := taskEngine.(*DockerTaskEngine).pullContainer(sleepTask, container)
+	if assert.Error(t, metadata.Error) {
+		assert.Equal(t, "ImageDigestMismatchError", metadata.Error.ErrorName())
+	}
+	assert.Equal(t, api.ContainerStopped, container.GetDesiredStatus())
+}
+
+func TestPullContainerAcceptsExpectedImageDigest(t *testing.T) {
+	ctrl, client, _, taskEngine, _, imageManager := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	container.ExpectedImageDigest = "sha256:expected"
+	imageState := &image.ImageState{Image: &image.Image{ImageID: "sha256:1234"}}
+	gomock.InOrder(
+		client.EXPECT().PullImage("busybox@sha256:expected", nil).Return(DockerContainerMetadata{}),
+		imageManager.EXPECT().RecordContainerReference(container).Do(func(container *api.Container) {
+			container.ImageDigest = "sha256:expected"
+		}).Return(nil),
+		imageManager.EXPECT().GetImageStateFromImageName("busybox@sha256:expected").Return(imageState),
+	)
+
+	metadata := taskEngine.(*DockerTaskEngine).pullContainer(sleepTask, container)
+	assert.NoError(t, metadata.Error)
+}
+
+func TestPullContainerPreferCachedPullsImageWithOtherDigest(t *testing.T) {
+	cfg := defaultConfig
+	cfg.ImagePullBehavior = dockerclient.ImagePullPreferCachedBehavior
+	ctrl, client, _, taskEngine, _, imageManager := mocks(t, &cfg)
+	defer ctrl.Finish()
+
+	sleepTask := testdata.LoadTask("sleep5")
+	container := sleepTask.Containers[0]
+	container.ExpectedImageDigest = "sha256:expected"
+	imageState := &image.ImageState{Image: &image.Image{ImageID: "sha256:1234"}}
+	gomock.InOrder(
+		client.EXPECT().InspectImage("busybox@sha256:expected").Return(&docker.Image{RepoDigests: []string{"busybox@sha256:cached"}}, nil),
+		client.EXPECT().PullImage("busybox@sha256:expected", nil).Return(DockerContainerMetadata{}),
+		imageManager.EXPECT().RecordContainerReference(container).Do(func(container *api.Container) {
+			container.ImageDigest = "sha256:expected"
+		}).Return(nil),
+		imageManager.EXPECT().GetImageStateFromImageName("busybox@sha256:expected").Return(imageState),
+	)
+
+	metadata := taskEngine.(*DockerTaskEngine).pullContainer(sleepTask, container)
+	assert.NoError(t, metadata.Error)
+}
@@ -0,0 +1,298 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/aws/amazon-ecs-agent/agent/logger/audit"
+	"github.com/cihub/seelog"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// danglingImageTag is the repository tag Docker reports for images without one
+const danglingImageTag = "<none>:<none>"
+
+// UnmanagedResourceCandidate is a container or image that the agent did not
+// create and that the cleanup of unmanaged resources would remove, along with
+// the reason it was chosen
+type UnmanagedResourceCandidate struct {
+	ID     string
+	Names  []string
+	Reason string
+	// imageID is the ID of the image used by a container
+	imageID string
+}
+
+// newCleanupAuditLogger creates the log of the unmanaged images and containers
+// removed by the image manager
+func newCleanupAuditLogger(cfg *config.Config) audit.CleanupAuditLogger {
+	logger, err := seelog.LoggerFromConfigAsString(audit.CleanupAuditLoggerConfig(cfg))
+	if err != nil {
+		seelog.Errorf("Error initializing the cleanup audit log: %v", err)
+		logger = seelog.Disabled
+	}
+	return audit.NewCleanupAuditLog(cfg, logger)
+}
+
+// removeUnmanagedResources removes exited containers that were not started by
+// the agent, followed by the images that the agent did not pull and that no
+// remaining container uses. It does nothing unless the cleanup of unmanaged
+// resources has been enabled.
+func (imageManager *dockerImageManager) removeUnmanagedResources() {
+	if !imageManager.unmanagedCleanupEnabled {
+		return
+	}
+	imageManager.cleanupLock.Lock()
+	defer imageManager.cleanupLock.Unlock()
+
+	imagesInUse, err := imageManager.removeUnmanagedContainers()
+	if err != nil {
+		seelog.Warnf("Error listing containers, skipping the cleanup of unmanaged resources: %v", err)
+		return
+	}
+	imageManager.removeUnmanagedImages(imagesInUse)
+}
+
+// removeUnmanagedContainers removes the exited containers that the agent does
+// not know about and returns the IDs of the images used by the containers
+// that remain, including those of the agent
+func (imageManager *dockerImageManager) removeUnmanagedContainers() (map[string]bool, error) {
+	containers, imagesInUse, err := imageManager.unmanagedContainersForRemoval()
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range containers {
+		seelog.Infof("Removing unmanaged container %s (%s): %s", container.ID, container.Names[0], container.Reason)
+		err = imageManager.client.RemoveContainer(container.ID, removeContainerTimeout)
+		if err != nil {
+			seelog.Warnf("Error removing unmanaged container %s: %v", container.ID, err)
+			imagesInUse[container.imageID] = true
+			continue
+		}
+		imageManager.cleanupAuditLogger.LogContainerRemoval(container.ID, container.Names[0], container.Reason)
+	}
+	return imagesInUse, nil
+}
+
+// unmanagedContainersForRemoval returns the exited containers that the agent
+// does not know about and that can be removed, along with the IDs of the
+// images used by every other container
+func (imageManager *dockerImageManager) unmanagedContainersForRemoval() ([]UnmanagedResourceCandidate, map[string]bool, error) {
+	response := imageManager.client.ListContainers(true, ListContainersTimeout)
+	if response.Error != nil {
+		return nil, nil, response.Error
+	}
+
+	var containers []UnmanagedResourceCandidate
+	imagesInUse := make(map[string]bool)
+	for _, dockerID := range response.DockerIDs {
+		managedContainer, managed := imageManager.state.ContainerById(dockerID)
+		if managed && managedContainer.Container.ImageID != "" {
+			imagesInUse[managedContainer.Container.ImageID] = true
+			continue
+		}
+		container, err := imageManager.client.InspectContainer(dockerID, inspectContainerTimeout)
+		if err != nil {
+			seelog.Warnf("Error inspecting container %s: %v", dockerID, err)
+			continue
+		}
+		if managed {
+			// The image of the container has not been recorded yet
+			imagesInUse[container.Image] = true
+			continue
+		}
+		reason, removable := imageManager.isUnmanagedContainerRemovable(container)
+		if !removable {
+			imagesInUse[container.Image] = true
+			continue
+		}
+		containers = append(containers, UnmanagedResourceCandidate{
+			ID:      dockerID,
+			Names:   []string{strings.TrimPrefix(container.Name, "/")},
+			Reason:  reason,
+			imageID: container.Image,
+		})
+	}
+	return containers, imagesInUse, nil
+}
+
+// isUnmanagedContainerRemovable returns the reason for removing a container
+// that the agent did not start, or false if the container should be kept
+func (imageManager *dockerImageManager) isUnmanagedContainerRemovable(container *docker.Container) (string, bool) {
+	if container.State.Running || container.State.Paused || container.State.Restarting || container.State.FinishedAt.IsZero() {
+		return "", false
+	}
+	if container.Config != nil {
+		// Containers created by an agent whose state was lost are left to ECS
+		for label := range container.Config.Labels {
+			if strings.HasPrefix(label, labelPrefix) {
+				return "", false
+			}
+		}
+		if imageManager.isUnmanagedImageExcluded(container.Config.Image) {
+			return "", false
+		}
+	}
+	if imageManager.isUnmanagedResourceExcluded(strings.TrimPrefix(container.Name, "/")) {
+		return "", false
+	}
+	exitedFor := time.Now().Sub(container.State.FinishedAt)
+	if exitedFor < imageManager.unmanagedContainerMinimumAge {
+		return "", false
+	}
+	return fmt.Sprintf("exited %v ago", exitedFor-exitedFor%time.Second), true
+}
+
+// removeUnmanagedImages removes dangling images, and images old enough that
+// the agent did not pull, unless they are excluded or in use
+func (imageManager *dockerImageManager) removeUnmanagedImages(imagesInUse map[string]bool) {
+	seelog.Debug("Attempting to obtain ImagePullDeleteLock for removing unmanaged images")
+	ImagePullDeleteLock.Lock()
+	defer ImagePullDeleteLock.Unlock()
+	images, err := imageManager.unmanagedImagesForRemoval(imagesInUse)
+	if err != nil {
+		seelog.Warnf("Error listing images, skipping the cleanup of unmanaged images: %v", err)
+		return
+	}
+	for _, unmanagedImage := range images {
+		imageManager.removeUnmanagedImage(unmanagedImage.ID, unmanagedImage.Names, unmanagedImage.Reason)
+	}
+}
+
+// unmanagedImagesForRemoval returns the dangling images, and the images old
+// enough that the agent did not pull, that are neither excluded nor in use
+func (imageManager *dockerImageManager) unmanagedImagesForRemoval(imagesInUse map[string]bool) ([]UnmanagedResourceCandidate, error) {
+	response := imageManager.client.ListImages(ListImagesTimeout)
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	var images []UnmanagedResourceCandidate
+	for _, listedImage := range response.Images {
+		if imagesInUse[listedImage.ID] || imageManager.isImageManaged(listedImage.ID) {
+			continue
+		}
+		var imageNames []string
+		for _, repoTag := range listedImage.RepoTags {
+			if repoTag != danglingImageTag {
+				imageNames = append(imageNames, repoTag)
+			}
+		}
+		if imageManager.isUnmanagedImageExcluded(imageNames...) {
+			continue
+		}
+
+		reason := "dangling image"
+		if len(imageNames) > 0 {
+			inspected, err := imageManager.client.InspectImage(listedImage.ID)
+			if err != nil {
+				seelog.Warnf("Error inspecting unmanaged image %s: %v", listedImage.ID, err)
+				continue
+			}
+			age := time.Now().Sub(inspected.Created)
+			if age < imageManager.unmanagedImageMinimumAge {
+				continue
+			}
+			reason = fmt.Sprintf("unmanaged image created %v ago", age-age%time.Second)
+		}
+		images = append(images, UnmanagedResourceCandidate{ID: listedImage.ID, Names: imageNames, Reason: reason})
+	}
+	return images, nil
+}
+
+// removeUnmanagedImage untags each name of an image, which deletes it once
+// the last one is removed, or removes it by ID if it has no names. The names
+// removed are recorded in the audit log even if a later one fails, as the
+// image has lost them either way.
+func (imageManager *dockerImageManager) removeUnmanagedImage(imageID string, imageNames []string, reason string) {
+	seelog.Infof("Removing unmanaged image %s %v: %s", imageID, imageNames, reason)
+	if len(imageNames) == 0 {
+		err := imageManager.client.RemoveImage(imageID, removeImageTimeout)
+		if err != nil && err.Error() != imageNotFoundForDeletionError {
+			seelog.Warnf("Error removing unmanaged image %s: %v", imageID, err)
+			return
+		}
+		imageManager.cleanupAuditLogger.LogImageRemoval(imageID, nil, reason)
+		return
+	}
+
+	var removedNames []string
+	for _, imageName := range imageNames {
+		err := imageManager.client.RemoveImage(imageName, removeImageTimeout)
+		if err != nil && err.Error() != imageNotFoundForDeletionError {
+			seelog.Warnf("Error removing unmanaged image %s: %v", imageName, err)
+			if len(removedNames) > 0 {
+				imageManager.cleanupAuditLogger.LogImageRemoval(imageID, removedNames, reason+", partially untagged")
+			}
+			return
+		}
+		removedNames = append(removedNames, imageName)
+	}
+	imageManager.cleanupAuditLogger.LogImageRemoval(imageID, removedNames, reason)
+}
+
+// isImageManaged returns true if the image is tracked by the image manager
+func (imageManager *dockerImageManager) isImageManaged(imageID string) bool {
+	imageManager.updateLock.RLock()
+	defer imageManager.updateLock.RUnlock()
+	_, ok := imageManager.getImageState(imageID)
+	return ok
+}
+
+// isUnmanagedResourceExcluded returns true if any of the names matches a name
+// or pattern in the unmanaged cleanup exclusion list
+func (imageManager *dockerImageManager) isUnmanagedResourceExcluded(names ...string) bool {
+	for _, name := range names {
+		for _, pattern := range imageManager.unmanagedExcluded {
+			// Patterns are validated when the configuration is loaded
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isUnmanagedImageExcluded returns true if any of the image names is excluded
+// from the unmanaged cleanup or from the image cleanup, or is also a name of
+// an image that has been pinned
+func (imageManager *dockerImageManager) isUnmanagedImageExcluded(imageNames ...string) bool {
+	if imageManager.isUnmanagedResourceExcluded(imageNames...) {
+		return true
+	}
+	for _, imageName := range imageNames {
+		if imageManager.isImageNameExcluded(imageName) {
+			return true
+		}
+	}
+
+	imageManager.updateLock.RLock()
+	defer imageManager.updateLock.RUnlock()
+	for _, imageState := range imageManager.getAllImageStates() {
+		if !imageState.IsPinned() {
+			continue
+		}
+		for _, imageName := range imageNames {
+			if imageState.HasImageName(imageName) {
+				return true
+			}
+		}
+	}
+	return false
+}
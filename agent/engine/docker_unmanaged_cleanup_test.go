@@ -0,0 +1,217 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	mock_audit "github.com/aws/amazon-ecs-agent/agent/logger/audit/mocks"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func exitedContainer(name string, image string, exitedFor time.Duration) *docker.Container {
+	return &docker.Container{
+		Name:   "/" + name,
+		Image:  image,
+		Config: &docker.Config{Image: name + "-image"},
+		State:  docker.State{FinishedAt: time.Now().Add(-exitedFor)},
+	}
+}
+
+func TestRemoveUnmanagedResourcesDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	// No calls are expected to the docker client or the audit log
+	imageManager := &dockerImageManager{client: client, state: dockerstate.NewDockerTaskEngineState()}
+	imageManager.removeUnmanagedResources()
+}
+
+func TestRemoveUnmanagedContainers(t *testing.T) {
+	running := exitedContainer("running", "sha256:running", 0)
+	running.State = docker.State{Running: true}
+	labeled := exitedContainer("labeled", "sha256:labeled", 2*time.Hour)
+	labeled.Config.Labels = map[string]string{labelPrefix + "task-arn": "lost"}
+	excludedImage := exitedContainer("excluded", "sha256:excluded", 2*time.Hour)
+	excludedImage.Config.Image = "keep/tools"
+	cleanupExcludedImage := exitedContainer("agent", "sha256:agent", 2*time.Hour)
+	cleanupExcludedImage.Config.Image = "amazon/amazon-ecs-agent:latest"
+
+	for _, tc := range []struct {
+		name                string
+		managedImageID      string
+		managed             bool
+		container           *docker.Container
+		removeErr           error
+		removed             bool
+		expectedImagesInUse map[string]bool
+	}{
+		{"running", "", false, running, nil, false, map[string]bool{"sha256:running": true}},
+		{"recent", "", false, exitedContainer("recent", "sha256:recent", time.Minute), nil, false, map[string]bool{"sha256:recent": true}},
+		{"labeled", "", false, labeled, nil, false, map[string]bool{"sha256:labeled": true}},
+		{"excluded name", "", false, exitedContainer("keep-me", "sha256:named", 2*time.Hour), nil, false, map[string]bool{"sha256:named": true}},
+		{"excluded image", "", false, excludedImage, nil, false, map[string]bool{"sha256:excluded": true}},
+		{"image excluded from image cleanup", "", false, cleanupExcludedImage, nil, false, map[string]bool{"sha256:agent": true}},
+		{"stray", "", false, exitedContainer("stray", "sha256:stray", 2*time.Hour), nil, true, map[string]bool{}},
+		{"remove error", "", false, exitedContainer("failed", "sha256:failed", 2*time.Hour), errors.New("conflict"), false, map[string]bool{"sha256:failed": true}},
+		// Containers known to the engine are only inspected when the ID of
+		// their image has not been recorded
+		{"managed", "sha256:managed", true, nil, nil, false, map[string]bool{"sha256:managed": true}},
+		{"managed without image ID", "", true, exitedContainer("managed", "sha256:managed", 2*time.Hour), nil, false, map[string]bool{"sha256:managed": true}},
+	} {
+		ctrl := gomock.NewController(t)
+		client := NewMockDockerClient(ctrl)
+		auditLogger := mock_audit.NewMockCleanupAuditLogger(ctrl)
+		imageManager := &dockerImageManager{
+			client:                       client,
+			state:                        dockerstate.NewDockerTaskEngineState(),
+			unmanagedContainerMinimumAge: time.Hour,
+			unmanagedExcluded:            []string{"keep/*", "keep-me"},
+			excludedImages:               []string{"amazon/amazon-ecs-agent:*"},
+			cleanupAuditLogger:           auditLogger,
+		}
+		if tc.managed {
+			container := &api.Container{Name: "c", ImageID: tc.managedImageID}
+			imageManager.state.AddContainer(&api.DockerContainer{DockerId: "id", Container: container}, &api.Task{Arn: "arn"})
+		}
+
+		client.EXPECT().ListContainers(true, ListContainersTimeout).Return(ListContainersResponse{DockerIDs: []string{"id"}})
+		if tc.container != nil {
+			client.EXPECT().InspectContainer("id", inspectContainerTimeout).Return(tc.container, nil)
+		}
+		if tc.removed || tc.removeErr != nil {
+			client.EXPECT().RemoveContainer("id", removeContainerTimeout).Return(tc.removeErr)
+		}
+		if tc.removed {
+			auditLogger.EXPECT().LogContainerRemoval("id", tc.container.Name[1:], gomock.Any())
+		}
+
+		imagesInUse, err := imageManager.removeUnmanagedContainers()
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expectedImagesInUse, imagesInUse, tc.name)
+		ctrl.Finish()
+	}
+}
+
+func TestRemoveUnmanagedImages(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	oldReason := "unmanaged image created 48h0m0s ago"
+	conflict := errors.New("conflict")
+
+	for _, tc := range []struct {
+		name         string
+		listedImage  docker.APIImages
+		createdAt    time.Time
+		removals     []string
+		removeErrs   []error
+		audited      bool
+		auditedNames []string
+		auditReason  string
+	}{
+		{"managed", docker.APIImages{ID: "sha256:managed", RepoTags: []string{"managed:latest"}}, time.Time{}, nil, nil, false, nil, ""},
+		{"in use", docker.APIImages{ID: "sha256:inuse", RepoTags: []string{"inuse:latest"}}, time.Time{}, nil, nil, false, nil, ""},
+		{"excluded", docker.APIImages{ID: "sha256:excluded", RepoTags: []string{"keep/base:latest"}}, time.Time{}, nil, nil, false, nil, ""},
+		{"excluded from image cleanup", docker.APIImages{ID: "sha256:agent", RepoTags: []string{"amazon/amazon-ecs-agent:latest"}}, time.Time{}, nil, nil, false, nil, ""},
+		// A newer image under the name of a pinned image keeps the name
+		{"pinned name", docker.APIImages{ID: "sha256:newpinned", RepoTags: []string{"pinned:latest"}}, time.Time{}, nil, nil, false, nil, ""},
+		{"new", docker.APIImages{ID: "sha256:new", RepoTags: []string{"new:latest"}}, time.Now(), nil, nil, false, nil, ""},
+		{"dangling", docker.APIImages{ID: "sha256:dangling", RepoTags: []string{danglingImageTag}}, time.Time{},
+			[]string{"sha256:dangling"}, []error{nil}, true, nil, "dangling image"},
+		{"old", docker.APIImages{ID: "sha256:old", RepoTags: []string{"old:latest", "old:1"}}, old,
+			[]string{"old:latest", "old:1"}, []error{nil, nil}, true, []string{"old:latest", "old:1"}, oldReason},
+		{"remove error", docker.APIImages{ID: "sha256:failed", RepoTags: []string{"failed:latest", "failed:1"}}, old,
+			[]string{"failed:latest"}, []error{conflict}, false, nil, ""},
+		// Names removed before an error are still recorded
+		{"partially untagged", docker.APIImages{ID: "sha256:partial", RepoTags: []string{"partial:latest", "partial:1"}}, old,
+			[]string{"partial:latest", "partial:1"}, []error{nil, conflict}, true, []string{"partial:latest"}, oldReason + ", partially untagged"},
+	} {
+		ctrl := gomock.NewController(t)
+		client := NewMockDockerClient(ctrl)
+		auditLogger := mock_audit.NewMockCleanupAuditLogger(ctrl)
+		imageManager := &dockerImageManager{
+			client:                   client,
+			state:                    dockerstate.NewDockerTaskEngineState(),
+			unmanagedImageMinimumAge: 24 * time.Hour,
+			unmanagedExcluded:        []string{"keep/*"},
+			excludedImages:           []string{"amazon/amazon-ecs-agent:*"},
+			cleanupAuditLogger:       auditLogger,
+		}
+		addUnusedImageState(imageManager, "sha256:managed", "managed:latest", time.Now())
+		addUnusedImageState(imageManager, "sha256:pinned", "pinned:latest", time.Now()).SetPinned(true)
+
+		client.EXPECT().ListImages(ListImagesTimeout).Return(ListImagesResponse{Images: []docker.APIImages{tc.listedImage}})
+		if !tc.createdAt.IsZero() {
+			client.EXPECT().InspectImage(tc.listedImage.ID).Return(&docker.Image{Created: tc.createdAt}, nil)
+		}
+		var removals []*gomock.Call
+		for i, reference := range tc.removals {
+			removals = append(removals, client.EXPECT().RemoveImage(reference, removeImageTimeout).Return(tc.removeErrs[i]))
+		}
+		gomock.InOrder(removals...)
+		if tc.audited {
+			auditLogger.EXPECT().LogImageRemoval(tc.listedImage.ID, tc.auditedNames, tc.auditReason)
+		}
+
+		imageManager.removeUnmanagedImages(map[string]bool{"sha256:inuse": true})
+		ctrl.Finish()
+	}
+}
+
+func TestRemoveUnmanagedResourcesListContainersError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+
+	// Images are not removed when the containers using them are unknown
+	client.EXPECT().ListContainers(true, ListContainersTimeout).Return(ListContainersResponse{Error: errors.New("error")})
+
+	imageManager := &dockerImageManager{client: client, state: dockerstate.NewDockerTaskEngineState(), unmanagedCleanupEnabled: true}
+	imageManager.removeUnmanagedResources()
+}
+
+func TestRemoveUnmanagedResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := NewMockDockerClient(ctrl)
+	auditLogger := mock_audit.NewMockCleanupAuditLogger(ctrl)
+
+	gomock.InOrder(
+		client.EXPECT().ListContainers(true, ListContainersTimeout).Return(ListContainersResponse{DockerIDs: []string{"stray"}}),
+		client.EXPECT().InspectContainer("stray", inspectContainerTimeout).Return(exitedContainer("stray", "sha256:stray", 2*time.Hour), nil),
+		client.EXPECT().RemoveContainer("stray", removeContainerTimeout).Return(nil),
+		auditLogger.EXPECT().LogContainerRemoval("stray", "stray", gomock.Any()),
+		client.EXPECT().ListImages(ListImagesTimeout).Return(ListImagesResponse{Images: []docker.APIImages{
+			{ID: "sha256:stray", RepoTags: []string{danglingImageTag}},
+		}}),
+		client.EXPECT().RemoveImage("sha256:stray", removeImageTimeout).Return(nil),
+		auditLogger.EXPECT().LogImageRemoval("sha256:stray", nil, "dangling image"),
+	)
+
+	imageManager := &dockerImageManager{
+		client:                       client,
+		state:                        dockerstate.NewDockerTaskEngineState(),
+		unmanagedCleanupEnabled:      true,
+		unmanagedContainerMinimumAge: time.Hour,
+		cleanupAuditLogger:           auditLogger,
+	}
+	imageManager.removeUnmanagedResources()
+}
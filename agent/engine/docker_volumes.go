@@ -0,0 +1,155 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	"github.com/aws/amazon-ecs-agent/agent/utils"
+	"github.com/cihub/seelog"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// dockerVolumeAPIVersion is the first Docker remote API version with volumes
+	dockerVolumeAPIVersion = dockerclient.Version_1_21
+
+	// A volume can be reported as in use for a moment after the last of its
+	// containers is removed, so removing it is tried several times
+	removeVolumeRetries            = 5
+	removeVolumeRetryStartDelay    = 500 * time.Millisecond
+	removeVolumeRetryMaxDelay      = 5 * time.Second
+	removeVolumeRetryDelayMultiple = 2
+)
+
+// dockerVolumeLock is held while a Docker volume is inspected and created
+type dockerVolumeLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// dockerVolumeLocks holds a lock for each Docker volume name in use, so that
+// volumes with different names are created concurrently
+type dockerVolumeLocks struct {
+	lock  sync.Mutex
+	locks map[string]*dockerVolumeLock
+}
+
+func newDockerVolumeLocks() *dockerVolumeLocks {
+	return &dockerVolumeLocks{locks: make(map[string]*dockerVolumeLock)}
+}
+
+// lockVolume locks the volume name and returns the function that unlocks it. The
+// lock of a name is forgotten once no caller holds or waits for it.
+func (volumeLocks *dockerVolumeLocks) lockVolume(name string) func() {
+	volumeLocks.lock.Lock()
+	volumeLock, ok := volumeLocks.locks[name]
+	if !ok {
+		volumeLock = &dockerVolumeLock{}
+		volumeLocks.locks[name] = volumeLock
+	}
+	volumeLock.waiters++
+	volumeLocks.lock.Unlock()
+
+	volumeLock.Lock()
+	return func() {
+		volumeLock.Unlock()
+		volumeLocks.lock.Lock()
+		defer volumeLocks.lock.Unlock()
+		volumeLock.waiters--
+		if volumeLock.waiters == 0 {
+			delete(volumeLocks.locks, name)
+		}
+	}
+}
+
+// createDockerVolumes makes sure each Docker volume that the container mounts
+// exists before the container is created
+func (engine *DockerTaskEngine) createDockerVolumes(task *api.Task, container *api.Container) engineError {
+	for _, mountPoint := range container.MountPoints {
+		hostVolume, ok := task.HostVolumeByName(mountPoint.SourceVolume)
+		if !ok {
+			// Reported when the container's binds are resolved
+			continue
+		}
+		volume, ok := hostVolume.(*api.DockerVolume)
+		if !ok {
+			continue
+		}
+		if err := engine.createDockerVolume(mountPoint.SourceVolume, volume); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (engine *DockerTaskEngine) createDockerVolume(name string, volume *api.DockerVolume) engineError {
+	if err := volume.Validate(); err != nil {
+		return CannotCreateVolumeError{name, err.Error()}
+	}
+
+	unlock := engine.dockerVolumeLocks.lockVolume(volume.DockerVolumeName)
+	defer unlock()
+	client := engine.client.WithVersion(dockerVolumeAPIVersion)
+	resp := client.InspectVolume(volume.DockerVolumeName, inspectVolumeTimeout)
+	if resp.Error == nil {
+		// A task scoped volume exists if another of the task's containers
+		// created it first, or if the agent restarted since creating it
+		if volume.Scope == api.DockerVolumeSharedScope && volume.Driver != "" && resp.DockerVolume.Driver != volume.Driver {
+			return CannotCreateVolumeError{name, "shared volume " + volume.DockerVolumeName +
+				" already exists with driver " + resp.DockerVolume.Driver + " instead of " + volume.Driver}
+		}
+		return nil
+	}
+	if resp.Error != docker.ErrNoSuchVolume {
+		return CannotCreateVolumeError{name, "unable to inspect volume " + volume.DockerVolumeName + ": " + resp.Error.Error()}
+	}
+	if volume.Scope == api.DockerVolumeSharedScope && !volume.Autoprovision {
+		return CannotCreateVolumeError{name, "shared volume " + volume.DockerVolumeName + " does not exist and is not set to be autoprovisioned"}
+	}
+
+	seelog.Infof("Creating Docker volume %s with driver %q", volume.DockerVolumeName, volume.Driver)
+	resp = client.CreateVolume(volume.DockerVolumeName, volume.Driver, volume.DriverOpts, volume.Labels, createVolumeTimeout)
+	if resp.Error != nil {
+		return CannotCreateVolumeError{name, "unable to create volume " + volume.DockerVolumeName + ": " + resp.Error.Error()}
+	}
+	return nil
+}
+
+// removeDockerVolumes removes the task scoped Docker volumes of a task whose
+// containers have been removed. Shared volumes are left in place.
+func (engine *DockerTaskEngine) removeDockerVolumes(task *api.Task) {
+	for _, taskVolume := range task.Volumes {
+		volume, ok := taskVolume.Volume.(*api.DockerVolume)
+		if !ok || volume.Scope != api.DockerVolumeTaskScope || volume.DockerVolumeName == "" {
+			continue
+		}
+		client := engine.client.WithVersion(dockerVolumeAPIVersion)
+		backoff := utils.NewSimpleBackoff(removeVolumeRetryStartDelay, removeVolumeRetryMaxDelay, 0.2, removeVolumeRetryDelayMultiple)
+		err := utils.RetryNWithBackoff(backoff, removeVolumeRetries, func() error {
+			err := client.RemoveVolume(volume.DockerVolumeName, removeVolumeTimeout)
+			if err != nil && err != docker.ErrNoSuchVolume {
+				seelog.Infof("Unable to remove Docker volume %s of task %s, retrying: %v", volume.DockerVolumeName, task.Arn, err)
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			seelog.Warnf("Unable to remove Docker volume %s of task %s, leaving it in place: %v", volume.DockerVolumeName, task.Arn, err)
+		}
+	}
+}
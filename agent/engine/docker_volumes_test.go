@@ -0,0 +1,168 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func dockerVolumeTestTask(volume *api.DockerVolume) *api.Task {
+	return &api.Task{
+		Arn: "arn",
+		Containers: []*api.Container{{
+			Name:        "app",
+			MountPoints: []api.MountPoint{{SourceVolume: "data", ContainerPath: "/data"}},
+		}},
+		Volumes: []api.TaskVolume{
+			{Name: "data", Volume: volume},
+			{Name: "host", Volume: &api.FSHostVolume{FSSourcePath: "/host"}},
+		},
+	}
+}
+
+func TestCreateDockerVolumesCreatesMissingVolume(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	volume := &api.DockerVolume{
+		Scope:            api.DockerVolumeTaskScope,
+		Driver:           "local",
+		DriverOpts:       map[string]string{"type": "tmpfs"},
+		Labels:           map[string]string{"team": "storage"},
+		DockerVolumeName: "ecs-family-1-data-abcd",
+	}
+	task := dockerVolumeTestTask(volume)
+	client.EXPECT().WithVersion(dockerclient.Version_1_21).Return(client)
+	gomock.InOrder(
+		client.EXPECT().InspectVolume("ecs-family-1-data-abcd", inspectVolumeTimeout).Return(VolumeResponse{Error: docker.ErrNoSuchVolume}),
+		client.EXPECT().CreateVolume("ecs-family-1-data-abcd", "local", volume.DriverOpts, volume.Labels, createVolumeTimeout).Return(VolumeResponse{DockerVolume: &docker.Volume{}}),
+	)
+
+	assert.Nil(t, taskEngine.(*DockerTaskEngine).createDockerVolumes(task, task.Containers[0]))
+}
+
+func TestCreateDockerVolumesUsesExistingVolume(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := dockerVolumeTestTask(&api.DockerVolume{
+		Scope:            api.DockerVolumeSharedScope,
+		Driver:           "rexray/ebs",
+		DockerVolumeName: "data",
+	})
+	client.EXPECT().WithVersion(dockerclient.Version_1_21).Return(client)
+	client.EXPECT().InspectVolume("data", inspectVolumeTimeout).Return(VolumeResponse{DockerVolume: &docker.Volume{Name: "data", Driver: "rexray/ebs"}})
+
+	assert.Nil(t, taskEngine.(*DockerTaskEngine).createDockerVolumes(task, task.Containers[0]))
+}
+
+func TestCreateDockerVolumesSharedVolumeErrors(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	volume := &api.DockerVolume{
+		Scope:            api.DockerVolumeSharedScope,
+		Driver:           "rexray/ebs",
+		DockerVolumeName: "data",
+	}
+	task := dockerVolumeTestTask(volume)
+	client.EXPECT().WithVersion(dockerclient.Version_1_21).Return(client).Times(2)
+
+	// Missing, and not to be autoprovisioned
+	client.EXPECT().InspectVolume("data", inspectVolumeTimeout).Return(VolumeResponse{Error: docker.ErrNoSuchVolume})
+	err := taskEngine.(*DockerTaskEngine).createDockerVolumes(task, task.Containers[0])
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "CannotCreateVolumeError", err.ErrorName())
+	}
+
+	// Created earlier with another driver
+	volume.Autoprovision = true
+	client.EXPECT().InspectVolume("data", inspectVolumeTimeout).Return(VolumeResponse{DockerVolume: &docker.Volume{Name: "data", Driver: "local"}})
+	err = taskEngine.(*DockerTaskEngine).createDockerVolumes(task, task.Containers[0])
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "CannotCreateVolumeError", err.ErrorName())
+	}
+
+	// Invalid configurations fail before Docker is called
+	volume.Scope = api.DockerVolumeTaskScope
+	err = taskEngine.(*DockerTaskEngine).createDockerVolumes(task, task.Containers[0])
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "CannotCreateVolumeError", err.ErrorName())
+	}
+}
+
+func TestSweepTaskRemovesTaskScopedVolumes(t *testing.T) {
+	ctrl, client, _, taskEngine, _, imageManager := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := dockerVolumeTestTask(&api.DockerVolume{Scope: api.DockerVolumeTaskScope, DockerVolumeName: "ecs-family-1-data-abcd"})
+	task.Volumes = append(task.Volumes, api.TaskVolume{
+		Name:   "shared",
+		Volume: &api.DockerVolume{Scope: api.DockerVolumeSharedScope, DockerVolumeName: "shared"},
+	})
+	imageManager.EXPECT().RemoveContainerReferenceFromImageState(gomock.Any()).Return(nil)
+	client.EXPECT().WithVersion(dockerclient.Version_1_21).Return(client)
+	client.EXPECT().RemoveVolume("ecs-family-1-data-abcd", removeVolumeTimeout).Return(nil)
+
+	taskEngine.(*DockerTaskEngine).sweepTask(task)
+}
+
+func TestSweepTaskRetriesVolumeRemoval(t *testing.T) {
+	ctrl, client, _, taskEngine, _, imageManager := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := dockerVolumeTestTask(&api.DockerVolume{Scope: api.DockerVolumeTaskScope, DockerVolumeName: "ecs-family-1-data-abcd"})
+	imageManager.EXPECT().RemoveContainerReferenceFromImageState(gomock.Any()).Return(nil)
+	client.EXPECT().WithVersion(dockerclient.Version_1_21).Return(client)
+	gomock.InOrder(
+		client.EXPECT().RemoveVolume("ecs-family-1-data-abcd", removeVolumeTimeout).Return(errors.New("volume is in use")),
+		client.EXPECT().RemoveVolume("ecs-family-1-data-abcd", removeVolumeTimeout).Return(nil),
+	)
+
+	taskEngine.(*DockerTaskEngine).sweepTask(task)
+}
+
+func TestDockerVolumeLocks(t *testing.T) {
+	volumeLocks := newDockerVolumeLocks()
+	unlockData := volumeLocks.lockVolume("data")
+
+	// Other volumes are not held up by the lock of data
+	unlockLogs := volumeLocks.lockVolume("logs")
+	unlockLogs()
+
+	done := make(chan struct{})
+	go func() {
+		unlock := volumeLocks.lockVolume("data")
+		unlock()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Expected the second lock of data to wait for the first")
+	case <-time.After(10 * time.Millisecond):
+	}
+	unlockData()
+	<-done
+
+	assert.Empty(t, volumeLocks.locks, "Locks no longer held should be forgotten")
+}
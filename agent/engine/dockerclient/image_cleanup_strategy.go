@@ -0,0 +1,42 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerclient
+
+// ImageCleanupStrategy determines the order in which the image cleanup
+// deletes the images that are eligible for deletion
+type ImageCleanupStrategy string
+
+const (
+	// ImageCleanupLeastRecentlyUsedStrategy deletes the images that have gone
+	// unused the longest first
+	ImageCleanupLeastRecentlyUsedStrategy ImageCleanupStrategy = "lru"
+	// ImageCleanupLargestFirstStrategy deletes the largest images first
+	ImageCleanupLargestFirstStrategy ImageCleanupStrategy = "largest-first"
+	// ImageCleanupOldestPulledStrategy deletes the images that were pulled
+	// the longest ago first
+	ImageCleanupOldestPulledStrategy ImageCleanupStrategy = "oldest-pulled"
+	// ImageCleanupLeastFrequentlyUsedStrategy deletes the images used by the
+	// fewest containers first
+	ImageCleanupLeastFrequentlyUsedStrategy ImageCleanupStrategy = "lfu"
+)
+
+// IsValid returns true if the strategy is one the agent understands
+func (strategy ImageCleanupStrategy) IsValid() bool {
+	switch strategy {
+	case ImageCleanupLeastRecentlyUsedStrategy, ImageCleanupLargestFirstStrategy,
+		ImageCleanupOldestPulledStrategy, ImageCleanupLeastFrequentlyUsedStrategy:
+		return true
+	}
+	return false
+}
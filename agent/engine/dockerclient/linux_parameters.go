@@ -0,0 +1,83 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerclient
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LinuxParameter names a Linux specific setting of a container's host config
+type LinuxParameter string
+
+const (
+	LinuxCapabilitiesParameter     LinuxParameter = "capabilities"
+	LinuxDevicesParameter          LinuxParameter = "devices"
+	LinuxUlimitsParameter          LinuxParameter = "ulimits"
+	LinuxSysctlsParameter          LinuxParameter = "sysctls"
+	LinuxSharedMemorySizeParameter LinuxParameter = "shm-size"
+	LinuxTmpfsParameter            LinuxParameter = "tmpfs"
+	LinuxPidModeParameter          LinuxParameter = "pid-mode"
+	LinuxIpcModeParameter          LinuxParameter = "ipc-mode"
+	// The security options below are only set by the instance-wide container
+	// defaults
+	LinuxNoNewPrivilegesParameter LinuxParameter = "no-new-privileges"
+	LinuxSeccompParameter         LinuxParameter = "seccomp"
+)
+
+// LinuxParameterMinimumVersion is the first Docker remote API version that
+// accepts each Linux parameter
+var LinuxParameterMinimumVersion = map[LinuxParameter]DockerVersion{
+	LinuxCapabilitiesParameter:     Version_1_17,
+	LinuxDevicesParameter:          Version_1_17,
+	LinuxUlimitsParameter:          Version_1_18,
+	LinuxSysctlsParameter:          Version_1_24,
+	LinuxSharedMemorySizeParameter: Version_1_22,
+	LinuxTmpfsParameter:            Version_1_22,
+	LinuxPidModeParameter:          Version_1_17,
+	LinuxIpcModeParameter:          Version_1_17,
+	LinuxNoNewPrivilegesParameter:  Version_1_23,
+	LinuxSeccompParameter:          Version_1_22,
+}
+
+var ulimitNames = map[string]bool{
+	"core": true, "cpu": true, "data": true, "fsize": true, "locks": true, "memlock": true,
+	"msgqueue": true, "nice": true, "nofile": true, "nproc": true, "rss": true, "rtprio": true,
+	"rttime": true, "sigpending": true, "stack": true,
+}
+
+// IsUlimitName returns true if Docker knows the named ulimit
+func IsUlimitName(name string) bool {
+	return ulimitNames[name]
+}
+
+// AtLeast returns true if the version is the same as, or later than, other
+func (version DockerVersion) AtLeast(other DockerVersion) bool {
+	major, minor := version.parts()
+	otherMajor, otherMinor := other.parts()
+	if major != otherMajor {
+		return major > otherMajor
+	}
+	return minor >= otherMinor
+}
+
+func (version DockerVersion) parts() (int, int) {
+	parts := strings.SplitN(string(version), ".", 2)
+	major, _ := strconv.Atoi(parts[0])
+	minor := 0
+	if len(parts) == 2 {
+		minor, _ = strconv.Atoi(parts[1])
+	}
+	return major, minor
+}
@@ -0,0 +1,29 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerVersionAtLeast(t *testing.T) {
+	assert.True(t, Version_1_24.AtLeast(Version_1_17))
+	assert.True(t, Version_1_22.AtLeast(Version_1_22))
+	assert.False(t, Version_1_18.AtLeast(Version_1_24))
+	assert.True(t, DockerVersion("2.0").AtLeast(Version_1_24))
+	assert.False(t, Version_1_24.AtLeast(DockerVersion("2.0")))
+}
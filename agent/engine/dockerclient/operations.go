@@ -0,0 +1,49 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerclient
+
+// DockerOperation is a kind of call made to the Docker daemon whose
+// concurrency can be limited
+type DockerOperation string
+
+const (
+	PullOperation            DockerOperation = "pull"
+	CreateOperation          DockerOperation = "create"
+	StartOperation           DockerOperation = "start"
+	StopOperation            DockerOperation = "stop"
+	RemoveContainerOperation DockerOperation = "removeContainer"
+	RemoveImageOperation     DockerOperation = "removeImage"
+	InspectOperation         DockerOperation = "inspect"
+)
+
+// DockerOperations lists every DockerOperation in the order they are reported
+var DockerOperations = []DockerOperation{
+	PullOperation,
+	CreateOperation,
+	StartOperation,
+	StopOperation,
+	RemoveContainerOperation,
+	RemoveImageOperation,
+	InspectOperation,
+}
+
+// IsValid returns true if the operation is one that can be limited
+func (op DockerOperation) IsValid() bool {
+	for _, known := range DockerOperations {
+		if op == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerclient
+
+// ImagePullBehavior controls whether an image is pulled before a container
+// that uses it is created
+type ImagePullBehavior string
+
+const (
+	// ImagePullAlwaysBehavior pulls the image for every container
+	ImagePullAlwaysBehavior ImagePullBehavior = "always"
+	// ImagePullOnceBehavior pulls the image only if it has not already been
+	// pulled for a previous container on this instance and is still present
+	ImagePullOnceBehavior ImagePullBehavior = "once"
+	// ImagePullPreferCachedBehavior pulls the image only if it is not present
+	// on the instance, regardless of how it got there
+	ImagePullPreferCachedBehavior ImagePullBehavior = "prefer-cached"
+)
+
+// IsValid returns true if the behavior is one the agent understands
+func (behavior ImagePullBehavior) IsValid() bool {
+	switch behavior {
+	case ImagePullAlwaysBehavior, ImagePullOnceBehavior, ImagePullPreferCachedBehavior:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerclient
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+var signalsByName = map[string]docker.Signal{
+	"ABRT":   docker.SIGABRT,
+	"ALRM":   docker.SIGALRM,
+	"HUP":    docker.SIGHUP,
+	"INT":    docker.SIGINT,
+	"KILL":   docker.SIGKILL,
+	"PWR":    docker.SIGPWR,
+	"QUIT":   docker.SIGQUIT,
+	"STOP":   docker.SIGSTOP,
+	"TERM":   docker.SIGTERM,
+	"USR1":   docker.SIGUSR1,
+	"USR2":   docker.SIGUSR2,
+	"WINCH":  docker.SIGWINCH,
+	"XCPU":   docker.SIGXCPU,
+	"XFSZ":   docker.SIGXFSZ,
+	"VTALRM": docker.SIGVTALRM,
+}
+
+// ParseSignal converts a signal given by name, with or without the 'SIG'
+// prefix, or by number into a signal that can be sent with KillContainer
+func ParseSignal(signal string) (docker.Signal, error) {
+	if number, err := strconv.Atoi(signal); err == nil {
+		if number <= 0 || number > 64 {
+			return 0, errors.New("invalid signal number " + signal)
+		}
+		return docker.Signal(number), nil
+	}
+	name := strings.TrimPrefix(strings.ToUpper(signal), "SIG")
+	if parsed, ok := signalsByName[name]; ok {
+		return parsed, nil
+	}
+	return 0, errors.New("unrecognized signal '" + signal + "'")
+}
@@ -0,0 +1,45 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerclient
+
+import (
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestParseSignal(t *testing.T) {
+	testCases := map[string]docker.Signal{
+		"SIGQUIT": docker.SIGQUIT,
+		"quit":    docker.SIGQUIT,
+		"USR1":    docker.SIGUSR1,
+		"15":      docker.SIGTERM,
+	}
+	for input, expected := range testCases {
+		signal, err := ParseSignal(input)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %s: %v", input, err)
+		}
+		if signal != expected {
+			t.Errorf("Expected %s to parse as %d, got %d", input, expected, signal)
+		}
+	}
+
+	for _, input := range []string{"", "SIGFOO", "0", "100"} {
+		if _, err := ParseSignal(input); err == nil {
+			t.Errorf("Expected error parsing %q", input)
+		}
+	}
+}
@@ -167,6 +167,7 @@ func TestFindAvailableVersiosn(t *testing.T) {
 	mockClient121 := mock_dockeriface.NewMockClient(ctrl)
 	mockClient122 := mock_dockeriface.NewMockClient(ctrl)
 	mockClient123 := mock_dockeriface.NewMockClient(ctrl)
+	mockClient124 := mock_dockeriface.NewMockClient(ctrl)
 
 	expectedEndpoint := "expectedEndpoint"
 
@@ -190,6 +191,8 @@ func TestFindAvailableVersiosn(t *testing.T) {
 			return mockClient122, nil
 		case Version_1_23:
 			return mockClient123, nil
+		case Version_1_24:
+			return mockClient124, nil
 		default:
 			t.Fatal("Unrecognized version")
 		}
@@ -203,8 +206,9 @@ func TestFindAvailableVersiosn(t *testing.T) {
 	mockClient121.EXPECT().Ping()
 	mockClient122.EXPECT().Ping()
 	mockClient123.EXPECT().Ping()
+	mockClient124.EXPECT().Ping()
 
-	expectedVersions := []DockerVersion{Version_1_17, Version_1_19, Version_1_20, Version_1_21, Version_1_22, Version_1_23}
+	expectedVersions := []DockerVersion{Version_1_17, Version_1_19, Version_1_20, Version_1_21, Version_1_22, Version_1_23, Version_1_24}
 
 	factory := NewFactory(expectedEndpoint)
 	versions := factory.FindAvailableVersions()
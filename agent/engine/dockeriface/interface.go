@@ -25,20 +25,28 @@ import (
 type Client interface {
 	AddEventListener(listener chan<- *docker.APIEvents) error
 	CreateContainer(opts docker.CreateContainerOptions) (*docker.Container, error)
+	CreateExec(opts docker.CreateExecOptions) (*docker.Exec, error)
+	CreateVolume(opts docker.CreateVolumeOptions) (*docker.Volume, error)
 	ImportImage(opts docker.ImportImageOptions) error
 	InspectContainer(id string) (*docker.Container, error)
 	InspectContainerWithContext(id string, ctx context.Context) (*docker.Container, error)
+	InspectExec(id string) (*docker.ExecInspect, error)
 	InspectImage(name string) (*docker.Image, error)
+	InspectVolume(name string) (*docker.Volume, error)
+	KillContainer(opts docker.KillContainerOptions) error
 	ListContainers(opts docker.ListContainersOptions) ([]docker.APIContainers, error)
+	ListImages(opts docker.ListImagesOptions) ([]docker.APIImages, error)
 	Ping() error
 	PullImage(opts docker.PullImageOptions, auth docker.AuthConfiguration) error
 	RemoveContainer(opts docker.RemoveContainerOptions) error
 	RemoveEventListener(listener chan *docker.APIEvents) error
 	StartContainer(id string, hostConfig *docker.HostConfig) error
 	StartContainerWithContext(id string, hostConfig *docker.HostConfig, ctx context.Context) error
+	StartExec(id string, opts docker.StartExecOptions) error
 	StopContainer(id string, timeout uint) error
 	StopContainerWithContext(id string, timeout uint, ctx context.Context) error
 	Stats(opts docker.StatsOptions) error
 	Version() (*docker.Env, error)
 	RemoveImage(imageName string) error
+	RemoveVolume(name string) error
 }
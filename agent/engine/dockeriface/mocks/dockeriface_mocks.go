@@ -64,6 +64,28 @@ func (_mr *_MockClientRecorder) CreateContainer(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateContainer", arg0)
 }
 
+func (_m *MockClient) CreateExec(_param0 go_dockerclient.CreateExecOptions) (*go_dockerclient.Exec, error) {
+	ret := _m.ctrl.Call(_m, "CreateExec", _param0)
+	ret0, _ := ret[0].(*go_dockerclient.Exec)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) CreateExec(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateExec", arg0)
+}
+
+func (_m *MockClient) CreateVolume(_param0 go_dockerclient.CreateVolumeOptions) (*go_dockerclient.Volume, error) {
+	ret := _m.ctrl.Call(_m, "CreateVolume", _param0)
+	ret0, _ := ret[0].(*go_dockerclient.Volume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) CreateVolume(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateVolume", arg0)
+}
+
 func (_m *MockClient) ImportImage(_param0 go_dockerclient.ImportImageOptions) error {
 	ret := _m.ctrl.Call(_m, "ImportImage", _param0)
 	ret0, _ := ret[0].(error)
@@ -96,6 +118,17 @@ func (_mr *_MockClientRecorder) InspectContainerWithContext(arg0, arg1 interface
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "InspectContainerWithContext", arg0, arg1)
 }
 
+func (_m *MockClient) InspectExec(_param0 string) (*go_dockerclient.ExecInspect, error) {
+	ret := _m.ctrl.Call(_m, "InspectExec", _param0)
+	ret0, _ := ret[0].(*go_dockerclient.ExecInspect)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) InspectExec(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "InspectExec", arg0)
+}
+
 func (_m *MockClient) InspectImage(_param0 string) (*go_dockerclient.Image, error) {
 	ret := _m.ctrl.Call(_m, "InspectImage", _param0)
 	ret0, _ := ret[0].(*go_dockerclient.Image)
@@ -107,6 +140,27 @@ func (_mr *_MockClientRecorder) InspectImage(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "InspectImage", arg0)
 }
 
+func (_m *MockClient) InspectVolume(_param0 string) (*go_dockerclient.Volume, error) {
+	ret := _m.ctrl.Call(_m, "InspectVolume", _param0)
+	ret0, _ := ret[0].(*go_dockerclient.Volume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) InspectVolume(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "InspectVolume", arg0)
+}
+
+func (_m *MockClient) KillContainer(_param0 go_dockerclient.KillContainerOptions) error {
+	ret := _m.ctrl.Call(_m, "KillContainer", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockClientRecorder) KillContainer(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "KillContainer", arg0)
+}
+
 func (_m *MockClient) ListContainers(_param0 go_dockerclient.ListContainersOptions) ([]go_dockerclient.APIContainers, error) {
 	ret := _m.ctrl.Call(_m, "ListContainers", _param0)
 	ret0, _ := ret[0].([]go_dockerclient.APIContainers)
@@ -118,6 +172,17 @@ func (_mr *_MockClientRecorder) ListContainers(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListContainers", arg0)
 }
 
+func (_m *MockClient) ListImages(_param0 go_dockerclient.ListImagesOptions) ([]go_dockerclient.APIImages, error) {
+	ret := _m.ctrl.Call(_m, "ListImages", _param0)
+	ret0, _ := ret[0].([]go_dockerclient.APIImages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) ListImages(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListImages", arg0)
+}
+
 func (_m *MockClient) Ping() error {
 	ret := _m.ctrl.Call(_m, "Ping")
 	ret0, _ := ret[0].(error)
@@ -168,6 +233,16 @@ func (_mr *_MockClientRecorder) RemoveImage(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "RemoveImage", arg0)
 }
 
+func (_m *MockClient) RemoveVolume(_param0 string) error {
+	ret := _m.ctrl.Call(_m, "RemoveVolume", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockClientRecorder) RemoveVolume(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RemoveVolume", arg0)
+}
+
 func (_m *MockClient) StartContainer(_param0 string, _param1 *go_dockerclient.HostConfig) error {
 	ret := _m.ctrl.Call(_m, "StartContainer", _param0, _param1)
 	ret0, _ := ret[0].(error)
@@ -188,6 +263,16 @@ func (_mr *_MockClientRecorder) StartContainerWithContext(arg0, arg1, arg2 inter
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "StartContainerWithContext", arg0, arg1, arg2)
 }
 
+func (_m *MockClient) StartExec(_param0 string, _param1 go_dockerclient.StartExecOptions) error {
+	ret := _m.ctrl.Call(_m, "StartExec", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockClientRecorder) StartExec(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "StartExec", arg0, arg1)
+}
+
 func (_m *MockClient) Stats(_param0 go_dockerclient.StatsOptions) error {
 	ret := _m.ctrl.Call(_m, "Stats", _param0)
 	ret0, _ := ret[0].(error)
@@ -228,3 +313,4 @@ func (_m *MockClient) Version() (*go_dockerclient.Env, error) {
 func (_mr *_MockClientRecorder) Version() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Version")
 }
+
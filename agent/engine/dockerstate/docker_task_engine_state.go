@@ -171,6 +171,12 @@ func (state *DockerTaskEngineState) AddContainer(container *api.DockerContainer,
 		existingMap = make(map[string]*api.DockerContainer, len(task.Containers))
 		state.taskToId[task.Arn] = existingMap
 	}
+	// A container that is restarted is recreated with a new docker id; stop
+	// tracking the one it replaces so events for it are no longer routed here
+	if previous, ok := existingMap[container.Container.Name]; ok && previous.DockerId != "" && previous.DockerId != container.DockerId {
+		delete(state.idToTask, previous.DockerId)
+		delete(state.idToContainer, previous.DockerId)
+	}
 	existingMap[container.Container.Name] = container
 
 	if container.DockerId != "" {
@@ -128,6 +128,31 @@ func TestTwophaseAddContainer(t *testing.T) {
 	}
 }
 
+func TestAddContainerReplacesDockerId(t *testing.T) {
+	state := NewDockerTaskEngineState()
+	testContainer := &api.Container{
+		Name: "c1",
+	}
+	testTask := &api.Task{
+		Arn:        "t1",
+		Containers: []*api.Container{testContainer},
+	}
+
+	state.AddTask(testTask)
+	state.AddContainer(&api.DockerContainer{DockerId: "did1", Container: testContainer}, testTask)
+	state.AddContainer(&api.DockerContainer{DockerId: "did2", Container: testContainer}, testTask)
+
+	if _, ok := state.ContainerById("did1"); ok {
+		t.Error("Expected replaced container id to no longer be tracked")
+	}
+	if _, ok := state.TaskById("did1"); ok {
+		t.Error("Expected replaced container id to no longer map to the task")
+	}
+	if _, ok := state.ContainerById("did2"); !ok {
+		t.Error("Expected new container id to be tracked")
+	}
+}
+
 func TestRemoveTask(t *testing.T) {
 	state := NewDockerTaskEngineState()
 	testContainer := &api.Container{
@@ -0,0 +1,235 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/cihub/seelog"
+)
+
+// emptyVolumePermissions are the permissions of the directories backing
+// empty volumes, the same as those of the volumes Docker creates
+const emptyVolumePermissions = 0755
+
+// initializeEmptyVolumes picks a directory under the empty volume data root
+// for each empty volume of the task that does not have one yet. Tasks
+// checkpointed by older agents lose the internal container that used to back
+// their empty volumes if it has not been created yet.
+func (engine *DockerTaskEngine) initializeEmptyVolumes(task *api.Task) {
+	if task.RemoveEmptyVolumeContainer() {
+		seelog.Infof("Task %s: empty volumes are no longer backed by a container", task.Arn)
+	}
+	for _, taskVolume := range task.Volumes {
+		volume, ok := taskVolume.Volume.(*api.EmptyHostVolume)
+		if !ok || volume.HostPath != "" {
+			continue
+		}
+		if taskVolume.Name != filepath.Base(taskVolume.Name) || taskVolume.Name == "." || taskVolume.Name == ".." {
+			// Leaving the host path empty fails the containers that mount it
+			seelog.Warnf("Task %s: invalid empty volume name %q", task.Arn, taskVolume.Name)
+			continue
+		}
+		volume.HostPath = filepath.Join(engine.emptyVolumeTaskDir(task), taskVolume.Name)
+	}
+}
+
+// emptyVolumeTaskDir returns the directory holding the empty volumes of a
+// task, which is named after the task's ID
+func (engine *DockerTaskEngine) emptyVolumeTaskDir(task *api.Task) string {
+	return filepath.Join(engine.cfg.EmptyVolumeDataRoot, taskDirName(task))
+}
+
+// agentPath returns the path at which the agent sees a path on the host. They
+// differ below the host data directory, which is mounted as the data
+// directory of the agent when it runs in a container.
+func (engine *DockerTaskEngine) agentPath(hostPath string) string {
+	if engine.cfg.DataDirOnHost == "" || engine.cfg.DataDir == "" {
+		return hostPath
+	}
+	relative, err := filepath.Rel(engine.cfg.DataDirOnHost, hostPath)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return hostPath
+	}
+	return filepath.Join(engine.cfg.DataDir, relative)
+}
+
+// taskDirName returns the name of the host directories the agent creates for
+// a task: its ID, with any character that could not be used in a path
+// replaced
+func taskDirName(task *api.Task) string {
+	return dirName(task.Arn[strings.LastIndex(task.Arn, "/")+1:])
+}
+
+// dirName replaces the characters of name other than letters, digits,
+// dashes and underscores so that it can be used as a directory name
+func dirName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '-'
+	}, name)
+}
+
+// createEmptyVolumes creates the directories backing the empty volumes that
+// the container mounts before the container is created
+func (engine *DockerTaskEngine) createEmptyVolumes(task *api.Task, container *api.Container) engineError {
+	taskDir := engine.emptyVolumeTaskDir(task)
+	for _, mountPoint := range container.MountPoints {
+		hostVolume, ok := task.HostVolumeByName(mountPoint.SourceVolume)
+		if !ok {
+			continue
+		}
+		volume, ok := hostVolume.(*api.EmptyHostVolume)
+		if !ok || filepath.Dir(volume.HostPath) != taskDir {
+			// Volumes created by the internal container of an older agent
+			// are managed by Docker
+			continue
+		}
+		if err := volume.Validate(); err != nil {
+			return CannotCreateVolumeError{mountPoint.SourceVolume, err.Error()}
+		}
+		path := engine.agentPath(volume.HostPath)
+		if volume.SizeLimit > 0 && path != volume.HostPath {
+			// Docker would not see the filesystem mounted by the agent
+			return CannotCreateVolumeError{mountPoint.SourceVolume, "size limited empty volumes need an empty volume data root outside of the host data directory"}
+		}
+		_, err := os.Stat(path)
+		created := os.IsNotExist(err)
+		if err := os.MkdirAll(path, emptyVolumePermissions); err != nil {
+			return CannotCreateVolumeError{mountPoint.SourceVolume, "unable to create directory " + path + ": " + err.Error()}
+		}
+		if volume.SizeLimit > 0 {
+			engine.emptyVolumeLock.Lock()
+			err := mountScratchVolume(volume)
+			engine.emptyVolumeLock.Unlock()
+			if err != nil {
+				return CannotCreateVolumeError{mountPoint.SourceVolume, "unable to mount a filesystem of " + strconv.FormatInt(volume.SizeLimit, 10) + " MiB at " + volume.HostPath + ": " + err.Error()}
+			}
+		}
+		if created {
+			if err := engine.setEmptyVolumeOwner(task, container, path); err != nil {
+				return CannotCreateVolumeError{mountPoint.SourceVolume, "unable to change the owner of " + path + ": " + err.Error()}
+			}
+		}
+	}
+	return nil
+}
+
+// setEmptyVolumeOwner gives a new empty volume to the user that the first
+// container mounting it runs as, so that it can write to the volume when it
+// does not run as root. Users given by name cannot be looked up on the host,
+// so their volumes are left to root.
+func (engine *DockerTaskEngine) setEmptyVolumeOwner(task *api.Task, container *api.Container, path string) error {
+	if !emptyVolumeOwnersSupported {
+		return nil
+	}
+	config, err := task.DockerConfig(container)
+	if err != nil || config.User == "" {
+		// Invalid configs fail the creation of the container afterwards
+		return nil
+	}
+	uid, gid, ok := numericUser(config.User)
+	if !ok {
+		seelog.Warnf("Task %s: empty volume %s is owned by root, as user %q of container %s is not numeric", task.Arn, path, config.User, container.Name)
+		return nil
+	}
+	return os.Chown(path, uid, gid)
+}
+
+// numericUser parses a user given as "uid" or "uid:gid". The gid is -1 when
+// it is not given.
+func numericUser(user string) (int, int, bool) {
+	parts := strings.SplitN(user, ":", 2)
+	uid, err := strconv.Atoi(parts[0])
+	if err != nil || uid < 0 {
+		return 0, 0, false
+	}
+	gid := -1
+	if len(parts) == 2 {
+		gid, err = strconv.Atoi(parts[1])
+		if err != nil || gid < 0 {
+			return 0, 0, false
+		}
+	}
+	return uid, gid, true
+}
+
+// removeEmptyVolumes deletes the directory holding the empty volumes of a
+// task whose containers have been removed
+func (engine *DockerTaskEngine) removeEmptyVolumes(task *api.Task) {
+	taskDir := engine.emptyVolumeTaskDir(task)
+	if engine.cfg.EmptyVolumeDataRoot == "" || taskDir == filepath.Clean(engine.cfg.EmptyVolumeDataRoot) {
+		return
+	}
+	for _, volume := range scratchVolumes(task) {
+		if filepath.Dir(volume.HostPath) != taskDir {
+			continue
+		}
+		if err := unmountScratchVolume(volume); err != nil {
+			// Removing the directory would delete the files of the volume
+			// through the mount and leave the mount behind
+			seelog.Warnf("Task %s: unable to unmount %s: %v", task.Arn, volume.HostPath, err)
+			return
+		}
+	}
+	if err := os.RemoveAll(engine.agentPath(taskDir)); err != nil {
+		seelog.Warnf("Task %s: unable to remove empty volumes in %s: %v", task.Arn, taskDir, err)
+	}
+}
+
+// ScratchVolumeUsage is the space used in a size limited empty volume
+type ScratchVolumeUsage struct {
+	VolumeName string
+	UsedBytes  uint64
+	SizeBytes  uint64
+}
+
+// TaskScratchVolumeUsage returns the usage of the size limited empty volumes
+// of a task that are currently mounted
+func TaskScratchVolumeUsage(task *api.Task) []ScratchVolumeUsage {
+	var usage []ScratchVolumeUsage
+	for _, taskVolume := range task.Volumes {
+		volume, ok := taskVolume.Volume.(*api.EmptyHostVolume)
+		if !ok || volume.SizeLimit == 0 || volume.HostPath == "" {
+			continue
+		}
+		used, size, err := scratchVolumeUsage(volume)
+		if err != nil {
+			continue
+		}
+		usage = append(usage, ScratchVolumeUsage{
+			VolumeName: taskVolume.Name,
+			UsedBytes:  used,
+			SizeBytes:  size,
+		})
+	}
+	return usage
+}
+
+// scratchVolumes returns the size limited empty volumes of a task
+func scratchVolumes(task *api.Task) []*api.EmptyHostVolume {
+	var volumes []*api.EmptyHostVolume
+	for _, taskVolume := range task.Volumes {
+		if volume, ok := taskVolume.Volume.(*api.EmptyHostVolume); ok && volume.SizeLimit > 0 {
+			volumes = append(volumes, volume)
+		}
+	}
+	return volumes
+}
@@ -0,0 +1,188 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func emptyVolumeTestTask() *api.Task {
+	return &api.Task{
+		Arn: "arn:aws:ecs:us-west-2:1234567890:task/f44b4fc9-adb0-4f4f-9dff-871512310588",
+		Containers: []*api.Container{{
+			Name:        "app",
+			MountPoints: []api.MountPoint{{SourceVolume: "scratch", ContainerPath: "/scratch"}},
+		}},
+		Volumes: []api.TaskVolume{
+			{Name: "scratch", Volume: &api.EmptyHostVolume{}},
+			{Name: "host", Volume: &api.FSHostVolume{FSSourcePath: "/host"}},
+		},
+	}
+}
+
+func emptyVolumeTestDataRoot(t *testing.T) (string, func()) {
+	dataRoot, err := ioutil.TempDir("", "ecs-empty-volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dataRoot, func() { os.RemoveAll(dataRoot) }
+}
+
+func TestEmptyVolumesLifecycle(t *testing.T) {
+	dataRoot, cleanup := emptyVolumeTestDataRoot(t)
+	defer cleanup()
+	cfg := defaultTestConfig()
+	cfg.EmptyVolumeDataRoot = dataRoot
+	ctrl, _, _, taskEngine, _, imageManager := mocks(t, cfg)
+	defer ctrl.Finish()
+	engine := taskEngine.(*DockerTaskEngine)
+
+	task := emptyVolumeTestTask()
+	engine.initializeEmptyVolumes(task)
+	taskDir := filepath.Join(dataRoot, "f44b4fc9-adb0-4f4f-9dff-871512310588")
+	hostPath := filepath.Join(taskDir, "scratch")
+	assert.Equal(t, hostPath, task.Volumes[0].Volume.SourcePath())
+	assert.Equal(t, "/host", task.Volumes[1].Volume.SourcePath())
+
+	assert.Nil(t, engine.createEmptyVolumes(task, task.Containers[0]))
+	info, err := os.Stat(hostPath)
+	if assert.Nil(t, err) {
+		assert.True(t, info.IsDir())
+	}
+
+	imageManager.EXPECT().RemoveContainerReferenceFromImageState(gomock.Any()).Return(nil)
+	engine.sweepTask(task)
+	_, err = os.Stat(taskDir)
+	assert.True(t, os.IsNotExist(err), "Task directory should have been removed")
+	_, err = os.Stat(dataRoot)
+	assert.Nil(t, err, "Data root should be kept")
+}
+
+func TestInitializeEmptyVolumesKeepsHostPath(t *testing.T) {
+	ctrl, _, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := emptyVolumeTestTask()
+	task.Volumes[0].Volume = &api.EmptyHostVolume{HostPath: "/var/lib/docker/volumes/abcd/_data"}
+	task.Volumes = append(task.Volumes, api.TaskVolume{Name: "../escape", Volume: &api.EmptyHostVolume{}})
+	taskEngine.(*DockerTaskEngine).initializeEmptyVolumes(task)
+
+	assert.Equal(t, "/var/lib/docker/volumes/abcd/_data", task.Volumes[0].Volume.SourcePath())
+	assert.Empty(t, task.Volumes[2].Volume.SourcePath(), "Names leaving the task directory should be rejected")
+}
+
+func TestInitializeEmptyVolumesMigratesInternalContainer(t *testing.T) {
+	ctrl, _, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := emptyVolumeTestTask()
+	task.Containers[0].RunDependencies = []string{"~internal~ecs-emptyvolume-source"}
+	task.Containers = append(task.Containers, &api.Container{
+		Name:        "~internal~ecs-emptyvolume-source",
+		Image:       "amazon/ecs-emptyvolume-base:autogenerated",
+		MountPoints: []api.MountPoint{{SourceVolume: "scratch", ContainerPath: "/ecs-empty-volume/scratch"}},
+		IsInternal:  true,
+	})
+	taskEngine.(*DockerTaskEngine).initializeEmptyVolumes(task)
+
+	assert.Equal(t, 1, len(task.Containers))
+	assert.Empty(t, task.Containers[0].RunDependencies)
+	assert.Equal(t, filepath.Join(defaultConfig.EmptyVolumeDataRoot, "f44b4fc9-adb0-4f4f-9dff-871512310588", "scratch"), task.Volumes[0].Volume.SourcePath())
+}
+
+func TestCreateEmptyVolumesInvalidSizeLimit(t *testing.T) {
+	dataRoot, cleanup := emptyVolumeTestDataRoot(t)
+	defer cleanup()
+	cfg := defaultTestConfig()
+	cfg.EmptyVolumeDataRoot = dataRoot
+	ctrl, _, _, taskEngine, _, _ := mocks(t, cfg)
+	defer ctrl.Finish()
+	engine := taskEngine.(*DockerTaskEngine)
+
+	task := emptyVolumeTestTask()
+	task.Volumes[0].Volume = &api.EmptyHostVolume{SizeLimit: 64, Backing: "zram"}
+	engine.initializeEmptyVolumes(task)
+
+	err := engine.createEmptyVolumes(task, task.Containers[0])
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "CannotCreateVolumeError", err.ErrorName())
+	}
+	_, statErr := os.Stat(task.Volumes[0].Volume.SourcePath())
+	assert.True(t, os.IsNotExist(statErr), "Invalid volumes should not be created")
+}
+
+func TestEmptyVolumesInHostDataDir(t *testing.T) {
+	dataDir, cleanup := emptyVolumeTestDataRoot(t)
+	defer cleanup()
+	cfg := defaultTestConfig()
+	cfg.DataDir = dataDir
+	cfg.DataDirOnHost = "/var/lib/ecs/data"
+	cfg.EmptyVolumeDataRoot = "/var/lib/ecs/data/volumes"
+	ctrl, _, _, taskEngine, _, imageManager := mocks(t, cfg)
+	defer ctrl.Finish()
+	engine := taskEngine.(*DockerTaskEngine)
+
+	task := emptyVolumeTestTask()
+	engine.initializeEmptyVolumes(task)
+	assert.Equal(t, "/var/lib/ecs/data/volumes/f44b4fc9-adb0-4f4f-9dff-871512310588/scratch", task.Volumes[0].Volume.SourcePath(), "Docker should be given the path on the host")
+
+	assert.Nil(t, engine.createEmptyVolumes(task, task.Containers[0]))
+	taskDir := filepath.Join(dataDir, "volumes", "f44b4fc9-adb0-4f4f-9dff-871512310588")
+	_, err := os.Stat(filepath.Join(taskDir, "scratch"))
+	assert.Nil(t, err, "The volume should be created in the data directory of the agent")
+
+	imageManager.EXPECT().RemoveContainerReferenceFromImageState(gomock.Any()).Return(nil)
+	engine.sweepTask(task)
+	_, err = os.Stat(taskDir)
+	assert.True(t, os.IsNotExist(err), "Task directory should have been removed")
+
+	task = emptyVolumeTestTask()
+	task.Volumes[0].Volume = &api.EmptyHostVolume{SizeLimit: 64}
+	engine.initializeEmptyVolumes(task)
+	err = engine.createEmptyVolumes(task, task.Containers[0])
+	assert.NotNil(t, err, "Size limited volumes cannot be mounted in the data directory of the agent")
+}
+
+func TestNumericUser(t *testing.T) {
+	testCases := []struct {
+		user string
+		uid  int
+		gid  int
+		ok   bool
+	}{
+		{"1000", 1000, -1, true},
+		{"1000:100", 1000, 100, true},
+		{"0:0", 0, 0, true},
+		{"nginx", 0, 0, false},
+		{"1000:staff", 0, 0, false},
+		{"-1", 0, 0, false},
+	}
+
+	for _, tc := range testCases {
+		uid, gid, ok := numericUser(tc.user)
+		assert.Equal(t, tc.ok, ok, tc.user)
+		if tc.ok {
+			assert.Equal(t, tc.uid, uid, tc.user)
+			assert.Equal(t, tc.gid, gid, tc.user)
+		}
+	}
+}
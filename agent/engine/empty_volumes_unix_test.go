@@ -0,0 +1,53 @@
+// +build !windows,!integration
+
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmptyVolumesOwnedByContainerUser(t *testing.T) {
+	dataRoot, cleanup := emptyVolumeTestDataRoot(t)
+	defer cleanup()
+	cfg := defaultTestConfig()
+	cfg.EmptyVolumeDataRoot = dataRoot
+	ctrl, _, _, taskEngine, _, _ := mocks(t, cfg)
+	defer ctrl.Finish()
+	engine := taskEngine.(*DockerTaskEngine)
+
+	// Only root can give files away
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 {
+		uid, gid = 1234, 5678
+	}
+	user := fmt.Sprintf(`{"User":"%d:%d"}`, uid, gid)
+	task := emptyVolumeTestTask()
+	task.Containers[0].DockerConfig.Config = &user
+	engine.initializeEmptyVolumes(task)
+
+	assert.Nil(t, engine.createEmptyVolumes(task, task.Containers[0]))
+	info, err := os.Stat(task.Volumes[0].Volume.SourcePath())
+	if assert.Nil(t, err) {
+		stat := info.Sys().(*syscall.Stat_t)
+		assert.Equal(t, uint32(uid), stat.Uid)
+		assert.Equal(t, uint32(gid), stat.Gid)
+	}
+}
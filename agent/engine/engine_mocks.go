@@ -128,6 +128,14 @@ func (_mr *_MockTaskEngineRecorder) MustInit() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "MustInit")
 }
 
+func (_m *MockTaskEngine) SetContainerInstanceArn(_param0 string) {
+	_m.ctrl.Call(_m, "SetContainerInstanceArn", _param0)
+}
+
+func (_mr *_MockTaskEngineRecorder) SetContainerInstanceArn(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetContainerInstanceArn", arg0)
+}
+
 func (_m *MockTaskEngine) SetSaver(_param0 statemanager.Saver) {
 	_m.ctrl.Call(_m, "SetSaver", _param0)
 }
@@ -210,6 +218,16 @@ func (_mr *_MockDockerClientRecorder) CreateContainer(arg0, arg1, arg2, arg3 int
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateContainer", arg0, arg1, arg2, arg3)
 }
 
+func (_m *MockDockerClient) CreateVolume(_param0 string, _param1 string, _param2 map[string]string, _param3 map[string]string, _param4 time.Duration) VolumeResponse {
+	ret := _m.ctrl.Call(_m, "CreateVolume", _param0, _param1, _param2, _param3, _param4)
+	ret0, _ := ret[0].(VolumeResponse)
+	return ret0
+}
+
+func (_mr *_MockDockerClientRecorder) CreateVolume(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateVolume", arg0, arg1, arg2, arg3, arg4)
+}
+
 func (_m *MockDockerClient) DescribeContainer(_param0 string) (api.ContainerStatus, DockerContainerMetadata) {
 	ret := _m.ctrl.Call(_m, "DescribeContainer", _param0)
 	ret0, _ := ret[0].(api.ContainerStatus)
@@ -221,6 +239,16 @@ func (_mr *_MockDockerClientRecorder) DescribeContainer(arg0 interface{}) *gomoc
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DescribeContainer", arg0)
 }
 
+func (_m *MockDockerClient) ExecContainer(_param0 string, _param1 []string, _param2 time.Duration) DockerExecResult {
+	ret := _m.ctrl.Call(_m, "ExecContainer", _param0, _param1, _param2)
+	ret0, _ := ret[0].(DockerExecResult)
+	return ret0
+}
+
+func (_mr *_MockDockerClientRecorder) ExecContainer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ExecContainer", arg0, arg1, arg2)
+}
+
 func (_m *MockDockerClient) InspectContainer(_param0 string, _param1 time.Duration) (*go_dockerclient.Container, error) {
 	ret := _m.ctrl.Call(_m, "InspectContainer", _param0, _param1)
 	ret0, _ := ret[0].(*go_dockerclient.Container)
@@ -243,6 +271,16 @@ func (_mr *_MockDockerClientRecorder) InspectImage(arg0 interface{}) *gomock.Cal
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "InspectImage", arg0)
 }
 
+func (_m *MockDockerClient) InspectVolume(_param0 string, _param1 time.Duration) VolumeResponse {
+	ret := _m.ctrl.Call(_m, "InspectVolume", _param0, _param1)
+	ret0, _ := ret[0].(VolumeResponse)
+	return ret0
+}
+
+func (_mr *_MockDockerClientRecorder) InspectVolume(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "InspectVolume", arg0, arg1)
+}
+
 func (_m *MockDockerClient) ListContainers(_param0 bool, _param1 time.Duration) ListContainersResponse {
 	ret := _m.ctrl.Call(_m, "ListContainers", _param0, _param1)
 	ret0, _ := ret[0].(ListContainersResponse)
@@ -253,6 +291,26 @@ func (_mr *_MockDockerClientRecorder) ListContainers(arg0, arg1 interface{}) *go
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListContainers", arg0, arg1)
 }
 
+func (_m *MockDockerClient) ListImages(_param0 time.Duration) ListImagesResponse {
+	ret := _m.ctrl.Call(_m, "ListImages", _param0)
+	ret0, _ := ret[0].(ListImagesResponse)
+	return ret0
+}
+
+func (_mr *_MockDockerClientRecorder) ListImages(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListImages", arg0)
+}
+
+func (_m *MockDockerClient) OperationStats() []DockerOperationStats {
+	ret := _m.ctrl.Call(_m, "OperationStats")
+	ret0, _ := ret[0].([]DockerOperationStats)
+	return ret0
+}
+
+func (_mr *_MockDockerClientRecorder) OperationStats() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "OperationStats")
+}
+
 func (_m *MockDockerClient) PullImage(_param0 string, _param1 *api.RegistryAuthenticationData) DockerContainerMetadata {
 	ret := _m.ctrl.Call(_m, "PullImage", _param0, _param1)
 	ret0, _ := ret[0].(DockerContainerMetadata)
@@ -283,6 +341,16 @@ func (_mr *_MockDockerClientRecorder) RemoveImage(arg0, arg1 interface{}) *gomoc
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "RemoveImage", arg0, arg1)
 }
 
+func (_m *MockDockerClient) RemoveVolume(_param0 string, _param1 time.Duration) error {
+	ret := _m.ctrl.Call(_m, "RemoveVolume", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockDockerClientRecorder) RemoveVolume(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RemoveVolume", arg0, arg1)
+}
+
 func (_m *MockDockerClient) StartContainer(_param0 string, _param1 time.Duration) DockerContainerMetadata {
 	ret := _m.ctrl.Call(_m, "StartContainer", _param0, _param1)
 	ret0, _ := ret[0].(DockerContainerMetadata)
@@ -304,14 +372,14 @@ func (_mr *_MockDockerClientRecorder) Stats(arg0, arg1 interface{}) *gomock.Call
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Stats", arg0, arg1)
 }
 
-func (_m *MockDockerClient) StopContainer(_param0 string, _param1 time.Duration) DockerContainerMetadata {
-	ret := _m.ctrl.Call(_m, "StopContainer", _param0, _param1)
+func (_m *MockDockerClient) StopContainer(_param0 string, _param1 time.Duration, _param2 string, _param3 time.Duration) DockerContainerMetadata {
+	ret := _m.ctrl.Call(_m, "StopContainer", _param0, _param1, _param2, _param3)
 	ret0, _ := ret[0].(DockerContainerMetadata)
 	return ret0
 }
 
-func (_mr *_MockDockerClientRecorder) StopContainer(arg0, arg1 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "StopContainer", arg0, arg1)
+func (_mr *_MockDockerClientRecorder) StopContainer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "StopContainer", arg0, arg1, arg2, arg3)
 }
 
 func (_m *MockDockerClient) SupportedVersions() []dockerclient.DockerVersion {
@@ -374,6 +442,14 @@ func (_mr *_MockImageManagerRecorder) AddAllImageStates(arg0 interface{}) *gomoc
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "AddAllImageStates", arg0)
 }
 
+func (_m *MockImageManager) CheckDiskPressure() {
+	_m.ctrl.Call(_m, "CheckDiskPressure")
+}
+
+func (_mr *_MockImageManagerRecorder) CheckDiskPressure() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CheckDiskPressure")
+}
+
 func (_m *MockImageManager) GetImageStateFromImageName(_param0 string) *image.ImageState {
 	ret := _m.ctrl.Call(_m, "GetImageStateFromImageName", _param0)
 	ret0, _ := ret[0].(*image.ImageState)
@@ -384,6 +460,26 @@ func (_mr *_MockImageManagerRecorder) GetImageStateFromImageName(arg0 interface{
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetImageStateFromImageName", arg0)
 }
 
+func (_m *MockImageManager) ImageCleanupDryRun() ImageCleanupPlan {
+	ret := _m.ctrl.Call(_m, "ImageCleanupDryRun")
+	ret0, _ := ret[0].(ImageCleanupPlan)
+	return ret0
+}
+
+func (_mr *_MockImageManagerRecorder) ImageCleanupDryRun() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ImageCleanupDryRun")
+}
+
+func (_m *MockImageManager) ImagePrewarmStatus() []ImagePrewarmStatus {
+	ret := _m.ctrl.Call(_m, "ImagePrewarmStatus")
+	ret0, _ := ret[0].([]ImagePrewarmStatus)
+	return ret0
+}
+
+func (_mr *_MockImageManagerRecorder) ImagePrewarmStatus() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ImagePrewarmStatus")
+}
+
 func (_m *MockImageManager) RecordContainerReference(_param0 *api.Container) error {
 	ret := _m.ctrl.Call(_m, "RecordContainerReference", _param0)
 	ret0, _ := ret[0].(error)
@@ -404,6 +500,17 @@ func (_mr *_MockImageManagerRecorder) RemoveContainerReferenceFromImageState(arg
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "RemoveContainerReferenceFromImageState", arg0)
 }
 
+func (_m *MockImageManager) SetImagePinned(_param0 string, _param1 bool) (*image.ImageState, bool) {
+	ret := _m.ctrl.Call(_m, "SetImagePinned", _param0, _param1)
+	ret0, _ := ret[0].(*image.ImageState)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+func (_mr *_MockImageManagerRecorder) SetImagePinned(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetImagePinned", arg0, arg1)
+}
+
 func (_m *MockImageManager) SetSaver(_param0 statemanager.Saver) {
 	_m.ctrl.Call(_m, "SetSaver", _param0)
 }
@@ -419,3 +526,19 @@ func (_m *MockImageManager) StartImageCleanupProcess(_param0 context.Context) {
 func (_mr *_MockImageManagerRecorder) StartImageCleanupProcess(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "StartImageCleanupProcess", arg0)
 }
+
+func (_m *MockImageManager) StartDiskPressureProcess(_param0 context.Context) {
+	_m.ctrl.Call(_m, "StartDiskPressureProcess", _param0)
+}
+
+func (_mr *_MockImageManagerRecorder) StartDiskPressureProcess(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "StartDiskPressureProcess", arg0)
+}
+
+func (_m *MockImageManager) StartImagePrewarmProcess(_param0 context.Context) {
+	_m.ctrl.Call(_m, "StartImagePrewarmProcess", _param0)
+}
+
+func (_mr *_MockImageManagerRecorder) StartImagePrewarmProcess(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "StartImagePrewarmProcess", arg0)
+}
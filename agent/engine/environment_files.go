@@ -0,0 +1,301 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/httpclient"
+	"github.com/cihub/seelog"
+)
+
+const (
+	environmentFileTimeout = 30 * time.Second
+	// maxEnvironmentFileSize bounds the size of the environment files read
+	maxEnvironmentFileSize = 1024 * 1024
+)
+
+// environmentFiles loads the environment files of containers. The variables
+// of each file are kept until the task is cleaned up, so that every file is
+// only fetched once per task.
+type environmentFiles struct {
+	client *http.Client
+	// root is the directory that environment files on the host must be in
+	root string
+
+	lock  sync.Mutex
+	tasks map[string]*taskEnvironmentFiles
+}
+
+// taskEnvironmentFiles holds the variables of the environment files of a
+// task, by location. Its lock is held while a file is fetched, so that
+// containers sharing a file wait for it rather than fetch it again.
+type taskEnvironmentFiles struct {
+	lock  sync.Mutex
+	files map[string]map[string]string
+}
+
+func newEnvironmentFiles(root string) *environmentFiles {
+	return &environmentFiles{
+		client: httpclient.New(environmentFileTimeout, false),
+		root:   root,
+		tasks:  make(map[string]*taskEnvironmentFiles),
+	}
+}
+
+// environment returns the variables set by the environment files of a
+// container. Files later in the list take precedence over earlier ones.
+func (files *environmentFiles) environment(task *api.Task, container *api.Container) (map[string]string, error) {
+	files.lock.Lock()
+	taskFiles, ok := files.tasks[task.Arn]
+	if !ok {
+		taskFiles = &taskEnvironmentFiles{files: make(map[string]map[string]string)}
+		files.tasks[task.Arn] = taskFiles
+	}
+	files.lock.Unlock()
+
+	taskFiles.lock.Lock()
+	defer taskFiles.lock.Unlock()
+	env := make(map[string]string)
+	for _, location := range container.EnvironmentFiles {
+		fileEnv, ok := taskFiles.files[location]
+		if !ok {
+			data, err := files.read(location)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", location, err)
+			}
+			fileEnv, err = parseEnvironmentFile(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", location, err)
+			}
+			seelog.Debugf("Task %s: loaded %d variables from environment file %s", task.Arn, len(fileEnv), location)
+			taskFiles.files[location] = fileEnv
+		}
+		for name, value := range fileEnv {
+			env[name] = value
+		}
+	}
+	return env, nil
+}
+
+// remove forgets the environment files of a task
+func (files *environmentFiles) remove(task *api.Task) {
+	files.lock.Lock()
+	defer files.lock.Unlock()
+	delete(files.tasks, task.Arn)
+}
+
+// read returns the contents of an environment file, which is either an
+// absolute path on the host, under the environment files root, or an http or
+// https URL
+func (files *environmentFiles) read(location string) ([]byte, error) {
+	var reader io.Reader
+	if filepath.IsAbs(location) {
+		path, err := files.resolve(location)
+		if err != nil {
+			return nil, err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	} else {
+		locationURL, err := url.Parse(location)
+		if err != nil || (locationURL.Scheme != "http" && locationURL.Scheme != "https") {
+			return nil, fmt.Errorf("environment file is neither an absolute path nor an http or https URL")
+		}
+		resp, err := files.client.Get(location)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d fetching environment file", resp.StatusCode)
+		}
+		reader = resp.Body
+	}
+	data, err := ioutil.ReadAll(&io.LimitedReader{R: reader, N: maxEnvironmentFileSize + 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxEnvironmentFileSize {
+		return nil, fmt.Errorf("environment file is larger than %d bytes", maxEnvironmentFileSize)
+	}
+	return data, nil
+}
+
+// resolve returns the path of an environment file on the host, with symbolic
+// links resolved, if it is under the environment files root. Files elsewhere,
+// such as the agent's own configuration or secrets, must not be readable by
+// tasks.
+func (files *environmentFiles) resolve(path string) (string, error) {
+	if files.root == "" {
+		return "", errors.New("environment files cannot be read from the host")
+	}
+	root, err := filepath.EvalSymlinks(files.root)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("path is outside of " + files.root)
+	}
+	return resolved, nil
+}
+
+// parseEnvironmentFile parses a file in dotenv format: one NAME=value
+// assignment per line, optionally preceded by "export". Blank lines and lines
+// starting with # are ignored. Values may be single quoted, taken literally,
+// or double quoted, in which case \n, \r, \t, \", \\ and \$ are escapes.
+// Unquoted values end at a # preceded by a space. Errors never quote values,
+// which may be sensitive.
+func parseEnvironmentFile(data []byte) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+			line = strings.TrimSpace(line[len("export"):])
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected NAME=value", lineNumber)
+		}
+		name := strings.TrimSpace(parts[0])
+		if !isEnvironmentName(name) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNumber, name)
+		}
+		value, err := parseEnvironmentValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		env[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// isEnvironmentName returns true if name is a portable name for an
+// environment variable: letters, digits and underscores, not starting with a
+// digit
+func isEnvironmentName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseEnvironmentValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	var parsed bytes.Buffer
+	var rest string
+	switch value[0] {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quoted value")
+		}
+		parsed.WriteString(value[1 : end+1])
+		rest = value[end+2:]
+	case '"':
+		i := 1
+		for ; i < len(value) && value[i] != '"'; i++ {
+			if value[i] != '\\' {
+				parsed.WriteByte(value[i])
+				continue
+			}
+			i++
+			if i == len(value) {
+				break
+			}
+			switch value[i] {
+			case 'n':
+				parsed.WriteByte('\n')
+			case 'r':
+				parsed.WriteByte('\r')
+			case 't':
+				parsed.WriteByte('\t')
+			case '"', '\\', '$':
+				parsed.WriteByte(value[i])
+			default:
+				return "", fmt.Errorf("invalid escape sequence \\%c in double quoted value", value[i])
+			}
+		}
+		if i >= len(value) {
+			return "", fmt.Errorf("unterminated double quoted value")
+		}
+		rest = value[i+1:]
+	default:
+		if comment := strings.Index(value, " #"); comment >= 0 {
+			value = value[:comment]
+		}
+		if comment := strings.Index(value, "\t#"); comment >= 0 {
+			value = value[:comment]
+		}
+		return strings.TrimSpace(value), nil
+	}
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected characters after quoted value")
+	}
+	return parsed.String(), nil
+}
+
+// withEnvironmentFiles returns the environment of a container with the
+// variables of its environment files added. Variables set in the container's
+// environment take precedence over those of its environment files.
+func withEnvironmentFiles(env []string, fileEnv map[string]string) []string {
+	set := make(map[string]bool, len(env))
+	for _, variable := range env {
+		set[strings.SplitN(variable, "=", 2)[0]] = true
+	}
+	merged := make([]string, 0, len(env)+len(fileEnv))
+	for name, value := range fileEnv {
+		if !set[name] {
+			merged = append(merged, name+"="+value)
+		}
+	}
+	return append(merged, env...)
+}
@@ -0,0 +1,186 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvironmentFile(t *testing.T) {
+	env, err := parseEnvironmentFile([]byte(`# database settings
+DB_HOST=db.example.com
+export DB_PORT = 5432
+DB_NAME=app # trailing comment
+DB_URL=postgres://db#primary
+
+GREETING="Hello,\n\"world\""
+LITERAL='$HOME \n'
+EMPTY=
+QUOTED_EMPTY="" # nothing
+`))
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"DB_HOST":      "db.example.com",
+		"DB_PORT":      "5432",
+		"DB_NAME":      "app",
+		"DB_URL":       "postgres://db#primary",
+		"GREETING":     "Hello,\n\"world\"",
+		"LITERAL":      "$HOME \\n",
+		"EMPTY":        "",
+		"QUOTED_EMPTY": "",
+	}, env)
+}
+
+func TestParseEnvironmentFileErrors(t *testing.T) {
+	for _, data := range []string{
+		"NO_ASSIGNMENT",
+		"1ST=value",
+		"MY-VAR=value",
+		"=value",
+		"OPEN=\"secret",
+		"OPEN='secret",
+		"ESCAPE=\"\\q\"",
+		"TRAILING=\"secret\" more",
+	} {
+		_, err := parseEnvironmentFile([]byte("VALID=1\n" + data + "\n"))
+		if assert.Error(t, err, "expected an error for %s", data) {
+			assert.Contains(t, err.Error(), "line 2")
+			assert.NotContains(t, err.Error(), "secret")
+		}
+	}
+}
+
+func TestEnvironmentFilesCachedPerTask(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, "SHARED=http\nREMOTE=1\n")
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "ecs-environment-files")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	local := filepath.Join(dir, "app.env")
+	require.Nil(t, ioutil.WriteFile(local, []byte("SHARED=file\nLOCAL=1\n"), 0644))
+
+	files := newEnvironmentFiles(dir)
+	task := &api.Task{Arn: "arn"}
+	container := &api.Container{EnvironmentFiles: []string{server.URL + "/app.env", local}}
+	for i := 0; i < 2; i++ {
+		env, err := files.environment(task, container)
+		require.Nil(t, err)
+		assert.Equal(t, map[string]string{"SHARED": "file", "REMOTE": "1", "LOCAL": "1"}, env)
+	}
+	assert.Equal(t, 1, requests, "environment file fetched more than once")
+
+	files.remove(task)
+	_, err = files.environment(task, container)
+	require.Nil(t, err)
+	assert.Equal(t, 2, requests, "environment file not fetched again after the task was removed")
+}
+
+func TestEnvironmentFilesInvalidLocation(t *testing.T) {
+	files := newEnvironmentFiles(os.TempDir())
+	task := &api.Task{Arn: "arn"}
+	for _, location := range []string{"app.env", "ftp://example.com/app.env", filepath.Join(os.TempDir(), "does-not-exist.env")} {
+		_, err := files.environment(task, &api.Container{EnvironmentFiles: []string{location}})
+		assert.Error(t, err, "expected an error for %s", location)
+	}
+}
+
+func TestEnvironmentFilesOutsideOfRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs-environment-files")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "etc", "ecs", "environment-files")
+	require.Nil(t, os.MkdirAll(root, 0755))
+	config := filepath.Join(dir, "etc", "ecs", "ecs.config")
+	require.Nil(t, ioutil.WriteFile(config, []byte("ECS_ENGINE_AUTH_DATA=secret\n"), 0644))
+	require.Nil(t, os.Symlink(config, filepath.Join(root, "link.env")))
+
+	files := newEnvironmentFiles(root)
+	task := &api.Task{Arn: "arn"}
+	for _, location := range []string{
+		root + "/../ecs.config",
+		root + "/../../../etc/ecs/ecs.config",
+		filepath.Join(root, "link.env"),
+		"../../etc/ecs/ecs.config",
+	} {
+		_, err := files.environment(task, &api.Container{EnvironmentFiles: []string{location}})
+		assert.Error(t, err, "expected an error for %s", location)
+	}
+
+	_, err = newEnvironmentFiles("").environment(task, &api.Container{EnvironmentFiles: []string{config}})
+	assert.Error(t, err, "expected an error without an environment files root")
+}
+
+func TestCreateContainerWithEnvironmentFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs-environment-files")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	cfg := defaultTestConfig()
+	cfg.EnvironmentFilesRoot = dir
+	ctrl, client, _, taskEngine, _, _ := mocks(t, cfg)
+	defer ctrl.Finish()
+	local := filepath.Join(dir, "app.env")
+	require.Nil(t, ioutil.WriteFile(local, []byte("LOG_LEVEL=debug\nPORT=8080\n"), 0644))
+
+	container := &api.Container{
+		Name:             "app",
+		Environment:      map[string]string{"LOG_LEVEL": "info"},
+		EnvironmentFiles: []string{local},
+	}
+	task := &api.Task{Arn: "arn", Containers: []*api.Container{container}}
+	client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(config *docker.Config, hostConfig, name, timeout interface{}) {
+			env := config.Env
+			sort.Strings(env)
+			assert.Equal(t, []string{"LOG_LEVEL=info", "PORT=8080"}, env)
+		})
+
+	metadata := taskEngine.(*DockerTaskEngine).createContainer(task, container)
+	assert.Nil(t, metadata.Error)
+}
+
+func TestCreateContainerInvalidEnvironmentFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs-environment-files")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	cfg := defaultTestConfig()
+	cfg.EnvironmentFilesRoot = dir
+	ctrl, _, _, taskEngine, _, _ := mocks(t, cfg)
+	defer ctrl.Finish()
+	local := filepath.Join(dir, "app.env")
+	require.Nil(t, ioutil.WriteFile(local, []byte("PORT=8080\nnot an assignment\n"), 0644))
+
+	container := &api.Container{Name: "app", EnvironmentFiles: []string{local}}
+	task := &api.Task{Arn: "arn", Containers: []*api.Container{container}}
+	metadata := taskEngine.(*DockerTaskEngine).createContainer(task, container)
+	assert.IsType(t, CannotXContainerError{}, metadata.Error)
+	assert.Contains(t, metadata.Error.Error(), "Invalid environment file "+local+": line 2")
+}
@@ -137,3 +137,86 @@ func (err TaskStoppedBeforePullBeginError) Error() string {
 func (TaskStoppedBeforePullBeginError) ErrorName() string {
 	return "TaskStoppedBeforePullBeginError"
 }
+
+// TaskStoppedDuringPullError is a type for containers that stopped waiting for
+// their image to be pulled because the task was stopped
+type TaskStoppedDuringPullError struct {
+	taskArn string
+}
+
+func (err TaskStoppedDuringPullError) Error() string {
+	return "Task stopped while waiting for image pull for task: " + err.taskArn
+}
+
+// ErrorName returns the name of the error
+func (TaskStoppedDuringPullError) ErrorName() string {
+	return "TaskStoppedDuringPullError"
+}
+
+// ContainerUnhealthyError is a type for essential containers that were stopped
+// because their health check kept failing
+type ContainerUnhealthyError struct {
+	output string
+}
+
+func (err ContainerUnhealthyError) Error() string {
+	if err.output == "" {
+		return "Container failed its health check"
+	}
+	return "Container failed its health check: " + err.output
+}
+
+// ErrorName returns the name of the error
+func (ContainerUnhealthyError) ErrorName() string {
+	return "ContainerUnhealthyError"
+}
+
+// ImageDigestMismatchError is a type for containers whose image, once pulled,
+// does not have the digest the container was pinned to
+type ImageDigestMismatchError struct {
+	image    string
+	expected string
+	actual   string
+}
+
+func (err ImageDigestMismatchError) Error() string {
+	actual := err.actual
+	if actual == "" {
+		actual = "unknown"
+	}
+	return "Image " + err.image + " has digest " + actual + ", expected " + err.expected
+}
+
+// ErrorName returns the name of the error
+func (ImageDigestMismatchError) ErrorName() string {
+	return "ImageDigestMismatchError"
+}
+
+// TaskAdmissionError is a type for tasks that were stopped before starting
+// because they do not fit on the instance
+type TaskAdmissionError struct {
+	msg string
+}
+
+func (err TaskAdmissionError) Error() string { return err.msg }
+
+// ErrorName returns the name of the error
+func (TaskAdmissionError) ErrorName() string {
+	return "TaskAdmissionError"
+}
+
+// CannotCreateVolumeError is a type for containers that could not be created
+// because a volume they mount could not be created
+type CannotCreateVolumeError struct {
+	volume string
+	msg    string
+}
+
+func (err CannotCreateVolumeError) Error() string {
+	return "Volume " + err.volume + ": " + err.msg
+}
+
+// ErrorName returns the name of the error
+func (CannotCreateVolumeError) ErrorName() string {
+	return "CannotCreateVolumeError"
+}
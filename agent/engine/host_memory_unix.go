@@ -0,0 +1,69 @@
+// +build !windows
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// hostMemory returns the total memory of the instance and the memory that is
+// free for new containers, in MiB. Memory the kernel can reclaim, such as the
+// page cache, counts as free.
+func hostMemory() (int64, int64, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+	return parseMemInfo(file)
+}
+
+// parseMemInfo returns the total and free memory, in MiB, given in the format
+// of /proc/meminfo
+func parseMemInfo(memInfo io.Reader) (int64, int64, error) {
+	fields := make(map[string]int64)
+	scanner := bufio.NewScanner(memInfo)
+	for scanner.Scan() {
+		// Lines look like "MemTotal:       16337456 kB"
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		fields[strings.TrimSuffix(parts[0], ":")] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	total, ok := fields["MemTotal"]
+	if !ok || total == 0 {
+		return 0, 0, errors.New("MemTotal is missing from /proc/meminfo or could not be parsed")
+	}
+	available, ok := fields["MemAvailable"]
+	if !ok {
+		// Kernels older than 3.14 do not estimate the available memory
+		available = fields["MemFree"] + fields["Buffers"] + fields["Cached"]
+	}
+	return total / 1024, available / 1024, nil
+}
@@ -0,0 +1,47 @@
+// +build !windows,!integration
+
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMemInfo(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		memInfo           string
+		expectedTotal     int64
+		expectedAvailable int64
+		expectErr         bool
+	}{
+		{"available", "MemTotal: 2097152 kB\nMemFree: 102400 kB\nMemAvailable: 1048576 kB\n", 2048, 1024, false},
+		{"old kernel", "MemTotal: 2097152 kB\nMemFree: 102400 kB\nBuffers: 102400 kB\nCached: 204800 kB\n", 2048, 400, false},
+		{"missing total", "MemFree: 102400 kB\nMemAvailable: 1048576 kB\n", 0, 0, true},
+		{"unparsable total", "MemTotal: lots kB\nMemAvailable: 1048576 kB\n", 0, 0, true},
+	} {
+		total, available, err := parseMemInfo(strings.NewReader(tc.memInfo))
+		if tc.expectErr {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expectedTotal, total, tc.name)
+		assert.Equal(t, tc.expectedAvailable, available, tc.name)
+	}
+}
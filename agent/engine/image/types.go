@@ -26,6 +26,9 @@ type Image struct {
 	ImageID string
 	Names   []string
 	Size    int64
+	// RepoDigests are the repository digests of the image, each in the form
+	// "repository@sha256:..."
+	RepoDigests []string
 }
 
 // ImageState represents a docker image
@@ -35,6 +38,16 @@ type ImageState struct {
 	Containers []*api.Container `json:"-"`
 	PulledAt   time.Time
 	LastUsedAt time.Time
+	// Pinned images are never removed by the image cleanup
+	Pinned bool
+	// PrewarmPinned records that the image was pinned because the name of a
+	// pre-warmed image resolved to it. The pre-warm pins an image only once,
+	// so that an unpin at runtime is kept until the name moves to another
+	// image.
+	PrewarmPinned bool
+	// UseCount is the number of containers that have been created from the
+	// image since the agent started tracking it
+	UseCount   int
 	updateLock sync.RWMutex
 }
 
@@ -54,12 +67,82 @@ func (imageState *ImageState) AddImageName(imageName string) {
 	}
 }
 
+// SetPinned sets whether the image is protected from image cleanup
+func (imageState *ImageState) SetPinned(pinned bool) {
+	imageState.updateLock.Lock()
+	defer imageState.updateLock.Unlock()
+	imageState.Pinned = pinned
+}
+
+// IsPinned returns true if the image is protected from image cleanup
+func (imageState *ImageState) IsPinned() bool {
+	imageState.updateLock.RLock()
+	defer imageState.updateLock.RUnlock()
+	return imageState.Pinned
+}
+
+// PinForPrewarm pins the image the first time the name of a pre-warmed image
+// resolves to it. It returns true if the image was pinned.
+func (imageState *ImageState) PinForPrewarm() bool {
+	imageState.updateLock.Lock()
+	defer imageState.updateLock.Unlock()
+	if imageState.PrewarmPinned {
+		return false
+	}
+	imageState.PrewarmPinned = true
+	imageState.Pinned = true
+	return true
+}
+
+// ReleasePrewarmPin removes the pin the pre-warm placed on the image, once no
+// pre-warmed name resolves to it any longer. It returns true if the image was
+// unpinned.
+func (imageState *ImageState) ReleasePrewarmPin() bool {
+	imageState.updateLock.Lock()
+	defer imageState.updateLock.Unlock()
+	if !imageState.PrewarmPinned {
+		return false
+	}
+	imageState.PrewarmPinned = false
+	unpinned := imageState.Pinned
+	imageState.Pinned = false
+	return unpinned
+}
+
+// SetRepoDigests records the repository digests of the image
+func (imageState *ImageState) SetRepoDigests(repoDigests []string) {
+	imageState.updateLock.Lock()
+	defer imageState.updateLock.Unlock()
+	imageState.Image.RepoDigests = repoDigests
+}
+
+// GetRepoDigests returns the repository digests of the image
+func (imageState *ImageState) GetRepoDigests() []string {
+	imageState.updateLock.RLock()
+	defer imageState.updateLock.RUnlock()
+	return imageState.Image.RepoDigests
+}
+
+// IncrementUseCount records that a container has been created from the image
+func (imageState *ImageState) IncrementUseCount() {
+	imageState.updateLock.Lock()
+	defer imageState.updateLock.Unlock()
+	imageState.UseCount++
+}
+
+// GetUseCount returns the number of containers created from the image
+func (imageState *ImageState) GetUseCount() int {
+	imageState.updateLock.RLock()
+	defer imageState.updateLock.RUnlock()
+	return imageState.UseCount
+}
+
 func (imageState *ImageState) HasNoAssociatedContainers() bool {
 	return len(imageState.Containers) == 0
 }
 
 func (imageState *ImageState) UpdateImageState(container *api.Container) {
-	imageState.AddImageName(container.Image)
+	imageState.AddImageName(container.ImageReference())
 	imageState.UpdateContainerReference(container)
 }
 
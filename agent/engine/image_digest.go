@@ -0,0 +1,57 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// repoDigestForImage picks, from the repository digests of an inspected
+// image, the digest for the repository the image was referenced by. The
+// digest is returned without the repository, as in "sha256:...".
+func repoDigestForImage(imageName string, repoDigests []string) string {
+	repository, _ := docker.ParseRepositoryTag(imageName)
+	for _, repoDigest := range repoDigests {
+		digestRepository, digest := splitRepoDigest(repoDigest)
+		if digestRepository == repository {
+			return digest
+		}
+	}
+	// An image pulled from one repository has a single digest, whatever
+	// name Docker normalised that repository to
+	if len(repoDigests) == 1 {
+		_, digest := splitRepoDigest(repoDigests[0])
+		return digest
+	}
+	return ""
+}
+
+// splitRepoDigest splits a "repository@sha256:..." reference into the
+// repository and the digest. A bare digest is returned with no repository.
+func splitRepoDigest(repoDigest string) (string, string) {
+	n := strings.LastIndex(repoDigest, "@")
+	if n < 0 {
+		return "", repoDigest
+	}
+	return repoDigest[:n], repoDigest[n+1:]
+}
+
+// imageDigestMatches returns true if the actual digest of an image is the
+// expected one, which may be given either bare or with its repository
+func imageDigestMatches(expected, actual string) bool {
+	_, expectedDigest := splitRepoDigest(expected)
+	return actual != "" && expectedDigest == actual
+}
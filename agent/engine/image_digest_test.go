@@ -0,0 +1,48 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoDigestForImage(t *testing.T) {
+	repoDigests := []string{"localhost:5000/app@sha256:local", "app@sha256:hub"}
+	testCases := []struct {
+		image       string
+		repoDigests []string
+		expected    string
+	}{
+		{"app", repoDigests, "sha256:hub"},
+		{"app:latest", repoDigests, "sha256:hub"},
+		{"localhost:5000/app:1.0", repoDigests, "sha256:local"},
+		{"app@sha256:hub", repoDigests, "sha256:hub"},
+		{"other", repoDigests, ""},
+		{"library/busybox", []string{"busybox@sha256:only"}, "sha256:only"},
+		{"busybox", nil, ""},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, repoDigestForImage(tc.image, tc.repoDigests), tc.image)
+	}
+}
+
+func TestImageDigestMatches(t *testing.T) {
+	assert.True(t, imageDigestMatches("sha256:abc", "sha256:abc"))
+	assert.True(t, imageDigestMatches("busybox@sha256:abc", "sha256:abc"))
+	assert.False(t, imageDigestMatches("sha256:abc", "sha256:def"))
+	assert.False(t, imageDigestMatches("sha256:abc", ""))
+}
@@ -0,0 +1,112 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+)
+
+// ImageEvictionStrategy decides the order in which the image cleanup deletes
+// the images that are eligible for deletion
+type ImageEvictionStrategy interface {
+	// EvictBefore returns true if the first image should be deleted before
+	// the second one
+	EvictBefore(first *image.ImageState, second *image.ImageState) bool
+	// Reason explains why the image is deleted ahead of the others
+	Reason(imageState *image.ImageState) string
+}
+
+// NewImageEvictionStrategy returns the eviction strategy for the configured
+// image cleanup strategy, defaulting to least recently used
+func NewImageEvictionStrategy(strategy dockerclient.ImageCleanupStrategy) ImageEvictionStrategy {
+	switch strategy {
+	case dockerclient.ImageCleanupLargestFirstStrategy:
+		return &largestFirstStrategy{}
+	case dockerclient.ImageCleanupOldestPulledStrategy:
+		return &oldestPulledStrategy{}
+	case dockerclient.ImageCleanupLeastFrequentlyUsedStrategy:
+		return &leastFrequentlyUsedStrategy{}
+	default:
+		return &leastRecentlyUsedStrategy{}
+	}
+}
+
+type leastRecentlyUsedStrategy struct{}
+
+func (*leastRecentlyUsedStrategy) EvictBefore(first *image.ImageState, second *image.ImageState) bool {
+	return first.LastUsedAt.Before(second.LastUsedAt)
+}
+
+func (*leastRecentlyUsedStrategy) Reason(imageState *image.ImageState) string {
+	return "least recently used, last used at " + imageState.LastUsedAt.UTC().Format(time.RFC3339)
+}
+
+type largestFirstStrategy struct{}
+
+func (*largestFirstStrategy) EvictBefore(first *image.ImageState, second *image.ImageState) bool {
+	return first.Image.Size > second.Image.Size
+}
+
+func (*largestFirstStrategy) Reason(imageState *image.ImageState) string {
+	return fmt.Sprintf("largest image, %d bytes", imageState.Image.Size)
+}
+
+type oldestPulledStrategy struct{}
+
+func (*oldestPulledStrategy) EvictBefore(first *image.ImageState, second *image.ImageState) bool {
+	return first.PulledAt.Before(second.PulledAt)
+}
+
+func (*oldestPulledStrategy) Reason(imageState *image.ImageState) string {
+	return "oldest pulled, pulled at " + imageState.PulledAt.UTC().Format(time.RFC3339)
+}
+
+type leastFrequentlyUsedStrategy struct{}
+
+// EvictBefore breaks ties between images used equally often by deleting the
+// least recently used one first
+func (*leastFrequentlyUsedStrategy) EvictBefore(first *image.ImageState, second *image.ImageState) bool {
+	firstUseCount, secondUseCount := first.GetUseCount(), second.GetUseCount()
+	if firstUseCount != secondUseCount {
+		return firstUseCount < secondUseCount
+	}
+	return first.LastUsedAt.Before(second.LastUsedAt)
+}
+
+func (*leastFrequentlyUsedStrategy) Reason(imageState *image.ImageState) string {
+	return fmt.Sprintf("least frequently used, used by %d containers", imageState.GetUseCount())
+}
+
+// imageStatesByEvictionOrder sorts image states in the order the eviction
+// strategy deletes them
+type imageStatesByEvictionOrder struct {
+	imageStates []*image.ImageState
+	strategy    ImageEvictionStrategy
+}
+
+func (byOrder imageStatesByEvictionOrder) Len() int {
+	return len(byOrder.imageStates)
+}
+
+func (byOrder imageStatesByEvictionOrder) Less(i, j int) bool {
+	return byOrder.strategy.EvictBefore(byOrder.imageStates[i], byOrder.imageStates[j])
+}
+
+func (byOrder imageStatesByEvictionOrder) Swap(i, j int) {
+	byOrder.imageStates[i], byOrder.imageStates[j] = byOrder.imageStates[j], byOrder.imageStates[i]
+}
@@ -0,0 +1,84 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEvictionTestImageState(imageID string, size int64, pulledAt time.Time, lastUsedAt time.Time, useCount int) *image.ImageState {
+	return &image.ImageState{
+		Image:      &image.Image{ImageID: imageID, Size: size},
+		PulledAt:   pulledAt,
+		LastUsedAt: lastUsedAt,
+		UseCount:   useCount,
+	}
+}
+
+func TestImageEvictionStrategies(t *testing.T) {
+	now := time.Now()
+	// Each image comes first for exactly one of the strategies
+	recentlyUsedSmall := newEvictionTestImageState("lfu", 10, now.Add(-2*time.Hour), now.Add(-time.Minute), 1)
+	oldestPulled := newEvictionTestImageState("oldest-pulled", 20, now.Add(-3*time.Hour), now.Add(-2*time.Minute), 5)
+	largest := newEvictionTestImageState("largest-first", 30, now.Add(-time.Hour), now.Add(-3*time.Minute), 3)
+	leastRecentlyUsed := newEvictionTestImageState("lru", 5, now.Add(-90*time.Minute), now.Add(-time.Hour), 4)
+	imageStates := []*image.ImageState{recentlyUsedSmall, oldestPulled, largest, leastRecentlyUsed}
+
+	for _, tc := range []struct {
+		strategy      dockerclient.ImageCleanupStrategy
+		expectedOrder []string
+	}{
+		{dockerclient.ImageCleanupLeastRecentlyUsedStrategy, []string{"lru", "largest-first", "oldest-pulled", "lfu"}},
+		{dockerclient.ImageCleanupLargestFirstStrategy, []string{"largest-first", "oldest-pulled", "lfu", "lru"}},
+		{dockerclient.ImageCleanupOldestPulledStrategy, []string{"oldest-pulled", "lfu", "lru", "largest-first"}},
+		{dockerclient.ImageCleanupLeastFrequentlyUsedStrategy, []string{"lfu", "largest-first", "lru", "oldest-pulled"}},
+	} {
+		imageManager := &dockerImageManager{evictionStrategy: NewImageEvictionStrategy(tc.strategy)}
+		var order []string
+		for _, imageState := range imageManager.sortImagesForDeletion(imageStates) {
+			order = append(order, imageState.Image.ImageID)
+		}
+		assert.Equal(t, tc.expectedOrder, order, "Wrong deletion order for strategy %s", tc.strategy)
+	}
+	assert.Equal(t, "lfu", imageStates[0].Image.ImageID, "Sorting should not reorder the candidates passed in")
+}
+
+func TestLeastFrequentlyUsedStrategyBreaksTiesByLastUse(t *testing.T) {
+	strategy := NewImageEvictionStrategy(dockerclient.ImageCleanupLeastFrequentlyUsedStrategy)
+	older := newEvictionTestImageState("older", 0, time.Time{}, time.Now().Add(-time.Hour), 2)
+	newer := newEvictionTestImageState("newer", 0, time.Time{}, time.Now(), 2)
+	assert.True(t, strategy.EvictBefore(older, newer))
+	assert.False(t, strategy.EvictBefore(newer, older))
+}
+
+func TestImageEvictionStrategyReasons(t *testing.T) {
+	pulledAt := time.Date(2016, 10, 1, 12, 0, 0, 0, time.UTC)
+	lastUsedAt := time.Date(2016, 10, 2, 12, 0, 0, 0, time.UTC)
+	imageState := newEvictionTestImageState("sha256:abc", 1024, pulledAt, lastUsedAt, 3)
+
+	assert.Equal(t, "least recently used, last used at 2016-10-02T12:00:00Z",
+		NewImageEvictionStrategy(dockerclient.ImageCleanupLeastRecentlyUsedStrategy).Reason(imageState))
+	assert.Equal(t, "largest image, 1024 bytes",
+		NewImageEvictionStrategy(dockerclient.ImageCleanupLargestFirstStrategy).Reason(imageState))
+	assert.Equal(t, "oldest pulled, pulled at 2016-10-01T12:00:00Z",
+		NewImageEvictionStrategy(dockerclient.ImageCleanupOldestPulledStrategy).Reason(imageState))
+	assert.Equal(t, "least frequently used, used by 3 containers",
+		NewImageEvictionStrategy(dockerclient.ImageCleanupLeastFrequentlyUsedStrategy).Reason(imageState))
+}
@@ -0,0 +1,123 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+)
+
+// imagePull is a pull of an image shared by every container that needs it
+// while it is in progress
+type imagePull struct {
+	done   chan struct{}
+	result DockerContainerMetadata
+
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// imagePullGroup deduplicates concurrent pulls of the same image. Callers
+// asking for an image that is already being pulled with the same credentials
+// wait for that pull rather than starting their own.
+type imagePullGroup struct {
+	lock  sync.Mutex
+	pulls map[string]*imagePull
+}
+
+// sharedImagePulls is the pull group of both the task engine and the image
+// manager, so that an image being pre-warmed is pulled once for the pre-warm
+// and for any container that needs it meanwhile
+var sharedImagePulls = newImagePullGroup()
+
+func newImagePullGroup() *imagePullGroup {
+	return &imagePullGroup{pulls: make(map[string]*imagePull)}
+}
+
+// imagePullKey identifies pulls that may be shared: those of the same image
+// reference made with the same registry credentials
+func imagePullKey(image string, authData *api.RegistryAuthenticationData) string {
+	if authData == nil {
+		return image
+	}
+	identity := []string{image, authData.Type}
+	if authData.ECRAuthData != nil {
+		identity = append(identity, authData.ECRAuthData.RegistryId, authData.ECRAuthData.Region, authData.ECRAuthData.EndpointOverride)
+	}
+	return strings.Join(identity, "|")
+}
+
+// pull returns the result of calling pullFn for the key, sharing a single call
+// between all concurrent callers. A caller stops waiting once its ctx is done,
+// in which case ok is false. When every caller has stopped waiting, the
+// context passed to pullFn is cancelled and the next caller starts a new pull.
+func (group *imagePullGroup) pull(ctx context.Context, key string, pullFn func(context.Context) DockerContainerMetadata) (metadata DockerContainerMetadata, ok bool) {
+	group.lock.Lock()
+	shared, inProgress := group.pulls[key]
+	if !inProgress {
+		pullCtx, cancel := context.WithCancel(context.Background())
+		shared = &imagePull{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		group.pulls[key] = shared
+		go func() {
+			shared.result = pullFn(pullCtx)
+			group.forget(key, shared)
+			cancel()
+			close(shared.done)
+		}()
+	} else {
+		log.Debug("Waiting for image pull already in progress", "key", key)
+	}
+	shared.waiters++
+	group.lock.Unlock()
+
+	select {
+	case <-shared.done:
+		return shared.result, true
+	case <-ctx.Done():
+	}
+
+	group.lock.Lock()
+	shared.waiters--
+	abandoned := shared.waiters == 0
+	if abandoned {
+		group.forgetLocked(key, shared)
+	}
+	group.lock.Unlock()
+	if abandoned {
+		log.Debug("Nothing is waiting for image pull any longer; cancelling it", "key", key)
+		shared.cancel()
+	}
+	return DockerContainerMetadata{}, false
+}
+
+func (group *imagePullGroup) forget(key string, pull *imagePull) {
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	group.forgetLocked(key, pull)
+}
+
+// forgetLocked stops new callers from joining the pull. It must be called
+// with the group's lock held.
+func (group *imagePullGroup) forgetLocked(key string, pull *imagePull) {
+	if group.pulls[key] == pull {
+		delete(group.pulls, key)
+	}
+}
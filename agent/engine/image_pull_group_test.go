@@ -0,0 +1,184 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImagePullKey(t *testing.T) {
+	ecrAuth := func(registryID string) *api.RegistryAuthenticationData {
+		return &api.RegistryAuthenticationData{
+			Type:        "ecr",
+			ECRAuthData: &api.ECRAuthData{RegistryId: registryID, Region: "us-west-2"},
+		}
+	}
+	assert.Equal(t, imagePullKey("nginx:latest", nil), imagePullKey("nginx:latest", nil))
+	assert.NotEqual(t, imagePullKey("nginx:latest", nil), imagePullKey("nginx:1.11", nil))
+	assert.Equal(t, imagePullKey("repo/app", ecrAuth("1")), imagePullKey("repo/app", ecrAuth("1")))
+	assert.NotEqual(t, imagePullKey("repo/app", ecrAuth("1")), imagePullKey("repo/app", ecrAuth("2")))
+	assert.NotEqual(t, imagePullKey("repo/app", nil), imagePullKey("repo/app", ecrAuth("1")))
+}
+
+func TestImagePullGroupSharesPull(t *testing.T) {
+	group := newImagePullGroup()
+	release := make(chan struct{})
+	calls := 0
+	pullFn := func(ctx context.Context) DockerContainerMetadata {
+		calls++
+		<-release
+		return DockerContainerMetadata{Error: CannotXContainerError{"Pull", "not found"}}
+	}
+
+	var wait sync.WaitGroup
+	results := make(chan DockerContainerMetadata, 3)
+	for i := 0; i < 3; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			metadata, ok := group.pull(context.Background(), "nginx:latest", pullFn)
+			assert.True(t, ok)
+			results <- metadata
+		}()
+	}
+	// Let every caller join the pull before it completes
+	for {
+		group.lock.Lock()
+		pull := group.pulls["nginx:latest"]
+		joined := pull != nil && pull.waiters == 3
+		group.lock.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wait.Wait()
+	close(results)
+
+	assert.Equal(t, 1, calls, "only one pull should be made")
+	for metadata := range results {
+		assert.Error(t, metadata.Error, "every caller should see the pull error")
+	}
+	assert.Empty(t, group.pulls, "completed pull should be forgotten")
+}
+
+func TestImagePullGroupCancelsAbandonedPull(t *testing.T) {
+	group := newImagePullGroup()
+	pullCancelled := make(chan struct{})
+	pullFn := func(ctx context.Context) DockerContainerMetadata {
+		<-ctx.Done()
+		close(pullCancelled)
+		return DockerContainerMetadata{}
+	}
+
+	stillWaiting, stopWaiting := context.WithCancel(context.Background())
+	stopped, stop := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		_, ok := group.pull(stillWaiting, "nginx:latest", pullFn)
+		done <- ok
+	}()
+	go func() {
+		_, ok := group.pull(stopped, "nginx:latest", pullFn)
+		done <- ok
+	}()
+	for {
+		group.lock.Lock()
+		pull := group.pulls["nginx:latest"]
+		joined := pull != nil && pull.waiters == 2
+		group.lock.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stop()
+	assert.False(t, <-done)
+	select {
+	case <-pullCancelled:
+		t.Fatal("Pull should not be cancelled while a caller still waits for it")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	stopWaiting()
+	assert.False(t, <-done)
+	<-pullCancelled
+	group.lock.Lock()
+	assert.Empty(t, group.pulls, "abandoned pull should be forgotten")
+	group.lock.Unlock()
+}
+
+func TestPullContainerJoiningPullChecksImage(t *testing.T) {
+	for _, inspectErr := range []error{nil, errors.New("no such image")} {
+		ctrl, client, _, privateTaskEngine, _, imageManager := mocks(t, &defaultConfig)
+		taskEngine := privateTaskEngine.(*DockerTaskEngine)
+		first := &api.Container{Name: "first", Image: "nginx:latest"}
+		joining := &api.Container{Name: "joining", Image: "nginx:latest"}
+
+		release := make(chan struct{})
+		client.EXPECT().PullImage("nginx:latest", nil).Do(func(image, auth interface{}) { <-release }).Return(DockerContainerMetadata{})
+		// Only the container that started the pull is recorded by it
+		imageManager.EXPECT().RecordContainerReference(first).Return(nil)
+		imageManager.EXPECT().GetImageStateFromImageName("nginx:latest").Return(nil).AnyTimes()
+		imageManager.EXPECT().CheckDiskPressure().AnyTimes()
+		if inspectErr == nil {
+			client.EXPECT().InspectImage("nginx:latest").Return(&docker.Image{ID: "sha256:abc"}, nil)
+			imageManager.EXPECT().RecordContainerReference(joining).Return(nil)
+		} else {
+			client.EXPECT().InspectImage("nginx:latest").Return(nil, inspectErr)
+		}
+
+		results := make(map[*api.Container]chan DockerContainerMetadata)
+		for i, container := range []*api.Container{first, joining} {
+			result := make(chan DockerContainerMetadata, 1)
+			results[container] = result
+			go func(container *api.Container) {
+				result <- taskEngine.pullContainer(&api.Task{Arn: container.Name}, container)
+			}(container)
+			// Wait for the container to start or join the pull
+			for {
+				taskEngine.imagePulls.lock.Lock()
+				pull := taskEngine.imagePulls.pulls["nginx:latest"]
+				joined := pull != nil && pull.waiters == i+1
+				taskEngine.imagePulls.lock.Unlock()
+				if joined {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+		close(release)
+
+		assert.NoError(t, (<-results[first]).Error)
+		if inspectErr == nil {
+			assert.NoError(t, (<-results[joining]).Error)
+		} else {
+			assert.IsType(t, CannotXContainerError{}, (<-results[joining]).Error, "image deleted after the pull should not be used")
+		}
+		ctrl.Finish()
+	}
+}
+
@@ -34,6 +34,9 @@ type TaskEngine interface {
 	// running or stopped, as well as providing portbinding and other metadata
 	TaskEvents() (<-chan api.TaskStateChange, <-chan api.ContainerStateChange)
 	SetSaver(statemanager.Saver)
+	// SetContainerInstanceArn sets the ARN of the container instance the
+	// engine runs tasks on
+	SetContainerInstanceArn(string)
 
 	// AddTask adds a new task to the task engine and manages its container's
 	// lifecycle. If it returns an error, the task was not added.
@@ -0,0 +1,99 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+)
+
+// checkLinuxParameters validates the container's Linux parameters and checks
+// that Docker supports each of them, as well as the settings added by the
+// container defaults. It returns the Docker API version the container must be
+// created with.
+func (engine *DockerTaskEngine) checkLinuxParameters(container *api.Container, defaultParameters []dockerclient.LinuxParameter) (dockerclient.DockerVersion, error) {
+	parameters := defaultParameters
+	if container.LinuxParameters != nil {
+		if !linuxParametersSupported {
+			return "", errors.New("Linux parameters are not supported on this platform")
+		}
+		if err := container.LinuxParameters.Validate(); err != nil {
+			return "", err
+		}
+		parameters = append(container.LinuxParameters.Parameters(), defaultParameters...)
+	}
+	required := dockerclient.Version_1_17
+	if len(parameters) == 0 {
+		return required, nil
+	}
+	versions := make(map[dockerclient.DockerVersion]bool)
+	for _, version := range engine.client.SupportedVersions() {
+		versions[version] = true
+	}
+	for _, parameter := range parameters {
+		minimum := dockerclient.LinuxParameterMinimumVersion[parameter]
+		version, ok := oldestSupportedVersion(versions, minimum)
+		if !ok {
+			return "", fmt.Errorf("%s require Docker API version %s", parameter, minimum)
+		}
+		if !required.AtLeast(version) {
+			required = version
+		}
+	}
+	return required, nil
+}
+
+// linuxParameterCapabilities returns a capability for each Linux parameter
+// supported by one of the given Docker API versions
+func linuxParameterCapabilities(versions map[dockerclient.DockerVersion]bool) []string {
+	if !linuxParametersSupported {
+		return nil
+	}
+	var capabilities []string
+	for _, parameter := range []dockerclient.LinuxParameter{
+		dockerclient.LinuxCapabilitiesParameter,
+		dockerclient.LinuxDevicesParameter,
+		dockerclient.LinuxUlimitsParameter,
+		dockerclient.LinuxSysctlsParameter,
+		dockerclient.LinuxSharedMemorySizeParameter,
+		dockerclient.LinuxTmpfsParameter,
+		dockerclient.LinuxPidModeParameter,
+		dockerclient.LinuxIpcModeParameter,
+	} {
+		if _, ok := oldestSupportedVersion(versions, dockerclient.LinuxParameterMinimumVersion[parameter]); ok {
+			capabilities = append(capabilities, capabilityPrefix+"linux-parameters."+string(parameter))
+		}
+	}
+	return capabilities
+}
+
+// oldestSupportedVersion returns the oldest of the supported Docker API
+// versions that is at least the minimum version, or false if none is
+func oldestSupportedVersion(versions map[dockerclient.DockerVersion]bool, minimum dockerclient.DockerVersion) (dockerclient.DockerVersion, bool) {
+	var oldest dockerclient.DockerVersion
+	found := false
+	for version, supported := range versions {
+		if !supported || !version.AtLeast(minimum) {
+			continue
+		}
+		if !found || oldest.AtLeast(version) {
+			oldest = version
+			found = true
+		}
+	}
+	return oldest, found
+}
@@ -0,0 +1,179 @@
+// +build !windows,!integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func linuxParametersTestTask(lp *api.LinuxParameters) *api.Task {
+	return &api.Task{
+		Arn:        "arn",
+		Family:     "family",
+		Version:    "1",
+		Containers: []*api.Container{{Name: "app", LinuxParameters: lp}},
+	}
+}
+
+func TestCreateContainerInvalidLinuxParameters(t *testing.T) {
+	ctrl, _, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := linuxParametersTestTask(&api.LinuxParameters{
+		Sysctls: map[string]string{"kernel.hostname": "app"},
+	})
+
+	metadata := taskEngine.(*DockerTaskEngine).createContainer(task, task.Containers[0])
+	assert.IsType(t, CannotXContainerError{}, metadata.Error)
+	assert.Contains(t, metadata.Error.Error(), "Invalid Linux parameters")
+}
+
+func TestCreateContainerLinuxParametersUnsupportedVersion(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := linuxParametersTestTask(&api.LinuxParameters{
+		Sysctls: map[string]string{"net.core.somaxconn": "1024"},
+	})
+	client.EXPECT().SupportedVersions().Return([]dockerclient.DockerVersion{
+		dockerclient.Version_1_17,
+		dockerclient.Version_1_22,
+	})
+
+	metadata := taskEngine.(*DockerTaskEngine).createContainer(task, task.Containers[0])
+	assert.IsType(t, CannotXContainerError{}, metadata.Error)
+	assert.Contains(t, metadata.Error.Error(), "1.24")
+}
+
+func TestCreateContainerLinuxParametersRaisesVersion(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := linuxParametersTestTask(&api.LinuxParameters{
+		Capabilities:     &api.KernelCapabilities{Add: []string{"SYS_PTRACE"}},
+		SharedMemorySize: 64,
+	})
+	versionedClient := NewMockDockerClient(ctrl)
+	client.EXPECT().SupportedVersions().Return([]dockerclient.DockerVersion{
+		dockerclient.Version_1_17,
+		dockerclient.Version_1_22,
+	})
+	client.EXPECT().WithVersion(dockerclient.Version_1_22).Return(versionedClient)
+	versionedClient.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(config *docker.Config, hostConfig *docker.HostConfig, name string, timeout interface{}) {
+			assert.Equal(t, []string{"SYS_PTRACE"}, hostConfig.CapAdd)
+			assert.Equal(t, int64(64*1024*1024), hostConfig.ShmSize)
+		})
+
+	metadata := taskEngine.(*DockerTaskEngine).createContainer(task, task.Containers[0])
+	assert.Nil(t, metadata.Error)
+}
+
+func TestCreateContainerLinuxParametersRaisesVersionPastMinimum(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	task := linuxParametersTestTask(&api.LinuxParameters{
+		SharedMemorySize: 64,
+	})
+	versionedClient := NewMockDockerClient(ctrl)
+	// The daemon does not support the version that introduced the parameter,
+	// but does support a later one
+	client.EXPECT().SupportedVersions().Return([]dockerclient.DockerVersion{
+		dockerclient.Version_1_17,
+		dockerclient.Version_1_24,
+		dockerclient.Version_1_23,
+	})
+	client.EXPECT().WithVersion(dockerclient.Version_1_23).Return(versionedClient)
+	versionedClient.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+	metadata := taskEngine.(*DockerTaskEngine).createContainer(task, task.Containers[0])
+	assert.Nil(t, metadata.Error)
+}
+
+func TestCreateContainerDefaultsRaiseVersion(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	taskEngine.(*DockerTaskEngine).containerDefaults = &api.ContainerDefaults{NoNewPrivileges: true}
+	task := linuxParametersTestTask(nil)
+	versionedClient := NewMockDockerClient(ctrl)
+	client.EXPECT().SupportedVersions().Return([]dockerclient.DockerVersion{
+		dockerclient.Version_1_17,
+		dockerclient.Version_1_23,
+	})
+	client.EXPECT().WithVersion(dockerclient.Version_1_23).Return(versionedClient)
+	versionedClient.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(config *docker.Config, hostConfig *docker.HostConfig, name string, timeout interface{}) {
+			assert.Equal(t, []string{"no-new-privileges"}, hostConfig.SecurityOpt)
+		})
+
+	metadata := taskEngine.(*DockerTaskEngine).createContainer(task, task.Containers[0])
+	assert.Nil(t, metadata.Error)
+}
+
+func TestCreateContainerDefaultsUnsupportedVersion(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	taskEngine.(*DockerTaskEngine).containerDefaults = &api.ContainerDefaults{
+		Ulimits: []api.Ulimit{{Name: "nofile", SoftLimit: 1024, HardLimit: 4096}},
+	}
+	task := linuxParametersTestTask(nil)
+	client.EXPECT().SupportedVersions().Return([]dockerclient.DockerVersion{
+		dockerclient.Version_1_17,
+	})
+
+	metadata := taskEngine.(*DockerTaskEngine).createContainer(task, task.Containers[0])
+	assert.IsType(t, CannotXContainerError{}, metadata.Error)
+	assert.Contains(t, metadata.Error.Error(), "1.18")
+}
+
+func TestCapabilitiesLinuxParametersPastMinimumVersion(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	client.EXPECT().SupportedVersions().Return([]dockerclient.DockerVersion{
+		dockerclient.Version_1_17,
+		dockerclient.Version_1_24,
+	})
+
+	capabilities := taskEngine.Capabilities()
+	assert.Contains(t, capabilities, "com.amazonaws.ecs.capability.linux-parameters.ulimits")
+	assert.Contains(t, capabilities, "com.amazonaws.ecs.capability.linux-parameters.shm-size")
+	assert.Contains(t, capabilities, "com.amazonaws.ecs.capability.linux-parameters.sysctls")
+}
+
+func TestCapabilitiesLinuxParameters(t *testing.T) {
+	ctrl, client, _, taskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+
+	client.EXPECT().SupportedVersions().Return([]dockerclient.DockerVersion{
+		dockerclient.Version_1_17,
+		dockerclient.Version_1_22,
+	})
+
+	capabilities := taskEngine.Capabilities()
+	assert.Contains(t, capabilities, "com.amazonaws.ecs.capability.linux-parameters.shm-size")
+	assert.Contains(t, capabilities, "com.amazonaws.ecs.capability.linux-parameters.tmpfs")
+	assert.Contains(t, capabilities, "com.amazonaws.ecs.capability.linux-parameters.ulimits")
+	assert.NotContains(t, capabilities, "com.amazonaws.ecs.capability.linux-parameters.sysctls")
+}
@@ -0,0 +1,134 @@
+// +build linux
+
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+)
+
+// mkfsCommand creates the filesystem of the images backing loop mounted
+// scratch volumes
+var mkfsCommand = "mkfs.ext4"
+
+var errScratchVolumeNotMounted = errors.New("scratch volume is not mounted")
+
+// mountScratchVolume mounts a filesystem of the volume's size limit at its
+// host path, unless one is mounted there already
+func mountScratchVolume(volume *api.EmptyHostVolume) error {
+	mounted, err := isMountPoint(volume.HostPath)
+	if err != nil || mounted {
+		return err
+	}
+	if volume.Backing != api.ScratchBackingLoop {
+		options := fmt.Sprintf("size=%dm,mode=755", volume.SizeLimit)
+		return syscall.Mount("tmpfs", volume.HostPath, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, options)
+	}
+
+	// The agent's container image does not include these, so loop backed
+	// volumes are only available when they have been added to it
+	for _, command := range []string{mkfsCommand, "mount"} {
+		if _, err := exec.LookPath(command); err != nil {
+			return fmt.Errorf("loop backed volumes need %s: %v", command, err)
+		}
+	}
+	image := volume.HostPath + ".img"
+	if _, err := os.Stat(image); os.IsNotExist(err) {
+		if err := createScratchVolumeImage(image, volume.SizeLimit); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	return runScratchVolumeCommand("mount", "-o", "loop,nosuid,nodev", image, volume.HostPath)
+}
+
+// createScratchVolumeImage creates a sparse filesystem image of the given
+// size in MiB
+func createScratchVolumeImage(image string, size int64) error {
+	file, err := os.OpenFile(image, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	err = file.Truncate(size * 1024 * 1024)
+	file.Close()
+	if err == nil {
+		err = runScratchVolumeCommand(mkfsCommand, "-q", "-F", "-m", "0", image)
+	}
+	if err != nil {
+		os.Remove(image)
+	}
+	return err
+}
+
+func runScratchVolumeCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// unmountScratchVolume unmounts the filesystem at the volume's host path, if
+// there is one. Loop devices set up by mount are released along with it.
+func unmountScratchVolume(volume *api.EmptyHostVolume) error {
+	mounted, err := isMountPoint(volume.HostPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil || !mounted {
+		return err
+	}
+	return syscall.Unmount(volume.HostPath, 0)
+}
+
+// scratchVolumeUsage returns the bytes used in, and the size of, the
+// filesystem mounted at the volume's host path
+func scratchVolumeUsage(volume *api.EmptyHostVolume) (uint64, uint64, error) {
+	mounted, err := isMountPoint(volume.HostPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !mounted {
+		return 0, 0, errScratchVolumeNotMounted
+	}
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(volume.HostPath, &stat); err != nil {
+		return 0, 0, err
+	}
+	blockSize := uint64(stat.Bsize)
+	return (stat.Blocks - stat.Bfree) * blockSize, stat.Blocks * blockSize, nil
+}
+
+// isMountPoint returns true if a filesystem is mounted at the path, which is
+// the case when it is on another device than its parent directory
+func isMountPoint(path string) (bool, error) {
+	var stat, parentStat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return false, err
+	}
+	if err := syscall.Stat(filepath.Dir(path), &parentStat); err != nil {
+		return false, err
+	}
+	return stat.Dev != parentStat.Dev, nil
+}
@@ -0,0 +1,67 @@
+// +build linux,!integration
+
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsMountPoint(t *testing.T) {
+	mounted, err := isMountPoint("/proc")
+	assert.Nil(t, err)
+	assert.True(t, mounted, "/proc should be a mount point")
+
+	dir, err := ioutil.TempDir("", "ecs-scratch-volume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mounted, err = isMountPoint(dir)
+	assert.Nil(t, err)
+	assert.False(t, mounted, "A plain directory should not be a mount point")
+
+	_, err = isMountPoint(dir + "/missing")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTaskScratchVolumeUsage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs-scratch-volume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	task := &api.Task{
+		Volumes: []api.TaskVolume{
+			{Name: "mounted", Volume: &api.EmptyHostVolume{HostPath: "/proc", SizeLimit: 64}},
+			{Name: "unmounted", Volume: &api.EmptyHostVolume{HostPath: dir, SizeLimit: 64}},
+			{Name: "unlimited", Volume: &api.EmptyHostVolume{HostPath: "/proc"}},
+		},
+	}
+	usage := TaskScratchVolumeUsage(task)
+	if assert.Equal(t, 1, len(usage)) {
+		assert.Equal(t, "mounted", usage[0].VolumeName)
+	}
+
+	// Volumes that are not mounted have nothing to unmount
+	assert.Nil(t, unmountScratchVolume(&api.EmptyHostVolume{HostPath: dir, SizeLimit: 64}))
+	assert.Nil(t, unmountScratchVolume(&api.EmptyHostVolume{HostPath: dir + "/missing", SizeLimit: 64}))
+}
@@ -0,0 +1,36 @@
+// +build !linux
+
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"errors"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+)
+
+var errScratchVolumeUnsupported = errors.New("size limited empty volumes are only supported on Linux")
+
+func mountScratchVolume(volume *api.EmptyHostVolume) error {
+	return errScratchVolumeUnsupported
+}
+
+func unmountScratchVolume(volume *api.EmptyHostVolume) error {
+	return nil
+}
+
+func scratchVolumeUsage(volume *api.EmptyHostVolume) (uint64, uint64, error) {
+	return 0, 0, errScratchVolumeUnsupported
+}
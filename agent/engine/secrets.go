@@ -0,0 +1,68 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/secrets"
+)
+
+// resolveSecrets returns the values of the secrets of a container by the
+// name of the environment variable they are set in. The errors returned
+// never include the value of a secret.
+func (engine *DockerTaskEngine) resolveSecrets(container *api.Container) (map[string]*secrets.Value, error) {
+	if engine.secretProviderErr != nil {
+		return nil, fmt.Errorf("secret providers are not configured: %v", engine.secretProviderErr)
+	}
+	values := make(map[string]*secrets.Value, len(container.Secrets))
+	for i := range container.Secrets {
+		secret := &container.Secrets[i]
+		if err := secret.Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := values[secret.Name]; ok {
+			return nil, fmt.Errorf("secret %s is set more than once", secret.Name)
+		}
+		ref, err := url.Parse(secret.ValueFrom)
+		if err != nil {
+			return nil, err
+		}
+		value, err := engine.secretProvider.GetSecret(ref)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve secret %s: %v", secret.Name, err)
+		}
+		values[secret.Name] = value
+	}
+	return values, nil
+}
+
+// withSecrets returns the environment of a container with the values of its
+// secrets set. Secrets take precedence over plain variables of the same name.
+func withSecrets(env []string, values map[string]*secrets.Value) []string {
+	merged := make([]string, 0, len(env)+len(values))
+	for _, variable := range env {
+		name := strings.SplitN(variable, "=", 2)[0]
+		if _, ok := values[name]; !ok {
+			merged = append(merged, variable)
+		}
+	}
+	for name, value := range values {
+		merged = append(merged, name+"="+value.Contents())
+	}
+	return merged
+}
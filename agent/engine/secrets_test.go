@@ -0,0 +1,98 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/secrets"
+	"github.com/aws/amazon-ecs-agent/agent/secrets/mocks"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateContainerWithSecrets(t *testing.T) {
+	ctrl, client, _, privateTaskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+	provider := mock_secrets.NewMockSecretProvider(ctrl)
+	taskEngine.secretProvider = provider
+
+	container := &api.Container{
+		Name:        "app",
+		Environment: map[string]string{"DB_PASSWORD": "placeholder", "DB_USER": "app"},
+		Secrets:     []api.Secret{{Name: "DB_PASSWORD", ValueFrom: "file:///etc/ecs/secrets/db#password"}},
+	}
+	task := &api.Task{Arn: "arn", Containers: []*api.Container{container}}
+
+	provider.EXPECT().GetSecret(gomock.Any()).Do(func(ref interface{}) {
+		assert.Equal(t, "/etc/ecs/secrets/db", ref.(*url.URL).Path)
+		assert.Equal(t, "password", ref.(*url.URL).Fragment)
+	}).Return(secrets.NewValue("hunter2"), nil)
+	client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(config *docker.Config, hostConfig, name, timeout interface{}) {
+			env := config.Env
+			sort.Strings(env)
+			assert.Equal(t, []string{"DB_PASSWORD=hunter2", "DB_USER=app"}, env)
+		})
+
+	metadata := taskEngine.createContainer(task, container)
+	require.Nil(t, metadata.Error)
+	assert.Equal(t, "placeholder", container.Environment["DB_PASSWORD"], "secret value saved in the container")
+}
+
+func TestCreateContainerSecretNotResolved(t *testing.T) {
+	ctrl, _, _, privateTaskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+	provider := mock_secrets.NewMockSecretProvider(ctrl)
+	taskEngine.secretProvider = provider
+
+	container := &api.Container{
+		Name:    "app",
+		Secrets: []api.Secret{{Name: "TOKEN", ValueFrom: "http://127.0.0.1:8200/secrets/token"}},
+	}
+	task := &api.Task{Arn: "arn", Containers: []*api.Container{container}}
+
+	provider.EXPECT().GetSecret(gomock.Any()).Return(nil, errors.New("secret provider returned status 404"))
+	metadata := taskEngine.createContainer(task, container)
+	assert.IsType(t, CannotXContainerError{}, metadata.Error)
+	assert.Contains(t, metadata.Error.Error(), "unable to resolve secret TOKEN")
+}
+
+func TestResolveSecretsInvalid(t *testing.T) {
+	ctrl, _, _, privateTaskEngine, _, _ := mocks(t, &defaultConfig)
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+	provider := mock_secrets.NewMockSecretProvider(ctrl)
+	taskEngine.secretProvider = provider
+
+	for _, invalid := range [][]api.Secret{
+		{{Name: "", ValueFrom: "file:///etc/ecs/secrets/db"}},
+		{{Name: "A=B", ValueFrom: "file:///etc/ecs/secrets/db"}},
+		{{Name: "DB", ValueFrom: "/etc/ecs/secrets/db"}},
+		{{Name: "DB", ValueFrom: "file:///etc/ecs/secrets/a"}, {Name: "DB", ValueFrom: "file:///etc/ecs/secrets/b"}},
+	} {
+		provider.EXPECT().GetSecret(gomock.Any()).Return(secrets.NewValue("value"), nil).AnyTimes()
+		_, err := taskEngine.resolveSecrets(&api.Container{Secrets: invalid})
+		assert.Error(t, err, "expected an error for secrets %v", invalid)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/cihub/seelog"
+)
+
+// cpuUnitsPerCore is the number of CPU units ECS counts for each core
+const cpuUnitsPerCore = 1024
+
+// hostPort identifies a port on the instance that a container binds to
+type hostPort struct {
+	port     uint16
+	protocol api.TransportProtocol
+}
+
+// taskAdmission decides whether a new task fits on the instance alongside
+// the tasks the engine is already running. The backend makes the same
+// decision from what it thinks the instance has left, which drifts from
+// reality when, for example, ReservedMemory is wrong or containers run on the
+// instance outside of ECS.
+type taskAdmission struct {
+	cfg   *config.Config
+	state *dockerstate.DockerTaskEngineState
+	// hostCPU returns the CPU units of the instance
+	hostCPU func() int64
+	// hostMemory returns the total memory of the instance and the memory that
+	// is free for new containers, both in MiB
+	hostMemory func() (int64, int64, error)
+}
+
+func newTaskAdmission(cfg *config.Config, state *dockerstate.DockerTaskEngineState) *taskAdmission {
+	return &taskAdmission{
+		cfg:        cfg,
+		state:      state,
+		hostCPU:    func() int64 { return int64(runtime.NumCPU() * cpuUnitsPerCore) },
+		hostMemory: hostMemory,
+	}
+}
+
+// admit returns a TaskAdmissionError if the task needs more CPU or memory
+// than is left on the instance, or binds a host port that is already taken
+func (admission *taskAdmission) admit(task *api.Task) error {
+	var usedCPU, usedMemory, pendingMemory int64
+	usedPorts := admission.reservedPorts()
+	for _, other := range admission.state.AllTasks() {
+		if other.Arn == task.Arn {
+			continue
+		}
+		holdsResources := false
+		for _, container := range other.Containers {
+			if !containerHoldsResources(container) {
+				continue
+			}
+			holdsResources = true
+			usedCPU += int64(container.Cpu)
+			usedMemory += int64(container.Memory)
+			// Containers that have not started yet are not using any memory
+			// but soon will be
+			if container.GetKnownStatus() < api.ContainerRunning {
+				pendingMemory += int64(container.Memory)
+			}
+			for _, binding := range container.Ports {
+				if binding.HostPort != 0 {
+					usedPorts[hostPort{binding.HostPort, binding.Protocol}] = "task " + other.Arn
+				}
+			}
+		}
+		// Empty volumes held in memory last as long as the task's containers
+		if holdsResources {
+			usedMemory += other.ScratchVolumeMemory()
+		}
+	}
+
+	var cpu int64
+	memory := task.ScratchVolumeMemory()
+	for _, container := range task.Containers {
+		cpu += int64(container.Cpu)
+		memory += int64(container.Memory)
+		for _, binding := range container.Ports {
+			if binding.HostPort == 0 {
+				continue
+			}
+			port := hostPort{binding.HostPort, binding.Protocol}
+			if user, ok := usedPorts[port]; ok {
+				return TaskAdmissionError{fmt.Sprintf("host port %d/%s of container %s is already used by %s",
+					binding.HostPort, binding.Protocol.String(), container.Name, user)}
+			}
+			usedPorts[port] = "container " + container.Name
+		}
+	}
+
+	hostCPU := admission.hostCPU()
+	if cpu > 0 && usedCPU+cpu > hostCPU {
+		return TaskAdmissionError{fmt.Sprintf("task needs %d CPU units but only %d of %d are not used by other tasks",
+			cpu, maxInt64(hostCPU-usedCPU, 0), hostCPU)}
+	}
+
+	if memory == 0 {
+		return nil
+	}
+	totalMemory, freeMemory, err := admission.hostMemory()
+	if err != nil {
+		seelog.Warnf("Unable to read the memory of the instance, admitting task %s without checking its memory: %v", task.Arn, err)
+		return nil
+	}
+	capacity := totalMemory - int64(admission.cfg.ReservedMemory)
+	if usedMemory+memory > capacity {
+		return TaskAdmissionError{fmt.Sprintf("task needs %d MiB of memory but only %d of %d MiB are not used by other tasks",
+			memory, maxInt64(capacity-usedMemory, 0), capacity)}
+	}
+	if pendingMemory+memory > freeMemory {
+		return TaskAdmissionError{fmt.Sprintf("task needs %d MiB of memory but only %d MiB are free on the instance once tasks that are starting are accounted for",
+			memory, maxInt64(freeMemory-pendingMemory, 0))}
+	}
+	return nil
+}
+
+// reservedPorts returns the host ports that no task may bind
+func (admission *taskAdmission) reservedPorts() map[hostPort]string {
+	ports := make(map[hostPort]string)
+	for _, port := range admission.cfg.ReservedPorts {
+		ports[hostPort{port, api.TransportProtocolTCP}] = "the reserved ports"
+	}
+	for _, port := range admission.cfg.ReservedPortsUDP {
+		ports[hostPort{port, api.TransportProtocolUDP}] = "the reserved ports"
+	}
+	return ports
+}
+
+// containerHoldsResources returns true if the container is using, or is
+// about to use, the CPU, memory and ports it asked for
+func containerHoldsResources(container *api.Container) bool {
+	knownStatus := container.GetKnownStatus()
+	if knownStatus.Terminal() {
+		return false
+	}
+	// A container that is meant to stop before it was ever created never
+	// uses anything
+	return !container.DesiredTerminal() || knownStatus >= api.ContainerCreated
+}
+
+// rejectTask records the admission error on each of the task's containers
+// and moves the task towards stopped before any of them are created
+func rejectTask(task *api.Task, err error) {
+	for _, container := range task.Containers {
+		container.ApplyingError = api.NewNamedError(err)
+	}
+	task.SetDesiredStatus(api.TaskStopped)
+	task.UpdateDesiredStatus()
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,150 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTaskAdmission(cfg *config.Config, totalMemory, freeMemory int64) *taskAdmission {
+	admission := newTaskAdmission(cfg, dockerstate.NewDockerTaskEngineState())
+	admission.hostCPU = func() int64 { return 2048 }
+	admission.hostMemory = func() (int64, int64, error) { return totalMemory, freeMemory, nil }
+	return admission
+}
+
+func admissionTestTask(arn string, cpu, memory uint, hostPorts ...uint16) *api.Task {
+	container := &api.Container{
+		Name:          "web",
+		Cpu:           cpu,
+		Memory:        memory,
+		DesiredStatus: api.ContainerRunning,
+	}
+	for _, port := range hostPorts {
+		container.Ports = append(container.Ports, api.PortBinding{ContainerPort: port, HostPort: port})
+	}
+	return &api.Task{
+		Arn:           arn,
+		DesiredStatus: api.TaskRunning,
+		Containers:    []*api.Container{container},
+	}
+}
+
+func assertTaskAdmissionError(t *testing.T, err error) {
+	if assert.Error(t, err) {
+		assert.Equal(t, "TaskAdmissionError", err.(engineError).ErrorName())
+	}
+}
+
+func TestTaskAdmissionAdmitsTaskThatFits(t *testing.T) {
+	admission := newTestTaskAdmission(&config.Config{}, 4096, 2048)
+	running := admissionTestTask("running", 1024, 1024, 80)
+	running.Containers[0].SetKnownStatus(api.ContainerRunning)
+	admission.state.AddTask(running)
+
+	assert.NoError(t, admission.admit(admissionTestTask("new", 1024, 2048, 8080)))
+}
+
+func TestTaskAdmissionRejectsCPU(t *testing.T) {
+	admission := newTestTaskAdmission(&config.Config{}, 4096, 4096)
+	admission.state.AddTask(admissionTestTask("running", 1536, 0))
+
+	assertTaskAdmissionError(t, admission.admit(admissionTestTask("new", 1024, 0)))
+	assert.NoError(t, admission.admit(admissionTestTask("new", 512, 0)))
+}
+
+func TestTaskAdmissionRejectsMemoryUsedByOtherTasks(t *testing.T) {
+	admission := newTestTaskAdmission(&config.Config{ReservedMemory: 512}, 4096, 4096)
+	running := admissionTestTask("running", 0, 3072)
+	running.Containers[0].SetKnownStatus(api.ContainerRunning)
+	admission.state.AddTask(running)
+
+	assertTaskAdmissionError(t, admission.admit(admissionTestTask("new", 0, 1024)))
+	assert.NoError(t, admission.admit(admissionTestTask("new", 0, 512)))
+}
+
+func TestTaskAdmissionRejectsMemoryNotFreeOnInstance(t *testing.T) {
+	// Memory used outside of ECS leaves less free than the tasks account for
+	admission := newTestTaskAdmission(&config.Config{}, 8192, 1024)
+	assertTaskAdmissionError(t, admission.admit(admissionTestTask("new", 0, 2048)))
+
+	// Tasks that have not started yet will soon use the memory they asked for
+	admission.state.AddTask(admissionTestTask("starting", 0, 768))
+	assertTaskAdmissionError(t, admission.admit(admissionTestTask("new", 0, 512)))
+	assert.NoError(t, admission.admit(admissionTestTask("new", 0, 256)))
+}
+
+func TestTaskAdmissionCountsTmpfsScratchVolumes(t *testing.T) {
+	admission := newTestTaskAdmission(&config.Config{}, 4096, 4096)
+	running := admissionTestTask("running", 0, 1024)
+	running.Volumes = []api.TaskVolume{{Name: "scratch", Volume: &api.EmptyHostVolume{SizeLimit: 2048}}}
+	running.Containers[0].SetKnownStatus(api.ContainerRunning)
+	admission.state.AddTask(running)
+
+	assertTaskAdmissionError(t, admission.admit(admissionTestTask("new", 0, 1536)))
+
+	task := admissionTestTask("new", 0, 512)
+	task.Volumes = []api.TaskVolume{{Name: "scratch", Volume: &api.EmptyHostVolume{SizeLimit: 1024}}}
+	assertTaskAdmissionError(t, admission.admit(task))
+	task.Volumes[0].Volume = &api.EmptyHostVolume{SizeLimit: 1024, Backing: api.ScratchBackingLoop}
+	assert.NoError(t, admission.admit(task))
+}
+
+func TestTaskAdmissionSkipsMemoryCheckWhenMemoryUnknown(t *testing.T) {
+	admission := newTestTaskAdmission(&config.Config{}, 0, 0)
+	admission.hostMemory = func() (int64, int64, error) { return 0, 0, errors.New("no meminfo") }
+
+	assert.NoError(t, admission.admit(admissionTestTask("new", 0, 2048)))
+}
+
+func TestTaskAdmissionRejectsHostPortInUse(t *testing.T) {
+	admission := newTestTaskAdmission(&config.Config{ReservedPorts: []uint16{22}}, 4096, 4096)
+	admission.state.AddTask(admissionTestTask("running", 0, 0, 80))
+
+	assertTaskAdmissionError(t, admission.admit(admissionTestTask("new", 0, 0, 80)))
+	assertTaskAdmissionError(t, admission.admit(admissionTestTask("new", 0, 0, 22)))
+	// The same port over UDP is free
+	udpTask := admissionTestTask("new", 0, 0, 80)
+	udpTask.Containers[0].Ports[0].Protocol = api.TransportProtocolUDP
+	assert.NoError(t, admission.admit(udpTask))
+}
+
+func TestTaskAdmissionIgnoresStoppedContainers(t *testing.T) {
+	admission := newTestTaskAdmission(&config.Config{}, 4096, 4096)
+	stopped := admissionTestTask("stopped", 2048, 4096, 80)
+	stopped.Containers[0].SetKnownStatus(api.ContainerStopped)
+	admission.state.AddTask(stopped)
+	rejected := admissionTestTask("rejected", 2048, 4096, 80)
+	rejectTask(rejected, TaskAdmissionError{"does not fit"})
+	admission.state.AddTask(rejected)
+
+	assert.NoError(t, admission.admit(admissionTestTask("new", 2048, 4096, 80)))
+}
+
+func TestRejectTask(t *testing.T) {
+	task := admissionTestTask("new", 0, 0)
+	rejectTask(task, TaskAdmissionError{"does not fit"})
+
+	assert.Equal(t, api.TaskStopped, task.GetDesiredStatus())
+	container := task.Containers[0]
+	assert.Equal(t, api.ContainerStopped, container.GetDesiredStatus())
+	assert.Equal(t, "TaskAdmissionError: does not fit", container.ApplyingError.Error())
+}
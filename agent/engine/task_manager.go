@@ -17,6 +17,8 @@ import (
 	"sync"
 	"time"
 
+	"golang.org/x/net/context"
+
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dependencygraph"
@@ -42,6 +44,18 @@ type acsTransition struct {
 	desiredStatus api.TaskStatus
 }
 
+type healthCheckResult struct {
+	container *api.Container
+	status    api.HealthStatus
+}
+
+// containerRestart is the outcome of creating and starting a new docker
+// container for a container that exited and is being restarted
+type containerRestart struct {
+	container *api.Container
+	metadata  DockerContainerMetadata
+}
+
 // managedTask is a type that is meant to manage the lifecycle of a task.
 // There should be only one managed task construct for a given task arn and the
 // managed task should be the only thing to modify the task's known or desired statuses.
@@ -69,6 +83,23 @@ type managedTask struct {
 
 	acsMessages    chan acsTransition
 	dockerMessages chan dockerContainerChange
+	healthMessages chan healthCheckResult
+
+	// healthCheckers maps the names of containers whose health is currently
+	// being evaluated to the function that stops the evaluation
+	healthCheckers map[string]context.CancelFunc
+
+	// restartMessages receives the outcome of restarting containers whose
+	// restart backoff has elapsed
+	restartMessages chan containerRestart
+	// pendingRestarts maps the names of exited containers that are waiting to
+	// be restarted to the function that abandons the restart
+	pendingRestarts map[string]context.CancelFunc
+
+	// stopping is done once the task is meant to stop, so that work done on
+	// its behalf, such as waiting for an image to be pulled, is abandoned
+	stopping       context.Context
+	cancelStopping context.CancelFunc
 
 	// unexpectedStart is a once that controls stopping a container that
 	// unexpectedly started one time.
@@ -87,11 +118,16 @@ type managedTask struct {
 // This method must only be called when the engine.processTasks write lock is
 // already held.
 func (engine *DockerTaskEngine) newManagedTask(task *api.Task) *managedTask {
+	stopping, cancelStopping := context.WithCancel(context.Background())
 	t := &managedTask{
-		Task:           task,
-		acsMessages:    make(chan acsTransition),
-		dockerMessages: make(chan dockerContainerChange),
-		engine:         engine,
+		Task:            task,
+		acsMessages:     make(chan acsTransition),
+		dockerMessages:  make(chan dockerContainerChange),
+		healthMessages:  make(chan healthCheckResult),
+		restartMessages: make(chan containerRestart),
+		stopping:        stopping,
+		cancelStopping:  cancelStopping,
+		engine:          engine,
 	}
 	engine.managedTasks[task.Arn] = t
 	return t
@@ -104,8 +140,20 @@ func (mtask *managedTask) overseeTask() {
 	// 'desiredstatus'es which are a construct of the engine used only here,
 	// not present on the backend
 	mtask.UpdateStatus()
+	// Only tasks using DependsOn are validated, so that task definitions that
+	// earlier agents accepted are still run
+	if mtask.GetKnownStatus() == api.TaskStatusNone && !mtask.GetDesiredStatus().Terminal() && dependencygraph.UsesDependsOn(mtask.Task) {
+		if err := dependencygraph.ValidateDependencies(mtask.Task); err != nil {
+			llog.Error("Invalid container dependencies; stopping task", "err", err)
+			mtask.failDependencies(err)
+		}
+	}
 	// If this was a 'state restore', send all unsent statuses
 	mtask.emitCurrentStatus()
+	// Resume evaluating the health of containers that were already running
+	for _, container := range mtask.Containers {
+		mtask.updateHealthCheck(container)
+	}
 
 	if mtask.StartSequenceNumber != 0 && !mtask.GetDesiredStatus().Terminal() {
 		llog.Debug("Waiting for any previous stops to complete", "seqnum", mtask.StartSequenceNumber)
@@ -163,6 +211,9 @@ func (mtask *managedTask) overseeTask() {
 	// We only break out of the above if this task is known to be stopped. Do
 	// onetime cleanup here, including removing the task after a timeout
 	llog.Debug("Task has reached stopped. We're just waiting and removing containers now")
+	mtask.cancelStopping()
+	mtask.stopHealthChecks()
+	mtask.cancelPendingRestarts()
 	taskCredentialsID := mtask.GetCredentialsId()
 	if taskCredentialsID != "" {
 		mtask.engine.credentialsManager.RemoveCredentials(taskCredentialsID)
@@ -218,6 +269,16 @@ func (mtask *managedTask) handleContainerChange(containerChange dockerContainerC
 	event := containerChange.event
 	llog.Debug("Handling container change", "change", containerChange)
 
+	if cancel, pending := mtask.pendingRestarts[container.Name]; pending {
+		if !container.DesiredTerminal() {
+			llog.Debug("Container is waiting to be restarted; ignoring change", "change", containerChange)
+			return
+		}
+		// The container is being stopped for good, so the restart is abandoned
+		cancel()
+		delete(mtask.pendingRestarts, container.Name)
+	}
+
 	// Cases: If this is a forward transition (else) update the container to be known to be at that status.
 	// If this is a backwards transition stopped->running, the first time set it
 	// to be known running so it will be stopped. Subsequently ignore these backward transitions
@@ -238,6 +299,11 @@ func (mtask *managedTask) handleContainerChange(containerChange dockerContainerC
 		seelog.Infof("Redundant container state change for task %s: %s to %s, but already %s", mtask.Task, container, event.Status, containerKnownStatus)
 		return
 	}
+	if event.Status == api.ContainerStopped && !container.DesiredTerminal() && container.ShouldRestart(event.ExitCode) {
+		mtask.scheduleContainerRestart(container, event)
+		return
+	}
+
 	currentKnownStatus := containerKnownStatus
 	container.SetKnownStatus(event.Status)
 
@@ -287,16 +353,25 @@ func (mtask *managedTask) handleContainerChange(containerChange dockerContainerC
 		seelog.Warnf("Failed to write container change event to event stream, err %v", err)
 	}
 
+	container.SetTransitionTime(container.GetKnownStatus(), ttime.Now())
 	if event.ExitCode != nil && event.ExitCode != container.KnownExitCode {
 		container.KnownExitCode = event.ExitCode
 	}
 	if event.PortBindings != nil {
-		container.KnownPortBindings = event.PortBindings
+		container.SetKnownPortBindings(event.PortBindings)
+	}
+	if event.Networks != nil {
+		container.KnownNetworks = event.Networks
 	}
 	if event.Volumes != nil {
 		mtask.UpdateMountPoints(container, event.Volumes)
 	}
 
+	mtask.updateHealthCheck(container)
+	if event.Status == api.ContainerRunning {
+		mtask.engine.updateContainerMetadataFile(mtask.Task, container)
+	}
+
 	mtask.engine.emitContainerEvent(mtask.Task, container, "")
 	if mtask.UpdateStatus() {
 		llog.Debug("Container change also resulted in task change")
@@ -305,9 +380,230 @@ func (mtask *managedTask) handleContainerChange(containerChange dockerContainerC
 	}
 }
 
+// handleHealthCheckResult records a change in a container's health. An
+// essential container that becomes unhealthy causes the task to be stopped.
+func (mtask *managedTask) handleHealthCheckResult(result healthCheckResult) {
+	container := result.container
+	_, restartPending := mtask.pendingRestarts[container.Name]
+	if restartPending || container.GetKnownStatus() != api.ContainerRunning {
+		// Stale result for a container that has since moved on
+		return
+	}
+	if container.GetHealthStatus().Status == result.status.Status {
+		return
+	}
+	seelog.Infof("Container health changed for task %s: container %s is %s", mtask.Task, container, result.status.Status)
+	container.SetHealthStatus(result.status)
+	mtask.engine.emitContainerEvent(mtask.Task, container, "")
+
+	if result.status.Status == api.ContainerUnhealthy && container.Essential && !container.DesiredTerminal() {
+		seelog.Warnf("Essential container is unhealthy; stopping task %s", mtask.Task)
+		if container.ApplyingError == nil {
+			container.ApplyingError = api.NewNamedError(ContainerUnhealthyError{result.status.Output})
+		}
+		container.SetDesiredStatus(api.ContainerStopped)
+		mtask.UpdateDesiredStatus()
+	}
+}
+
+// updateHealthCheck starts evaluating the health of a container once it is
+// known to be running and stops once it is no longer running
+func (mtask *managedTask) updateHealthCheck(container *api.Container) {
+	if container.HealthCheck == nil {
+		return
+	}
+	if mtask.healthCheckers == nil {
+		mtask.healthCheckers = make(map[string]context.CancelFunc)
+	}
+	if container.GetKnownStatus() != api.ContainerRunning {
+		mtask.stopHealthCheck(container.Name)
+		return
+	}
+	if _, running := mtask.healthCheckers[container.Name]; !running {
+		ctx, cancel := context.WithCancel(context.Background())
+		mtask.healthCheckers[container.Name] = cancel
+		go mtask.runHealthCheck(ctx, container)
+	}
+}
+
+func (mtask *managedTask) stopHealthCheck(name string) {
+	if cancel, running := mtask.healthCheckers[name]; running {
+		cancel()
+		delete(mtask.healthCheckers, name)
+	}
+}
+
+func (mtask *managedTask) stopHealthChecks() {
+	for name := range mtask.healthCheckers {
+		mtask.stopHealthCheck(name)
+	}
+}
+
+// runHealthCheck periodically evaluates the container's health check and
+// reports the result back to the managedTask until the context is cancelled.
+// Failures within the start period are not counted, and a container is only
+// reported unhealthy after the configured number of consecutive failures.
+func (mtask *managedTask) runHealthCheck(ctx context.Context, container *api.Container) {
+	healthCheck := container.HealthCheck
+	startPeriodEnd := mtask.time().Now().Add(healthCheck.StartPeriodDuration())
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-mtask.time().After(healthCheck.IntervalDuration()):
+		}
+
+		healthy, output := mtask.engine.checkContainerHealth(mtask.Task, container)
+		status := api.ContainerHealthy
+		if healthy {
+			failures = 0
+		} else {
+			if mtask.time().Now().Before(startPeriodEnd) {
+				continue
+			}
+			failures++
+			if failures < healthCheck.RetryCount() {
+				continue
+			}
+			status = api.ContainerUnhealthy
+		}
+
+		result := healthCheckResult{
+			container: container,
+			status: api.HealthStatus{
+				Status: status,
+				Since:  mtask.time().Now(),
+				Output: output,
+			},
+		}
+		select {
+		case mtask.healthMessages <- result:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// scheduleContainerRestart handles the exit of a container that its restart
+// policy says should be started again. The container stays known running, so
+// that the task's status is unaffected, while it is restarted.
+func (mtask *managedTask) scheduleContainerRestart(container *api.Container, event DockerContainerChangeEvent) {
+	if event.ExitCode != nil {
+		container.KnownExitCode = event.ExitCode
+	}
+	if event.Error != nil && container.ApplyingError == nil {
+		container.ApplyingError = api.NewNamedError(event.Error)
+	}
+	err := mtask.engine.containerChangeEventStream.WriteToEventStream(event)
+	if err != nil {
+		seelog.Warnf("Failed to write container change event to event stream, err %v", err)
+	}
+	mtask.stopHealthCheck(container.Name)
+
+	delay := container.RestartPolicy.BackoffDuration(container.GetRestartCount())
+	seelog.Infof("Container exited; restarting it in %s, task: %s, container: %s", delay, mtask.Task, container)
+	if mtask.pendingRestarts == nil {
+		mtask.pendingRestarts = make(map[string]context.CancelFunc)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	mtask.pendingRestarts[container.Name] = cancel
+	go mtask.restartContainerAfter(ctx, container, delay)
+}
+
+// restartContainerAfter waits for the restart backoff, removes the exited
+// docker container and creates and starts a new one in its place. The outcome
+// is handed back to the managedTask, unless the restart has been abandoned.
+func (mtask *managedTask) restartContainerAfter(ctx context.Context, container *api.Container, delay time.Duration) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-mtask.time().After(delay):
+	}
+	err := mtask.engine.removeContainer(mtask.Task, container)
+	if err != nil {
+		seelog.Warnf("Unable to remove exited container before restarting it, task: %s, container: %s, err: %v", mtask.Task, container, err)
+	}
+	restart := containerRestart{container: container}
+	for _, status := range []api.ContainerStatus{api.ContainerCreated, api.ContainerRunning} {
+		if ctx.Err() != nil {
+			return
+		}
+		restart.metadata = mtask.engine.applyContainerState(mtask.Task, container, status)
+		if restart.metadata.Error != nil {
+			break
+		}
+	}
+	select {
+	case mtask.restartMessages <- restart:
+	case <-ctx.Done():
+	}
+}
+
+// handleContainerRestart records the outcome of restarting a container. The
+// known status of the container is left running throughout; each restart
+// instead increments its restart count. A restart that fails is handled as
+// another exit of the container.
+func (mtask *managedTask) handleContainerRestart(restart containerRestart) {
+	container := restart.container
+	cancel, pending := mtask.pendingRestarts[container.Name]
+	if !pending {
+		return
+	}
+	cancel()
+	delete(mtask.pendingRestarts, container.Name)
+	if container.DesiredTerminal() {
+		return
+	}
+
+	container.IncrementRestartCount()
+	metadata := restart.metadata
+	if metadata.Error != nil {
+		seelog.Warnf("Unable to restart container (attempt %d), task: %s, container: %s, err: %v", container.GetRestartCount(), mtask.Task, container, metadata.Error)
+		mtask.handleContainerChange(dockerContainerChange{
+			container: container,
+			event: DockerContainerChangeEvent{
+				Status:                  api.ContainerStopped,
+				DockerContainerMetadata: metadata,
+			},
+		})
+		return
+	}
+
+	seelog.Infof("Restarted container (attempt %d), task: %s, container: %s", container.GetRestartCount(), mtask.Task, container)
+	container.KnownExitCode = nil
+	if metadata.PortBindings != nil {
+		container.SetKnownPortBindings(metadata.PortBindings)
+	}
+	if metadata.Networks != nil {
+		container.KnownNetworks = metadata.Networks
+	}
+	container.SetHealthStatus(api.HealthStatus{})
+	container.SetTransitionTime(api.ContainerRunning, mtask.time().Now())
+	mtask.updateHealthCheck(container)
+	mtask.engine.updateContainerMetadataFile(mtask.Task, container)
+	mtask.engine.emitContainerEvent(mtask.Task, container, "")
+}
+
+func (mtask *managedTask) cancelPendingRestarts() {
+	for name, cancel := range mtask.pendingRestarts {
+		cancel()
+		delete(mtask.pendingRestarts, name)
+	}
+}
+
 func (mtask *managedTask) steadyState() bool {
 	taskKnownStatus := mtask.GetKnownStatus()
-	return taskKnownStatus == api.TaskRunning && taskKnownStatus >= mtask.GetDesiredStatus()
+	if taskKnownStatus != api.TaskRunning || taskKnownStatus < mtask.GetDesiredStatus() {
+		return false
+	}
+	// A running task may still have containers that are being restarted
+	for _, container := range mtask.Containers {
+		if !container.DesiredTerminal() && container.GetKnownStatus() < container.GetDesiredStatus() {
+			return false
+		}
+	}
+	return true
 }
 
 // waitEvent waits for any event to occur. If the event is the passed in
@@ -324,6 +620,14 @@ func (mtask *managedTask) waitEvent(stopWaiting <-chan bool) bool {
 		log.Debug("Got container event for task", "task", mtask.Task)
 		mtask.handleContainerChange(dockerChange)
 		return false
+	case healthResult := <-mtask.healthMessages:
+		log.Debug("Got health check result for task", "task", mtask.Task)
+		mtask.handleHealthCheckResult(healthResult)
+		return false
+	case restart := <-mtask.restartMessages:
+		log.Debug("Got container restart for task", "task", mtask.Task)
+		mtask.handleContainerRestart(restart)
+		return false
 	case b := <-stopWaiting:
 		log.Debug("No longer waiting", "task", mtask.Task)
 		return b
@@ -350,8 +654,17 @@ func (mtask *managedTask) containerNextState(container *api.Container) (api.Cont
 		return api.ContainerStatusNone, false, false
 	}
 	if !dependencygraph.DependenciesAreResolved(container, mtask.Containers) {
-		clog.Debug("Can't apply state to container yet; dependencies unresolved", "state", containerDesiredStatus)
-		return api.ContainerStatusNone, false, false
+		err := dependencygraph.DependencyFailure(container, mtask.Containers)
+		if err == nil {
+			clog.Debug("Can't apply state to container yet; dependencies unresolved", "state", containerDesiredStatus)
+			return api.ContainerStatusNone, false, false
+		}
+		clog.Warn("Container dependencies can never be resolved; stopping container", "err", err)
+		if container.ApplyingError == nil {
+			container.ApplyingError = api.NewNamedError(err)
+		}
+		container.SetDesiredStatus(api.ContainerStopped)
+		mtask.UpdateDesiredStatus()
 	}
 
 	var nextState api.ContainerStatus
@@ -361,6 +674,10 @@ func (mtask *managedTask) containerNextState(container *api.Container) (api.Cont
 			// If it's not currently running we do not need to do anything to make it become stopped.
 			return nextState, false, true
 		}
+		if mtask.stoppingInDependencyOrder() && !dependencygraph.DependentsAreStopped(container, mtask.Containers) {
+			clog.Debug("Can't stop container yet; containers that depend on it are still running")
+			return api.ContainerStatusNone, false, false
+		}
 	} else {
 		nextState = containerKnownStatus + 1
 	}
@@ -374,6 +691,15 @@ func (mtask *managedTask) containerNextState(container *api.Container) (api.Cont
 // docker completes.
 func (mtask *managedTask) progressContainers() {
 	log.Debug("Progressing task", "task", mtask.Task)
+	if mtask.GetDesiredStatus().Terminal() {
+		mtask.cancelStopping()
+		// Containers only wait on each other while stopping if they have
+		// dependencies to wait for
+		if mtask.GetStopDeadline().IsZero() && dependencygraph.HasDependencies(mtask.Task) {
+			mtask.SetStopDeadline(mtask.time().Now().Add(mtask.engine.cfg.TaskStopTimeout))
+			mtask.engine.saver.Save()
+		}
+	}
 	// max number of transitions length to ensure writes will never block on
 	// these and if we exit early transitions can exit the goroutine and it'll
 	// get GC'd eventually
@@ -404,6 +730,29 @@ func (mtask *managedTask) progressContainers() {
 		}(cont, nextState)
 	}
 
+	if !anyCanTransition && mtask.waitingOnDependencies() {
+		// Containers are waiting for others to e.g. exit or become healthy; only
+		// an event can change that
+		log.Debug("Waiting for container dependencies to be met", "task", mtask.Task)
+		mtask.waitEvent(nil)
+		return
+	}
+
+	if !anyCanTransition && mtask.waitingOnDependents() {
+		// Dependents are only held up by a stop that is being retried; give up
+		// on ordering once the deadline passes
+		stopDeadline := mtask.GetStopDeadline()
+		log.Debug("Waiting for dependent containers to stop", "task", mtask.Task, "deadline", stopDeadline)
+		deadline := make(chan bool, 1)
+		timer := mtask.time().After(stopDeadline.Sub(mtask.time().Now()))
+		go func() {
+			<-timer
+			deadline <- true
+		}()
+		mtask.waitEvent(deadline)
+		return
+	}
+
 	if !anyCanTransition {
 		log.Crit("Task in a bad state; it's not steadystate but no containers want to transition", "task", mtask.Task)
 		if mtask.GetDesiredStatus().Terminal() {
@@ -430,6 +779,8 @@ func (mtask *managedTask) progressContainers() {
 			log.Debug("Still waiting for", "map", transitionsMap)
 		}
 		if mtask.GetDesiredStatus().Terminal() || mtask.GetKnownStatus().Terminal() {
+			// Stop waiting for images that are still being pulled
+			mtask.cancelStopping()
 			allWaitingOnPulled := true
 			for _, desired := range transitionsMap {
 				if desired != api.ContainerPulled {
@@ -454,6 +805,60 @@ func (mtask *managedTask) progressContainers() {
 	}
 }
 
+// failDependencies records a dependency error on the container it concerns
+// and moves the task towards stopped
+func (mtask *managedTask) failDependencies(err error) {
+	containerName := ""
+	if dependencyErr, ok := err.(*dependencygraph.DependencyError); ok {
+		containerName = dependencyErr.ContainerName
+	}
+	for _, container := range mtask.Containers {
+		if container.Name == containerName || containerName == "" {
+			container.ApplyingError = api.NewNamedError(err)
+		}
+	}
+	mtask.SetDesiredStatus(api.TaskStopped)
+	mtask.UpdateDesiredStatus()
+}
+
+// waitingOnDependencies returns true if a container is held back only by
+// DependsOn conditions that may still be met
+func (mtask *managedTask) waitingOnDependencies() bool {
+	for _, cont := range mtask.Containers {
+		if cont.DesiredTerminal() || cont.GetKnownStatus() >= cont.GetDesiredStatus() {
+			continue
+		}
+		if !dependencygraph.DependsOnAreResolved(cont, mtask.Containers) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitingOnDependents returns true if a running container is being kept from
+// stopping only because containers that depend on it are still running
+func (mtask *managedTask) waitingOnDependents() bool {
+	if !mtask.stoppingInDependencyOrder() {
+		return false
+	}
+	for _, cont := range mtask.Containers {
+		if !cont.DesiredTerminal() || cont.GetKnownStatus() != api.ContainerRunning {
+			continue
+		}
+		if !dependencygraph.DependentsAreStopped(cont, mtask.Containers) {
+			return true
+		}
+	}
+	return false
+}
+
+// stoppingInDependencyOrder returns true while the task is being stopped and
+// has not yet spent longer than the configured TaskStopTimeout doing so
+func (mtask *managedTask) stoppingInDependencyOrder() bool {
+	stopDeadline := mtask.GetStopDeadline()
+	return !stopDeadline.IsZero() && mtask.time().Now().Before(stopDeadline)
+}
+
 func (mtask *managedTask) time() ttime.Time {
 	mtask._timeOnce.Do(func() {
 		if mtask._time == nil {
@@ -515,6 +920,8 @@ func (mtask *managedTask) discardEventsUntil(done chan struct{}) {
 		select {
 		case <-mtask.dockerMessages:
 		case <-mtask.acsMessages:
+		case <-mtask.healthMessages:
+		case <-mtask.restartMessages:
 		case <-done:
 			return
 		}
@@ -526,6 +933,8 @@ func (mtask *managedTask) discardPendingMessages() {
 		select {
 		case <-mtask.dockerMessages:
 		case <-mtask.acsMessages:
+		case <-mtask.healthMessages:
+		case <-mtask.restartMessages:
 		default:
 			return
 		}
@@ -13,8 +13,12 @@
 
 package engine
 
-import "fmt"
-import "github.com/aws/amazon-ecs-agent/agent/api"
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	docker "github.com/fsouza/go-dockerclient"
+)
 
 // ContainerNotFound is a type for a missing container
 type ContainerNotFound struct {
@@ -38,6 +42,7 @@ type DockerContainerMetadata struct {
 	DockerID     string
 	ExitCode     *int
 	PortBindings []api.PortBinding
+	Networks     []api.Network
 	Error        engineError
 	Volumes      map[string]string
 }
@@ -48,3 +53,25 @@ type ListContainersResponse struct {
 	DockerIDs []string
 	Error     error
 }
+
+// ListImagesResponse encapsulates the response from the docker client for the
+// ListImages call.
+type ListImagesResponse struct {
+	Images []docker.APIImages
+	Error  error
+}
+
+// VolumeResponse encapsulates the response from the docker client for the
+// CreateVolume and InspectVolume calls.
+type VolumeResponse struct {
+	DockerVolume *docker.Volume
+	Error        error
+}
+
+// DockerExecResult encapsulates the outcome of running a command inside a
+// container with ExecContainer.
+type DockerExecResult struct {
+	ExitCode int
+	Output   string
+	Error    error
+}
@@ -22,9 +22,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/amazon-ecs-agent/agent/api"
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/credentials"
 	"github.com/aws/amazon-ecs-agent/agent/handlers"
+	"github.com/aws/amazon-ecs-agent/agent/handlers/taskmetadata"
 	"github.com/aws/amazon-ecs-agent/agent/logger/audit"
 	"github.com/aws/amazon-ecs-agent/agent/logger/audit/request"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
@@ -68,8 +70,9 @@ type errorMessage struct {
 	httpErrorCode int
 }
 
-// ServeHTTP serves IAM Role Credentials for Tasks being managed by the agent.
-func ServeHTTP(credentialsManager credentials.Manager, containerInstanceArn string, cfg *config.Config) {
+// ServeHTTP serves IAM Role Credentials for Tasks being managed by the agent,
+// along with the metadata of the tasks.
+func ServeHTTP(credentialsManager credentials.Manager, containerInstanceArn string, cfg *config.Config, stateResolver handlers.DockerStateResolver, statsResolver taskmetadata.StatsResolver) {
 	// Create and initialize the audit log
 	// TODO Use seelog's programmatic configuration instead of xml.
 	logger, err := log.LoggerFromConfigAsString(audit.AuditLoggerConfig(cfg))
@@ -81,7 +84,7 @@ func ServeHTTP(credentialsManager credentials.Manager, containerInstanceArn stri
 
 	auditLogger := audit.NewAuditLog(containerInstanceArn, cfg, logger)
 
-	server := setupServer(credentialsManager, auditLogger)
+	server := setupServer(credentialsManager, auditLogger, taskmetadata.Handler(stateResolver, statsResolver, cfg.Cluster))
 
 	for {
 		utils.RetryWithBackoff(utils.NewSimpleBackoff(time.Second, time.Minute, 0.2, 2), func() error {
@@ -96,10 +99,14 @@ func ServeHTTP(credentialsManager credentials.Manager, containerInstanceArn stri
 }
 
 // setupServer starts the HTTP server for serving IAM Role Credentials for Tasks.
-func setupServer(credentialsManager credentials.Manager, auditLogger audit.AuditLogger) *http.Server {
+// The metadata of tasks is served by metadataHandler, if it is not nil.
+func setupServer(credentialsManager credentials.Manager, auditLogger audit.AuditLogger, metadataHandler func(http.ResponseWriter, *http.Request)) *http.Server {
 	serverMux := http.NewServeMux()
 	serverMux.HandleFunc(credentials.V1CredentialsPath, credentialsV1V2RequestHandler(credentialsManager, auditLogger, getV1CredentialsID, apiVersion1))
 	serverMux.HandleFunc(credentials.V2CredentialsPath+"/", credentialsV1V2RequestHandler(credentialsManager, auditLogger, getV2CredentialsID, apiVersion2))
+	if metadataHandler != nil {
+		serverMux.HandleFunc(api.TaskMetadataPath+"/", metadataHandler)
+	}
 
 	// Log all requests and then pass through to serverMux
 	loggingServeMux := http.NewServeMux()
@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/aws/amazon-ecs-agent/agent/api"
 	"github.com/aws/amazon-ecs-agent/agent/credentials"
 	mock_credentials "github.com/aws/amazon-ecs-agent/agent/credentials/mocks"
 	mock_audit "github.com/aws/amazon-ecs-agent/agent/logger/audit/mocks"
@@ -166,13 +167,32 @@ func TestCredentialsV2RequestWhenCredentialsFound(t *testing.T) {
 	assert.Equal(t, secretAccessKey, credentials.SecretAccessKey, "Incorrect credentials received: secret access key")
 }
 
+// TestTaskMetadataPathServed tests that requests for task metadata are passed
+// to the metadata handler
+func TestTaskMetadataPathServed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var requestedPath string
+	metadataHandler := func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+	}
+	server := setupServer(mock_credentials.NewMockManager(ctrl), mock_audit.NewMockAuditLogger(ctrl), metadataHandler)
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", api.TaskMetadataPath+"/id", nil)
+	server.Handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code, "Incorrect return code")
+	assert.Equal(t, api.TaskMetadataPath+"/id", requestedPath)
+}
+
 func testErrorResponsesFromServer(t *testing.T, path string, expectedErrorMessage *errorMessage) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	credentialsManager := mock_credentials.NewMockManager(ctrl)
 	auditLog := mock_audit.NewMockAuditLogger(ctrl)
-	server := setupServer(credentialsManager, auditLog)
+	server := setupServer(credentialsManager, auditLog, nil)
 
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", path, nil)
@@ -205,7 +225,7 @@ func getResponseForCredentialsRequest(t *testing.T, expectedStatus int,
 	defer ctrl.Finish()
 	credentialsManager := mock_credentials.NewMockManager(ctrl)
 	auditLog := mock_audit.NewMockAuditLogger(ctrl)
-	server := setupServer(credentialsManager, auditLog)
+	server := setupServer(credentialsManager, auditLog, nil)
 	recorder := httptest.NewRecorder()
 
 	creds, ok := getCredentials()
@@ -12,12 +12,14 @@
 // permissions and limitations under the License.
 
 // Automatically generated by MockGen. DO NOT EDIT!
-// Source: github.com/aws/amazon-ecs-agent/agent/handlers (interfaces: DockerStateResolver)
+// Source: github.com/aws/amazon-ecs-agent/agent/handlers (interfaces: DockerStateResolver,DockerOperationStatsResolver,ImagePrewarmStatusResolver,ImageCleanupDryRunResolver,ImagePinner)
 
 package mock_handlers
 
 import (
+	engine "github.com/aws/amazon-ecs-agent/agent/engine"
 	dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	image "github.com/aws/amazon-ecs-agent/agent/engine/image"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -51,3 +53,128 @@ func (_m *MockDockerStateResolver) State() *dockerstate.DockerTaskEngineState {
 func (_mr *_MockDockerStateResolverRecorder) State() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "State")
 }
+
+// Mock of DockerOperationStatsResolver interface
+type MockDockerOperationStatsResolver struct {
+	ctrl     *gomock.Controller
+	recorder *_MockDockerOperationStatsResolverRecorder
+}
+
+// Recorder for MockDockerOperationStatsResolver (not exported)
+type _MockDockerOperationStatsResolverRecorder struct {
+	mock *MockDockerOperationStatsResolver
+}
+
+func NewMockDockerOperationStatsResolver(ctrl *gomock.Controller) *MockDockerOperationStatsResolver {
+	mock := &MockDockerOperationStatsResolver{ctrl: ctrl}
+	mock.recorder = &_MockDockerOperationStatsResolverRecorder{mock}
+	return mock
+}
+
+func (_m *MockDockerOperationStatsResolver) EXPECT() *_MockDockerOperationStatsResolverRecorder {
+	return _m.recorder
+}
+
+func (_m *MockDockerOperationStatsResolver) DockerOperationStats() []engine.DockerOperationStats {
+	ret := _m.ctrl.Call(_m, "DockerOperationStats")
+	ret0, _ := ret[0].([]engine.DockerOperationStats)
+	return ret0
+}
+
+func (_mr *_MockDockerOperationStatsResolverRecorder) DockerOperationStats() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DockerOperationStats")
+}
+
+// Mock of ImagePrewarmStatusResolver interface
+type MockImagePrewarmStatusResolver struct {
+	ctrl     *gomock.Controller
+	recorder *_MockImagePrewarmStatusResolverRecorder
+}
+
+// Recorder for MockImagePrewarmStatusResolver (not exported)
+type _MockImagePrewarmStatusResolverRecorder struct {
+	mock *MockImagePrewarmStatusResolver
+}
+
+func NewMockImagePrewarmStatusResolver(ctrl *gomock.Controller) *MockImagePrewarmStatusResolver {
+	mock := &MockImagePrewarmStatusResolver{ctrl: ctrl}
+	mock.recorder = &_MockImagePrewarmStatusResolverRecorder{mock}
+	return mock
+}
+
+func (_m *MockImagePrewarmStatusResolver) EXPECT() *_MockImagePrewarmStatusResolverRecorder {
+	return _m.recorder
+}
+
+func (_m *MockImagePrewarmStatusResolver) ImagePrewarmStatus() []engine.ImagePrewarmStatus {
+	ret := _m.ctrl.Call(_m, "ImagePrewarmStatus")
+	ret0, _ := ret[0].([]engine.ImagePrewarmStatus)
+	return ret0
+}
+
+func (_mr *_MockImagePrewarmStatusResolverRecorder) ImagePrewarmStatus() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ImagePrewarmStatus")
+}
+
+// Mock of ImageCleanupDryRunResolver interface
+type MockImageCleanupDryRunResolver struct {
+	ctrl     *gomock.Controller
+	recorder *_MockImageCleanupDryRunResolverRecorder
+}
+
+// Recorder for MockImageCleanupDryRunResolver (not exported)
+type _MockImageCleanupDryRunResolverRecorder struct {
+	mock *MockImageCleanupDryRunResolver
+}
+
+func NewMockImageCleanupDryRunResolver(ctrl *gomock.Controller) *MockImageCleanupDryRunResolver {
+	mock := &MockImageCleanupDryRunResolver{ctrl: ctrl}
+	mock.recorder = &_MockImageCleanupDryRunResolverRecorder{mock}
+	return mock
+}
+
+func (_m *MockImageCleanupDryRunResolver) EXPECT() *_MockImageCleanupDryRunResolverRecorder {
+	return _m.recorder
+}
+
+func (_m *MockImageCleanupDryRunResolver) ImageCleanupDryRun() engine.ImageCleanupPlan {
+	ret := _m.ctrl.Call(_m, "ImageCleanupDryRun")
+	ret0, _ := ret[0].(engine.ImageCleanupPlan)
+	return ret0
+}
+
+func (_mr *_MockImageCleanupDryRunResolverRecorder) ImageCleanupDryRun() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ImageCleanupDryRun")
+}
+
+// Mock of ImagePinner interface
+type MockImagePinner struct {
+	ctrl     *gomock.Controller
+	recorder *_MockImagePinnerRecorder
+}
+
+// Recorder for MockImagePinner (not exported)
+type _MockImagePinnerRecorder struct {
+	mock *MockImagePinner
+}
+
+func NewMockImagePinner(ctrl *gomock.Controller) *MockImagePinner {
+	mock := &MockImagePinner{ctrl: ctrl}
+	mock.recorder = &_MockImagePinnerRecorder{mock}
+	return mock
+}
+
+func (_m *MockImagePinner) EXPECT() *_MockImagePinnerRecorder {
+	return _m.recorder
+}
+
+func (_m *MockImagePinner) SetImagePinned(_param0 string, _param1 bool) (*image.ImageState, bool) {
+	ret := _m.ctrl.Call(_m, "SetImagePinned", _param0, _param1)
+	ret0, _ := ret[0].(*image.ImageState)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+func (_mr *_MockImagePinnerRecorder) SetImagePinned(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetImagePinned", arg0, arg1)
+}
@@ -0,0 +1,171 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package taskmetadata serves containers the metadata of their task, along
+// with the latest utilization stats of its containers. Each task's metadata
+// is served under an unguessable ID, which is only given to the task's own
+// containers.
+package taskmetadata
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/handlers"
+	"github.com/aws/amazon-ecs-agent/agent/stats"
+	log "github.com/cihub/seelog"
+)
+
+// StatsResolver returns the most recent utilization stats of a container
+type StatsResolver interface {
+	ContainerStats(taskArn string, dockerID string) (*stats.UsageStats, error)
+}
+
+// errorResponse is returned, with a status other than 200, when the metadata
+// of a task cannot be served
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler returns the handler of requests for the metadata of a task, which
+// are made to TaskMetadataPath followed by the ID of the task's endpoint
+func Handler(stateResolver handlers.DockerStateResolver, statsResolver StatsResolver, cluster string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, api.TaskMetadataPath+"/")
+		if id == "" || id == r.URL.Path || strings.Contains(id, "/") {
+			writeJSON(w, http.StatusBadRequest, &errorResponse{Code: "NoIdInRequest", Message: "No task metadata ID in the request"})
+			return
+		}
+		state := stateResolver.State()
+		task, ok := taskByMetadataEndpointID(state, id)
+		if !ok {
+			log.Infof("Task metadata requested for an unknown ID. Request IP Address: %s", r.RemoteAddr)
+			writeJSON(w, http.StatusNotFound, &errorResponse{Code: "InvalidIdInRequest", Message: "Task metadata ID not found"})
+			return
+		}
+		containerMap, _ := state.ContainerMapByArn(task.Arn)
+		writeJSON(w, http.StatusOK, newTaskResponse(task, containerMap, statsResolver, cluster))
+	}
+}
+
+func taskByMetadataEndpointID(state *dockerstate.DockerTaskEngineState, id string) (*api.Task, bool) {
+	for _, task := range state.AllTasks() {
+		if task.MetadataEndpointID != "" && task.MetadataEndpointID == id {
+			return task, true
+		}
+	}
+	return nil, false
+}
+
+func newTaskResponse(task *api.Task, containerMap map[string]*api.DockerContainer, statsResolver StatsResolver, cluster string) *TaskResponse {
+	knownStatus := task.GetKnownStatus()
+	desiredStatus := task.GetDesiredStatus()
+	response := &TaskResponse{
+		Cluster:     cluster,
+		TaskARN:     task.Arn,
+		Family:      task.Family,
+		Revision:    task.Version,
+		KnownStatus: knownStatus.BackendStatus(),
+	}
+	if desiredStatus != knownStatus {
+		response.DesiredStatus = desiredStatus.BackendStatus()
+	}
+
+	for _, container := range task.Containers {
+		if container.IsInternal {
+			continue
+		}
+		containerResponse := ContainerResponse{
+			Name:          container.Name,
+			Image:         container.Image,
+			ImageID:       container.ImageID,
+			DesiredStatus: container.GetDesiredStatus().String(),
+			KnownStatus:   container.GetKnownStatus().String(),
+			ExitCode:      container.KnownExitCode,
+			Limits: LimitsResponse{
+				CPU:    container.Cpu,
+				Memory: container.Memory,
+			},
+		}
+		labels, err := engine.ContainerLabels(task, container, cluster)
+		if err != nil {
+			log.Warnf("Unable to determine the labels of container %s of task %s: %v", container.Name, task.Arn, err)
+		} else {
+			containerResponse.Labels = labels
+		}
+		for _, binding := range container.GetKnownPortBindings() {
+			containerResponse.Ports = append(containerResponse.Ports, PortResponse{
+				ContainerPort: binding.ContainerPort,
+				HostPort:      binding.HostPort,
+				BindIP:        binding.BindIp,
+				Protocol:      binding.Protocol.String(),
+			})
+		}
+		for _, network := range container.KnownNetworks {
+			networkResponse := NetworkResponse{Name: network.Name}
+			if network.IPv4Address != "" {
+				networkResponse.IPv4Addresses = []string{network.IPv4Address}
+			}
+			if network.IPv6Address != "" {
+				networkResponse.IPv6Addresses = []string{network.IPv6Address}
+			}
+			containerResponse.Networks = append(containerResponse.Networks, networkResponse)
+		}
+		if dockerContainer, ok := containerMap[container.Name]; ok {
+			containerResponse.DockerID = dockerContainer.DockerId
+			containerResponse.DockerName = dockerContainer.DockerName
+			if dockerContainer.DockerId != "" && statsResolver != nil {
+				containerResponse.Stats = newStatsResponse(statsResolver, task.Arn, dockerContainer.DockerId)
+			}
+		}
+		response.Containers = append(response.Containers, containerResponse)
+	}
+	return response
+}
+
+// newStatsResponse returns nil if no stats have been collected for the
+// container
+func newStatsResponse(statsResolver StatsResolver, taskArn string, dockerID string) *StatsResponse {
+	usage, err := statsResolver.ContainerStats(taskArn, dockerID)
+	if err != nil {
+		return nil
+	}
+	response := &StatsResponse{
+		MemoryUsageMiB: usage.MemoryUsageInMegs,
+		Timestamp:      usage.Timestamp,
+	}
+	// The CPU usage is not a number until a second sample has been taken
+	cpu := float64(usage.CPUUsagePerc)
+	if !math.IsNaN(cpu) && !math.IsInf(cpu, 0) {
+		response.CPUUsagePercent = &usage.CPUUsagePerc
+	}
+	return response
+}
+
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		log.Errorf("Error marshaling task metadata: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(responseJSON)
+}
@@ -0,0 +1,164 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package taskmetadata
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/handlers/mocks"
+	"github.com/aws/amazon-ecs-agent/agent/stats"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const metadataEndpointID = "5b7e7a3c-1f0f-4bd1-9e5f-0d6a1a4a9b2e"
+
+// fakeStatsResolver returns the stats it holds by docker ID
+type fakeStatsResolver map[string]*stats.UsageStats
+
+func (resolver fakeStatsResolver) ContainerStats(taskArn string, dockerID string) (*stats.UsageStats, error) {
+	usage, ok := resolver[dockerID]
+	if !ok {
+		return nil, errors.New("no stats")
+	}
+	return usage, nil
+}
+
+func testState() *dockerstate.DockerTaskEngineState {
+	rawConfig := `{"Labels":{"team":"web"}}`
+	exitCode := 0
+	task := &api.Task{
+		Arn:                "arn:aws:ecs:us-west-2:123456789012:task/12345678-90ab-cdef-1234-56780abcdef1",
+		Family:             "web",
+		Version:            "3",
+		DesiredStatus:      api.TaskRunning,
+		KnownStatus:        api.TaskRunning,
+		MetadataEndpointID: metadataEndpointID,
+		Containers: []*api.Container{
+			{
+				Name:          "app",
+				Image:         "nginx:latest",
+				ImageID:       "sha256:abc",
+				Cpu:           256,
+				Memory:        512,
+				DesiredStatus: api.ContainerRunning,
+				KnownStatus:   api.ContainerRunning,
+				DockerConfig:  api.DockerConfig{Config: &rawConfig},
+				KnownPortBindings: []api.PortBinding{
+					{ContainerPort: 80, HostPort: 32768, BindIp: "0.0.0.0", Protocol: api.TransportProtocolTCP},
+				},
+				KnownNetworks: []api.Network{{Name: "bridge", IPv4Address: "172.17.0.2"}},
+			},
+			{
+				Name:          "init",
+				Image:         "busybox",
+				DesiredStatus: api.ContainerStopped,
+				KnownStatus:   api.ContainerStopped,
+				KnownExitCode: &exitCode,
+			},
+		},
+	}
+	state := dockerstate.NewDockerTaskEngineState()
+	state.AddTask(task)
+	state.AddContainer(&api.DockerContainer{DockerId: "app-id", DockerName: "ecs-web-3-app", Container: task.Containers[0]}, task)
+	state.AddContainer(&api.DockerContainer{DockerId: "init-id", DockerName: "ecs-web-3-init", Container: task.Containers[1]}, task)
+	return state
+}
+
+func getMetadata(t *testing.T, path string, statsResolver StatsResolver) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	stateResolver := mock_handlers.NewMockDockerStateResolver(ctrl)
+	stateResolver.EXPECT().State().Return(testState()).AnyTimes()
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	Handler(stateResolver, statsResolver, "default")(recorder, req)
+	return recorder
+}
+
+func TestTaskMetadata(t *testing.T) {
+	cpu := float32(math.NaN())
+	timestamp := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	statsResolver := fakeStatsResolver{
+		"app-id": {CPUUsagePerc: cpu, MemoryUsageInMegs: 42, Timestamp: timestamp},
+	}
+	recorder := getMetadata(t, api.TaskMetadataPath+"/"+metadataEndpointID, statsResolver)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var response TaskResponse
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "default", response.Cluster)
+	assert.Equal(t, "web", response.Family)
+	assert.Equal(t, "3", response.Revision)
+	assert.Equal(t, "RUNNING", response.KnownStatus)
+	assert.Empty(t, response.DesiredStatus)
+	require.Len(t, response.Containers, 2)
+
+	app := response.Containers[0]
+	assert.Equal(t, "app-id", app.DockerID)
+	assert.Equal(t, "ecs-web-3-app", app.DockerName)
+	assert.Equal(t, "nginx:latest", app.Image)
+	assert.Equal(t, "sha256:abc", app.ImageID)
+	assert.Equal(t, "RUNNING", app.KnownStatus)
+	assert.Equal(t, LimitsResponse{CPU: 256, Memory: 512}, app.Limits)
+	assert.Equal(t, "web", app.Labels["team"])
+	assert.Equal(t, "web", app.Labels["com.amazonaws.ecs.task-definition-family"])
+	assert.Equal(t, []PortResponse{{ContainerPort: 80, HostPort: 32768, BindIP: "0.0.0.0", Protocol: "tcp"}}, app.Ports)
+	assert.Equal(t, []NetworkResponse{{Name: "bridge", IPv4Addresses: []string{"172.17.0.2"}}}, app.Networks)
+	require.NotNil(t, app.Stats)
+	assert.Nil(t, app.Stats.CPUUsagePercent, "CPU usage is not a number before a second sample")
+	assert.Equal(t, uint32(42), app.Stats.MemoryUsageMiB)
+	assert.True(t, timestamp.Equal(app.Stats.Timestamp))
+
+	initContainer := response.Containers[1]
+	assert.Equal(t, "STOPPED", initContainer.KnownStatus)
+	require.NotNil(t, initContainer.ExitCode)
+	assert.Equal(t, 0, *initContainer.ExitCode)
+	assert.Nil(t, initContainer.Stats)
+}
+
+func TestTaskMetadataWithoutStats(t *testing.T) {
+	recorder := getMetadata(t, api.TaskMetadataPath+"/"+metadataEndpointID, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response TaskResponse
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Len(t, response.Containers, 2)
+	assert.Nil(t, response.Containers[0].Stats)
+}
+
+func TestTaskMetadataInvalidID(t *testing.T) {
+	for path, status := range map[string]int{
+		api.TaskMetadataPath + "/":                                 http.StatusBadRequest,
+		api.TaskMetadataPath + "/" + metadataEndpointID + "/other": http.StatusBadRequest,
+		api.TaskMetadataPath + "/5b7e7a3c":                         http.StatusNotFound,
+	} {
+		recorder := getMetadata(t, path, nil)
+		assert.Equal(t, status, recorder.Code, "incorrect status for %s", path)
+		var response errorResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.NotContains(t, recorder.Body.String(), "web", "task metadata served for %s", path)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package taskmetadata
+
+import "time"
+
+// TaskResponse is the metadata of a task, as served to its containers
+type TaskResponse struct {
+	Cluster       string
+	TaskARN       string
+	Family        string
+	Revision      string
+	DesiredStatus string `json:",omitempty"`
+	KnownStatus   string
+	Containers    []ContainerResponse
+}
+
+// ContainerResponse is the metadata of a container of a task
+type ContainerResponse struct {
+	DockerID      string `json:"DockerId,omitempty"`
+	DockerName    string `json:",omitempty"`
+	Name          string
+	Image         string
+	ImageID       string            `json:",omitempty"`
+	Labels        map[string]string `json:",omitempty"`
+	DesiredStatus string
+	KnownStatus   string
+	ExitCode      *int `json:",omitempty"`
+	Limits        LimitsResponse
+	Ports         []PortResponse    `json:",omitempty"`
+	Networks      []NetworkResponse `json:",omitempty"`
+	// Stats are the most recent utilization stats of the container. They are
+	// only collected when metrics are enabled.
+	Stats *StatsResponse `json:",omitempty"`
+}
+
+// LimitsResponse holds the resources reserved for a container: CPU units
+// and memory in MiB
+type LimitsResponse struct {
+	CPU    uint
+	Memory uint
+}
+
+// PortResponse is a container port bound to a port of the host
+type PortResponse struct {
+	ContainerPort uint16
+	HostPort      uint16
+	BindIP        string `json:",omitempty"`
+	Protocol      string
+}
+
+// NetworkResponse is a network a container is attached to
+type NetworkResponse struct {
+	Name          string
+	IPv4Addresses []string `json:",omitempty"`
+	IPv6Addresses []string `json:",omitempty"`
+}
+
+// StatsResponse holds the utilization of a container at a point in time.
+// CPUUsagePercent is missing until two samples have been collected.
+type StatsResponse struct {
+	CPUUsagePercent *float32 `json:",omitempty"`
+	MemoryUsageMiB  uint32
+	Timestamp       time.Time
+}
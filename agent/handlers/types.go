@@ -13,7 +13,13 @@
 
 package handlers
 
-import "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+import (
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+)
 
 type MetadataResponse struct {
 	Cluster              string
@@ -28,18 +34,203 @@ type TaskResponse struct {
 	Family        string
 	Version       string
 	Containers    []ContainerResponse
+	// ScratchVolumes lists the size limited empty volumes of the task that
+	// are mounted
+	ScratchVolumes []ScratchVolumeResponse `json:",omitempty"`
+}
+
+// ScratchVolumeResponse describes the space used in a size limited empty
+// volume
+type ScratchVolumeResponse struct {
+	Name      string
+	UsedBytes uint64
+	SizeBytes uint64
 }
 
 type TasksResponse struct {
 	Tasks []*TaskResponse
 }
 
+// TaskV2Response describes a task in the 'v2/tasks' API. Unlike
+// TaskResponse, it describes the status, resources and history of each
+// container.
+type TaskV2Response struct {
+	Arn             string
+	DesiredStatus   string `json:",omitempty"`
+	KnownStatus     string
+	KnownStatusTime *time.Time `json:",omitempty"`
+	Family          string
+	Version         string
+	Containers      []ContainerV2Response
+	ScratchVolumes  []ScratchVolumeResponse `json:",omitempty"`
+}
+
+type TasksV2Response struct {
+	Tasks []*TaskV2Response
+}
+
+// ContainerV2Response describes a container in the 'v2/tasks' API. Times at
+// which the container last reached each status are omitted until it does.
+type ContainerV2Response struct {
+	DockerId      string
+	DockerName    string
+	Name          string
+	Image         string
+	ImageID       string `json:",omitempty"`
+	ImageDigest   string `json:",omitempty"`
+	DesiredStatus string
+	KnownStatus   string
+	ExitCode      *int           `json:",omitempty"`
+	ApplyingError *ErrorResponse `json:",omitempty"`
+	HealthStatus  string         `json:",omitempty"`
+	RestartCount  int            `json:",omitempty"`
+	CPU           uint
+	Memory        uint
+	Ports         []PortResponse             `json:",omitempty"`
+	Volumes       []VolumeResponse           `json:",omitempty"`
+	Policies      *ContainerPoliciesResponse `json:",omitempty"`
+	PulledAt      *time.Time                 `json:",omitempty"`
+	CreatedAt     *time.Time                 `json:",omitempty"`
+	StartedAt     *time.Time                 `json:",omitempty"`
+	StoppedAt     *time.Time                 `json:",omitempty"`
+}
+
+// ErrorResponse is an error that occurred trying to transition a container
+// to its desired status
+type ErrorResponse struct {
+	Name    string
+	Message string
+}
+
+// PortResponse is a container port bound to a port of the host
+type PortResponse struct {
+	ContainerPort uint16
+	HostPort      uint16
+	BindIp        string `json:",omitempty"`
+	Protocol      string
+}
+
+// VolumeResponse is a volume mounted in a container. Source is the path of
+// the volume on the host, if known.
+type VolumeResponse struct {
+	Name          string
+	Source        string `json:",omitempty"`
+	ContainerPath string
+	ReadOnly      bool `json:",omitempty"`
+}
+
 type ContainerResponse struct {
-	DockerId   string
-	DockerName string
-	Name       string
+	DockerId     string
+	DockerName   string
+	Name         string
+	HealthStatus string `json:",omitempty"`
+	RestartCount int    `json:",omitempty"`
+	ImageDigest  string `json:",omitempty"`
+	// Policies are the effective log configuration, ulimits and security
+	// options of the container, after instance-wide defaults are applied
+	Policies *ContainerPoliciesResponse `json:",omitempty"`
+}
+
+// ContainerPoliciesResponse describes the log configuration, ulimits and
+// security options a container is created with
+type ContainerPoliciesResponse struct {
+	LogDriver       string            `json:",omitempty"`
+	LogOptions      map[string]string `json:",omitempty"`
+	Ulimits         []UlimitResponse  `json:",omitempty"`
+	NoNewPrivileges bool              `json:",omitempty"`
+	SeccompProfile  string            `json:",omitempty"`
+}
+
+type UlimitResponse struct {
+	Name string
+	Soft int64
+	Hard int64
+}
+
+// DockerOperationResponse describes the calls made to Docker for one type of
+// operation. Durations are formatted as strings such as "1.5s".
+type DockerOperationResponse struct {
+	Operation           string
+	Limit               int `json:",omitempty"`
+	InFlight            int
+	Queued              int
+	Calls               int
+	AverageQueueWait    string
+	MaxQueueWait        string
+	AverageCallDuration string
+	MaxCallDuration     string
+}
+
+type DockerOperationsResponse struct {
+	Operations []DockerOperationResponse
+}
+
+// ImagePrewarmResponse describes the progress of pulling an image that is
+// pre-warmed on the instance
+type ImagePrewarmResponse struct {
+	Image         string
+	State         string
+	LastAttemptAt *time.Time `json:",omitempty"`
+	LastPulledAt  *time.Time `json:",omitempty"`
+	Error         string     `json:",omitempty"`
+}
+
+type ImagePrewarmsResponse struct {
+	Images []ImagePrewarmResponse
+}
+
+// ImageResponse describes an image tracked by the agent
+type ImageResponse struct {
+	ImageID    string
+	Names      []string
+	Size       int64
+	PulledAt   time.Time
+	LastUsedAt time.Time
+	Pinned     bool
+	UseCount   int
+}
+
+// ImageDeletionCandidateResponse describes an image that the next image
+// cleanup cycle would delete
+type ImageDeletionCandidateResponse struct {
+	ImageResponse
+	Reason string
+}
+
+// UnmanagedResourceResponse is a container or image not created by the agent
+// that the cleanup of unmanaged resources would remove
+type UnmanagedResourceResponse struct {
+	ID     string
+	Names  []string
+	Reason string
+}
+
+// ImageCleanupDryRunResponse lists the images that the next image cleanup
+// cycle would delete, in order, and the containers and images that the
+// cleanup of unmanaged resources would remove
+type ImageCleanupDryRunResponse struct {
+	Strategy            string
+	Images              []ImageDeletionCandidateResponse
+	UnmanagedContainers []UnmanagedResourceResponse
+	UnmanagedImages     []UnmanagedResourceResponse
 }
 
 type DockerStateResolver interface {
 	State() *dockerstate.DockerTaskEngineState
 }
+
+type DockerOperationStatsResolver interface {
+	DockerOperationStats() []engine.DockerOperationStats
+}
+
+type ImagePrewarmStatusResolver interface {
+	ImagePrewarmStatus() []engine.ImagePrewarmStatus
+}
+
+type ImageCleanupDryRunResolver interface {
+	ImageCleanupDryRun() engine.ImageCleanupPlan
+}
+
+type ImagePinner interface {
+	SetImagePinned(imageRef string, pinned bool) (*image.ImageState, bool)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/engine"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
 	"github.com/aws/amazon-ecs-agent/agent/logger"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
 	"github.com/aws/amazon-ecs-agent/agent/version"
@@ -35,6 +36,7 @@ var log = logger.ForModule("Handlers")
 const (
 	dockerIdQueryField = "dockerid"
 	taskArnQueryField  = "taskarn"
+	imageQueryField    = "image"
 )
 
 type rootResponse struct {
@@ -62,15 +64,42 @@ func metadataV1RequestHandlerMaker(containerInstanceArn *string, cfg *config.Con
 	}
 }
 
-func newTaskResponse(task *api.Task, containerMap map[string]*api.DockerContainer) *TaskResponse {
+func newTaskResponse(task *api.Task, containerMap map[string]*api.DockerContainer, containerDefaults *api.ContainerDefaults) *TaskResponse {
 	containers := []ContainerResponse{}
 	for containerName, container := range containerMap {
 		if container.Container.IsInternal {
 			continue
 		}
-		containers = append(containers, ContainerResponse{container.DockerId, container.DockerName, containerName})
+		containerResponse := ContainerResponse{
+			DockerId:     container.DockerId,
+			DockerName:   container.DockerName,
+			Name:         containerName,
+			RestartCount: container.Container.GetRestartCount(),
+			ImageDigest:  container.Container.ImageDigest,
+			Policies:     newContainerPoliciesResponse(container.Container, containerDefaults),
+		}
+		if container.Container.HealthCheck != nil {
+			containerResponse.HealthStatus = container.Container.GetHealthStatus().Status.String()
+		}
+		containers = append(containers, containerResponse)
 	}
 
+	knownStatus, desiredStatus := taskBackendStatuses(task)
+	return &TaskResponse{
+		Arn:            task.Arn,
+		DesiredStatus:  desiredStatus,
+		KnownStatus:    knownStatus,
+		Family:         task.Family,
+		Version:        task.Version,
+		Containers:     containers,
+		ScratchVolumes: newScratchVolumesResponse(task),
+	}
+}
+
+// taskBackendStatuses returns the known and desired statuses of a task as
+// the backend names them. The desired status is left empty when the known
+// status is already past it.
+func taskBackendStatuses(task *api.Task) (string, string) {
 	knownStatus := task.GetKnownStatus()
 	knownBackendStatus := knownStatus.BackendStatus()
 	desiredStatusInAgent := task.GetDesiredStatus()
@@ -79,35 +108,62 @@ func newTaskResponse(task *api.Task, containerMap map[string]*api.DockerContaine
 	if (knownBackendStatus == "STOPPED" && desiredStatus != "STOPPED") || (knownBackendStatus == "RUNNING" && desiredStatus == "PENDING") {
 		desiredStatus = ""
 	}
+	return knownBackendStatus, desiredStatus
+}
 
-	return &TaskResponse{
-		Arn:           task.Arn,
-		DesiredStatus: desiredStatus,
-		KnownStatus:   knownBackendStatus,
-		Family:        task.Family,
-		Version:       task.Version,
-		Containers:    containers,
+func newScratchVolumesResponse(task *api.Task) []ScratchVolumeResponse {
+	var scratchVolumes []ScratchVolumeResponse
+	for _, usage := range engine.TaskScratchVolumeUsage(task) {
+		scratchVolumes = append(scratchVolumes, ScratchVolumeResponse{
+			Name:      usage.VolumeName,
+			UsedBytes: usage.UsedBytes,
+			SizeBytes: usage.SizeBytes,
+		})
 	}
+	return scratchVolumes
 }
 
-func newTasksResponse(state *dockerstate.DockerTaskEngineState) *TasksResponse {
+// newContainerPoliciesResponse returns nil if none of the policies are set,
+// in which case Docker's defaults apply
+func newContainerPoliciesResponse(container *api.Container, containerDefaults *api.ContainerDefaults) *ContainerPoliciesResponse {
+	policies, err := container.Policies(containerDefaults)
+	if err != nil {
+		log.Warn("Unable to determine container policies", "container", container, "err", err)
+		return nil
+	}
+	response := &ContainerPoliciesResponse{
+		LogDriver:       policies.LogDriver,
+		LogOptions:      policies.LogOptions,
+		NoNewPrivileges: policies.NoNewPrivileges,
+		SeccompProfile:  policies.SeccompProfile,
+	}
+	for _, ulimit := range policies.Ulimits {
+		response.Ulimits = append(response.Ulimits, UlimitResponse{Name: ulimit.Name, Soft: ulimit.SoftLimit, Hard: ulimit.HardLimit})
+	}
+	if response.LogDriver == "" && len(response.Ulimits) == 0 && !response.NoNewPrivileges && response.SeccompProfile == "" {
+		return nil
+	}
+	return response
+}
+
+func newTasksResponse(state *dockerstate.DockerTaskEngineState, containerDefaults *api.ContainerDefaults) *TasksResponse {
 	allTasks := state.AllTasks()
 	taskResponses := make([]*TaskResponse, len(allTasks))
 	for ndx, task := range allTasks {
 		containerMap, _ := state.ContainerMapByArn(task.Arn)
-		taskResponses[ndx] = newTaskResponse(task, containerMap)
+		taskResponses[ndx] = newTaskResponse(task, containerMap, containerDefaults)
 	}
 
 	return &TasksResponse{Tasks: taskResponses}
 }
 
 // Creates JSON response and sets the http status code for the task queried.
-func createTaskJSONResponse(task *api.Task, found bool, resourceId string, state *dockerstate.DockerTaskEngineState) ([]byte, int) {
+func createTaskJSONResponse(task *api.Task, found bool, resourceId string, state *dockerstate.DockerTaskEngineState, containerDefaults *api.ContainerDefaults) ([]byte, int) {
 	var responseJSON []byte
 	status := http.StatusOK
 	if found {
 		containerMap, _ := state.ContainerMapByArn(task.Arn)
-		responseJSON, _ = json.Marshal(newTaskResponse(task, containerMap))
+		responseJSON, _ = json.Marshal(newTaskResponse(task, containerMap, containerDefaults))
 	} else {
 		log.Warn("Could not find requsted resource: " + resourceId)
 		responseJSON, _ = json.Marshal(&TaskResponse{})
@@ -119,7 +175,7 @@ func createTaskJSONResponse(task *api.Task, found bool, resourceId string, state
 // Creates response for the 'v1/tasks' API. Lists all tasks if the request
 // doesn't contain any fields. Returns a Task if either of 'dockerid' or
 // 'taskarn' are specified in the request.
-func tasksV1RequestHandlerMaker(taskEngine DockerStateResolver) func(http.ResponseWriter, *http.Request) {
+func tasksV1RequestHandlerMaker(taskEngine DockerStateResolver, containerDefaults *api.ContainerDefaults) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var responseJSON []byte
 		dockerTaskEngineState := taskEngine.State()
@@ -135,21 +191,163 @@ func tasksV1RequestHandlerMaker(taskEngine DockerStateResolver) func(http.Respon
 		if dockerIdExists {
 			// Create TaskResponse for the docker id in the query.
 			task, found := dockerTaskEngineState.TaskById(dockerId)
-			responseJSON, status = createTaskJSONResponse(task, found, dockerId, dockerTaskEngineState)
+			responseJSON, status = createTaskJSONResponse(task, found, dockerId, dockerTaskEngineState, containerDefaults)
 			w.WriteHeader(status)
 		} else if taskArnExists {
 			// Create TaskResponse for the task arn in the query.
 			task, found := dockerTaskEngineState.TaskByArn(taskArn)
-			responseJSON, status = createTaskJSONResponse(task, found, taskArn, dockerTaskEngineState)
+			responseJSON, status = createTaskJSONResponse(task, found, taskArn, dockerTaskEngineState, containerDefaults)
 			w.WriteHeader(status)
 		} else {
 			// List all tasks.
-			responseJSON, _ = json.Marshal(newTasksResponse(dockerTaskEngineState))
+			responseJSON, _ = json.Marshal(newTasksResponse(dockerTaskEngineState, containerDefaults))
 		}
 		w.Write(responseJSON)
 	}
 }
 
+func newDockerOperationsResponse(stats []engine.DockerOperationStats) *DockerOperationsResponse {
+	average := func(total time.Duration, calls int) time.Duration {
+		if calls == 0 {
+			return 0
+		}
+		return total / time.Duration(calls)
+	}
+	operations := make([]DockerOperationResponse, len(stats))
+	for i, opStats := range stats {
+		operations[i] = DockerOperationResponse{
+			Operation:           string(opStats.Operation),
+			Limit:               opStats.Limit,
+			InFlight:            opStats.InFlight,
+			Queued:              opStats.Queued,
+			Calls:               opStats.Calls,
+			AverageQueueWait:    average(opStats.TotalQueueWait, opStats.Calls).String(),
+			MaxQueueWait:        opStats.MaxQueueWait.String(),
+			AverageCallDuration: average(opStats.TotalCallDuration, opStats.Calls).String(),
+			MaxCallDuration:     opStats.MaxCallDuration.String(),
+		}
+	}
+	return &DockerOperationsResponse{Operations: operations}
+}
+
+// Creates response for the 'v1/docker/operations' API. Lists the concurrency
+// limit of each type of Docker operation along with how long calls have spent
+// queued for a free slot and how long the calls themselves took.
+func dockerOperationsV1RequestHandlerMaker(resolver DockerOperationStatsResolver) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, _ := json.Marshal(newDockerOperationsResponse(resolver.DockerOperationStats()))
+		w.Write(responseJSON)
+	}
+}
+
+// optionalTime returns nil for the zero time, so that it is omitted from
+// responses
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func newImagePrewarmsResponse(statuses []engine.ImagePrewarmStatus) *ImagePrewarmsResponse {
+	images := make([]ImagePrewarmResponse, len(statuses))
+	for i, status := range statuses {
+		images[i] = ImagePrewarmResponse{
+			Image:         status.Image,
+			State:         string(status.State),
+			LastAttemptAt: optionalTime(status.LastAttemptAt),
+			LastPulledAt:  optionalTime(status.LastPulledAt),
+			Error:         status.Error,
+		}
+	}
+	return &ImagePrewarmsResponse{Images: images}
+}
+
+// Creates response for the 'v1/images/prewarm' API. Lists the images that are
+// pre-warmed on the instance along with the progress of pulling them.
+func imagePrewarmV1RequestHandlerMaker(resolver ImagePrewarmStatusResolver) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, _ := json.Marshal(newImagePrewarmsResponse(resolver.ImagePrewarmStatus()))
+		w.Write(responseJSON)
+	}
+}
+
+func newImageResponse(imageState *image.ImageState) *ImageResponse {
+	names := make([]string, len(imageState.Image.Names))
+	copy(names, imageState.Image.Names)
+	return &ImageResponse{
+		ImageID:    imageState.Image.ImageID,
+		Names:      names,
+		Size:       imageState.Image.Size,
+		PulledAt:   imageState.PulledAt,
+		LastUsedAt: imageState.LastUsedAt,
+		Pinned:     imageState.IsPinned(),
+		UseCount:   imageState.GetUseCount(),
+	}
+}
+
+func newImageCleanupDryRunResponse(plan engine.ImageCleanupPlan) *ImageCleanupDryRunResponse {
+	images := make([]ImageDeletionCandidateResponse, len(plan.Images))
+	for i, candidate := range plan.Images {
+		images[i] = ImageDeletionCandidateResponse{
+			ImageResponse: *newImageResponse(candidate.ImageState),
+			Reason:        candidate.Reason,
+		}
+	}
+	return &ImageCleanupDryRunResponse{
+		Strategy:            string(plan.Strategy),
+		Images:              images,
+		UnmanagedContainers: newUnmanagedResourceResponses(plan.UnmanagedContainers),
+		UnmanagedImages:     newUnmanagedResourceResponses(plan.UnmanagedImages),
+	}
+}
+
+func newUnmanagedResourceResponses(candidates []engine.UnmanagedResourceCandidate) []UnmanagedResourceResponse {
+	resources := make([]UnmanagedResourceResponse, len(candidates))
+	for i, candidate := range candidates {
+		resources[i] = UnmanagedResourceResponse{ID: candidate.ID, Names: candidate.Names, Reason: candidate.Reason}
+	}
+	return resources
+}
+
+// Creates response for the 'v1/images/cleanup/dryrun' API. Lists the images
+// the next image cleanup cycle would delete, and the unmanaged containers and
+// images it would remove, and why, without deleting them.
+func imageCleanupDryRunV1RequestHandlerMaker(resolver ImageCleanupDryRunResolver) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, _ := json.Marshal(newImageCleanupDryRunResponse(resolver.ImageCleanupDryRun()))
+		w.Write(responseJSON)
+	}
+}
+
+// Creates response for the 'v1/images/pin' and 'v1/images/unpin' APIs. The
+// image given by the 'image' field, either an image ID or name, is pinned so
+// that image cleanup never removes it, or unpinned. Only POST is accepted.
+func imagePinV1RequestHandlerMaker(pinner ImagePinner, pinned bool) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		imageRef, ok := ValueFromRequest(r, imageQueryField)
+		if !ok || imageRef == "" {
+			log.Info("Request is missing the " + imageQueryField + " field")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		imageState, found := pinner.SetImagePinned(imageRef, pinned)
+		if !found {
+			log.Warn("Could not find requested image: " + imageRef)
+			responseJSON, _ := json.Marshal(&ImageResponse{})
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(responseJSON)
+			return
+		}
+		responseJSON, _ := json.Marshal(newImageResponse(imageState))
+		w.Write(responseJSON)
+	}
+}
+
 var licenseProvider = utils.NewLicenseProvider()
 
 func licenseHandler(w http.ResponseWriter, h *http.Request) {
@@ -161,11 +359,19 @@ func licenseHandler(w http.ResponseWriter, h *http.Request) {
 	}
 }
 
-func setupServer(containerInstanceArn *string, taskEngine DockerStateResolver, cfg *config.Config) http.Server {
+func setupServer(containerInstanceArn *string, taskEngine DockerStateResolver, operationStats DockerOperationStatsResolver, imagePrewarm ImagePrewarmStatusResolver, imagePinner ImagePinner, imageCleanup ImageCleanupDryRunResolver, containerDefaults *api.ContainerDefaults, cfg *config.Config) http.Server {
 	serverFunctions := map[string]func(w http.ResponseWriter, r *http.Request){
-		"/v1/metadata": metadataV1RequestHandlerMaker(containerInstanceArn, cfg),
-		"/v1/tasks":    tasksV1RequestHandlerMaker(taskEngine),
-		"/license":     licenseHandler,
+		"/v1/metadata":              metadataV1RequestHandlerMaker(containerInstanceArn, cfg),
+		"/v1/tasks":                 tasksV1RequestHandlerMaker(taskEngine, containerDefaults),
+		"/v1/docker/operations":     dockerOperationsV1RequestHandlerMaker(operationStats),
+		"/v1/images/prewarm":        imagePrewarmV1RequestHandlerMaker(imagePrewarm),
+		"/v1/images/cleanup/dryrun": imageCleanupDryRunV1RequestHandlerMaker(imageCleanup),
+		"/v2/tasks":                 tasksV2RequestHandlerMaker(taskEngine, containerDefaults),
+		"/license":                  licenseHandler,
+	}
+	if cfg.ImagePinAPIEnabled {
+		serverFunctions["/v1/images/pin"] = imagePinV1RequestHandlerMaker(imagePinner, true)
+		serverFunctions["/v1/images/unpin"] = imagePinV1RequestHandlerMaker(imagePinner, false)
 	}
 
 	paths := make([]string, 0, len(serverFunctions))
@@ -207,7 +413,7 @@ func ServeHttp(containerInstanceArn *string, taskEngine engine.TaskEngine, cfg *
 	// Revisit if we ever add another type..
 	dockerTaskEngine := taskEngine.(*engine.DockerTaskEngine)
 
-	server := setupServer(containerInstanceArn, dockerTaskEngine, cfg)
+	server := setupServer(containerInstanceArn, dockerTaskEngine, dockerTaskEngine, dockerTaskEngine, dockerTaskEngine, dockerTaskEngine, dockerTaskEngine.ContainerDefaults(), cfg)
 	for {
 		once := sync.Once{}
 		utils.RetryWithBackoff(utils.NewSimpleBackoff(time.Second, time.Minute, 0.2, 2), func() error {
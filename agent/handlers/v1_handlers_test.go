@@ -18,12 +18,17 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/aws/amazon-ecs-agent/agent/engine"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
 	"github.com/aws/amazon-ecs-agent/agent/handlers/mocks"
 	"github.com/aws/amazon-ecs-agent/agent/handlers/mocks/http"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
@@ -139,7 +144,7 @@ func TestBackendMismatchMapping(t *testing.T) {
 	stateSetupHelper(state, []*api.Task{testTask})
 
 	mockStateResolver.EXPECT().State().Return(state)
-	requestHandler := tasksV1RequestHandlerMaker(mockStateResolver)
+	requestHandler := tasksV1RequestHandlerMaker(mockStateResolver, nil)
 
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/v1/tasks", nil)
@@ -158,6 +163,302 @@ func TestBackendMismatchMapping(t *testing.T) {
 	}
 }
 
+func TestTaskResponseContainerPolicies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStateResolver := mock_handlers.NewMockDockerStateResolver(ctrl)
+	rawHostConfig := `{"LogConfig":{"Type":"syslog"}}`
+	testTask := &api.Task{
+		Arn: "task1",
+		Containers: []*api.Container{
+			{Name: "app"},
+			{Name: "logger", DockerConfig: api.DockerConfig{HostConfig: &rawHostConfig}},
+		},
+	}
+	state := dockerstate.NewDockerTaskEngineState()
+	stateSetupHelper(state, []*api.Task{testTask})
+	mockStateResolver.EXPECT().State().Return(state)
+	containerDefaults := &api.ContainerDefaults{
+		LogDriver:       "json-file",
+		LogOptions:      map[string]string{"max-size": "10m"},
+		Ulimits:         []api.Ulimit{{Name: "nofile", SoftLimit: 1024, HardLimit: 4096}},
+		NoNewPrivileges: true,
+	}
+	requestHandler := tasksV1RequestHandlerMaker(mockStateResolver, containerDefaults)
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/tasks?taskarn=task1", nil)
+	requestHandler(recorder, req)
+
+	var taskResponse TaskResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &taskResponse); err != nil {
+		t.Fatal(err)
+	}
+	policies := make(map[string]*ContainerPoliciesResponse)
+	for _, container := range taskResponse.Containers {
+		policies[container.Name] = container.Policies
+	}
+	expected := map[string]*ContainerPoliciesResponse{
+		"app": {
+			LogDriver:       "json-file",
+			LogOptions:      map[string]string{"max-size": "10m"},
+			Ulimits:         []UlimitResponse{{Name: "nofile", Soft: 1024, Hard: 4096}},
+			NoNewPrivileges: true,
+		},
+		"logger": {
+			LogDriver:       "syslog",
+			Ulimits:         []UlimitResponse{{Name: "nofile", Soft: 1024, Hard: 4096}},
+			NoNewPrivileges: true,
+		},
+	}
+	if !reflect.DeepEqual(expected, policies) {
+		t.Errorf("Expected container policies %+v, got %+v", expected, policies)
+	}
+}
+
+func TestDockerOperationsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOperationStats := mock_handlers.NewMockDockerOperationStatsResolver(ctrl)
+	mockOperationStats.EXPECT().DockerOperationStats().Return([]engine.DockerOperationStats{
+		{
+			Operation:         dockerclient.PullOperation,
+			Limit:             2,
+			Queued:            1,
+			Calls:             2,
+			TotalQueueWait:    3 * time.Second,
+			MaxQueueWait:      2 * time.Second,
+			TotalCallDuration: time.Minute,
+			MaxCallDuration:   40 * time.Second,
+		},
+		{Operation: dockerclient.StartOperation},
+	})
+	handler := dockerOperationsV1RequestHandlerMaker(mockOperationStats)
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/docker/operations", nil)
+	handler(recorder, req)
+
+	var response DockerOperationsResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []DockerOperationResponse{
+		{
+			Operation:           "pull",
+			Limit:               2,
+			Queued:              1,
+			Calls:               2,
+			AverageQueueWait:    "1.5s",
+			MaxQueueWait:        "2s",
+			AverageCallDuration: "30s",
+			MaxCallDuration:     "40s",
+		},
+		{
+			Operation:           "start",
+			AverageQueueWait:    "0s",
+			MaxQueueWait:        "0s",
+			AverageCallDuration: "0s",
+			MaxCallDuration:     "0s",
+		},
+	}
+	if !reflect.DeepEqual(expected, response.Operations) {
+		t.Errorf("Unexpected operations response: %+v", response.Operations)
+	}
+}
+
+func TestImagePrewarmHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pulledAt := time.Date(2016, time.October, 1, 12, 0, 0, 0, time.UTC)
+	mockImagePrewarm := mock_handlers.NewMockImagePrewarmStatusResolver(ctrl)
+	mockImagePrewarm.EXPECT().ImagePrewarmStatus().Return([]engine.ImagePrewarmStatus{
+		{
+			Image:         "nginx:latest",
+			State:         engine.ImagePrewarmPulled,
+			LastAttemptAt: pulledAt,
+			LastPulledAt:  pulledAt,
+		},
+		{Image: "busybox:latest", State: engine.ImagePrewarmPending},
+	})
+	handler := imagePrewarmV1RequestHandlerMaker(mockImagePrewarm)
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/images/prewarm", nil)
+	handler(recorder, req)
+
+	var response ImagePrewarmsResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ImagePrewarmResponse{
+		{
+			Image:         "nginx:latest",
+			State:         "PULLED",
+			LastAttemptAt: &pulledAt,
+			LastPulledAt:  &pulledAt,
+		},
+		{Image: "busybox:latest", State: "PENDING"},
+	}
+	if !reflect.DeepEqual(expected, response.Images) {
+		t.Errorf("Unexpected image prewarm response: %+v", response.Images)
+	}
+}
+
+func TestImagePinHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	imageState := &image.ImageState{
+		Image: &image.Image{ImageID: "sha256:base", Names: []string{"base:latest"}, Size: 100},
+	}
+	mockImagePinner := mock_handlers.NewMockImagePinner(ctrl)
+	mockImagePinner.EXPECT().SetImagePinned("base:latest", true).Do(func(string, bool) {
+		imageState.SetPinned(true)
+	}).Return(imageState, true)
+	handler := imagePinV1RequestHandlerMaker(mockImagePinner, true)
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/images/pin?image=base:latest", nil)
+	handler(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+	var response ImageResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ImageID != "sha256:base" || !response.Pinned || !reflect.DeepEqual(response.Names, []string{"base:latest"}) {
+		t.Errorf("Unexpected image response: %+v", response)
+	}
+}
+
+func TestImageUnpinHandlerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImagePinner := mock_handlers.NewMockImagePinner(ctrl)
+	mockImagePinner.EXPECT().SetImagePinned("unknown:latest", false).Return(nil, false)
+	handler := imagePinV1RequestHandlerMaker(mockImagePinner, false)
+
+	for _, testCase := range []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{"GET", "/v1/images/unpin?image=base:latest", http.StatusMethodNotAllowed},
+		{"POST", "/v1/images/unpin", http.StatusBadRequest},
+		{"POST", "/v1/images/unpin?image=unknown:latest", http.StatusNotFound},
+	} {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest(testCase.method, testCase.path, nil)
+		handler(recorder, req)
+		if recorder.Code != testCase.expectedStatus {
+			t.Errorf("%s %s: expected status %d, got %d", testCase.method, testCase.path, testCase.expectedStatus, recorder.Code)
+		}
+	}
+}
+
+func TestImagePinAPIIsOffByDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	imageState := &image.ImageState{
+		Image: &image.Image{ImageID: "sha256:base", Names: []string{"base:latest"}},
+	}
+	mockImagePinner := mock_handlers.NewMockImagePinner(ctrl)
+	mockImagePinner.EXPECT().SetImagePinned("base:latest", true).Return(imageState, true)
+
+	for _, enabled := range []bool{false, true} {
+		cfg := &config.Config{Cluster: testClusterArn, ImagePinAPIEnabled: enabled}
+		server := setupServer(utils.Strptr(testContainerInstanceArn), nil, nil, nil, mockImagePinner, nil, nil, cfg)
+
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/images/pin?image=base:latest", nil)
+		server.Handler.ServeHTTP(recorder, req)
+
+		if !enabled {
+			// Without the handler the request falls through to the list of
+			// available commands, which must not mention the pin API
+			var root rootResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &root); err != nil || len(root.AvailableCommands) == 0 {
+				t.Fatalf("Expected the list of available commands, got %s", recorder.Body.String())
+			}
+			for _, command := range root.AvailableCommands {
+				if command == "/v1/images/pin" || command == "/v1/images/unpin" {
+					t.Errorf("Pin API listed while disabled: %v", root.AvailableCommands)
+				}
+			}
+			continue
+		}
+		var response ImageResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response.ImageID != "sha256:base" {
+			t.Errorf("Expected the pinned image, got %s", recorder.Body.String())
+		}
+	}
+}
+
+func TestImageCleanupDryRunHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lastUsedAt := time.Date(2016, time.October, 1, 12, 0, 0, 0, time.UTC)
+	imageState := &image.ImageState{
+		Image:      &image.Image{ImageID: "sha256:large", Names: []string{"large:latest"}, Size: 300},
+		LastUsedAt: lastUsedAt,
+		UseCount:   4,
+	}
+	mockImageCleanup := mock_handlers.NewMockImageCleanupDryRunResolver(ctrl)
+	mockImageCleanup.EXPECT().ImageCleanupDryRun().Return(engine.ImageCleanupPlan{
+		Strategy: dockerclient.ImageCleanupLargestFirstStrategy,
+		Images:   []engine.ImageDeletionCandidate{{ImageState: imageState, Reason: "largest image, 300 bytes"}},
+		UnmanagedContainers: []engine.UnmanagedResourceCandidate{
+			{ID: "stray-id", Names: []string{"stray"}, Reason: "exited 2h0m0s ago"},
+		},
+		UnmanagedImages: []engine.UnmanagedResourceCandidate{{ID: "sha256:dangling", Reason: "dangling image"}},
+	})
+	handler := imageCleanupDryRunV1RequestHandlerMaker(mockImageCleanup)
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/images/cleanup/dryrun", nil)
+	handler(recorder, req)
+
+	var response ImageCleanupDryRunResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ImageCleanupDryRunResponse{
+		Strategy: "largest-first",
+		Images: []ImageDeletionCandidateResponse{
+			{
+				ImageResponse: ImageResponse{
+					ImageID:    "sha256:large",
+					Names:      []string{"large:latest"},
+					Size:       300,
+					LastUsedAt: lastUsedAt,
+					UseCount:   4,
+				},
+				Reason: "largest image, 300 bytes",
+			},
+		},
+		UnmanagedContainers: []UnmanagedResourceResponse{
+			{ID: "stray-id", Names: []string{"stray"}, Reason: "exited 2h0m0s ago"},
+		},
+		UnmanagedImages: []UnmanagedResourceResponse{{ID: "sha256:dangling", Reason: "dangling image"}},
+	}
+	if !reflect.DeepEqual(expected, response) {
+		t.Errorf("Unexpected image cleanup dry run response: %+v", response)
+	}
+}
+
 func TestLicenseHandler(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -225,9 +526,11 @@ func taskDiffHelper(t *testing.T, expected []*api.Task, actual TasksResponse) {
 			continue
 		}
 		for _, respCont := range respTask.Containers {
-			_, ok := task.ContainerByName(respCont.Name)
+			cont, ok := task.ContainerByName(respCont.Name)
 			if !ok {
 				t.Errorf("Could not find container %v", respCont.Name)
+			} else if respCont.ImageDigest != cont.ImageDigest {
+				t.Errorf("ImageDigest mismatch: %v != %v", respCont.ImageDigest, cont.ImageDigest)
 			}
 			if respCont.DockerId == "" {
 				t.Error("blank dockerid")
@@ -260,7 +563,8 @@ var testTasks = []*api.Task{
 		Version:       "2",
 		Containers: []*api.Container{
 			{
-				Name: "foo",
+				Name:        "foo",
+				ImageDigest: "sha256:4bf5ad1c",
 			},
 		},
 	},
@@ -289,7 +593,11 @@ func performMockRequest(t *testing.T, path string) *httptest.ResponseRecorder {
 	stateSetupHelper(state, testTasks)
 
 	mockStateResolver.EXPECT().State().Return(state)
-	requestHandler := setupServer(utils.Strptr(testContainerInstanceArn), mockStateResolver, &config.Config{Cluster: testClusterArn})
+	mockOperationStats := mock_handlers.NewMockDockerOperationStatsResolver(ctrl)
+	mockImagePrewarm := mock_handlers.NewMockImagePrewarmStatusResolver(ctrl)
+	mockImagePinner := mock_handlers.NewMockImagePinner(ctrl)
+	mockImageCleanup := mock_handlers.NewMockImageCleanupDryRunResolver(ctrl)
+	requestHandler := setupServer(utils.Strptr(testContainerInstanceArn), mockStateResolver, mockOperationStats, mockImagePrewarm, mockImagePinner, mockImageCleanup, nil, &config.Config{Cluster: testClusterArn})
 
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", path, nil)
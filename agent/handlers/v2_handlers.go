@@ -0,0 +1,152 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+)
+
+func newTaskV2Response(task *api.Task, containerMap map[string]*api.DockerContainer, containerDefaults *api.ContainerDefaults) *TaskV2Response {
+	containers := []ContainerV2Response{}
+	for _, container := range task.Containers {
+		if container.IsInternal {
+			continue
+		}
+		containers = append(containers, newContainerV2Response(task, container, containerMap[container.Name], containerDefaults))
+	}
+
+	knownStatus, desiredStatus := taskBackendStatuses(task)
+	return &TaskV2Response{
+		Arn:             task.Arn,
+		DesiredStatus:   desiredStatus,
+		KnownStatus:     knownStatus,
+		KnownStatusTime: optionalTime(task.GetKnownStatusTime()),
+		Family:          task.Family,
+		Version:         task.Version,
+		Containers:      containers,
+		ScratchVolumes:  newScratchVolumesResponse(task),
+	}
+}
+
+// newContainerV2Response describes a container, which has no Docker ID or
+// name until it is created
+func newContainerV2Response(task *api.Task, container *api.Container, dockerContainer *api.DockerContainer, containerDefaults *api.ContainerDefaults) ContainerV2Response {
+	transitionTimes := container.GetTransitionTimes()
+	response := ContainerV2Response{
+		Name:          container.Name,
+		Image:         container.Image,
+		ImageID:       container.ImageID,
+		ImageDigest:   container.ImageDigest,
+		DesiredStatus: container.GetDesiredStatus().String(),
+		KnownStatus:   container.GetKnownStatus().String(),
+		ExitCode:      container.KnownExitCode,
+		RestartCount:  container.GetRestartCount(),
+		CPU:           container.Cpu,
+		Memory:        container.Memory,
+		Policies:      newContainerPoliciesResponse(container, containerDefaults),
+		PulledAt:      optionalTime(transitionTimes.Pulled),
+		CreatedAt:     optionalTime(transitionTimes.Created),
+		StartedAt:     optionalTime(transitionTimes.Running),
+		StoppedAt:     optionalTime(transitionTimes.Stopped),
+	}
+	if dockerContainer != nil {
+		response.DockerId = dockerContainer.DockerId
+		response.DockerName = dockerContainer.DockerName
+	}
+	if container.ApplyingError != nil {
+		response.ApplyingError = &ErrorResponse{
+			Name:    container.ApplyingError.ErrorName(),
+			Message: container.ApplyingError.Err,
+		}
+	}
+	if container.HealthCheck != nil {
+		response.HealthStatus = container.GetHealthStatus().Status.String()
+	}
+	for _, binding := range container.GetKnownPortBindings() {
+		response.Ports = append(response.Ports, PortResponse{
+			ContainerPort: binding.ContainerPort,
+			HostPort:      binding.HostPort,
+			BindIp:        binding.BindIp,
+			Protocol:      binding.Protocol.String(),
+		})
+	}
+	for _, mountPoint := range container.MountPoints {
+		volume := VolumeResponse{
+			Name:          mountPoint.SourceVolume,
+			ContainerPath: mountPoint.ContainerPath,
+			ReadOnly:      mountPoint.ReadOnly,
+		}
+		if hostVolume, ok := task.HostVolumeByName(mountPoint.SourceVolume); ok {
+			volume.Source = hostVolume.SourcePath()
+		}
+		response.Volumes = append(response.Volumes, volume)
+	}
+	return response
+}
+
+func newTasksV2Response(state *dockerstate.DockerTaskEngineState, containerDefaults *api.ContainerDefaults) *TasksV2Response {
+	allTasks := state.AllTasks()
+	taskResponses := make([]*TaskV2Response, len(allTasks))
+	for ndx, task := range allTasks {
+		containerMap, _ := state.ContainerMapByArn(task.Arn)
+		taskResponses[ndx] = newTaskV2Response(task, containerMap, containerDefaults)
+	}
+
+	return &TasksV2Response{Tasks: taskResponses}
+}
+
+// Creates response for the 'v2/tasks' API. It takes the same fields as the
+// 'v1/tasks' API, but describes the status, resources and history of each
+// container so that tasks that are stuck can be diagnosed without Docker.
+func tasksV2RequestHandlerMaker(taskEngine DockerStateResolver, containerDefaults *api.ContainerDefaults) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dockerTaskEngineState := taskEngine.State()
+		dockerId, dockerIdExists := ValueFromRequest(r, dockerIdQueryField)
+		taskArn, taskArnExists := ValueFromRequest(r, taskArnQueryField)
+		if dockerIdExists && taskArnExists {
+			log.Info("Request contains both ", dockerIdQueryField, " and ", taskArnQueryField, ". Expect at most one of these.")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !dockerIdExists && !taskArnExists {
+			responseJSON, _ := json.Marshal(newTasksV2Response(dockerTaskEngineState, containerDefaults))
+			w.Write(responseJSON)
+			return
+		}
+
+		var task *api.Task
+		var found bool
+		resourceId := taskArn
+		if dockerIdExists {
+			task, found = dockerTaskEngineState.TaskById(dockerId)
+			resourceId = dockerId
+		} else {
+			task, found = dockerTaskEngineState.TaskByArn(taskArn)
+		}
+		if !found {
+			log.Warn("Could not find requested resource: " + resourceId)
+			responseJSON, _ := json.Marshal(&TaskV2Response{})
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(responseJSON)
+			return
+		}
+		containerMap, _ := dockerTaskEngineState.ContainerMapByArn(task.Arn)
+		responseJSON, _ := json.Marshal(newTaskV2Response(task, containerMap, containerDefaults))
+		w.Write(responseJSON)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/handlers/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMultipleTasksV2(t *testing.T) {
+	recorder := performMockRequest(t, "/v2/tasks")
+
+	var tasksResponse TasksV2Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tasksResponse))
+	require.Len(t, tasksResponse.Tasks, len(testTasks))
+	for _, taskResponse := range tasksResponse.Tasks {
+		task, ok := taskByArn(testTasks, taskResponse.Arn)
+		require.True(t, ok, "unexpected task %s", taskResponse.Arn)
+		require.Len(t, taskResponse.Containers, len(task.Containers))
+		for i, containerResponse := range taskResponse.Containers {
+			assert.Equal(t, task.Containers[i].Name, containerResponse.Name)
+			assert.Equal(t, "dockerid-"+task.Arn+"-"+containerResponse.Name, containerResponse.DockerId)
+		}
+	}
+}
+
+func TestGetTaskV2ByDockerID(t *testing.T) {
+	recorder := performMockRequest(t, "/v2/tasks?dockerid=dockerid-task2-foo")
+
+	var taskResponse TaskV2Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &taskResponse))
+	assert.Equal(t, "task2", taskResponse.Arn)
+	require.Len(t, taskResponse.Containers, 1)
+	assert.Equal(t, "sha256:4bf5ad1c", taskResponse.Containers[0].ImageDigest)
+}
+
+func TestGetTaskV2Errors(t *testing.T) {
+	for path, code := range map[string]int{
+		"/v2/tasks?dockerid=does-not-exist":    http.StatusNotFound,
+		"/v2/tasks?taskarn=doesnotexist":       http.StatusNotFound,
+		"/v2/tasks?taskarn=task2&dockerid=foo": http.StatusBadRequest,
+	} {
+		recorder := performMockRequest(t, path)
+		assert.Equal(t, code, recorder.Code, "unexpected status for %s", path)
+	}
+}
+
+func TestTaskV2ResponseContainerDetails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	exitCode := 1
+	pulledAt := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	app := &api.Container{
+		Name:              "app",
+		Image:             "nginx:latest",
+		ImageID:           "sha256:abc",
+		Cpu:               256,
+		Memory:            512,
+		DesiredStatus:     api.ContainerRunning,
+		KnownStatus:       api.ContainerStopped,
+		KnownExitCode:     &exitCode,
+		ApplyingError:     &api.DefaultNamedError{Name: "CannotStartContainerError", Err: "port is already allocated"},
+		KnownPortBindings: []api.PortBinding{{ContainerPort: 80, HostPort: 32768, BindIp: "0.0.0.0", Protocol: api.TransportProtocolTCP}},
+		MountPoints:       []api.MountPoint{{SourceVolume: "data", ContainerPath: "/data", ReadOnly: true}},
+	}
+	app.SetTransitionTime(api.ContainerPulled, pulledAt)
+	pending := &api.Container{Name: "pending", Image: "busybox"}
+	testTask := &api.Task{
+		Arn:           "task1",
+		DesiredStatus: api.TaskRunning,
+		KnownStatus:   api.TaskCreated,
+		Family:        "test",
+		Version:       "1",
+		Containers:    []*api.Container{app, pending},
+		Volumes:       []api.TaskVolume{{Name: "data", Volume: &api.FSHostVolume{FSSourcePath: "/srv/data"}}},
+	}
+	state := dockerstate.NewDockerTaskEngineState()
+	state.AddTask(testTask)
+	state.AddContainer(&api.DockerContainer{Container: app, DockerId: "dockerid-app", DockerName: "dockername-app"}, testTask)
+
+	mockStateResolver := mock_handlers.NewMockDockerStateResolver(ctrl)
+	mockStateResolver.EXPECT().State().Return(state)
+	requestHandler := tasksV2RequestHandlerMaker(mockStateResolver, nil)
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v2/tasks?taskarn=task1", nil)
+	requestHandler(recorder, req)
+
+	var taskResponse TaskV2Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &taskResponse))
+	assert.Equal(t, "PENDING", taskResponse.KnownStatus)
+	assert.Equal(t, "RUNNING", taskResponse.DesiredStatus)
+	require.Len(t, taskResponse.Containers, 2)
+
+	appResponse := taskResponse.Containers[0]
+	assert.Equal(t, "dockerid-app", appResponse.DockerId)
+	assert.Equal(t, "dockername-app", appResponse.DockerName)
+	assert.Equal(t, "nginx:latest", appResponse.Image)
+	assert.Equal(t, "sha256:abc", appResponse.ImageID)
+	assert.Equal(t, "RUNNING", appResponse.DesiredStatus)
+	assert.Equal(t, "STOPPED", appResponse.KnownStatus)
+	assert.Equal(t, &exitCode, appResponse.ExitCode)
+	assert.Equal(t, &ErrorResponse{Name: "CannotStartContainerError", Message: "port is already allocated"}, appResponse.ApplyingError)
+	assert.Equal(t, uint(256), appResponse.CPU)
+	assert.Equal(t, uint(512), appResponse.Memory)
+	assert.Equal(t, []PortResponse{{ContainerPort: 80, HostPort: 32768, BindIp: "0.0.0.0", Protocol: "tcp"}}, appResponse.Ports)
+	assert.Equal(t, []VolumeResponse{{Name: "data", Source: "/srv/data", ContainerPath: "/data", ReadOnly: true}}, appResponse.Volumes)
+	require.NotNil(t, appResponse.PulledAt)
+	assert.True(t, pulledAt.Equal(*appResponse.PulledAt))
+	assert.Nil(t, appResponse.CreatedAt)
+	assert.Nil(t, appResponse.StartedAt)
+	assert.Nil(t, appResponse.StoppedAt)
+
+	pendingResponse := taskResponse.Containers[1]
+	assert.Equal(t, "pending", pendingResponse.Name)
+	assert.Empty(t, pendingResponse.DockerId)
+	assert.Equal(t, "NONE", pendingResponse.KnownStatus)
+	assert.Nil(t, pendingResponse.ApplyingError)
+}
+
+func taskByArn(tasks []*api.Task, arn string) (*api.Task, bool) {
+	for _, task := range tasks {
+		if task.Arn == arn {
+			return task, true
+		}
+	}
+	return nil, false
+}
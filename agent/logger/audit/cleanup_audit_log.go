@@ -0,0 +1,71 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/config"
+)
+
+const (
+	removeImageEventType     = "RemoveUnmanagedImage"
+	removeContainerEventType = "RemoveUnmanagedContainer"
+
+	// cleanupAuditLogVersion is the version of the cleanup audit log
+	// For version '1', the fields are:
+	// 1. event time
+	// 2. event type ('RemoveUnmanagedImage' or 'RemoveUnmanagedContainer')
+	// 3. version
+	// 4. cluster
+	// 5. image or container id
+	// 6. image names or container name, comma separated
+	// 7. reason for the removal
+	cleanupAuditLogVersion = 1
+)
+
+type cleanupAuditLog struct {
+	cluster string
+	logger  InfoLogger
+}
+
+// NewCleanupAuditLog returns a CleanupAuditLogger that writes an entry to
+// logger for every image and container removed
+func NewCleanupAuditLog(cfg *config.Config, logger InfoLogger) CleanupAuditLogger {
+	return &cleanupAuditLog{
+		cluster: cfg.Cluster,
+		logger:  logger,
+	}
+}
+
+func (c *cleanupAuditLog) LogImageRemoval(imageID string, imageNames []string, reason string) {
+	c.logger.Info(c.constructEntry(removeImageEventType, imageID, imageNames, reason))
+}
+
+func (c *cleanupAuditLog) LogContainerRemoval(containerID string, containerName string, reason string) {
+	c.logger.Info(c.constructEntry(removeContainerEventType, containerID, []string{containerName}, reason))
+}
+
+func (c *cleanupAuditLog) constructEntry(eventType string, id string, names []string, reason string) string {
+	return fmt.Sprintf("%s %s %d %s %s %s %s",
+		time.Now().UTC().Format(time.RFC3339),
+		eventType,
+		cleanupAuditLogVersion,
+		populateField(c.cluster),
+		populateField(id),
+		populateField(strings.Join(names, ",")),
+		fmt.Sprintf(`"%s"`, reason))
+}
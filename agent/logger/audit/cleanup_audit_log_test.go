@@ -0,0 +1,84 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/config"
+	mock_infologger "github.com/aws/amazon-ecs-agent/agent/logger/audit/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanupAuditLogImageRemoval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInfoLogger := mock_infologger.NewMockInfoLogger(ctrl)
+	cleanupLogger := NewCleanupAuditLog(&config.Config{Cluster: dummyCluster}, mockInfoLogger)
+
+	mockInfoLogger.EXPECT().Info(gomock.Any()).Do(func(logLine string) {
+		tokens := strings.SplitN(logLine, " ", 7)
+		assert.Len(t, tokens, 7, "Incorrect number of tokens in cleanup audit log entry")
+		_, err := time.Parse(time.RFC3339, tokens[0])
+		assert.NoError(t, err, "event time is not formatted correctly")
+		assert.Equal(t, removeImageEventType, tokens[1], "event type does not match")
+		version, _ := strconv.Atoi(tokens[2])
+		assert.Equal(t, cleanupAuditLogVersion, version, "version does not match")
+		assert.Equal(t, dummyCluster, tokens[3], "cluster does not match")
+		assert.Equal(t, "sha256:abc", tokens[4], "image id does not match")
+		assert.Equal(t, "busybox:latest,busybox:1", tokens[5], "image names do not match")
+		assert.Equal(t, `"unmanaged image created 30h0m0s ago"`, tokens[6], "reason does not match")
+	})
+
+	cleanupLogger.LogImageRemoval("sha256:abc", []string{"busybox:latest", "busybox:1"}, "unmanaged image created 30h0m0s ago")
+}
+
+func TestCleanupAuditLogDanglingImageRemoval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInfoLogger := mock_infologger.NewMockInfoLogger(ctrl)
+	cleanupLogger := NewCleanupAuditLog(&config.Config{}, mockInfoLogger)
+
+	mockInfoLogger.EXPECT().Info(gomock.Any()).Do(func(logLine string) {
+		tokens := strings.SplitN(logLine, " ", 7)
+		assert.Equal(t, "-", tokens[3], "missing cluster should be logged as '-'")
+		assert.Equal(t, "-", tokens[5], "missing image names should be logged as '-'")
+	})
+
+	cleanupLogger.LogImageRemoval("sha256:abc", nil, "dangling image")
+}
+
+func TestCleanupAuditLogContainerRemoval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInfoLogger := mock_infologger.NewMockInfoLogger(ctrl)
+	cleanupLogger := NewCleanupAuditLog(&config.Config{Cluster: dummyCluster}, mockInfoLogger)
+
+	mockInfoLogger.EXPECT().Info(gomock.Any()).Do(func(logLine string) {
+		tokens := strings.SplitN(logLine, " ", 7)
+		assert.Equal(t, removeContainerEventType, tokens[1], "event type does not match")
+		assert.Equal(t, "c1", tokens[4], "container id does not match")
+		assert.Equal(t, "build-step", tokens[5], "container name does not match")
+	})
+
+	cleanupLogger.LogContainerRemoval("c1", "build-step", "exited 25h0m0s ago")
+}
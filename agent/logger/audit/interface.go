@@ -24,3 +24,10 @@ type AuditLogger interface {
 type InfoLogger interface {
 	Info(i ...interface{})
 }
+
+// CleanupAuditLogger records the removal of images and containers that were
+// not pulled or started by the agent
+type CleanupAuditLogger interface {
+	LogImageRemoval(imageID string, imageNames []string, reason string)
+	LogContainerRemoval(containerID string, containerName string, reason string)
+}
@@ -12,7 +12,7 @@
 // permissions and limitations under the License.
 
 // Automatically generated by MockGen. DO NOT EDIT!
-// Source: github.com/aws/amazon-ecs-agent/agent/logger/audit (interfaces: AuditLogger,InfoLogger)
+// Source: github.com/aws/amazon-ecs-agent/agent/logger/audit (interfaces: AuditLogger,CleanupAuditLogger,InfoLogger)
 
 package mock_audit
 
@@ -70,6 +70,43 @@ func (_mr *_MockAuditLoggerRecorder) Log(arg0, arg1, arg2 interface{}) *gomock.C
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Log", arg0, arg1, arg2)
 }
 
+// Mock of CleanupAuditLogger interface
+type MockCleanupAuditLogger struct {
+	ctrl     *gomock.Controller
+	recorder *_MockCleanupAuditLoggerRecorder
+}
+
+// Recorder for MockCleanupAuditLogger (not exported)
+type _MockCleanupAuditLoggerRecorder struct {
+	mock *MockCleanupAuditLogger
+}
+
+func NewMockCleanupAuditLogger(ctrl *gomock.Controller) *MockCleanupAuditLogger {
+	mock := &MockCleanupAuditLogger{ctrl: ctrl}
+	mock.recorder = &_MockCleanupAuditLoggerRecorder{mock}
+	return mock
+}
+
+func (_m *MockCleanupAuditLogger) EXPECT() *_MockCleanupAuditLoggerRecorder {
+	return _m.recorder
+}
+
+func (_m *MockCleanupAuditLogger) LogContainerRemoval(_param0 string, _param1 string, _param2 string) {
+	_m.ctrl.Call(_m, "LogContainerRemoval", _param0, _param1, _param2)
+}
+
+func (_mr *_MockCleanupAuditLoggerRecorder) LogContainerRemoval(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "LogContainerRemoval", arg0, arg1, arg2)
+}
+
+func (_m *MockCleanupAuditLogger) LogImageRemoval(_param0 string, _param1 []string, _param2 string) {
+	_m.ctrl.Call(_m, "LogImageRemoval", _param0, _param1, _param2)
+}
+
+func (_mr *_MockCleanupAuditLoggerRecorder) LogImageRemoval(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "LogImageRemoval", arg0, arg1, arg2)
+}
+
 // Mock of InfoLogger interface
 type MockInfoLogger struct {
 	ctrl     *gomock.Controller
@@ -33,3 +33,24 @@ func AuditLoggerConfig(cfg *config.Config) string {
 `
 	return config
 }
+
+// CleanupAuditLoggerConfig returns the seelog configuration of the log of
+// unmanaged images and containers removed by the agent
+func CleanupAuditLoggerConfig(cfg *config.Config) string {
+	config := `
+	<seelog type="asyncloop" minlevel="info">
+		<outputs formatid="main">
+			<console />`
+	if cfg.UnmanagedCleanupAuditLogFile != "" {
+		config += `<rollingfile filename="` + cfg.UnmanagedCleanupAuditLogFile + `" type="date"
+			 datepattern="2006-01-02" archivetype="none" maxrolls="30" />`
+	}
+	config += `
+		</outputs>
+		<formats>
+			<format id="main" format="%Msg%n" />
+		</formats>
+	</seelog>
+`
+	return config
+}
@@ -0,0 +1,97 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider reads secrets from files under a root directory. A reference
+// to a file without a fragment resolves to the file's contents, and one with
+// a fragment to a key of the JSON object in the file. A reference to a
+// directory must have a fragment, naming the file in it that holds the
+// secret.
+type FileProvider struct {
+	root string
+}
+
+// NewFileProvider returns a FileProvider reading secrets under root
+func NewFileProvider(root string) *FileProvider {
+	return &FileProvider{root: root}
+}
+
+func (provider *FileProvider) GetSecret(ref *url.URL) (*Value, error) {
+	path, err := provider.resolve(fileURLPath(ref))
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	key := ref.Fragment
+	if info.IsDir() {
+		if key == "" || key != filepath.Base(key) || key == "." || key == ".." {
+			return nil, fmt.Errorf("secret %s is a directory, but no file in it is named", ref.Path)
+		}
+		// The file is resolved again, as it may be a link out of the root
+		path, err = provider.resolve(filepath.Join(path, key))
+		if err != nil {
+			return nil, err
+		}
+		key = ""
+	}
+	secret, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return selectKey(secret, key)
+}
+
+// resolve returns the path, with symbolic links resolved, if it is under the
+// provider's root
+func (provider *FileProvider) resolve(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("secret path %s is not absolute", path)
+	}
+	root, err := filepath.EvalSymlinks(provider.root)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("secret path " + path + " is outside of " + provider.root)
+	}
+	return resolved, nil
+}
+
+// fileURLPath returns the local path of a file URL, which on Windows has
+// the form file:///C:/path
+func fileURLPath(ref *url.URL) string {
+	path := ref.Path
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
@@ -0,0 +1,98 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fileRef(path string, key string) *url.URL {
+	return &url.URL{Scheme: fileScheme, Path: filepath.ToSlash(path), Fragment: key}
+}
+
+func TestFileProvider(t *testing.T) {
+	root, err := ioutil.TempDir("", "ecs-secrets")
+	require.Nil(t, err)
+	defer os.RemoveAll(root)
+	outside, err := ioutil.TempDir("", "ecs-secrets-outside")
+	require.Nil(t, err)
+	defer os.RemoveAll(outside)
+
+	require.Nil(t, ioutil.WriteFile(filepath.Join(root, "token"), []byte("s3cr3t\n"), 0600))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(root, "db"), []byte(`{"user":"app","password":"hunter2"}`), 0600))
+	require.Nil(t, os.Mkdir(filepath.Join(root, "certs"), 0700))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(root, "certs", "key"), []byte("private"), 0600))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(outside, "token"), []byte("outside"), 0600))
+
+	provider := NewFileProvider(root)
+	for _, tc := range []struct {
+		path  string
+		key   string
+		value string
+	}{
+		{filepath.Join(root, "token"), "", "s3cr3t"},
+		{filepath.Join(root, "db"), "password", "hunter2"},
+		{filepath.Join(root, "certs"), "key", "private"},
+	} {
+		value, err := provider.GetSecret(fileRef(tc.path, tc.key))
+		if assert.Nil(t, err, "unexpected error for %s#%s", tc.path, tc.key) {
+			assert.Equal(t, tc.value, value.Contents())
+		}
+	}
+
+	for _, tc := range []struct {
+		path string
+		key  string
+	}{
+		{filepath.Join(root, "db"), "missing"},
+		{filepath.Join(root, "token"), "key"},
+		{filepath.Join(root, "certs"), ""},
+		{filepath.Join(root, "certs"), "../token"},
+		{filepath.Join(root, "missing"), ""},
+		{filepath.Join(root, "..", filepath.Base(outside), "token"), ""},
+		{filepath.Join(outside, "token"), ""},
+	} {
+		_, err := provider.GetSecret(fileRef(tc.path, tc.key))
+		assert.Error(t, err, "expected an error for %s#%s", tc.path, tc.key)
+	}
+}
+
+func TestFileProviderSymlinkOutsideRoot(t *testing.T) {
+	root, err := ioutil.TempDir("", "ecs-secrets")
+	require.Nil(t, err)
+	defer os.RemoveAll(root)
+	outside, err := ioutil.TempDir("", "ecs-secrets-outside")
+	require.Nil(t, err)
+	defer os.RemoveAll(outside)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(outside, "token"), []byte("outside"), 0600))
+	require.Nil(t, os.Mkdir(filepath.Join(root, "certs"), 0700))
+	if err := os.Symlink(filepath.Join(outside, "token"), filepath.Join(root, "link")); err != nil {
+		t.Skip("Unable to create symbolic links", err)
+	}
+	require.Nil(t, os.Symlink(filepath.Join(outside, "token"), filepath.Join(root, "certs", "link")))
+
+	provider := NewFileProvider(root)
+	_, err = provider.GetSecret(fileRef(filepath.Join(root, "link"), ""))
+	assert.Error(t, err, "expected an error for a link out of the root")
+	_, err = provider.GetSecret(fileRef(filepath.Join(root, "certs"), "link"))
+	assert.Error(t, err, "expected an error for a link out of the root in a directory")
+}
@@ -0,0 +1,89 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/httpclient"
+)
+
+const (
+	httpProviderTimeout = 10 * time.Second
+	// maxSecretSize bounds the size of the responses read from the provider
+	maxSecretSize = 64 * 1024
+)
+
+// HTTPProvider fetches secrets from an HTTP service, such as a local stand-in
+// for a secrets store. Only URLs under the provider's endpoint are fetched.
+// As with files, a fragment in the reference selects a key of the JSON
+// object returned.
+type HTTPProvider struct {
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewHTTPProvider returns an HTTPProvider fetching secrets under endpoint
+func NewHTTPProvider(endpoint string) (*HTTPProvider, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpointURL.Scheme != httpScheme && endpointURL.Scheme != httpsScheme {
+		return nil, fmt.Errorf("secret provider endpoint %s is not an HTTP URL", endpoint)
+	}
+	if !strings.HasSuffix(endpointURL.Path, "/") {
+		endpointURL.Path += "/"
+	}
+	client := httpclient.New(httpProviderTimeout, false)
+	// Redirects are not followed, as they could lead outside of the endpoint
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &HTTPProvider{endpoint: endpointURL, client: client}, nil
+}
+
+func (provider *HTTPProvider) GetSecret(ref *url.URL) (*Value, error) {
+	if ref.Scheme != provider.endpoint.Scheme || ref.Host != provider.endpoint.Host ||
+		path.Clean(ref.Path) != ref.Path || !strings.HasPrefix(ref.Path, provider.endpoint.Path) {
+		return nil, fmt.Errorf("secret %s is not under the secret provider endpoint", ref.Path)
+	}
+	// The request is made for the path checked above, without the key
+	request := *ref
+	request.RawPath = ""
+	request.Fragment = ""
+	resp, err := provider.client.Get(request.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("secret provider returned status %d for %s", resp.StatusCode, ref.Path)
+	}
+	secret, err := ioutil.ReadAll(&io.LimitedReader{R: resp.Body, N: maxSecretSize + 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) > maxSecretSize {
+		return nil, fmt.Errorf("secret %s is larger than %d bytes", ref.Path, maxSecretSize)
+	}
+	return selectKey(secret, ref.Fragment)
+}
@@ -0,0 +1,82 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/secrets/token":
+			fmt.Fprintln(w, "s3cr3t")
+		case "/secrets/db":
+			fmt.Fprint(w, `{"user":"app","password":"hunter2"}`)
+		case "/secrets/large":
+			fmt.Fprint(w, strings.Repeat("x", maxSecretSize+1))
+		case "/secrets/redirect":
+			http.Redirect(w, r, "/other/token", http.StatusFound)
+		case "/other/token":
+			fmt.Fprint(w, "other")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewHTTPProvider(server.URL + "/secrets")
+	require.Nil(t, err)
+	ref := func(path string) *url.URL {
+		parsed, err := url.Parse(server.URL + path)
+		require.Nil(t, err)
+		return parsed
+	}
+
+	value, err := provider.GetSecret(ref("/secrets/token"))
+	require.Nil(t, err)
+	assert.Equal(t, "s3cr3t", value.Contents())
+	value, err = provider.GetSecret(ref("/secrets/db#password"))
+	require.Nil(t, err)
+	assert.Equal(t, "hunter2", value.Contents())
+
+	for _, path := range []string{
+		"/secrets/missing",
+		"/secrets/large",
+		"/secrets/redirect",
+		"/other/token",
+		"/secrets/../other/token",
+		"/secretsother",
+	} {
+		_, err := provider.GetSecret(ref(path))
+		assert.Error(t, err, "expected an error for %s", path)
+	}
+
+	_, err = provider.GetSecret(&url.URL{Scheme: httpScheme, Host: "example.com", Path: "/secrets/token"})
+	assert.Error(t, err, "expected an error for another host")
+}
+
+func TestNewHTTPProviderInvalidEndpoint(t *testing.T) {
+	_, err := NewHTTPProvider("ftp://example.com/secrets/")
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	fileScheme  = "file"
+	httpScheme  = "http"
+	httpsScheme = "https"
+)
+
+// providers resolves each secret through the provider of its scheme
+type providers map[string]SecretProvider
+
+// NewSecretProvider returns a SecretProvider which reads file:// secrets
+// under fileRoot and fetches http:// and https:// secrets under
+// httpEndpoint. Either provider is disabled if its location is empty.
+func NewSecretProvider(fileRoot string, httpEndpoint string) (SecretProvider, error) {
+	p := make(providers)
+	if fileRoot != "" {
+		p[fileScheme] = NewFileProvider(fileRoot)
+	}
+	if httpEndpoint != "" {
+		httpProvider, err := NewHTTPProvider(httpEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		p[httpScheme] = httpProvider
+		p[httpsScheme] = httpProvider
+	}
+	return p, nil
+}
+
+func (p providers) GetSecret(ref *url.URL) (*Value, error) {
+	provider, ok := p[ref.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("no secret provider for scheme %q", ref.Scheme)
+	}
+	return provider.GetSecret(ref)
+}
+
+// selectKey returns the whole secret if key is empty, or else the value of
+// key in the secret, which must then be a JSON object of strings
+func selectKey(secret []byte, key string) (*Value, error) {
+	if key == "" {
+		return NewValue(strings.TrimRight(string(secret), "\r\n")), nil
+	}
+	var values map[string]string
+	// The error is not returned as it may quote the secret
+	if json.Unmarshal(secret, &values) != nil {
+		return nil, errors.New("secret is not a JSON object of strings")
+	}
+	value, ok := values[key]
+	if !ok {
+		return nil, fmt.Errorf("secret has no key %q", key)
+	}
+	return NewValue(value), nil
+}
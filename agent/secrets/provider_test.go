@@ -0,0 +1,53 @@
+// +build !integration
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueIsRedacted(t *testing.T) {
+	value := NewValue("hunter2")
+	assert.Equal(t, "hunter2", value.Contents())
+	for _, format := range []string{"%s", "%v", "%+v", "%#v"} {
+		assert.NotContains(t, fmt.Sprintf(format, value), "hunter2", "value printed with %s", format)
+		assert.NotContains(t, fmt.Sprintf(format, *value), "hunter2", "value printed with %s", format)
+	}
+	data, err := json.Marshal(struct{ Value *Value }{value})
+	require.Nil(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+}
+
+func TestSelectKeyDoesNotQuoteSecret(t *testing.T) {
+	_, err := selectKey([]byte(`{"password": hunter2}`), "password")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestSecretProviderSchemes(t *testing.T) {
+	provider, err := NewSecretProvider("", "")
+	require.Nil(t, err)
+	_, err = provider.GetSecret(&url.URL{Scheme: fileScheme, Path: "/etc/ecs/secrets/db"})
+	assert.Error(t, err, "expected an error for a disabled provider")
+
+	_, err = NewSecretProvider("/etc/ecs/secrets", "secrets.example.com")
+	assert.Error(t, err, "expected an error for an invalid endpoint")
+}
@@ -0,0 +1,47 @@
+// Copyright 2014-2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import "encoding/json"
+
+const redacted = "[redacted]"
+
+// Value is the value of a secret. It is a Stringer and a json.Marshaler
+// which never reveal its contents, so that it is not logged or saved to the
+// state file by accident.
+type Value struct {
+	contents string
+}
+
+// NewValue returns a new encapsulated secret value
+func NewValue(contents string) *Value {
+	return &Value{contents: contents}
+}
+
+func (value Value) String() string {
+	return redacted
+}
+
+func (value Value) GoString() string {
+	return redacted
+}
+
+// Contents returns the value of the secret
+func (value Value) Contents() string {
+	return value.contents
+}
+
+func (value Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
@@ -42,7 +42,24 @@ import (
 // 3) Add 'Protocol' field to 'portMappings' and 'KnownPortBindings'
 // 4) Add 'DockerConfig' struct
 // 5) Add 'ImageStates' struct as part of ImageManager
-const EcsDataVersion = 5
+// 6) Add 'healthCheck' and 'health' fields to containers
+// 7) Add 'restartPolicy' and 'restartCount' fields to containers
+// 8) Add 'dependsOn' field to containers
+// 9) Add 'stopTimeout' and 'stopSignal' fields to containers
+// 10) Add 'imagePullBehavior' field to containers
+// 11) Add 'Pinned' field to image states
+// 12) Add 'UseCount' field to image states
+// 13) Add 'RepoDigests' field to images and 'ImageDigest' fields to containers
+// 14) Add Docker volumes to task volumes
+// 15) Add 'sizeLimit' and 'backing' fields to empty volumes
+// 16) Add 'linuxParameters' field to containers
+// 17) Add 'secrets' field to containers
+// 18) Add 'environmentFiles' field to containers
+// 19) Add 'metadataEndpointId' field to tasks and 'KnownNetworks' to containers
+// 20) Add 'transitionTimes' field to containers
+// 21) Add 'stopDeadline' field to tasks
+// 22) Add 'PrewarmPinned' field to image states
+const EcsDataVersion = 22
 
 // Filename in the ECS_DATADIR
 const ecsDataFile = "ecs_agent_data.json"
@@ -193,6 +193,9 @@ func (engine *MockTaskEngine) TaskEvents() (<-chan api.TaskStateChange, <-chan a
 func (engine *MockTaskEngine) SetSaver(statemanager.Saver) {
 }
 
+func (engine *MockTaskEngine) SetContainerInstanceArn(string) {
+}
+
 func (engine *MockTaskEngine) AddTask(*api.Task) error {
 	return nil
 }
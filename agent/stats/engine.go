@@ -422,6 +422,45 @@ func (engine *DockerStatsEngine) getContainerMetricsForTask(taskArn string) ([]*
 	return containerMetrics, nil
 }
 
+// ContainerStats returns the most recent utilization stats collected for a
+// container of a task
+func (engine *DockerStatsEngine) ContainerStats(taskArn string, dockerID string) (*UsageStats, error) {
+	engine.containersLock.RLock()
+	defer engine.containersLock.RUnlock()
+
+	container, ok := engine.tasksToContainers[taskArn][dockerID]
+	if !ok {
+		return nil, fmt.Errorf("No stats collected for container %s", dockerID)
+	}
+	usageStats, err := container.statsQueue.GetRawUsageStats(1)
+	if err != nil {
+		return nil, err
+	}
+	return &usageStats[0], nil
+}
+
+// ScratchVolumeUsage returns the usage of the size limited empty volumes of a
+// task whose containers are being monitored. The usage is not published to
+// the backend.
+func (engine *DockerStatsEngine) ScratchVolumeUsage(taskArn string) ([]ecsengine.ScratchVolumeUsage, error) {
+	engine.containersLock.RLock()
+	var dockerID string
+	for id := range engine.tasksToContainers[taskArn] {
+		dockerID = id
+		break
+	}
+	engine.containersLock.RUnlock()
+	if dockerID == "" {
+		return nil, fmt.Errorf("No containers monitored for task %s", taskArn)
+	}
+
+	task, err := engine.resolver.ResolveTask(dockerID)
+	if err != nil {
+		return nil, err
+	}
+	return ecsengine.TaskScratchVolumeUsage(task), nil
+}
+
 func (engine *DockerStatsEngine) doRemoveContainer(container *StatsContainer, taskArn string) {
 	container.StopStatsCollection()
 	dockerID := container.containerMetadata.DockerID
@@ -211,6 +211,79 @@ func TestStatsEngineMetadataInStatsSets(t *testing.T) {
 	}
 }
 
+func TestStatsEngineContainerStats(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	resolver := mock_resolver.NewMockContainerMetadataResolver(mockCtrl)
+	t1 := &api.Task{Arn: "t1", Family: "f1"}
+	resolver.EXPECT().ResolveTask("c1").AnyTimes().Return(t1, nil)
+	resolver.EXPECT().ResolveContainer(gomock.Any()).AnyTimes().Return(&api.DockerContainer{
+		Container: &api.Container{},
+	}, nil)
+
+	engine := NewDockerStatsEngine(&cfg, nil, eventStream("TestStatsEngineContainerStats"))
+	engine.resolver = resolver
+	engine.cluster = defaultCluster
+	engine.containerInstanceArn = defaultContainerInstance
+	engine.addContainer("c1")
+	defer engine.removeContainer("c1")
+
+	if _, err := engine.ContainerStats("t1", "c1"); err == nil {
+		t.Error("Expected an error before any stats were collected")
+	}
+	containers, _ := engine.tasksToContainers["t1"]
+	for _, statsContainer := range containers {
+		statsContainer.statsQueue.Add(&ContainerStats{22400432, 1839104, parseNanoTime("2015-02-12T21:22:05.131117533Z")})
+		statsContainer.statsQueue.Add(&ContainerStats{116499979, 3649536, parseNanoTime("2015-02-12T21:22:05.232291187Z")})
+	}
+	usage, err := engine.ContainerStats("t1", "c1")
+	if err != nil {
+		t.Fatalf("Error getting container stats: %v", err)
+	}
+	if usage.MemoryUsageInMegs != 3 {
+		t.Errorf("Incorrect memory usage. Expected: 3, got: %d", usage.MemoryUsageInMegs)
+	}
+	if !usage.Timestamp.Equal(parseNanoTime("2015-02-12T21:22:05.232291187Z")) {
+		t.Errorf("Expected the most recent stats, got stats from %v", usage.Timestamp)
+	}
+	if _, err := engine.ContainerStats("t1", "c2"); err == nil {
+		t.Error("Expected an error for an unknown container")
+	}
+}
+
+func TestStatsEngineScratchVolumeUsage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	resolver := mock_resolver.NewMockContainerMetadataResolver(mockCtrl)
+	// Volumes that are not size limited, or not mounted yet, have no usage
+	t1 := &api.Task{Arn: "t1", Family: "f1", Volumes: []api.TaskVolume{
+		{Name: "unlimited", Volume: &api.EmptyHostVolume{}},
+		{Name: "unmounted", Volume: &api.EmptyHostVolume{SizeLimit: 1024}},
+	}}
+	resolver.EXPECT().ResolveTask("c1").AnyTimes().Return(t1, nil)
+	resolver.EXPECT().ResolveContainer(gomock.Any()).AnyTimes().Return(&api.DockerContainer{
+		Container: &api.Container{},
+	}, nil)
+
+	engine := NewDockerStatsEngine(&cfg, nil, eventStream("TestStatsEngineScratchVolumeUsage"))
+	engine.resolver = resolver
+	engine.cluster = defaultCluster
+	engine.containerInstanceArn = defaultContainerInstance
+	engine.addContainer("c1")
+	defer engine.removeContainer("c1")
+
+	usage, err := engine.ScratchVolumeUsage("t1")
+	if err != nil {
+		t.Fatalf("Error getting scratch volume usage: %v", err)
+	}
+	if len(usage) != 0 {
+		t.Errorf("Expected no scratch volume usage, got %v", usage)
+	}
+	if _, err := engine.ScratchVolumeUsage("t2"); err == nil {
+		t.Error("Expected an error for a task that is not monitored")
+	}
+}
+
 func TestStatsEngineInvalidTaskEngine(t *testing.T) {
 	statsEngine := NewDockerStatsEngine(&cfg, nil, eventStream("TestStatsEngineInvalidTaskEngine"))
 	taskEngine := &MockTaskEngine{}
@@ -39,8 +39,8 @@ const (
 	deregisterContainerInstanceHandler = "TCSDeregisterContainerInstanceHandler"
 )
 
-// StartMetricsSession starts a metric session. It initializes the stats engine
-// and invokes StartSession.
+// StartMetricsSession starts a metric session by invoking StartSession with the
+// stats engine of the params, which is expected to be initialized.
 func StartMetricsSession(params TelemetrySessionParams) {
 	disabled, err := params.isTelemetryDisabled()
 	if err != nil {
@@ -49,13 +49,7 @@ func StartMetricsSession(params TelemetrySessionParams) {
 	}
 
 	if !disabled {
-		statsEngine := stats.NewDockerStatsEngine(params.Cfg, params.DockerClient, params.ContainerChangeEventStream)
-		err := statsEngine.MustInit(params.TaskEngine, params.Cfg.Cluster, params.ContainerInstanceArn)
-		if err != nil {
-			log.Warn("Error initializing metrics engine", "err", err)
-			return
-		}
-		err = StartSession(params, statsEngine)
+		err = StartSession(params, params.StatsEngine)
 		if err != nil {
 			log.Warn("Error starting metrics session with backend", "err", err)
 			return